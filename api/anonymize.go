@@ -0,0 +1,192 @@
+package api
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pokt-foundation/portal-http-db/v2/types"
+)
+
+const (
+	// anonymizedKeyPrefix marks the anonymized application public keys, so they are not mistaken for raw keys
+	anonymizedKeyPrefix = "anon_"
+	// defaultAnonymizedKeyLength is the number of hex characters of the hash kept by default
+	defaultAnonymizedKeyLength = 16
+)
+
+var publicKeyType = reflect.TypeOf(types.PortalAppPublicKey(""))
+
+// ErrMissingAnonymizationSecret is returned for an anonymizer without a secret: the application public keys are public,
+// so their unkeyed hashes could be reversed by hashing the known keys.
+var ErrMissingAnonymizationSecret = errors.New("missing anonymization secret")
+
+// Anonymizer replaces the application public keys of responses with a stable keyed hash, e.g. to share dashboards
+// externally without exposing the raw keys.
+type Anonymizer struct {
+	// Secret keys the hash, so the anonymized keys cannot be matched by hashing known public keys
+	Secret string
+	// Length is the number of hex characters of the hash kept in the anonymized keys: 16 if not set
+	Length int
+}
+
+// Validate returns an error if the anonymized keys could be matched to the raw ones, i.e. if the anonymizer has no secret
+func (a Anonymizer) Validate() error {
+	if a.Secret == "" {
+		return ErrMissingAnonymizationSecret
+	}
+	return nil
+}
+
+// anonymizerContextKey is the request context key of the anonymizer of the request's responses, if any
+type anonymizerContextKey struct{}
+
+// withAnonymizer returns the request with the anonymizer applied to its responses
+func withAnonymizer(req *http.Request, anonymizer Anonymizer) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), anonymizerContextKey{}, anonymizer))
+}
+
+// requestAnonymizer returns the anonymizer of the request's responses, if they are anonymized
+func requestAnonymizer(req *http.Request) (Anonymizer, bool) {
+	anonymizer, ok := req.Context().Value(anonymizerContextKey{}).(Anonymizer)
+	return anonymizer, ok
+}
+
+// Key returns the anonymized application public key: the same key is always anonymized to the same value.
+func (a Anonymizer) Key(appPubKey types.PortalAppPublicKey) types.PortalAppPublicKey {
+	mac := hmac.New(sha256.New, []byte(a.Secret))
+	mac.Write([]byte(appPubKey))
+	hash := hex.EncodeToString(mac.Sum(nil))
+
+	length := a.Length
+	if length <= 0 || length > len(hash) {
+		length = defaultAnonymizedKeyLength
+	}
+	return types.PortalAppPublicKey(anonymizedKeyPrefix + hash[:length])
+}
+
+// Anonymize returns a copy of the response with its application public keys anonymized.
+//
+//	The keys are found by their type, and are also replaced wherever they appear in the response's other strings, e.g. in notes.
+func (a Anonymizer) Anonymize(resp any) any {
+	if resp == nil {
+		return nil
+	}
+
+	v := reflect.ValueOf(resp)
+	keys := make(map[string]bool)
+	collectPublicKeys(v, keys)
+
+	// The longer keys are replaced first, in case a key contains another
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		if key != "" {
+			sorted = append(sorted, key)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+
+	replacements := make([]string, 0, 2*len(sorted))
+	for _, key := range sorted {
+		replacements = append(replacements, key, string(a.Key(types.PortalAppPublicKey(key))))
+	}
+
+	return a.anonymizeValue(v, strings.NewReplacer(replacements...)).Interface()
+}
+
+// collectPublicKeys adds the application public keys held by the value to keys
+func collectPublicKeys(v reflect.Value, keys map[string]bool) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			collectPublicKeys(v.Elem(), keys)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			collectPublicKeys(v.Field(i), keys)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			collectPublicKeys(v.Index(i), keys)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			collectPublicKeys(iter.Key(), keys)
+			collectPublicKeys(iter.Value(), keys)
+		}
+	case reflect.String:
+		if v.Type() == publicKeyType {
+			keys[v.String()] = true
+		}
+	}
+}
+
+// anonymizeValue returns a copy of the value with its application public keys anonymized, and the other strings
+// rewritten by the replacer.
+func (a Anonymizer) anonymizeValue(v reflect.Value, replacer *strings.Replacer) reflect.Value {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		anonymized := reflect.New(v.Type().Elem())
+		anonymized.Elem().Set(a.anonymizeValue(v.Elem(), replacer))
+		return anonymized
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		anonymized := reflect.New(v.Type()).Elem()
+		anonymized.Set(a.anonymizeValue(v.Elem(), replacer))
+		return anonymized
+	case reflect.Struct:
+		// The unexported fields, e.g. of time.Time, are copied unchanged
+		anonymized := reflect.New(v.Type()).Elem()
+		anonymized.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if anonymized.Field(i).CanSet() {
+				anonymized.Field(i).Set(a.anonymizeValue(v.Field(i), replacer))
+			}
+		}
+		return anonymized
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		anonymized := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			anonymized.Index(i).Set(a.anonymizeValue(v.Index(i), replacer))
+		}
+		return anonymized
+	case reflect.Array:
+		anonymized := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			anonymized.Index(i).Set(a.anonymizeValue(v.Index(i), replacer))
+		}
+		return anonymized
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		anonymized := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			anonymized.SetMapIndex(a.anonymizeValue(iter.Key(), replacer), a.anonymizeValue(iter.Value(), replacer))
+		}
+		return anonymized
+	case reflect.String:
+		if v.Type() == publicKeyType {
+			return reflect.ValueOf(a.Key(types.PortalAppPublicKey(v.String())))
+		}
+		return reflect.ValueOf(replacer.Replace(v.String())).Convert(v.Type())
+	default:
+		return v
+	}
+}
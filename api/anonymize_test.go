@@ -0,0 +1,103 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pokt-foundation/portal-http-db/v2/types"
+)
+
+func TestAnonymizerKey(t *testing.T) {
+	anonymizer := Anonymizer{Secret: "secret"}
+
+	key := anonymizer.Key("app1")
+	if key != anonymizer.Key("app1") {
+		t.Errorf("Expected the same key to be anonymized to the same value, got: %s and %s", key, anonymizer.Key("app1"))
+	}
+	if key == anonymizer.Key("app2") {
+		t.Errorf("Expected different keys to be anonymized to different values, got: %s for both", key)
+	}
+	if key == (Anonymizer{Secret: "other secret"}).Key("app1") {
+		t.Errorf("Expected the anonymized key to depend on the secret, got: %s for both", key)
+	}
+	if !strings.HasPrefix(string(key), anonymizedKeyPrefix) || len(key) != len(anonymizedKeyPrefix)+defaultAnonymizedKeyLength {
+		t.Errorf("Expected a prefixed key of %d hex characters, got: %s", defaultAnonymizedKeyLength, key)
+	}
+	if key := (Anonymizer{Secret: "secret", Length: 8}).Key("app1"); len(key) != len(anonymizedKeyPrefix)+8 {
+		t.Errorf("Expected a prefixed key of 8 hex characters, got: %s", key)
+	}
+	if err := anonymizer.Validate(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := (Anonymizer{}).Validate(); !errors.Is(err, ErrMissingAnonymizationSecret) {
+		t.Errorf("Expected error: %v, got: %v", ErrMissingAnonymizationSecret, err)
+	}
+}
+
+func TestAnonymize(t *testing.T) {
+	now := time.Date(2022, time.July, 20, 0, 0, 0, 0, time.UTC)
+	anonymizer := Anonymizer{Secret: "secret"}
+	rawKeys := []types.PortalAppPublicKey{"2585504a028b138b4b535d2351bc45260a3de9cd66305a854049d1a5143392a1", "app2"} // pragma: allowlist secret
+	anon1, anon2 := anonymizer.Key(rawKeys[0]), anonymizer.Key(rawKeys[1])
+
+	testCases := []struct {
+		name     string
+		resp     any
+		expected any
+	}{
+		{
+			name:     "Keys of a list are anonymized",
+			resp:     rawKeys,
+			expected: []types.PortalAppPublicKey{anon1, anon2},
+		},
+		{
+			name: "Keys are anonymized in the notes",
+			resp: AppsComparisonResponse{
+				A:     AppRelaysResponse{PublicKey: rawKeys[0], From: now, Count: RelayCounts{Success: 1}},
+				B:     AppRelaysResponse{PublicKey: rawKeys[1], From: now},
+				Notes: []string{"Application app2 has no metrics: its relay counts are zero"},
+			},
+			expected: AppsComparisonResponse{
+				A:     AppRelaysResponse{PublicKey: anon1, From: now, Count: RelayCounts{Success: 1}},
+				B:     AppRelaysResponse{PublicKey: anon2, From: now},
+				Notes: []string{"Application " + string(anon2) + " has no metrics: its relay counts are zero"},
+			},
+		},
+		{
+			name: "Keys of the listed items are anonymized",
+			resp: ListResponse[PortalAppRelaysResponse]{
+				Data: []PortalAppRelaysResponse{{PortalAppID: "portal_app_1", PublicKeys: rawKeys}},
+				Meta: ListResponseMeta{From: now, Total: 1},
+			},
+			expected: ListResponse[PortalAppRelaysResponse]{
+				Data: []PortalAppRelaysResponse{{PortalAppID: "portal_app_1", PublicKeys: []types.PortalAppPublicKey{anon1, anon2}}},
+				Meta: ListResponseMeta{From: now, Total: 1},
+			},
+		},
+		{
+			name:     "Responses without keys are not changed",
+			resp:     []OriginClassificationsResponse{{Origin: "https://portal.pokt.network", Count: RelayCounts{Failure: 2}}},
+			expected: []OriginClassificationsResponse{{Origin: "https://portal.pokt.network", Count: RelayCounts{Failure: 2}}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			before, _ := json.Marshal(tc.resp)
+
+			got := anonymizer.Anonymize(tc.resp)
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+
+			after, _ := json.Marshal(tc.resp)
+			if string(before) != string(after) {
+				t.Errorf("Expected the response not to be modified, got: %s", after)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+var ErrTooManyAPIKeys = errors.New("too many API keys")
+
+// APIKeys is the set of API keys authorized to use the API. The set can be replaced while serving, e.g. to rotate the keys
+// on SIGHUP without restarting the server: in-flight requests are checked against either the previous or the new set.
+type APIKeys struct {
+	// max, if positive, is the maximum number of keys of the set
+	max  int
+	keys map[string]bool
+	mu   sync.RWMutex
+}
+
+// NewAPIKeys returns the set of the supplied API keys, limited to max keys if max is positive
+func NewAPIKeys(keys map[string]bool, max int) (*APIKeys, error) {
+	apiKeys := &APIKeys{max: max}
+	if err := apiKeys.Set(keys); err != nil {
+		return nil, err
+	}
+	return apiKeys, nil
+}
+
+// Set replaces the API keys: the set is left unchanged if the keys exceed the maximum
+func (k *APIKeys) Set(keys map[string]bool) error {
+	copied := make(map[string]bool, len(keys))
+	for key, enabled := range keys {
+		if enabled && key != "" {
+			copied[key] = true
+		}
+	}
+	if k.max > 0 && len(copied) > k.max {
+		return fmt.Errorf("%w: %d keys, the maximum is %d", ErrTooManyAPIKeys, len(copied), k.max)
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.keys = copied
+	return nil
+}
+
+// Contains returns whether the API key is authorized
+func (k *APIKeys) Contains(key string) bool {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	return k.keys[key]
+}
+
+// Len returns the number of authorized API keys
+func (k *APIKeys) Len() int {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	return len(k.keys)
+}
+
+// ReadAPIKeysFile returns the API keys listed in the file, separated by semicolons, as in the API_KEYS variable, or newlines
+func ReadAPIKeysFile(path string) (map[string]bool, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]bool)
+	for _, line := range strings.Split(string(content), "\n") {
+		for _, key := range strings.Split(line, ";") {
+			if key = strings.TrimSpace(key); key != "" {
+				keys[key] = true
+			}
+		}
+	}
+	return keys, nil
+}
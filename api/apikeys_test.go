@@ -0,0 +1,88 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pokt-foundation/utils-go/logger"
+)
+
+func TestAPIKeysReload(t *testing.T) {
+	apiKeys, err := NewAPIKeys(map[string]bool{"old": true, "kept": true}, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	fakeMeter := fakeRelayMeter{allResponse: []AppRelaysResponse{{PublicKey: "app1"}}}
+	httpServer := GetHttpServer(context.Background(), &fakeMeter, logger.New(), nil, ServerOptions{APIKeys: apiKeys})
+
+	statusCode := func(key string) int {
+		req := httptest.NewRequest(http.MethodGet, "http://relay-meter.pokt.network/v1/relays/apps", nil)
+		req.Header.Add("Authorization", key)
+		w := httptest.NewRecorder()
+		httpServer(w, req)
+		return w.Code
+	}
+
+	if code := statusCode("new"); code != http.StatusUnauthorized {
+		t.Fatalf("Expected status code: %d for a key not yet added, got: %d", http.StatusUnauthorized, code)
+	}
+
+	if err := apiKeys.Set(map[string]bool{"new": true, "kept": true}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for key, expected := range map[string]int{
+		"new":  http.StatusOK,
+		"kept": http.StatusOK,
+		"old":  http.StatusUnauthorized,
+	} {
+		if code := statusCode(key); code != expected {
+			t.Errorf("Expected status code: %d for key %q, got: %d", expected, key, code)
+		}
+	}
+}
+
+func TestMaxAPIKeys(t *testing.T) {
+	if _, err := NewAPIKeys(map[string]bool{"key1": true, "key2": true, "key3": true}, 2); !errors.Is(err, ErrTooManyAPIKeys) {
+		t.Fatalf("Expected error: %v, got: %v", ErrTooManyAPIKeys, err)
+	}
+
+	apiKeys, err := NewAPIKeys(map[string]bool{"key1": true, "key2": true}, 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Too many keys leave the current ones unchanged
+	if err := apiKeys.Set(map[string]bool{"key3": true, "key4": true, "key5": true}); !errors.Is(err, ErrTooManyAPIKeys) {
+		t.Fatalf("Expected error: %v, got: %v", ErrTooManyAPIKeys, err)
+	}
+	if !apiKeys.Contains("key1") || apiKeys.Contains("key3") {
+		t.Errorf("Expected the API keys to be unchanged")
+	}
+}
+
+func TestReadAPIKeysFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api-keys")
+	if err := os.WriteFile(path, []byte("key1;key2\n\n  key3 \n"), 0o600); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	got, err := ReadAPIKeysFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(map[string]bool{"key1": true, "key2": true, "key3": true}, got); diff != "" {
+		t.Errorf("unexpected value (-want +got):\n%s", diff)
+	}
+
+	if _, err := ReadAPIKeysFile(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Errorf("Expected an error reading a missing file")
+	}
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"time"
+	"unsafe"
+)
+
+// mapEntryOverhead is a rough estimate, in bytes, of the memory used by a map entry besides its key and value,
+// i.e. its share of the map's buckets, hashes and unused slots.
+const mapEntryOverhead = 48
+
+// CacheStats describes the size of the relay meter's in-memory caches, to detect when the dataset outgrows them.
+type CacheStats struct {
+	// DailyDays is the number of days of cached daily metrics
+	DailyDays int `json:"DailyDays"`
+	// DailyEntries is the number of cached daily relay counts, over all days and apps
+	DailyEntries  int `json:"DailyEntries"`
+	TodaysEntries int `json:"TodaysEntries"`
+	OriginEntries int `json:"OriginEntries"`
+	// LatencyEntries is the number of cached hourly latencies, over all apps
+	LatencyEntries int `json:"LatencyEntries"`
+	AppPlanEntries int `json:"AppPlanEntries"`
+	// EstimatedBytes is a rough estimate of the memory used by all the caches
+	EstimatedBytes int64 `json:"EstimatedBytes"`
+}
+
+// CacheStats returns the entry counts, and the estimated memory footprint, of the in-memory caches
+func (r *relayMeter) CacheStats() CacheStats {
+	r.rwMutex.RLock()
+	defer r.rwMutex.RUnlock()
+
+	return r.cacheStats()
+}
+
+// cacheStats returns the stats of the in-memory caches. The caller must hold the lock.
+func (r *relayMeter) cacheStats() CacheStats {
+	var (
+		stats CacheStats
+		bytes int64
+	)
+
+	relayCountsSize := int64(unsafe.Sizeof(RelayCounts{}))
+	stringSize := int64(unsafe.Sizeof(""))
+
+	stats.DailyDays = len(r.dailyUsage)
+	for _, counts := range r.dailyUsage {
+		bytes += int64(unsafe.Sizeof(time.Time{})) + mapEntryOverhead
+		stats.DailyEntries += len(counts)
+		for appPubKey := range counts {
+			bytes += stringSize + int64(len(appPubKey)) + relayCountsSize + mapEntryOverhead
+		}
+	}
+
+	stats.TodaysEntries = len(r.todaysUsage)
+	for appPubKey := range r.todaysUsage {
+		bytes += stringSize + int64(len(appPubKey)) + relayCountsSize + mapEntryOverhead
+	}
+
+	stats.OriginEntries = len(r.todaysOriginUsage)
+	for origin := range r.todaysOriginUsage {
+		bytes += stringSize + int64(len(origin)) + relayCountsSize + mapEntryOverhead
+	}
+
+	for appPubKey, latencies := range r.todaysLatency {
+		stats.LatencyEntries += len(latencies)
+		bytes += stringSize + int64(len(appPubKey)) + int64(unsafe.Sizeof(latencies)) + mapEntryOverhead
+		bytes += int64(cap(latencies)) * int64(unsafe.Sizeof(Latency{}))
+	}
+
+	stats.AppPlanEntries = len(r.appPlans)
+	for appPubKey, plan := range r.appPlans {
+		bytes += 2*stringSize + int64(len(appPubKey)) + int64(len(plan)) + mapEntryOverhead
+	}
+
+	stats.EstimatedBytes = bytes
+	return stats
+}
+
+// updateCacheMetrics sets the cache size metrics to the current size of the caches. The caller must hold the lock.
+func (r *relayMeter) updateCacheMetrics() {
+	stats := r.cacheStats()
+
+	for cache, entries := range map[string]int{
+		cacheDaily:    stats.DailyEntries,
+		cacheToday:    stats.TodaysEntries,
+		cacheOrigin:   stats.OriginEntries,
+		cacheLatency:  stats.LatencyEntries,
+		cacheAppPlans: stats.AppPlanEntries,
+	} {
+		cacheEntries.WithLabelValues(cache).Set(float64(entries))
+	}
+	cacheEstimatedBytes.Set(float64(stats.EstimatedBytes))
+}
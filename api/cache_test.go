@@ -0,0 +1,53 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pokt-foundation/portal-http-db/v2/types"
+	"github.com/pokt-foundation/utils-go/logger"
+)
+
+func TestCacheStats(t *testing.T) {
+	now, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
+	fakeBackend := fakeBackend{
+		usage: map[time.Time]map[types.PortalAppPublicKey]RelayCounts{
+			now.AddDate(0, 0, -2): {"app1": {Success: 10}, "app2": {Success: 5}},
+			now.AddDate(0, 0, -1): {"app1": {Success: 10}, "app2": {Failure: 5}, "app3": {Success: 1}},
+		},
+		todaysUsage: map[types.PortalAppPublicKey]RelayCounts{
+			"app1": {Success: 1},
+		},
+		todaysOriginUsage: map[types.PortalAppOrigin]RelayCounts{
+			"origin1": {Success: 1},
+			"origin2": {Failure: 1},
+		},
+		todaysLatency: map[types.PortalAppPublicKey][]Latency{
+			"app1": {{Time: now, Latency: 0.1}, {Time: now.Add(time.Hour), Latency: 0.2}},
+			"app2": {{Time: now, Latency: 0.3}},
+		},
+	}
+
+	relayMeter := NewRelayMeter(context.Background(), &fakeBackend, &fakeDriver{}, logger.New(), RelayMeterOptions{LoadInterval: 100 * time.Millisecond})
+	time.Sleep(200 * time.Millisecond)
+
+	got := relayMeter.CacheStats()
+	expected := CacheStats{
+		DailyDays:      2,
+		DailyEntries:   5,
+		TodaysEntries:  1,
+		OriginEntries:  2,
+		LatencyEntries: 3,
+	}
+	if got.EstimatedBytes <= 0 {
+		t.Errorf("Expected a positive estimate of the caches memory use, got: %d", got.EstimatedBytes)
+	}
+
+	// The estimate depends on the platform, so only the entry counts are compared
+	got.EstimatedBytes = 0
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("unexpected value (-want +got):\n%s", diff)
+	}
+}
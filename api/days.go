@@ -0,0 +1,20 @@
+package api
+
+import "time"
+
+// dayLocation is the location whose wall-clock day boundaries are used to bucket the relays
+var dayLocation = time.UTC
+
+// startOfDay returns the start, in the specified location, of the calendar day of t.
+//
+//	The calendar day is taken as t specifies it, i.e. t is not converted to the location first.
+func startOfDay(t time.Time, loc *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+// startOfNextDay returns the start, in the specified location, of the calendar day following the day of t.
+//
+//	Days are not assumed to be 24 hours long: on daylight-saving transitions a day can be 23 or 25 hours.
+func startOfNextDay(t time.Time, loc *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+}
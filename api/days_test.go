@@ -0,0 +1,104 @@
+package api
+
+import (
+	"testing"
+	"time"
+	// Embedded timezone database, so the tests do not depend on the system's
+	_ "time/tzdata"
+)
+
+func TestDayBoundaries(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	testCases := []struct {
+		name              string
+		t                 time.Time
+		expectedStart     time.Time
+		expectedNextStart time.Time
+		expectedLength    time.Duration
+	}{
+		{
+			name:              "Regular day",
+			t:                 time.Date(2023, 6, 15, 13, 30, 0, 0, newYork),
+			expectedStart:     time.Date(2023, 6, 15, 0, 0, 0, 0, newYork),
+			expectedNextStart: time.Date(2023, 6, 16, 0, 0, 0, 0, newYork),
+			expectedLength:    24 * time.Hour,
+		},
+		{
+			name:              "Spring-forward day is 23 hours long",
+			t:                 time.Date(2023, 3, 12, 23, 30, 0, 0, newYork),
+			expectedStart:     time.Date(2023, 3, 12, 0, 0, 0, 0, newYork),
+			expectedNextStart: time.Date(2023, 3, 13, 0, 0, 0, 0, newYork),
+			expectedLength:    23 * time.Hour,
+		},
+		{
+			name:              "Fall-back day is 25 hours long",
+			t:                 time.Date(2023, 11, 5, 23, 30, 0, 0, newYork),
+			expectedStart:     time.Date(2023, 11, 5, 0, 0, 0, 0, newYork),
+			expectedNextStart: time.Date(2023, 11, 6, 0, 0, 0, 0, newYork),
+			expectedLength:    25 * time.Hour,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			start := startOfDay(tc.t, newYork)
+			if !start.Equal(tc.expectedStart) {
+				t.Errorf("Expected start of day: %v, got: %v", tc.expectedStart, start)
+			}
+
+			next := startOfNextDay(tc.t, newYork)
+			if !next.Equal(tc.expectedNextStart) {
+				t.Errorf("Expected start of next day: %v, got: %v", tc.expectedNextStart, next)
+			}
+
+			if length := next.Sub(start); length != tc.expectedLength {
+				t.Errorf("Expected day length: %v, got: %v", tc.expectedLength, length)
+			}
+
+			// A relay late in the day is bucketed in its own day, which a fixed 24 hours addition would not do on a 25 hours day
+			if !tc.t.Before(next) || tc.t.Before(start) {
+				t.Errorf("Expected %v to be within [%v, %v)", tc.t, start, next)
+			}
+		})
+	}
+}
+
+func TestDayIterationAcrossDST(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	testCases := []struct {
+		name string
+		from time.Time
+	}{
+		{
+			name: "Iteration across spring-forward",
+			from: time.Date(2023, 3, 10, 0, 0, 0, 0, newYork),
+		},
+		{
+			name: "Iteration across fall-back",
+			from: time.Date(2023, 11, 3, 0, 0, 0, 0, newYork),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			day := tc.from
+			for i := 0; i < 5; i++ {
+				if day.Hour() != 0 || day.Minute() != 0 {
+					t.Fatalf("Expected day %d to start at midnight, got: %v", i, day)
+				}
+				if day.Day() != tc.from.Day()+i {
+					t.Fatalf("Expected day %d to be the %d, got: %v", i, tc.from.Day()+i, day)
+				}
+				day = startOfNextDay(day, newYork)
+			}
+		})
+	}
+}
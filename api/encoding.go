@@ -0,0 +1,347 @@
+package api
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// stringIntegersRequested returns whether the request asks for the integers of the response, e.g. the relay counts,
+// to be encoded as JSON strings, i.e. using intEncoding=string. This is meant for clients which parse JSON numbers
+// as float64, e.g. JavaScript, and would lose the precision of integers beyond 2^53.
+func stringIntegersRequested(req *http.Request) (bool, error) {
+	switch encoding := req.URL.Query().Get(PARAMETER_INT_ENCODING); encoding {
+	case "", INT_ENCODING_NUMBER:
+		return false, nil
+	case INT_ENCODING_STRING:
+		return true, nil
+	default:
+		return false, fmt.Errorf("Invalid %s: %q, expected %q or %q", PARAMETER_INT_ENCODING, encoding, INT_ENCODING_NUMBER, INT_ENCODING_STRING)
+	}
+}
+
+var (
+	marshalerType     = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+	// jsonFieldsCache holds the []jsonField of each struct type encoded with its integers as strings
+	jsonFieldsCache sync.Map
+)
+
+// marshalStringIntegers returns the JSON encoding of the value, as json.Marshal does, except that its integers are encoded
+// as strings, e.g. {"Success":12} as {"Success":"12"}.
+//
+//	The integers are found by their Go type, so the floats, e.g. the rates, stay numbers whatever their value.
+//	The values which marshal themselves, e.g. time.Time, are encoded by json.Marshal.
+func marshalStringIntegers(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := encodeStringIntegers(&buf, reflect.ValueOf(v)); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func encodeStringIntegers(buf *bytes.Buffer, v reflect.Value) error {
+	if !v.IsValid() || ((v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && v.IsNil()) {
+		buf.WriteString("null")
+		return nil
+	}
+	if v.Kind() != reflect.Interface && (v.Type().Implements(marshalerType) || v.Type().Implements(textMarshalerType)) {
+		return writeJSON(buf, v.Interface())
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return encodeStringIntegers(buf, v.Elem())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		buf.WriteByte('"')
+		buf.WriteString(strconv.FormatInt(v.Int(), 10))
+		buf.WriteByte('"')
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		buf.WriteByte('"')
+		buf.WriteString(strconv.FormatUint(v.Uint(), 10))
+		buf.WriteByte('"')
+	case reflect.Struct:
+		buf.WriteByte('{')
+		first := true
+		for _, field := range jsonFields(v.Type()) {
+			fieldValue, ok := fieldByIndex(v, field.index)
+			if !ok || (field.omitEmpty && isEmptyValue(fieldValue)) {
+				continue
+			}
+			if !first {
+				buf.WriteByte(',')
+			}
+			first = false
+			if err := writeJSON(buf, field.name); err != nil {
+				return err
+			}
+			buf.WriteByte(':')
+			if err := encodeStringIntegers(buf, fieldValue); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case reflect.Map:
+		return encodeMapStringIntegers(buf, v)
+	case reflect.Slice:
+		if v.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			// Byte slices are encoded as base64 strings
+			return writeJSON(buf, v.Interface())
+		}
+		return encodeElementsStringIntegers(buf, v)
+	case reflect.Array:
+		return encodeElementsStringIntegers(buf, v)
+	default:
+		return writeJSON(buf, v.Interface())
+	}
+
+	return nil
+}
+
+func encodeElementsStringIntegers(buf *bytes.Buffer, v reflect.Value) error {
+	buf.WriteByte('[')
+	for i := 0; i < v.Len(); i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := encodeStringIntegers(buf, v.Index(i)); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte(']')
+
+	return nil
+}
+
+// encodeMapStringIntegers encodes the map as a JSON object sorted by key, as json.Marshal does
+func encodeMapStringIntegers(buf *bytes.Buffer, v reflect.Value) error {
+	if v.IsNil() {
+		buf.WriteString("null")
+		return nil
+	}
+
+	type entry struct {
+		key   string
+		value reflect.Value
+	}
+	entries := make([]entry, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		key, err := mapKeyString(iter.Key())
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry{key: key, value: iter.Value()})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+
+	buf.WriteByte('{')
+	for i, e := range entries {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := writeJSON(buf, e.key); err != nil {
+			return err
+		}
+		buf.WriteByte(':')
+		if err := encodeStringIntegers(buf, e.value); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+
+	return nil
+}
+
+// mapKeyString returns the map key as the name of its JSON object member, as json.Marshal does
+func mapKeyString(key reflect.Value) (string, error) {
+	if key.Kind() == reflect.String {
+		return key.String(), nil
+	}
+	if marshaler, ok := key.Interface().(encoding.TextMarshaler); ok {
+		text, err := marshaler.MarshalText()
+		return string(text), err
+	}
+
+	switch key.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(key.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(key.Uint(), 10), nil
+	default:
+		return "", fmt.Errorf("unsupported map key type: %s", key.Type())
+	}
+}
+
+// writeJSON writes the value as encoded by json.Marshal
+func writeJSON(buf *bytes.Buffer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	buf.Write(data)
+
+	return nil
+}
+
+// jsonField is a member of the JSON object of a struct, as encoded by json.Marshal
+type jsonField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+	depth     int
+	tagged    bool
+}
+
+// jsonFields returns the members of the JSON object of the struct type, in the order of json.Marshal.
+//
+//	The fields of embedded structs are promoted, unless a field of the same name is less deeply embedded, or tagged at the same depth.
+func jsonFields(t reflect.Type) []jsonField {
+	if cached, ok := jsonFieldsCache.Load(t); ok {
+		return cached.([]jsonField)
+	}
+
+	candidates := appendJSONFields(nil, t, nil, map[reflect.Type]bool{t: true})
+
+	byName := make(map[string][]jsonField, len(candidates))
+	for _, field := range candidates {
+		byName[field.name] = append(byName[field.name], field)
+	}
+
+	var fields []jsonField
+	for _, field := range candidates {
+		if dominant, ok := dominantField(byName[field.name]); ok && dominant.depth == field.depth && dominant.tagged == field.tagged {
+			fields = append(fields, field)
+			// The other candidates of the same name are dropped
+			byName[field.name] = nil
+		}
+	}
+
+	jsonFieldsCache.Store(t, fields)
+	return fields
+}
+
+// appendJSONFields appends the fields of the struct type, and the promoted fields of its embedded structs, in index order
+func appendJSONFields(fields []jsonField, t reflect.Type, index []int, visited map[reflect.Type]bool) []jsonField {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		fieldIndex := append(append([]int(nil), index...), i)
+
+		if f.Anonymous && name == "" {
+			embedded := f.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if !visited[embedded] {
+					visited[embedded] = true
+					fields = appendJSONFields(fields, embedded, fieldIndex, visited)
+					delete(visited, embedded)
+				}
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+
+		field := jsonField{name: name, index: fieldIndex, depth: len(index), tagged: name != ""}
+		if name == "" {
+			field.name = f.Name
+		}
+		for _, option := range strings.Split(options, ",") {
+			if option == "omitempty" {
+				field.omitEmpty = true
+			}
+		}
+		fields = append(fields, field)
+	}
+
+	return fields
+}
+
+// dominantField returns the field which json.Marshal encodes among the fields of the same name, if any
+func dominantField(fields []jsonField) (jsonField, bool) {
+	if len(fields) == 0 {
+		return jsonField{}, false
+	}
+
+	depth := fields[0].depth
+	for _, field := range fields {
+		if field.depth < depth {
+			depth = field.depth
+		}
+	}
+
+	var shallowest, tagged []jsonField
+	for _, field := range fields {
+		if field.depth == depth {
+			shallowest = append(shallowest, field)
+			if field.tagged {
+				tagged = append(tagged, field)
+			}
+		}
+	}
+
+	switch {
+	case len(shallowest) == 1:
+		return shallowest[0], true
+	case len(tagged) == 1:
+		return tagged[0], true
+	default:
+		return jsonField{}, false
+	}
+}
+
+// fieldByIndex returns the struct's field, or false if it is promoted through a nil embedded pointer
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+
+	return v, true
+}
+
+// isEmptyValue reports whether the value is omitted by the omitempty option of json.Marshal
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Pointer:
+		return v.IsNil()
+	default:
+		return false
+	}
+}
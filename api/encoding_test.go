@@ -0,0 +1,72 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/pokt-foundation/portal-http-db/v2/types"
+)
+
+func TestMarshalStringIntegers(t *testing.T) {
+	from := time.Date(2022, time.July, 20, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name     string
+		value    any
+		expected string
+	}{
+		{
+			name:     "Integers beyond 2^53 are quoted unchanged",
+			value:    RelayCounts{Success: 1<<53 + 1, Failure: -1 << 63},
+			expected: `{"Success":"9007199254740993","Failure":"-9223372036854775808"}`,
+		},
+		{
+			name:     "Floats with a whole value are not quoted",
+			value:    RelayRate{SuccessRate: 1, FailureRate: 0},
+			expected: `{"SuccessRate":1,"FailureRate":0}`,
+		},
+		{
+			name: "Fields of embedded structs are promoted, and omitted if empty",
+			value: AppRelaysResponse{
+				PublicKey:   "app_12",
+				From:        from,
+				To:          from.AddDate(0, 0, 1),
+				Count:       RelayCounts{Success: 42},
+				DayCoverage: DayCoverage{DaysWithData: 1, DaysRequested: 1},
+				Notes:       []string{"42 days"},
+			},
+			expected: `{"Count":{"Success":"42","Failure":"0"},"From":"2022-07-20T00:00:00Z","To":"2022-07-21T00:00:00Z","Application":"app_12","Notes":["42 days"],"DaysWithData":"1","DaysRequested":"1"}`,
+		},
+		{
+			name:     "Map keys are sorted, and integers within slices and maps are quoted",
+			value:    map[types.PortalAppPublicKey][]int64{"app_2": {1, 2}, "app_1": nil},
+			expected: `{"app_1":null,"app_2":["1","2"]}`,
+		},
+		{
+			name:     "Integer map keys are encoded as by json.Marshal",
+			value:    map[int]int64{404: 2, 200: 40},
+			expected: `{"200":"40","404":"2"}`,
+		},
+		{
+			name:     "Nil values are null",
+			value:    (*RelayRate)(nil),
+			expected: `null`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := marshalStringIntegers(tc.value)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !json.Valid(got) {
+				t.Fatalf("Expected valid JSON, got: %s", got)
+			}
+			if string(got) != tc.expected {
+				t.Errorf("Expected: %s, got: %s", tc.expected, got)
+			}
+		})
+	}
+}
@@ -2,18 +2,22 @@ package api
 
 import (
 	"bytes"
+	"container/heap"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pokt-foundation/portal-http-db/v2/types"
 	"github.com/pokt-foundation/utils-go/logger"
+	"github.com/pokt-foundation/utils-go/numbers"
 )
 
 const (
@@ -29,24 +33,75 @@ const (
 var (
 	ErrPortalAppNotFound  = errors.New("PortalApp/portalAppID not found")
 	ErrAppLatencyNotFound = errors.New("app latency not found")
+	ErrDataLoaderStalled  = errors.New("data loader stalled")
+	// ErrInvalidTimespan is returned for a time period whose start follows its end
+	ErrInvalidTimespan = errors.New("Invalid timespan")
 )
 
+// UnknownPlanType is reported for the relays of applications whose portal app, and so plan type, is unknown
+const UnknownPlanType types.PayPlanType = "UNKNOWN"
+
 type RelayMeter interface {
 	// AppRelays returns total number of relays for the app over the specified time period
 	AppRelays(ctx context.Context, appPubKey types.PortalAppPublicKey, from, to time.Time) (AppRelaysResponse, error)
-	AllAppsRelays(ctx context.Context, from, to time.Time) ([]AppRelaysResponse, error)
+	// CompareAppsRelays returns the relay counts of two apps over the same time period, and the difference between them
+	CompareAppsRelays(ctx context.Context, appA, appB types.PortalAppPublicKey, from, to time.Time) (AppsComparisonResponse, error)
+	// AppSLO returns whether the success rate of the app's relays over the specified time period met the target success rate
+	AppSLO(ctx context.Context, appPubKey types.PortalAppPublicKey, target float64, from, to time.Time) (AppSLOResponse, error)
+	// AppHealth returns the relay counts and success rate of the app over the specified time period, with its latencies
+	AppHealth(ctx context.Context, appPubKey types.PortalAppPublicKey, from, to time.Time) (AppHealthResponse, error)
+	// AppRank returns the rank of the app among all the apps with metrics over the time period, by the specified relay count
+	AppRank(ctx context.Context, appPubKey types.PortalAppPublicKey, from, to time.Time, by OriginOrder) (AppRankResponse, error)
+	// AllAppsRelays returns the page of the relay counts of all the apps with metrics in the time period, sorted by public key, and the total number of apps
+	AllAppsRelays(ctx context.Context, from, to time.Time, page Page) ([]AppRelaysResponse, int, error)
+	// ActiveApps returns the sorted public keys of the apps with any relays over the specified time period
+	ActiveApps(ctx context.Context, from, to time.Time) ([]types.PortalAppPublicKey, error)
+	// AppsFullyFailing returns the apps with failed, but no successful, relays over the specified time period,
+	// and at least minVolume failed relays, sorted by descending failure count.
+	AppsFullyFailing(ctx context.Context, from, to time.Time, minVolume int64) ([]AppRelaysResponse, error)
 	UserRelays(ctx context.Context, user types.UserID, from, to time.Time) (UserRelaysResponse, error)
 	TotalRelays(ctx context.Context, from, to time.Time) (TotalRelaysResponse, error)
+	// PlanRelays returns the relay counts of each plan type, e.g. FREETIER_V0, over the specified time period
+	PlanRelays(ctx context.Context, from, to time.Time) ([]PlanRelaysResponse, error)
+	// TotalRelaysByDay returns the network relay counts for each day of the specified time period, up to and including today
+	TotalRelaysByDay(ctx context.Context, from, to time.Time) ([]DailyRelaysResponse, error)
+	// AppErrors returns the failed relays count of an application for each day in the time period
+	AppErrors(ctx context.Context, appPubKey types.PortalAppPublicKey, from, to time.Time) ([]DailyErrorsResponse, error)
 
 	// PortalAppRelays returns the metrics for a Portal
 	PortalAppRelays(ctx context.Context, portalAppID types.PortalAppID, from, to time.Time) (PortalAppRelaysResponse, error)
-	AllPortalAppsRelays(ctx context.Context, from, to time.Time) ([]PortalAppRelaysResponse, error)
+	// PortalAppsRelays returns the metrics for each of the specified Portal Apps: unknown Portal Apps are reported per entry
+	PortalAppsRelays(ctx context.Context, portalAppIDs []types.PortalAppID, from, to time.Time) ([]PortalAppRelaysResponse, error)
+	// AllPortalAppsRelays returns the page of the metrics of all the Portal Apps, sorted by ID, and the total number of Portal Apps
+	AllPortalAppsRelays(ctx context.Context, from, to time.Time, page Page) ([]PortalAppRelaysResponse, int, error)
+	// PortalAppsStale returns whether the last portal apps lookup failed, and the cached portal apps list was served instead
+	PortalAppsStale() bool
+	// PortalAppOverview returns the relays, latencies and whitelisted origins metrics of a portal app in a single response
+	PortalAppOverview(ctx context.Context, portalAppID types.PortalAppID, from, to time.Time) (PortalAppOverviewResponse, error)
 	AppLatency(ctx context.Context, appPubKey types.PortalAppPublicKey) (AppLatencyResponse, error)
-	AllAppsLatencies(ctx context.Context) ([]AppLatencyResponse, error)
-	AllRelaysOrigin(ctx context.Context, from, to time.Time) ([]OriginClassificationsResponse, error)
+	// Coverage returns the ranges of days of the specified time period for which daily metrics have been collected
+	Coverage(ctx context.Context, from, to time.Time) (CoverageResponse, error)
+	AllAppsLatencies(ctx context.Context, limit int) ([]AppLatencyResponse, error)
+	// AllRelaysOrigin returns the relay counts of all origins, or of the top origins ranked by the specified relay count if limit is positive
+	AllRelaysOrigin(ctx context.Context, from, to time.Time, limit int, by OriginOrder) ([]OriginClassificationsResponse, error)
 	RelaysOrigin(ctx context.Context, origin types.PortalAppOrigin, from, to time.Time) (OriginClassificationsResponse, error)
 
 	WriteHTTPSourceRelayCounts(ctx context.Context, counts []HTTPSourceRelayCount) error
+
+	// DataGeneration returns the number of times the cached data has been refreshed from the backend
+	DataGeneration() uint64
+	// ServedTimePeriod returns the time period served for the requested one, i.e. adjusted to whole days and clamped to the archived metrics
+	ServedTimePeriod(from, to time.Time) (time.Time, time.Time, error)
+	// CheckDataLoader returns an error if the data loader is not running periodically
+	CheckDataLoader() error
+	// StaleData returns a note for each of the cached metrics which the data loader has failed to refresh in time
+	StaleData() []string
+	// CacheStats returns the entry counts, and the estimated memory footprint, of the in-memory caches
+	CacheStats() CacheStats
+	// AppRawDailyRows returns the app's daily metrics as stored by the backend, bypassing the cache, to compare them with the served ones
+	AppRawDailyRows(ctx context.Context, appPubKey types.PortalAppPublicKey, from, to time.Time) (AppRawDailyRowsResponse, error)
+	// Limits returns how far back the metrics can be queried, as configured in the meter's options
+	Limits() LimitsResponse
 }
 
 type RelayCounts struct {
@@ -54,9 +109,91 @@ type RelayCounts struct {
 	Failure int64
 }
 
+// Add returns the sum of the relay counts
+func (c RelayCounts) Add(o RelayCounts) RelayCounts {
+	return RelayCounts{
+		Success: c.Success + o.Success,
+		Failure: c.Failure + o.Failure,
+	}
+}
+
+// Sub returns the difference of the relay counts, e.g. the relays counted since an earlier reading
+func (c RelayCounts) Sub(o RelayCounts) RelayCounts {
+	return RelayCounts{
+		Success: c.Success - o.Success,
+		Failure: c.Failure - o.Failure,
+	}
+}
+
+// Sum returns the sum of all the relay counts, i.e. zero counts for an empty slice
+func Sum(counts []RelayCounts) RelayCounts {
+	var total RelayCounts
+	for _, count := range counts {
+		total = total.Add(count)
+	}
+
+	return total
+}
+
 type Latency struct {
-	Time    time.Time
+	Time time.Time
+	// Latency is the mean latency of the hour
 	Latency float64
+	// Percentiles is only set if the source of the latencies provides them
+	Percentiles *LatencyPercentiles `json:",omitempty"`
+}
+
+// LatencyPercentiles are the percentiles of the latencies of an hour, in the same unit as its mean latency
+type LatencyPercentiles struct {
+	P50 float64
+	P95 float64
+	P99 float64
+}
+
+// RequestedTimePeriod holds the time period supplied by the caller, for the relay responses whose From and To are adjusted to day boundaries.
+//
+//	Each field is omitted if it was not supplied, or if it is equal to its adjusted value.
+type RequestedTimePeriod struct {
+	RequestedFrom *time.Time `json:"RequestedFrom,omitempty"`
+	RequestedTo   *time.Time `json:"RequestedTo,omitempty"`
+	// FromClamped is set if the supplied From preceded the archived time period, i.e. MaxPastDays, and From was moved to its first day
+	FromClamped bool `json:"FromClamped,omitempty"`
+}
+
+// DayCoverage reports how many days of the requested time period have collected metrics, e.g. 7 of 30,
+// as the counts of a time period only partially covered by the metrics may be misleading.
+type DayCoverage struct {
+	DaysWithData  int `json:"DaysWithData"`
+	DaysRequested int `json:"DaysRequested"`
+}
+
+// ratePrecision is the number of decimals of the rates: unlike the relay counts, which are exact integers, the rates
+// are derived floats, so digits beyond the meaningful ones are not reported.
+const ratePrecision = 6
+
+// RelayRate is the average number of relays per second over a time period
+type RelayRate struct {
+	SuccessRate float64 `json:"SuccessRate"`
+	FailureRate float64 `json:"FailureRate"`
+}
+
+// relayRate returns the average relays per second of the counts over the adjusted time period.
+//
+//	A time period including today is cut short at the current time, as today's counts only cover the elapsed part of the day.
+func relayRate(count RelayCounts, from, to, now time.Time) *RelayRate {
+	if now.Before(to) {
+		to = now
+	}
+
+	seconds := to.Sub(from).Seconds()
+	if seconds <= 0 {
+		return &RelayRate{}
+	}
+
+	return &RelayRate{
+		SuccessRate: numbers.RoundFloat(float64(count.Success)/seconds, ratePrecision),
+		FailureRate: numbers.RoundFloat(float64(count.Failure)/seconds, ratePrecision),
+	}
 }
 
 // TODO: refactor common fields
@@ -65,6 +202,12 @@ type AppRelaysResponse struct {
 	From      time.Time                `json:"From"`
 	To        time.Time                `json:"To"`
 	PublicKey types.PortalAppPublicKey `json:"Application"`
+	// Rate is only set if requested, i.e. using rate=true
+	Rate *RelayRate `json:"Rate,omitempty"`
+	// Notes explains any caveats on the returned counts, e.g. a time period shortened to the available metrics
+	Notes []string `json:"Notes,omitempty"`
+	RequestedTimePeriod
+	DayCoverage
 }
 
 type AppLatencyResponse struct {
@@ -79,20 +222,144 @@ type OriginClassificationsResponse struct {
 	From   time.Time             `json:"From"`
 	To     time.Time             `json:"To"`
 	Origin types.PortalAppOrigin `json:"Origin"`
+	RequestedTimePeriod
+	// Notes explains any caveats on the returned counts, e.g. a time period not covered by the origin metrics
+	Notes []string `json:"Notes,omitempty"`
+	// FailureUnavailable is set if no failures are collected for the origins, i.e. a zero failure count does not mean all relays succeeded
+	FailureUnavailable bool `json:"FailureUnavailable,omitempty"`
 }
 
+// originFailuresUnavailableNote explains the zero failure counts of the origins when no origin failures are collected
+const originFailuresUnavailableNote = "Failure counts are unavailable for the origins: a zero failure count does not mean all relays succeeded"
+
+// todayIncludedNote explains the partial counts of a time period which includes today
+const todayIncludedNote = "Today is included: its relays are counted so far"
+
 type UserRelaysResponse struct {
 	Count      RelayCounts                `json:"Count"`
 	From       time.Time                  `json:"From"`
 	To         time.Time                  `json:"To"`
 	User       types.UserID               `json:"User"`
 	PublicKeys []types.PortalAppPublicKey `json:"Applications"`
+	// PerApp is the relay counts of each of the user's apps: it is only returned if requested, i.e. using breakdown=true
+	PerApp map[types.PortalAppPublicKey]RelayCounts `json:"PerApp,omitempty"`
+	// Rate is only set if requested, i.e. using rate=true
+	Rate *RelayRate `json:"Rate,omitempty"`
+	// Notes explains any caveats on the returned counts, e.g. a time period shortened to the available metrics
+	Notes []string `json:"Notes,omitempty"`
+	RequestedTimePeriod
+	DayCoverage
 }
 
 type TotalRelaysResponse struct {
 	Count RelayCounts `json:"Count"`
 	From  time.Time   `json:"From"`
 	To    time.Time   `json:"To"`
+	// Rate is only set if requested, i.e. using rate=true
+	Rate *RelayRate `json:"Rate,omitempty"`
+	// Notes explains any caveats on the returned counts, e.g. a time period shortened to the available metrics
+	Notes []string `json:"Notes,omitempty"`
+	RequestedTimePeriod
+	DayCoverage
+}
+
+type PlanRelaysResponse struct {
+	Count    RelayCounts       `json:"Count"`
+	From     time.Time         `json:"From"`
+	To       time.Time         `json:"To"`
+	PlanType types.PayPlanType `json:"PlanType"`
+	RequestedTimePeriod
+}
+
+type DailyRelaysResponse struct {
+	Day   time.Time   `json:"Day"`
+	Count RelayCounts `json:"Count"`
+}
+
+type AppsComparisonResponse struct {
+	A AppRelaysResponse `json:"A"`
+	B AppRelaysResponse `json:"B"`
+	// Delta is the difference between the counts of app A and app B, i.e. A - B
+	Delta RelayCounts `json:"Delta"`
+	// Ratio is the ratio of app A's total relays to app B's: it is omitted if app B has no relays
+	Ratio *float64 `json:"Ratio,omitempty"`
+	// Notes explains any caveats on the returned counts, e.g. applications without any metrics
+	Notes []string `json:"Notes,omitempty"`
+}
+
+// DayRange is a range of consecutive days: both From and To are included
+type DayRange struct {
+	From time.Time `json:"From"`
+	To   time.Time `json:"To"`
+}
+
+type CoverageResponse struct {
+	From time.Time `json:"From"`
+	To   time.Time `json:"To"`
+	// Days are the ranges of days with collected daily metrics: today is never included, as its metrics are not final
+	Days []DayRange `json:"Days"`
+	RequestedTimePeriod
+}
+
+// DailyRow is a row of an app's daily metrics, as stored by the backend
+type DailyRow struct {
+	Day     time.Time `json:"Day"`
+	Success int64     `json:"Success"`
+	Failure int64     `json:"Failure"`
+}
+
+type AppRawDailyRowsResponse struct {
+	PublicKey types.PortalAppPublicKey `json:"Application"`
+	From      time.Time                `json:"From"`
+	To        time.Time                `json:"To"`
+	Rows      []DailyRow               `json:"Rows"`
+	RequestedTimePeriod
+}
+
+// LimitsResponse reports the effective archive window, so clients can constrain the time periods they request
+type LimitsResponse struct {
+	// MaxPastDays is the number of past days of metrics archived by the collector
+	MaxPastDays int `json:"MaxPastDays"`
+	// ServedPastDays is the number of past days of metrics served by the API: at most MaxPastDays
+	ServedPastDays int `json:"ServedPastDays"`
+	// EarliestFrom is the earliest day with metrics served by the API
+	EarliestFrom time.Time `json:"EarliestFrom"`
+}
+
+// AppSLOResponse reports the compliance of an app with a success rate target, i.e. a service level objective
+type AppSLOResponse struct {
+	PublicKey types.PortalAppPublicKey `json:"PublicKey"`
+	From      time.Time                `json:"From"`
+	To        time.Time                `json:"To"`
+	Count     RelayCounts              `json:"Count"`
+	// Target is the target success rate, between 0 and 1
+	Target float64 `json:"Target"`
+	// SuccessRate and Met are omitted for an app without any relays, as its success rate is undefined
+	SuccessRate *float64 `json:"SuccessRate,omitempty"`
+	Met         *bool    `json:"Met,omitempty"`
+	// Notes explains any caveats on the returned values, e.g. an undefined success rate
+	Notes []string `json:"Notes,omitempty"`
+	RequestedTimePeriod
+}
+
+// AppRankResponse reports the rank of an app among all the apps with metrics over the time period
+type AppRankResponse struct {
+	PublicKey types.PortalAppPublicKey `json:"PublicKey"`
+	From      time.Time                `json:"From"`
+	To        time.Time                `json:"To"`
+	Count     RelayCounts              `json:"Count"`
+	// By is the relay count the apps are ranked by: their total relays if not set
+	By OriginOrder `json:"By,omitempty"`
+	// Rank is one more than the number of apps with strictly more relays, i.e. tied apps share the same rank
+	Rank int `json:"Rank"`
+	// Total is the number of ranked apps, including the requested app
+	Total int `json:"Total"`
+	RequestedTimePeriod
+}
+
+type DailyErrorsResponse struct {
+	Day     time.Time `json:"Day"`
+	Failure int64     `json:"Failure"`
 }
 
 type PortalAppRelaysResponse struct {
@@ -101,13 +368,143 @@ type PortalAppRelaysResponse struct {
 	To          time.Time                  `json:"To"`
 	PortalAppID types.PortalAppID          `json:"Endpoint"`
 	PublicKeys  []types.PortalAppPublicKey `json:"Applications"`
+	// Notes explains any caveats on the returned counts, e.g. applications shared with other portal apps
+	Notes []string `json:"Notes,omitempty"`
+	// Rate is only set if requested, i.e. using rate=true
+	Rate *RelayRate `json:"Rate,omitempty"`
+	RequestedTimePeriod
+	DayCoverage
+}
+
+// AppHealthResponse combines the relay counts of an app with its latencies, e.g. to correlate its failed relays with slow ones
+type AppHealthResponse struct {
+	PublicKey types.PortalAppPublicKey `json:"PublicKey"`
+	From      time.Time                `json:"From"`
+	To        time.Time                `json:"To"`
+	Count     RelayCounts              `json:"Count"`
+	// SuccessRate, between 0 and 1, is omitted for an app without any relays
+	SuccessRate *float64 `json:"SuccessRate,omitempty"`
+	// AvgLatency is the mean of the app's hourly mean latencies, and P95Latency the highest of its hourly 95th percentiles.
+	//	Only today's latencies are held, so both are omitted for a time period before today, or an app without latencies:
+	//	P95Latency is also omitted if the source of the latencies does not provide percentiles.
+	AvgLatency *float64 `json:"AvgLatency,omitempty"`
+	P95Latency *float64 `json:"P95Latency,omitempty"`
+	// Notes explains any omitted values
+	Notes []string `json:"Notes,omitempty"`
+	RequestedTimePeriod
+}
+
+type PortalAppOverviewResponse struct {
+	Relays  PortalAppRelaysResponse         `json:"Relays"`
+	Latency []AppLatencyResponse            `json:"Latency"`
+	Origins []OriginClassificationsResponse `json:"Origins"`
 }
 
 type RelayMeterOptions struct {
 	LoadInterval     time.Duration
 	DailyMetricsTTL  time.Duration
 	TodaysMetricsTTL time.Duration
+	// TodaysLatencyTTL and TodaysOriginTTL, if set, refresh today's latencies and origin metrics independently of today's relay counts,
+	//	e.g. to serve the latencies, which are cheaper to serve stale, for longer. TodaysMetricsTTL is used otherwise.
+	TodaysLatencyTTL time.Duration
+	TodaysOriginTTL  time.Duration
 	MaxPastDays      time.Duration
+	// ServedPastDays, if set, limits the daily metrics held in memory, i.e. served by the API, to a shorter time period than MaxPastDays,
+	//	e.g. to serve the last 7 days while the collector archives 90 days of metrics.
+	ServedPastDays time.Duration
+	// AppsEffectiveFrom sets the From of each app returned by AllAppsRelays to the app's first day with data in the requested time period.
+	//	The start of the requested time period is used otherwise.
+	AppsEffectiveFrom bool
+	// CachePortalApps keeps the portal apps list loaded by the data loader, to serve AllPortalAppsRelays from the last-known
+	//	portal apps if the backend, i.e. PHD, is unreachable.
+	CachePortalApps bool
+	// OptionalOriginData and OptionalLatencyData, if set, do not require today's origin metrics, or latencies, for the meter
+	//	to have data, e.g. on a network with origin sampling disabled: all the metrics are otherwise reloaded on every run of
+	//	the data loader until they are all non-empty.
+	OptionalOriginData  bool
+	OptionalLatencyData bool
+	// DefaultPageLimit, if set, is the maximum number of items returned by the paginated lists, e.g. AllAppsRelays,
+	//	when the request does not set a limit. All the items are returned otherwise.
+	DefaultPageLimit int
+	// SlowQueryThreshold, if set, logs a warning with the parameters of any query of the meter taking longer, e.g. AppRelays
+	//	for a long time period. Queries are not timed otherwise.
+	SlowQueryThreshold time.Duration
+}
+
+// Page selects the items of a sorted list to return: Offset items are skipped, and at most Limit items are returned.
+//
+//	A zero Limit returns all the remaining items, unless RelayMeterOptions.DefaultPageLimit is set.
+type Page struct {
+	Limit  int
+	Offset int
+}
+
+// paginate returns the page of the sorted items, limited to defaultLimit items if the page sets no limit
+func paginate[T any](items []T, page Page, defaultLimit int) []T {
+	limit := page.Limit
+	if limit == 0 {
+		limit = defaultLimit
+	}
+
+	start := min(page.Offset, len(items))
+	end := len(items)
+	if limit > 0 {
+		end = min(start+limit, end)
+	}
+	return items[start:end]
+}
+
+// NormalizePublicKey returns the application public key in lowercase, so the same app reported, or requested, with different cases
+// is counted under a single key.
+func NormalizePublicKey(appPubKey types.PortalAppPublicKey) types.PortalAppPublicKey {
+	return types.PortalAppPublicKey(strings.ToLower(string(appPubKey)))
+}
+
+// NormalizeDailyRelayCountsKeys returns the daily counts keyed by the normalized app public keys, adding up the counts of keys differing only in case
+func NormalizeDailyRelayCountsKeys(dayMap map[time.Time]map[types.PortalAppPublicKey]RelayCounts) map[time.Time]map[types.PortalAppPublicKey]RelayCounts {
+	if dayMap == nil {
+		return nil
+	}
+
+	normalizedMap := make(map[time.Time]map[types.PortalAppPublicKey]RelayCounts, len(dayMap))
+
+	for day, appMap := range dayMap {
+		normalizedMap[day] = NormalizeRelayCountsKeys(appMap)
+	}
+
+	return normalizedMap
+}
+
+// NormalizeRelayCountsKeys returns the counts keyed by the normalized app public keys, adding up the counts of keys differing only in case
+func NormalizeRelayCountsKeys(appMap map[types.PortalAppPublicKey]RelayCounts) map[types.PortalAppPublicKey]RelayCounts {
+	if appMap == nil {
+		return nil
+	}
+
+	normalizedMap := make(map[types.PortalAppPublicKey]RelayCounts, len(appMap))
+
+	for app, count := range appMap {
+		app = NormalizePublicKey(app)
+		normalizedMap[app] = normalizedMap[app].Add(count)
+	}
+
+	return normalizedMap
+}
+
+// NormalizeLatencyKeys returns the latencies keyed by the normalized app public keys, joining the latencies of keys differing only in case
+func NormalizeLatencyKeys(latencyMap map[types.PortalAppPublicKey][]Latency) map[types.PortalAppPublicKey][]Latency {
+	if latencyMap == nil {
+		return nil
+	}
+
+	normalizedMap := make(map[types.PortalAppPublicKey][]Latency, len(latencyMap))
+
+	for app, latency := range latencyMap {
+		app = NormalizePublicKey(app)
+		normalizedMap[app] = append(normalizedMap[app], latency...)
+	}
+
+	return normalizedMap
 }
 
 type HTTPSourceRelayCount struct {
@@ -121,6 +518,8 @@ type HTTPSourceRelayCountInput struct {
 	AppPublicKey types.PortalAppPublicKey `json:"appPublicKey"`
 	Success      int64                    `json:"success"`
 	Error        int64                    `json:"error"`
+	// StatusCounts optionally holds the relay counts per HTTP status code: they are classified by the server, and added to the success and error counts.
+	StatusCounts map[int]int64 `json:"statusCounts,omitempty"`
 }
 
 type Backend interface {
@@ -129,6 +528,10 @@ type Backend interface {
 	TodaysUsage() (map[types.PortalAppPublicKey]RelayCounts, error)
 	TodaysLatency() (map[types.PortalAppPublicKey][]Latency, error)
 	TodaysOriginUsage() (map[types.PortalAppOrigin]RelayCounts, error)
+	// CoveredDays returns, in ascending order, the days of the specified time period, both included, with saved daily metrics
+	CoveredDays(from, to time.Time) ([]time.Time, error)
+	// RawDailyRows returns, in ascending order of day, the stored rows of the app's daily metrics for the specified time period, both included
+	RawDailyRows(appPubKey types.PortalAppPublicKey, from, to time.Time) ([]DailyRow, error)
 
 	// Is expected to return the list of portal app public keys owned by the user
 	UserPortalAppPubKeys(ctx context.Context, userID types.UserID) ([]types.PortalAppPublicKey, error)
@@ -161,14 +564,35 @@ type relayMeter struct {
 	Driver
 	*logger.Logger
 
-	dailyUsage        map[time.Time]map[types.PortalAppPublicKey]RelayCounts
+	dailyUsage map[time.Time]map[types.PortalAppPublicKey]RelayCounts
+	// dailyDays are the days of dailyUsage in ascending order, so a request only iterates the days of its time period
+	dailyDays         []time.Time
 	todaysUsage       map[types.PortalAppPublicKey]RelayCounts
 	todaysOriginUsage map[types.PortalAppOrigin]RelayCounts
 	todaysLatency     map[types.PortalAppPublicKey][]Latency
-
-	dailyTTL  time.Time
-	todaysTTL time.Time
-	rwMutex   sync.RWMutex
+	appPlans          map[types.PortalAppPublicKey]types.PayPlanType
+	// portalApps is the last portal apps list loaded by the data loader: it is only kept if CachePortalApps is set
+	portalApps []*types.PortalApp
+	// loadedTodaysUsage is today's usage as last loaded from the backend, i.e. without the uploaded counts:
+	//	it changes once the collector writes today's metrics again
+	loadedTodaysUsage map[types.PortalAppPublicKey]RelayCounts
+	// uploadedUsage holds the relay counts uploaded on uploadedDay since the collector last wrote today's metrics:
+	//	they are added to the loaded ones, as the backend only returns them once the collector has run
+	uploadedUsage map[types.PortalAppPublicKey]RelayCounts
+	uploadedDay   time.Time
+
+	dailyTTL   time.Time
+	todaysTTL  time.Time
+	latencyTTL time.Time
+	originTTL  time.Time
+	generation uint64
+	// lastLoaderTick is the time of the last completed periodic run, or the start, of the data loader
+	lastLoaderTick time.Time
+	rwMutex        sync.RWMutex
+	// loading is set while a data load is in progress, to coalesce overlapping loads
+	loading atomic.Bool
+	// portalAppsStale is set while the cached portal apps are served, due to the backend failing to return them
+	portalAppsStale atomic.Bool
 
 	RelayMeterOptions
 }
@@ -177,12 +601,18 @@ func (r *relayMeter) isEmpty() bool {
 	r.rwMutex.RLock()
 	defer r.rwMutex.RUnlock()
 
-	return len(r.dailyUsage) == 0 || len(r.todaysUsage) == 0 || len(r.todaysOriginUsage) == 0 || len(r.todaysLatency) == 0
+	if len(r.dailyUsage) == 0 || len(r.todaysUsage) == 0 {
+		return true
+	}
+	if len(r.todaysOriginUsage) == 0 && !r.RelayMeterOptions.OptionalOriginData {
+		return true
+	}
+	return len(r.todaysLatency) == 0 && !r.RelayMeterOptions.OptionalLatencyData
 }
 
 // TODO: for now, today's data gets overwritten every time. If needed add todays metrics in intervals as they occur in the day
 func (r *relayMeter) loadData(from, to time.Time) error {
-	var updateDaily, updateToday bool
+	var updateDaily, updateToday, updateLatency, updateOrigin bool
 
 	now := time.Now()
 
@@ -191,66 +621,141 @@ func (r *relayMeter) loadData(from, to time.Time) error {
 	var todaysOriginUsage map[types.PortalAppOrigin]RelayCounts
 	var todaysLatency map[types.PortalAppPublicKey][]Latency
 
-	var err error
+	var dailyErr, todayErr, latencyErr, originErr error
 
 	noDataYet := r.isEmpty()
+	dueDaily := noDataYet || now.After(r.dailyTTL)
+	dueToday := noDataYet || now.After(r.todaysTTL)
+	dueLatency := noDataYet || now.After(r.latencyTTL)
+	dueOrigin := noDataYet || now.After(r.originTTL)
+
+	// The backend calls are independent, so a slow daily query does not delay the refresh of today's metrics
+	var calls []func()
+	if dueDaily {
+		calls = append(calls, func() {
+			defer observeQuery(queryDailyUsage)()
+			dailyUsage, dailyErr = r.Backend.DailyUsage(from, to)
+			dailyUsage = NormalizeDailyRelayCountsKeys(dailyUsage)
+		})
+	}
+	if dueToday {
+		calls = append(calls, func() {
+			defer observeQuery(queryTodaysUsage)()
+			todaysUsage, todayErr = r.Backend.TodaysUsage()
+			todaysUsage = NormalizeRelayCountsKeys(todaysUsage)
+		})
+	}
+	if dueLatency {
+		calls = append(calls, func() {
+			defer observeQuery(queryTodaysLatency)()
+			todaysLatency, latencyErr = r.Backend.TodaysLatency()
+			todaysLatency = NormalizeLatencyKeys(todaysLatency)
+		})
+	}
+	if dueOrigin {
+		calls = append(calls, func() {
+			defer observeQuery(queryTodaysOriginUsage)()
+			todaysOriginUsage, originErr = r.Backend.TodaysOriginUsage()
+		})
+	}
+	concurrently(calls...)
 
-	if noDataYet || now.After(r.dailyTTL) {
-		updateDaily = true
-		// TODO: send backend requests concurrently
-		dailyUsage, err = r.Backend.DailyUsage(from, to)
-		if err != nil {
+	// The metrics which were loaded are applied even if another call failed, and the first error is then returned
+	var err error
+
+	if dueDaily {
+		if dailyErr != nil {
 			r.Logger.Warn("Error loading daily usage data",
-				slog.String("error", err.Error()),
+				slog.String("error", dailyErr.Error()),
+			)
+			err = dailyErr
+		} else {
+			updateDaily = true
+			r.Logger.Info("Received daily metrics",
+				slog.Int("daily_metrics_count", len(dailyUsage)),
 			)
-			return err
 		}
-		r.Logger.Info("Received daily metrics",
-			slog.Int("daily_metrics_count", len(dailyUsage)),
-		)
 	}
 
-	if noDataYet || now.After(r.todaysTTL) {
-		updateToday = true
-		todaysUsage, err = r.Backend.TodaysUsage()
-		if err != nil {
+	if dueToday {
+		if todayErr != nil {
 			r.Logger.Warn("Error loading todays usage data",
-				slog.String("error", err.Error()),
+				slog.String("error", todayErr.Error()),
+			)
+			if err == nil {
+				err = todayErr
+			}
+		} else {
+			updateToday = true
+			r.Logger.Info("Received todays metrics",
+				slog.Int("todays_metrics_count", len(todaysUsage)),
 			)
-			return err
 		}
+	}
 
-		todaysLatency, err = r.Backend.TodaysLatency()
-		if err != nil {
+	// A failure to load the latencies does not fail the load: the previous latencies are kept until the next attempt
+	if dueLatency {
+		if latencyErr != nil {
 			r.Logger.Warn("Error loading todays latency data",
-				slog.String("error", err.Error()),
+				slog.String("error", latencyErr.Error()),
 			)
+		} else {
+			updateLatency = true
 		}
-		r.Logger.Info("Received todays metrics",
-			slog.Int("todays_metrics_count", len(todaysUsage)),
-		)
+	}
 
-		todaysOriginUsage, err = r.Backend.TodaysOriginUsage()
-		if err != nil {
+	if dueOrigin {
+		if originErr != nil {
 			r.Logger.Warn("Error loading todays origin usage data",
-				slog.String("error", err.Error()),
+				slog.String("error", originErr.Error()),
+			)
+			if err == nil {
+				err = originErr
+			}
+		} else {
+			updateOrigin = true
+			r.Logger.Info("Received todays metrics",
+				slog.Int("todays_origin_metrics_count", len(todaysOriginUsage)),
 			)
-			return err
 		}
-		r.Logger.Info("Received todays metrics",
-			slog.Int("todays_origin_metrics_count", len(todaysOriginUsage)),
-		)
 	}
 
-	if !updateDaily && !updateToday {
-		return nil
+	if !updateDaily && !updateToday && !updateLatency && !updateOrigin {
+		if err == nil {
+			dataLoadLastSuccess.SetToCurrentTime()
+		}
+		return err
+	}
+
+	// A failure to load the portal apps should not prevent serving the relay counts: the previous plan types index,
+	//	and portal apps list, are kept instead
+	var appPlans map[types.PortalAppPublicKey]types.PayPlanType
+	observePortalApps := observeQuery(queryPortalApps)
+	portalApps, portalAppsErr := r.Backend.PortalApps(context.Background())
+	observePortalApps()
+	if portalAppsErr != nil {
+		r.Logger.Warn("Error loading portal apps plan types",
+			slog.String("error", portalAppsErr.Error()),
+		)
+	} else {
+		appPlans = r.appPlansOf(portalApps)
 	}
 
 	r.rwMutex.Lock()
 	defer r.rwMutex.Unlock()
 
+	r.generation++
+
+	if appPlans != nil {
+		r.appPlans = appPlans
+		if r.RelayMeterOptions.CachePortalApps {
+			r.portalApps = r.nonNilPortalApps(portalApps)
+		}
+	}
+
 	if updateDaily {
 		r.dailyUsage = dailyUsage
+		r.dailyDays = sortedDays(dailyUsage)
 
 		d := r.RelayMeterOptions.DailyMetricsTTL
 		if int(d.Seconds()) == 0 {
@@ -258,23 +763,190 @@ func (r *relayMeter) loadData(from, to time.Time) error {
 		}
 
 		r.dailyTTL = time.Now().Add(d)
+		cacheUpdates[cacheDaily].Store(time.Now().UnixNano())
 	}
 
 	if updateToday {
+		todaysUsage = r.withUploadedUsage(todaysUsage, now)
 		r.todaysUsage = todaysUsage
-		r.todaysOriginUsage = todaysOriginUsage
+		r.todaysTTL = time.Now().Add(r.todaysMetricsTTL(0))
+		cacheUpdates[cacheToday].Store(time.Now().UnixNano())
+
+		var total RelayCounts
+		for _, count := range todaysUsage {
+			total = total.Add(count)
+		}
+		todaysRelays.WithLabelValues(relaysSuccess).Set(float64(total.Success))
+		todaysRelays.WithLabelValues(relaysFailure).Set(float64(total.Failure))
+	}
+
+	if updateLatency {
 		r.todaysLatency = todaysLatency
+		r.latencyTTL = time.Now().Add(r.todaysMetricsTTL(r.RelayMeterOptions.TodaysLatencyTTL))
+	}
 
-		d := r.RelayMeterOptions.TodaysMetricsTTL
-		if int(d.Seconds()) == 0 {
-			d = time.Duration(TTL_TODAYS_METRICS_DEFAULT_SECONDS) * time.Second
+	if updateOrigin {
+		r.todaysOriginUsage = todaysOriginUsage
+		r.originTTL = time.Now().Add(r.todaysMetricsTTL(r.RelayMeterOptions.TodaysOriginTTL))
+	}
+
+	r.updateCacheMetrics()
+	if err == nil {
+		dataLoadLastSuccess.SetToCurrentTime()
+	}
+	return err
+}
+
+// concurrently runs the calls concurrently, and returns once all of them have completed.
+//
+//	A panic of a call is raised again by the calling goroutine, so the data loader recovers from it as from a sequential call.
+func concurrently(calls ...func()) {
+	var wg sync.WaitGroup
+	panics := make(chan any, len(calls))
+	for _, call := range calls {
+		wg.Add(1)
+		go func(call func()) {
+			defer wg.Done()
+			defer func() {
+				if rec := recover(); rec != nil {
+					panics <- rec
+				}
+			}()
+			call()
+		}(call)
+	}
+	wg.Wait()
+
+	close(panics)
+	if rec, ok := <-panics; ok {
+		panic(rec)
+	}
+}
+
+// appPlansOf returns the plan type of each application public key, using the portal apps the keys belong to
+func (r *relayMeter) appPlansOf(portalApps []*types.PortalApp) map[types.PortalAppPublicKey]types.PayPlanType {
+	appPlans := make(map[types.PortalAppPublicKey]types.PayPlanType)
+	for _, portalApp := range r.nonNilPortalApps(portalApps) {
+		for _, app := range portalApp.AATs {
+			if key := aatPubKey(app); key != "" {
+				appPlans[key] = portalApp.LegacyFields.PlanType
+			}
+		}
+	}
+
+	return appPlans
+}
+
+// todaysMetricsTTL returns the specified TTL of a subset of today's metrics if set, or the TTL of today's metrics otherwise
+func (r *relayMeter) todaysMetricsTTL(d time.Duration) time.Duration {
+	if int(d.Seconds()) == 0 {
+		d = r.RelayMeterOptions.TodaysMetricsTTL
+	}
+	if int(d.Seconds()) == 0 {
+		d = time.Duration(TTL_TODAYS_METRICS_DEFAULT_SECONDS) * time.Second
+	}
+
+	return d
+}
+
+// logSlowQuery logs a warning with the parameters of the query if it took longer than SlowQueryThreshold since start,
+// e.g. to catch the time periods or enrichments which are expensive to serve.
+//
+//	It is meant to be deferred by the queries, so the summation of the relay counts and any backend calls are measured.
+func (r *relayMeter) logSlowQuery(query string, start time.Time, params ...any) {
+	threshold := r.RelayMeterOptions.SlowQueryThreshold
+	if threshold <= 0 {
+		return
+	}
+
+	if duration := time.Since(start); duration > threshold {
+		r.Logger.Warn("Slow meter query",
+			append([]any{
+				slog.String("query", query),
+				slog.Duration("duration", duration),
+				slog.Duration("threshold", threshold),
+			}, params...)...,
+		)
+	}
+}
+
+func (r *relayMeter) ServedTimePeriod(from, to time.Time) (time.Time, time.Time, error) {
+	from, to, _, err := r.adjustRequestedTimePeriod(from, to)
+	return from, to, err
+}
+
+func (r *relayMeter) DataGeneration() uint64 {
+	r.rwMutex.RLock()
+	defer r.rwMutex.RUnlock()
+
+	return r.generation
+}
+
+// WriteHTTPSourceRelayCounts writes the uploaded relay counts using the driver, and merges today's counts into the in-memory cache,
+// so they are visible to the requests served before the collector writes them to today's metrics.
+func (r *relayMeter) WriteHTTPSourceRelayCounts(ctx context.Context, counts []HTTPSourceRelayCount) error {
+	if err := r.Driver.WriteHTTPSourceRelayCounts(ctx, counts); err != nil {
+		return err
+	}
+
+	today := startOfDay(time.Now().In(dayLocation), dayLocation)
+
+	r.rwMutex.Lock()
+	defer r.rwMutex.Unlock()
+
+	if !r.uploadedDay.Equal(today) {
+		r.uploadedUsage, r.uploadedDay = nil, today
+	}
+
+	var todaysCounts map[types.PortalAppPublicKey]RelayCounts
+	for _, count := range counts {
+		if !startOfDay(count.Day.In(dayLocation), dayLocation).Equal(today) {
+			continue
 		}
 
-		r.todaysTTL = time.Now().Add(d)
+		if todaysCounts == nil {
+			todaysCounts = make(map[types.PortalAppPublicKey]RelayCounts)
+		}
+		todaysCounts[count.AppPublicKey] = todaysCounts[count.AppPublicKey].Add(RelayCounts{Success: count.Success, Failure: count.Error})
 	}
+
+	// The cached maps may be shared with the backend, or the requests being served, so they are replaced rather than updated
+	r.uploadedUsage = addRelayCountsMaps(r.uploadedUsage, todaysCounts)
+	r.todaysUsage = addRelayCountsMaps(r.todaysUsage, todaysCounts)
+
 	return nil
 }
 
+// withUploadedUsage returns today's usage loaded from the backend with the relay counts uploaded since the collector last wrote it.
+// The caller must hold the write lock.
+//
+//	The collector writes the uploaded counts to today's metrics along with the other sources', so once the loaded usage changes
+//	the uploaded counts are dropped: they are then part of the loaded usage. The uploads of a past day are also dropped.
+func (r *relayMeter) withUploadedUsage(todaysUsage map[types.PortalAppPublicKey]RelayCounts, now time.Time) map[types.PortalAppPublicKey]RelayCounts {
+	if !r.uploadedDay.Equal(startOfDay(now.In(dayLocation), dayLocation)) || !maps.Equal(r.loadedTodaysUsage, todaysUsage) {
+		r.uploadedUsage = nil
+	}
+	r.loadedTodaysUsage = todaysUsage
+
+	if len(r.uploadedUsage) == 0 {
+		return todaysUsage
+	}
+	return addRelayCountsMaps(todaysUsage, r.uploadedUsage)
+}
+
+// addRelayCountsMaps returns a new map with the relay counts of both maps added up per app
+func addRelayCountsMaps(a, b map[types.PortalAppPublicKey]RelayCounts) map[types.PortalAppPublicKey]RelayCounts {
+	sum := make(map[types.PortalAppPublicKey]RelayCounts, len(a)+len(b))
+	for app, count := range a {
+		sum[app] = count
+	}
+	for app, count := range b {
+		sum[app] = sum[app].Add(count)
+	}
+
+	return sum
+}
+
 func Plog(args ...interface{}) {
 	for _, arg := range args {
 		var prettyJSON bytes.Buffer
@@ -293,6 +965,7 @@ func Plog(args ...interface{}) {
 //
 //	The From parameter is taken to mean the very start of the day that it specifies: the returned result includes all such relays
 func (r *relayMeter) AppRelays(ctx context.Context, appPubKey types.PortalAppPublicKey, from, to time.Time) (AppRelaysResponse, error) {
+	defer r.logSlowQuery("AppRelays", time.Now(), slog.String("appPubKey", string(appPubKey)), slog.Time("from", from), slog.Time("to", to))
 	r.Logger.Info("apiserver: Received AppRelays request",
 		slog.String("appPubKey", string(appPubKey)),
 		slog.Time("from", from),
@@ -304,9 +977,8 @@ func (r *relayMeter) AppRelays(ctx context.Context, appPubKey types.PortalAppPub
 		PublicKey: appPubKey,
 	}
 
-	// TODO: enforce MaxArchiveAge on From parameter
 	// TODO: enforce Today as maximum value for To parameter
-	from, to, err := AdjustTimePeriod(from, to)
+	from, to, requested, err := r.adjustRequestedTimePeriod(from, to)
 	if err != nil {
 		return resp, err
 	}
@@ -320,85 +992,348 @@ func (r *relayMeter) AppRelays(ctx context.Context, appPubKey types.PortalAppPub
 
 	Plog("DAYLY USAGE", r.dailyUsage)
 
-	var total RelayCounts
-	for day, counts := range r.dailyUsage {
-		// Note: Equal is not tested for 'to' parameter, as it is already adjusted to the start of the day after the specified date.
-		if (day.After(from) || day.Equal(from)) && day.Before(to) {
-			total.Success += counts[appPubKey].Success
-			total.Failure += counts[appPubKey].Failure
-		}
-	}
-
-	// TODO: Add a 'Notes' []string field to output: to provide an explanation when the input 'from' or 'to' parameters are corrected.
-	if today.Equal(to) || today.Before(to) {
-		total.Success += r.todaysUsage[appPubKey].Success
-		total.Failure += r.todaysUsage[appPubKey].Failure
-	}
-
-	resp.Count = total
+	resp.DayCoverage = r.dayCoverage(from, to, today)
+	notes := timePeriodNotes(from, to, today, requested)
+	from, requested, resp.Notes = r.clampToAvailableData(from, to, requested)
+	resp.Notes = append(notes, resp.Notes...)
+	resp.Count = r.appRelayCounts(appPubKey, from, to, today)
 	resp.From = from
 	resp.To = to
+	resp.RequestedTimePeriod = requested
 
 	return resp, nil
 }
 
-func (r *relayMeter) AppLatency(ctx context.Context, appPubKey types.PortalAppPublicKey) (AppLatencyResponse, error) {
-	r.Logger.Info("apiserver: Received AppLatency request",
-		slog.String("appPubKey", string(appPubKey)),
-	)
+// sortedDays returns the days of the daily metrics in ascending order
+func sortedDays(dailyUsage map[time.Time]map[types.PortalAppPublicKey]RelayCounts) []time.Time {
+	days := make([]time.Time, 0, len(dailyUsage))
+	for day := range dailyUsage {
+		days = append(days, day)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	return days
+}
 
-	appLatency := r.todaysLatency[appPubKey]
+// sortedDailyDays returns the days of the daily metrics in ascending order. The caller must hold the read lock.
+func (r *relayMeter) sortedDailyDays() []time.Time {
+	// The index only falls out of step if the daily metrics were not set by the data loader, e.g. in tests
+	if len(r.dailyDays) != len(r.dailyUsage) {
+		return sortedDays(r.dailyUsage)
+	}
+	return r.dailyDays
+}
 
-	if len(appLatency) == 0 {
-		return AppLatencyResponse{}, ErrAppLatencyNotFound
+// daysWithin returns, in ascending order, the days of the daily metrics within the adjusted time period,
+// i.e. without iterating the days outside it. The caller must hold the read lock.
+func (r *relayMeter) daysWithin(from, to time.Time) []time.Time {
+	days := r.sortedDailyDays()
+	// Note: 'to' is excluded, as it is already adjusted to the start of the day after the specified date.
+	start := sort.Search(len(days), func(i int) bool { return !days[i].Before(from) })
+	end := sort.Search(len(days), func(i int) bool { return !days[i].Before(to) })
+	if end < start {
+		end = start
 	}
+	return days[start:end]
+}
 
-	sort.Slice(appLatency, func(i, j int) bool {
-		return appLatency[i].Time.Before(appLatency[j].Time)
-	})
+// appRelayCounts returns the relay counts of the app over the adjusted time period. The caller must hold the read lock.
+func (r *relayMeter) appRelayCounts(appPubKey types.PortalAppPublicKey, from, to, today time.Time) RelayCounts {
+	var total RelayCounts
+	for _, day := range r.daysWithin(from, to) {
+		total = total.Add(r.dailyUsage[day][appPubKey])
+	}
 
-	return AppLatencyResponse{
-		PublicKey:    appPubKey,
-		DailyLatency: appLatency,
-		From:         appLatency[0].Time,
-		To:           appLatency[len(appLatency)-1].Time,
-	}, nil
+	if today.Equal(to) || today.Before(to) {
+		total = total.Add(r.todaysUsage[appPubKey])
+	}
+
+	return total
 }
 
-func (r *relayMeter) AllAppsLatencies(ctx context.Context) ([]AppLatencyResponse, error) {
-	r.Logger.Info("apiserver: Received AllAppsLatencies request")
+// dayCoverage returns the number of days of the adjusted time period, and of those with collected metrics.
+// Today is counted as a day with data once its metrics are loaded. The caller must hold the read lock.
+func (r *relayMeter) dayCoverage(from, to, today time.Time) DayCoverage {
+	var coverage DayCoverage
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		coverage.DaysRequested++
+	}
 
-	resp := []AppLatencyResponse{}
+	coverage.DaysWithData = len(r.daysWithin(from, to))
+	if (today.Equal(to) || today.Before(to)) && len(r.todaysUsage) > 0 {
+		coverage.DaysWithData++
+	}
 
-	for appPubKey, appLatency := range r.todaysLatency {
-		if len(appLatency) > 0 {
-			sort.Slice(appLatency, func(i, j int) bool {
-				return appLatency[i].Time.Before(appLatency[j].Time)
-			})
+	return coverage
+}
 
-			latencyResp := AppLatencyResponse{
-				PublicKey:    appPubKey,
-				DailyLatency: appLatency,
-				From:         appLatency[0].Time,
-				To:           appLatency[len(appLatency)-1].Time,
-			}
+// clampToAvailableData returns 'from' clamped to the earliest day with daily metrics, if the time period starts before it,
+// along with a note and the requested time period reporting the supplied 'from'. The caller must hold the read lock.
+//
+//	Without any daily metrics, e.g. only today's metrics are cached so far, the time period is returned as is.
+func (r *relayMeter) clampToAvailableData(from, to time.Time, requested RequestedTimePeriod) (time.Time, RequestedTimePeriod, []string) {
+	var earliest time.Time
+	if days := r.sortedDailyDays(); len(days) > 0 {
+		earliest = days[0]
+	}
+	if earliest.IsZero() || !from.Before(earliest) {
+		return from, requested, nil
+	}
 
-			resp = append(resp, latencyResp)
+	note := fmt.Sprintf("Metrics are only available from %s", earliest.Format(time.RFC3339))
+	// A time period ending before the earliest day has no metrics at all, so there is nothing to shorten it to
+	if !earliest.Before(to) {
+		return from, requested, []string{note}
+	}
+
+	if requested.RequestedFrom == nil {
+		requested.RequestedFrom = &from
+	}
+	return earliest, requested, []string{note + ": the time period was shortened to start then"}
+}
+
+// knownApp returns true if the app has any metrics in the cache, regardless of the time period. The caller must hold the read lock.
+func (r *relayMeter) knownApp(appPubKey types.PortalAppPublicKey) bool {
+	if _, ok := r.todaysUsage[appPubKey]; ok {
+		return true
+	}
+	for _, counts := range r.dailyUsage {
+		if _, ok := counts[appPubKey]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *relayMeter) CompareAppsRelays(ctx context.Context, appA, appB types.PortalAppPublicKey, from, to time.Time) (AppsComparisonResponse, error) {
+	defer r.logSlowQuery("CompareAppsRelays", time.Now(), slog.String("appA", string(appA)), slog.String("appB", string(appB)), slog.Time("from", from), slog.Time("to", to))
+	r.Logger.Info("apiserver: Received CompareAppsRelays request",
+		slog.String("appA", string(appA)),
+		slog.String("appB", string(appB)),
+		slog.Time("from", from),
+		slog.Time("to", to),
+	)
+
+	from, to, requested, err := r.adjustRequestedTimePeriod(from, to)
+	if err != nil {
+		return AppsComparisonResponse{}, err
+	}
+
+	// Get today's date in day-only format
+	now := time.Now()
+	_, today, _ := AdjustTimePeriod(now, now)
+
+	r.rwMutex.RLock()
+	defer r.rwMutex.RUnlock()
+
+	var resp AppsComparisonResponse
+	for _, app := range []struct {
+		appPubKey types.PortalAppPublicKey
+		relays    *AppRelaysResponse
+	}{{appA, &resp.A}, {appB, &resp.B}} {
+		*app.relays = AppRelaysResponse{
+			PublicKey:           app.appPubKey,
+			From:                from,
+			To:                  to,
+			Count:               r.appRelayCounts(app.appPubKey, from, to, today),
+			RequestedTimePeriod: requested,
+		}
+		if !r.knownApp(app.appPubKey) {
+			resp.Notes = append(resp.Notes, fmt.Sprintf("Application %s has no metrics: its relay counts are zero", app.appPubKey))
 		}
 	}
 
+	resp.Delta = resp.A.Count.Sub(resp.B.Count)
+	if totalB := resp.B.Count.Success + resp.B.Count.Failure; totalB != 0 {
+		ratio := float64(resp.A.Count.Success+resp.A.Count.Failure) / float64(totalB)
+		resp.Ratio = &ratio
+	}
+
 	return resp, nil
 }
 
-func (r *relayMeter) AllAppsRelays(ctx context.Context, from, to time.Time) ([]AppRelaysResponse, error) {
-	r.Logger.Info("apiserver: Received AllAppRelays request",
+func (r *relayMeter) AppSLO(ctx context.Context, appPubKey types.PortalAppPublicKey, target float64, from, to time.Time) (AppSLOResponse, error) {
+	defer r.logSlowQuery("AppSLO", time.Now(), slog.String("appPubKey", string(appPubKey)), slog.Float64("target", target), slog.Time("from", from), slog.Time("to", to))
+	r.Logger.Info("apiserver: Received AppSLO request",
+		slog.String("appPubKey", string(appPubKey)),
+		slog.Float64("target", target),
 		slog.Time("from", from),
 		slog.Time("to", to),
 	)
 
-	// TODO: enforce MaxArchiveAge on From parameter
-	// TODO: enforce Today as maximum value for To parameter
-	from, to, err := AdjustTimePeriod(from, to)
+	from, to, requested, err := r.adjustRequestedTimePeriod(from, to)
+	if err != nil {
+		return AppSLOResponse{}, err
+	}
+
+	// Get today's date in day-only format
+	now := time.Now()
+	_, today, _ := AdjustTimePeriod(now, now)
+
+	r.rwMutex.RLock()
+	defer r.rwMutex.RUnlock()
+
+	resp := AppSLOResponse{
+		PublicKey:           appPubKey,
+		From:                from,
+		To:                  to,
+		Count:               r.appRelayCounts(appPubKey, from, to, today),
+		Target:              target,
+		RequestedTimePeriod: requested,
+	}
+
+	total := resp.Count.Success + resp.Count.Failure
+	if total == 0 {
+		resp.Notes = append(resp.Notes, fmt.Sprintf("Application %s has no relays: its success rate is undefined", appPubKey))
+		return resp, nil
+	}
+
+	successRate := float64(resp.Count.Success) / float64(total)
+	met := successRate >= target
+	resp.SuccessRate = &successRate
+	resp.Met = &met
+
+	return resp, nil
+}
+
+// AppHealth returns the relay counts and success rate of the app over the time period, with the mean of today's latencies and their highest hourly P95.
+//
+//	Latencies are only held for today, so they are not reported for a time period ending before today. Values which cannot be
+//	computed, e.g. the success rate of an app without relays, are left unset and explained in the notes.
+func (r *relayMeter) AppHealth(ctx context.Context, appPubKey types.PortalAppPublicKey, from, to time.Time) (AppHealthResponse, error) {
+	defer r.logSlowQuery("AppHealth", time.Now(), slog.String("appPubKey", string(appPubKey)), slog.Time("from", from), slog.Time("to", to))
+	r.Logger.Info("apiserver: Received AppHealth request",
+		slog.String("appPubKey", string(appPubKey)),
+		slog.Time("from", from),
+		slog.Time("to", to),
+	)
+
+	from, to, requested, err := r.adjustRequestedTimePeriod(from, to)
+	if err != nil {
+		return AppHealthResponse{}, err
+	}
+
+	// Get today's date in day-only format
+	now := time.Now()
+	_, today, _ := AdjustTimePeriod(now, now)
+
+	r.rwMutex.RLock()
+	defer r.rwMutex.RUnlock()
+
+	resp := AppHealthResponse{
+		PublicKey:           appPubKey,
+		From:                from,
+		To:                  to,
+		Count:               r.appRelayCounts(appPubKey, from, to, today),
+		RequestedTimePeriod: requested,
+	}
+
+	if total := resp.Count.Success + resp.Count.Failure; total > 0 {
+		successRate := numbers.RoundFloat(float64(resp.Count.Success)/float64(total), ratePrecision)
+		resp.SuccessRate = &successRate
+	} else {
+		resp.Notes = append(resp.Notes, fmt.Sprintf("Application %s has no relays: its success rate is undefined", appPubKey))
+	}
+
+	if to.Before(today) {
+		resp.Notes = append(resp.Notes, "Latencies are only held for today, which is not within the time period")
+		return resp, nil
+	}
+
+	appLatency := r.todaysLatency[appPubKey]
+	if len(appLatency) == 0 {
+		resp.Notes = append(resp.Notes, fmt.Sprintf("Application %s has no latencies today", appPubKey))
+		return resp, nil
+	}
+
+	var sum float64
+	var p95 *float64
+	for _, latency := range appLatency {
+		sum += latency.Latency
+		if latency.Percentiles != nil && (p95 == nil || latency.Percentiles.P95 > *p95) {
+			p := latency.Percentiles.P95
+			p95 = &p
+		}
+	}
+	avg := numbers.RoundFloat(sum/float64(len(appLatency)), ratePrecision)
+	resp.AvgLatency = &avg
+	resp.P95Latency = p95
+	if p95 == nil {
+		resp.Notes = append(resp.Notes, fmt.Sprintf("Application %s has no latency percentiles today", appPubKey))
+	}
+
+	return resp, nil
+}
+
+// AppRank ranks the app among the apps with metrics over the time period: an app with no metrics at all returns AppNotFound.
+//
+//	An app known from other days, but without metrics over the time period, is ranked with zero relays.
+func (r *relayMeter) AppRank(ctx context.Context, appPubKey types.PortalAppPublicKey, from, to time.Time, by OriginOrder) (AppRankResponse, error) {
+	defer r.logSlowQuery("AppRank", time.Now(), slog.String("appPubKey", string(appPubKey)), slog.Time("from", from), slog.Time("to", to), slog.String("by", string(by)))
+	r.Logger.Info("apiserver: Received AppRank request",
+		slog.String("appPubKey", string(appPubKey)),
+		slog.String("by", string(by)),
+		slog.Time("from", from),
+		slog.Time("to", to),
+	)
+
+	from, to, requested, err := r.adjustRequestedTimePeriod(from, to)
+	if err != nil {
+		return AppRankResponse{}, err
+	}
+
+	// Get today's date in day-only format
+	now := time.Now()
+	_, today, _ := AdjustTimePeriod(now, now)
+
+	r.rwMutex.RLock()
+	defer r.rwMutex.RUnlock()
+
+	if !r.knownApp(appPubKey) {
+		return AppRankResponse{}, fmt.Errorf("%w: %s", AppNotFound, appPubKey)
+	}
+
+	counts := map[types.PortalAppPublicKey]RelayCounts{appPubKey: {}}
+	for _, day := range r.daysWithin(from, to) {
+		for app, count := range r.dailyUsage[day] {
+			counts[app] = counts[app].Add(count)
+		}
+	}
+	if today.Equal(to) || today.Before(to) {
+		for app, count := range r.todaysUsage {
+			counts[app] = counts[app].Add(count)
+		}
+	}
+
+	resp := AppRankResponse{
+		PublicKey:           appPubKey,
+		From:                from,
+		To:                  to,
+		Count:               counts[appPubKey],
+		By:                  by,
+		Rank:                1,
+		Total:               len(counts),
+		RequestedTimePeriod: requested,
+	}
+
+	appCount := by.count(resp.Count)
+	for _, count := range counts {
+		if by.count(count) > appCount {
+			resp.Rank++
+		}
+	}
+
+	return resp, nil
+}
+
+func (r *relayMeter) ActiveApps(ctx context.Context, from, to time.Time) ([]types.PortalAppPublicKey, error) {
+	defer r.logSlowQuery("ActiveApps", time.Now(), slog.Time("from", from), slog.Time("to", to))
+	r.Logger.Info("apiserver: Received ActiveApps request",
+		slog.Time("from", from),
+		slog.Time("to", to),
+	)
+
+	from, to, _, err := r.adjustRequestedTimePeriod(from, to)
 	if err != nil {
 		return nil, err
 	}
@@ -410,34 +1345,286 @@ func (r *relayMeter) AllAppsRelays(ctx context.Context, from, to time.Time) ([]A
 	r.rwMutex.RLock()
 	defer r.rwMutex.RUnlock()
 
-	rawResp := make(map[types.PortalAppPublicKey]AppRelaysResponse)
+	active := make(map[types.PortalAppPublicKey]bool)
+	addActive := func(counts map[types.PortalAppPublicKey]RelayCounts) {
+		for appPubKey, count := range counts {
+			if count.Success != 0 || count.Failure != 0 {
+				active[appPubKey] = true
+			}
+		}
+	}
 
-	for day, counts := range r.dailyUsage {
-		for appPubKey, relCounts := range counts {
-			total := rawResp[appPubKey].Count
+	for _, day := range r.daysWithin(from, to) {
+		addActive(r.dailyUsage[day])
+	}
+	if today.Equal(to) || today.Before(to) {
+		addActive(r.todaysUsage)
+	}
+
+	resp := make([]types.PortalAppPublicKey, 0, len(active))
+	for appPubKey := range active {
+		resp = append(resp, appPubKey)
+	}
+	sort.Slice(resp, func(i, j int) bool { return resp[i] < resp[j] })
+
+	return resp, nil
+}
+
+func (r *relayMeter) AppsFullyFailing(ctx context.Context, from, to time.Time, minVolume int64) ([]AppRelaysResponse, error) {
+	defer r.logSlowQuery("AppsFullyFailing", time.Now(), slog.Time("from", from), slog.Time("to", to), slog.Int64("minVolume", minVolume))
+	r.Logger.Info("apiserver: Received AppsFullyFailing request",
+		slog.Time("from", from),
+		slog.Time("to", to),
+		slog.Int64("minVolume", minVolume),
+	)
+
+	from, to, requested, err := r.adjustRequestedTimePeriod(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get today's date in day-only format
+	now := time.Now()
+	_, today, _ := AdjustTimePeriod(now, now)
+
+	r.rwMutex.RLock()
+	defer r.rwMutex.RUnlock()
+
+	// An app is only fully failing over the whole time period, so the window totals are computed first
+	totals := make(map[types.PortalAppPublicKey]RelayCounts)
+	for _, day := range r.daysWithin(from, to) {
+		for appPubKey, count := range r.dailyUsage[day] {
+			totals[appPubKey] = totals[appPubKey].Add(count)
+		}
+	}
+	if today.Equal(to) || today.Before(to) {
+		for appPubKey, count := range r.todaysUsage {
+			totals[appPubKey] = totals[appPubKey].Add(count)
+		}
+	}
+
+	resp := []AppRelaysResponse{}
+	for appPubKey, count := range totals {
+		if count.Success != 0 || count.Failure == 0 || count.Failure < minVolume {
+			continue
+		}
+		resp = append(resp, AppRelaysResponse{
+			PublicKey:           appPubKey,
+			From:                from,
+			To:                  to,
+			Count:               count,
+			RequestedTimePeriod: requested,
+		})
+	}
+	sort.Slice(resp, func(i, j int) bool {
+		if resp[i].Count.Failure != resp[j].Count.Failure {
+			return resp[i].Count.Failure > resp[j].Count.Failure
+		}
+		return resp[i].PublicKey < resp[j].PublicKey
+	})
+
+	return resp, nil
+}
+
+func (r *relayMeter) AppRawDailyRows(ctx context.Context, appPubKey types.PortalAppPublicKey, from, to time.Time) (AppRawDailyRowsResponse, error) {
+	defer r.logSlowQuery("AppRawDailyRows", time.Now(), slog.String("appPubKey", string(appPubKey)), slog.Time("from", from), slog.Time("to", to))
+	r.Logger.Info("apiserver: Received AppRawDailyRows request",
+		slog.String("app", string(appPubKey)),
+		slog.Time("from", from),
+		slog.Time("to", to),
+	)
+
+	from, to, requested, err := r.adjustRequestedTimePeriod(from, to)
+	if err != nil {
+		return AppRawDailyRowsResponse{}, err
+	}
+
+	// The backend includes both days, while the adjusted 'to' is the start of the day after the requested one
+	rows, err := r.Backend.RawDailyRows(appPubKey, from, to.AddDate(0, 0, -1))
+	if err != nil {
+		return AppRawDailyRowsResponse{}, err
+	}
+
+	return AppRawDailyRowsResponse{
+		PublicKey:           appPubKey,
+		From:                from,
+		To:                  to,
+		Rows:                rows,
+		RequestedTimePeriod: requested,
+	}, nil
+}
+
+func (r *relayMeter) Coverage(ctx context.Context, from, to time.Time) (CoverageResponse, error) {
+	defer r.logSlowQuery("Coverage", time.Now(), slog.Time("from", from), slog.Time("to", to))
+	r.Logger.Info("apiserver: Received Coverage request",
+		slog.Time("from", from),
+		slog.Time("to", to),
+	)
+
+	from, to, requested, err := r.adjustRequestedTimePeriod(from, to)
+	if err != nil {
+		return CoverageResponse{}, err
+	}
+
+	// The backend includes both days, while the adjusted 'to' is the start of the day after the requested one
+	days, err := r.Backend.CoveredDays(from, to.AddDate(0, 0, -1))
+	if err != nil {
+		return CoverageResponse{}, err
+	}
+
+	return CoverageResponse{
+		From:                from,
+		To:                  to,
+		Days:                dayRanges(days),
+		RequestedTimePeriod: requested,
+	}, nil
+}
+
+// dayRanges merges the sorted days into ranges of consecutive days
+func dayRanges(days []time.Time) []DayRange {
+	ranges := []DayRange{}
+	for _, day := range days {
+		day = startOfDay(day, dayLocation)
+		if last := len(ranges) - 1; last >= 0 && !day.After(startOfNextDay(ranges[last].To, dayLocation)) {
+			ranges[last].To = day
+			continue
+		}
+		ranges = append(ranges, DayRange{From: day, To: day})
+	}
+
+	return ranges
+}
+
+func (r *relayMeter) AppLatency(ctx context.Context, appPubKey types.PortalAppPublicKey) (AppLatencyResponse, error) {
+	defer r.logSlowQuery("AppLatency", time.Now(), slog.String("appPubKey", string(appPubKey)))
+	r.Logger.Info("apiserver: Received AppLatency request",
+		slog.String("appPubKey", string(appPubKey)),
+	)
+
+	r.rwMutex.RLock()
+	cached := r.todaysLatency[appPubKey]
+	// Sort a copy: the cached slice is shared with concurrent readers
+	appLatency := make([]Latency, len(cached))
+	copy(appLatency, cached)
+	r.rwMutex.RUnlock()
+
+	if len(appLatency) == 0 {
+		return AppLatencyResponse{}, ErrAppLatencyNotFound
+	}
+
+	sort.Slice(appLatency, func(i, j int) bool {
+		return appLatency[i].Time.Before(appLatency[j].Time)
+	})
+
+	return AppLatencyResponse{
+		PublicKey:    appPubKey,
+		DailyLatency: appLatency,
+		From:         appLatency[0].Time,
+		To:           appLatency[len(appLatency)-1].Time,
+	}, nil
+}
+
+// AllAppsLatencies returns today's latency of all applications.
+//
+//	A positive limit caps the number of returned applications: the ones with the most complete
+//	series are kept, with ties broken by the most recent data, so sparse series are dropped first.
+func (r *relayMeter) AllAppsLatencies(ctx context.Context, limit int) ([]AppLatencyResponse, error) {
+	defer r.logSlowQuery("AllAppsLatencies", time.Now(), slog.Int("limit", limit))
+	r.Logger.Info("apiserver: Received AllAppsLatencies request",
+		slog.Int("limit", limit),
+	)
+
+	r.rwMutex.RLock()
+	defer r.rwMutex.RUnlock()
 
-			// Note: Equal is not tested for 'to' parameter, as it is already adjusted to the start of the day after the specified date.
-			if (day.After(from) || day.Equal(from)) && day.Before(to) {
-				total.Success += relCounts.Success
-				total.Failure += relCounts.Failure
+	resp := []AppLatencyResponse{}
+
+	for appPubKey, cached := range r.todaysLatency {
+		if len(cached) > 0 {
+			// Sort a copy: the cached slice is shared with concurrent readers
+			appLatency := make([]Latency, len(cached))
+			copy(appLatency, cached)
+			sort.Slice(appLatency, func(i, j int) bool {
+				return appLatency[i].Time.Before(appLatency[j].Time)
+			})
+
+			latencyResp := AppLatencyResponse{
+				PublicKey:    appPubKey,
+				DailyLatency: appLatency,
+				From:         appLatency[0].Time,
+				To:           appLatency[len(appLatency)-1].Time,
 			}
 
+			resp = append(resp, latencyResp)
+		}
+	}
+
+	if limit <= 0 || len(resp) <= limit {
+		return resp, nil
+	}
+
+	sort.Slice(resp, func(i, j int) bool {
+		if len(resp[i].DailyLatency) != len(resp[j].DailyLatency) {
+			return len(resp[i].DailyLatency) > len(resp[j].DailyLatency)
+		}
+		if !resp[i].To.Equal(resp[j].To) {
+			return resp[i].To.After(resp[j].To)
+		}
+		return resp[i].PublicKey < resp[j].PublicKey
+	})
+
+	return resp[:limit], nil
+}
+
+func (r *relayMeter) AllAppsRelays(ctx context.Context, from, to time.Time, page Page) ([]AppRelaysResponse, int, error) {
+	defer r.logSlowQuery("AllAppsRelays", time.Now(), slog.Time("from", from), slog.Time("to", to), slog.Int("limit", page.Limit), slog.Int("offset", page.Offset))
+	r.Logger.Info("apiserver: Received AllAppRelays request",
+		slog.Time("from", from),
+		slog.Time("to", to),
+		slog.Int("limit", page.Limit),
+		slog.Int("offset", page.Offset),
+	)
+
+	// TODO: enforce Today as maximum value for To parameter
+	from, to, requested, err := r.adjustRequestedTimePeriod(from, to)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Get today's date in day-only format
+	now := time.Now()
+	_, today, _ := AdjustTimePeriod(now, now)
+
+	r.rwMutex.RLock()
+	defer r.rwMutex.RUnlock()
+
+	rawResp := make(map[types.PortalAppPublicKey]AppRelaysResponse)
+	// firstDay holds, for each app, the first day in the time period with any data
+	firstDay := make(map[types.PortalAppPublicKey]time.Time)
+	updateFirstDay := func(appPubKey types.PortalAppPublicKey, day time.Time) {
+		if first, ok := firstDay[appPubKey]; !ok || day.Before(first) {
+			firstDay[appPubKey] = day
+		}
+	}
+
+	for _, day := range r.daysWithin(from, to) {
+		for appPubKey, relCounts := range r.dailyUsage[day] {
+			updateFirstDay(appPubKey, day)
 			rawResp[appPubKey] = AppRelaysResponse{
 				PublicKey: appPubKey,
 				From:      from,
 				To:        to,
-				Count:     total,
+				Count:     rawResp[appPubKey].Count.Add(relCounts),
 			}
 		}
 	}
 
-	// TODO: Add a 'Notes' []string field to output: to provide an explanation when the input 'from' or 'to' parameters are corrected.
 	if today.Equal(to) || today.Before(to) {
 		for appPubKey, relCounts := range r.todaysUsage {
 			total := rawResp[appPubKey].Count
 
-			total.Success += relCounts.Success
-			total.Failure += relCounts.Failure
+			total = total.Add(relCounts)
+			updateFirstDay(appPubKey, startOfDay(now, dayLocation))
 
 			rawResp[appPubKey] = AppRelaysResponse{
 				PublicKey: appPubKey,
@@ -449,23 +1636,34 @@ func (r *relayMeter) AllAppsRelays(ctx context.Context, from, to time.Time) ([]A
 	}
 
 	resp := []AppRelaysResponse{}
+	notes := timePeriodNotes(from, to, today, requested)
 
-	for _, relResp := range rawResp {
+	for appPubKey, relResp := range rawResp {
+		if first, ok := firstDay[appPubKey]; ok && r.RelayMeterOptions.AppsEffectiveFrom {
+			relResp.From = first
+		}
+		relResp.RequestedTimePeriod = requested
+		relResp.Notes = notes
 		resp = append(resp, relResp)
 	}
 
-	return resp, nil
+	// The apps are sorted, so the same page holds the same apps across requests
+	sort.Slice(resp, func(i, j int) bool { return resp[i].PublicKey < resp[j].PublicKey })
+
+	return paginate(resp, page, r.RelayMeterOptions.DefaultPageLimit), len(resp), nil
 }
 
-func (r *relayMeter) AllRelaysOrigin(ctx context.Context, from, to time.Time) ([]OriginClassificationsResponse, error) {
+func (r *relayMeter) AllRelaysOrigin(ctx context.Context, from, to time.Time, limit int, by OriginOrder) ([]OriginClassificationsResponse, error) {
+	defer r.logSlowQuery("AllRelaysOrigin", time.Now(), slog.Time("from", from), slog.Time("to", to), slog.Int("limit", limit), slog.String("by", string(by)))
 	r.Logger.Info("apiserver: Received classifications by origin request",
 		slog.Time("from", from),
 		slog.Time("to", to),
+		slog.Int("limit", limit),
+		slog.String("by", string(by)),
 	)
 
-	// TODO: enforce MaxArchiveAge on From parameter
 	// TODO: enforce Today as maximum value for To parameter
-	from, to, err := AdjustTimePeriod(from, to)
+	from, to, requested, err := r.adjustRequestedTimePeriod(from, to)
 	if err != nil {
 		return nil, err
 	}
@@ -479,14 +1677,28 @@ func (r *relayMeter) AllRelaysOrigin(ctx context.Context, from, to time.Time) ([
 
 	rawResp := map[types.PortalAppOrigin]OriginClassificationsResponse{}
 
-	// TODO: Add a 'Notes' []string field to output: to provide an explanation when the input 'from' or 'to' parameters are corrected.
+	// Only today's origin metrics are kept, so each entry reports today as its time period, rather than the requested one.
+	startOfToday := today.AddDate(0, 0, -1)
+	var notes []string
+	if from.Before(startOfToday) || to.After(today) {
+		notes = append(notes, fmt.Sprintf("Only today's origin metrics are available: counts cover %s -- %s", startOfToday.Format(time.RFC3339), today.Format(time.RFC3339)))
+	}
+	failureUnavailable := !r.originFailuresAvailable()
+	if failureUnavailable {
+		notes = append(notes, originFailuresUnavailableNote)
+	}
+
 	if today.Equal(to) || today.Before(to) {
 		for origin, count := range r.todaysOriginUsage {
 			rawResp[origin] = OriginClassificationsResponse{
 				Origin: origin,
 				Count:  count,
-				From:   from,
-				To:     to,
+				From:   startOfToday,
+				To:     today,
+				Notes:  notes,
+
+				RequestedTimePeriod: requested,
+				FailureUnavailable:  failureUnavailable,
 			}
 		}
 	}
@@ -497,18 +1709,101 @@ func (r *relayMeter) AllRelaysOrigin(ctx context.Context, from, to time.Time) ([
 		resp = append(resp, relResp)
 	}
 
+	if limit > 0 || by != "" {
+		resp = rankOrigins(resp, limit, by)
+	}
+
 	return resp, nil
 }
 
+// originFailuresAvailable returns whether any origin has failed relays, i.e. whether origin failures are collected at all:
+// the origin metrics are sampled, and some sources only report their successes. The caller must hold the read lock.
+func (r *relayMeter) originFailuresAvailable() bool {
+	for _, count := range r.todaysOriginUsage {
+		if count.Failure > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// OriginOrder is the relay count origins, or apps, are ranked by: the total relays, i.e. the origin's traffic, if not set
+type OriginOrder string
+
+const (
+	OriginsBySuccess OriginOrder = "success"
+	OriginsByFailure OriginOrder = "failure"
+)
+
+func (o OriginOrder) count(c RelayCounts) int64 {
+	switch o {
+	case OriginsBySuccess:
+		return c.Success
+	case OriginsByFailure:
+		return c.Failure
+	default:
+		return c.Success + c.Failure
+	}
+}
+
+// rankedBefore returns whether origin a ranks above origin b: ties are ranked by origin, for a stable order
+func (o OriginOrder) rankedBefore(a, b OriginClassificationsResponse) bool {
+	if countA, countB := o.count(a.Count), o.count(b.Count); countA != countB {
+		return countA > countB
+	}
+	return a.Origin < b.Origin
+}
+
+// originsHeap is a min-heap of origins, i.e. the lowest ranked origin is at the root
+type originsHeap struct {
+	origins []OriginClassificationsResponse
+	by      OriginOrder
+}
+
+func (h *originsHeap) Len() int           { return len(h.origins) }
+func (h *originsHeap) Less(i, j int) bool { return h.by.rankedBefore(h.origins[j], h.origins[i]) }
+func (h *originsHeap) Swap(i, j int)      { h.origins[i], h.origins[j] = h.origins[j], h.origins[i] }
+func (h *originsHeap) Push(x any)         { h.origins = append(h.origins, x.(OriginClassificationsResponse)) }
+func (h *originsHeap) Pop() any {
+	last := h.origins[len(h.origins)-1]
+	h.origins = h.origins[:len(h.origins)-1]
+	return last
+}
+
+// rankOrigins returns the origins sorted by their rank, keeping only the top ones if limit is positive.
+//
+//	The top origins are selected using a heap bounded by the limit, so a few of many thousands origins are selected without sorting all of them.
+func rankOrigins(origins []OriginClassificationsResponse, limit int, by OriginOrder) []OriginClassificationsResponse {
+	if limit <= 0 || limit > len(origins) {
+		limit = len(origins)
+	}
+
+	h := &originsHeap{origins: make([]OriginClassificationsResponse, 0, limit+1), by: by}
+	for _, origin := range origins {
+		heap.Push(h, origin)
+		if h.Len() > limit {
+			heap.Pop(h)
+		}
+	}
+
+	// Popping the heap returns the lowest ranked origin first
+	ranked := make([]OriginClassificationsResponse, h.Len())
+	for i := len(ranked) - 1; i >= 0; i-- {
+		ranked[i] = heap.Pop(h).(OriginClassificationsResponse)
+	}
+
+	return ranked
+}
+
 func (r *relayMeter) RelaysOrigin(ctx context.Context, origin types.PortalAppOrigin, from, to time.Time) (OriginClassificationsResponse, error) {
+	defer r.logSlowQuery("RelaysOrigin", time.Now(), slog.String("origin", string(origin)), slog.Time("from", from), slog.Time("to", to))
 	r.Logger.Info("apiserver: Received classifications by origin request",
 		slog.Time("from", from),
 		slog.Time("to", to),
 	)
 
-	// TODO: enforce MaxArchiveAge on From parameter
 	// TODO: enforce Today as maximum value for To parameter
-	from, to, err := AdjustTimePeriod(from, to)
+	from, to, requested, err := r.adjustRequestedTimePeriod(from, to)
 	if err != nil {
 		return OriginClassificationsResponse{}, err
 	}
@@ -520,142 +1815,296 @@ func (r *relayMeter) RelaysOrigin(ctx context.Context, origin types.PortalAppOri
 	r.rwMutex.RLock()
 	defer r.rwMutex.RUnlock()
 
-	resp := OriginClassificationsResponse{}
+	// An origin without metrics has zero counts
+	resp := OriginClassificationsResponse{
+		Origin: origin,
+		To:     to,
+		From:   from,
+
+		RequestedTimePeriod: requested,
+		Notes:               timePeriodNotes(from, to, today, requested),
+	}
+
+	if today.Equal(to) || today.Before(to) {
+		// The origin must match exactly: e.g. app.test must not match the counts of app.test1.io
+		if count, ok := r.todaysOriginUsage[origin]; ok {
+			resp.Count = count
+			if !r.originFailuresAvailable() {
+				resp.FailureUnavailable = true
+				resp.Notes = append(resp.Notes, originFailuresUnavailableNote)
+			}
+		}
+	}
+
+	return resp, nil
+}
+
+// TODO: refactor the common processing done by both AppRelays and UserRelays
+func (r *relayMeter) UserRelays(ctx context.Context, userID types.UserID, from, to time.Time) (UserRelaysResponse, error) {
+	defer r.logSlowQuery("UserRelays", time.Now(), slog.String("userID", string(userID)), slog.Time("from", from), slog.Time("to", to))
+	r.Logger.Info("apiserver: Received UserRelays request",
+		slog.String("userID", string(userID)),
+		slog.Time("from", from),
+		slog.Time("to", to),
+	)
+	resp := UserRelaysResponse{
+		From: from,
+		To:   to,
+		User: userID,
+	}
+
+	// TODO: enforce Today as maximum value for To parameter
+	from, to, requested, err := r.adjustRequestedTimePeriod(from, to)
+	if err != nil {
+		return resp, err
+	}
+
+	// Get today's date in day-only format
+	now := time.Now()
+	_, today, _ := AdjustTimePeriod(now, now)
+
+	appPubKeys, err := r.Backend.UserPortalAppPubKeys(ctx, userID)
+	if err != nil {
+		r.Logger.Warn("Error getting user applications processing UserRelays request",
+			slog.String("error", err.Error()),
+			slog.String("userID", string(userID)),
+			slog.Time("to", to),
+		)
+		return resp, err
+	}
+
+	r.rwMutex.RLock()
+	defer r.rwMutex.RUnlock()
+
+	resp.DayCoverage = r.dayCoverage(from, to, today)
+	notes := timePeriodNotes(from, to, today, requested)
+	from, requested, resp.Notes = r.clampToAvailableData(from, to, requested)
+	resp.Notes = append(notes, resp.Notes...)
+
+	// The user's total is the sum of its apps' counts, which are kept for the per app breakdown
+	var total RelayCounts
+	perApp := make(map[types.PortalAppPublicKey]RelayCounts, len(appPubKeys))
+	for _, app := range appPubKeys {
+		count := r.appRelayCounts(app, from, to, today)
+		perApp[app] = count
+		total = total.Add(count)
+	}
+
+	resp.Count = total
+	resp.From = from
+	resp.To = to
+	resp.RequestedTimePeriod = requested
+	resp.PublicKeys = appPubKeys
+	resp.PerApp = perApp
+
+	return resp, nil
+}
+
+func (r *relayMeter) TotalRelays(ctx context.Context, from, to time.Time) (TotalRelaysResponse, error) {
+	defer r.logSlowQuery("TotalRelays", time.Now(), slog.Time("from", from), slog.Time("to", to))
+	r.Logger.Info("apiserver: Received TotalRelays request",
+		slog.Time("from", from),
+		slog.Time("to", to),
+	)
+	resp := TotalRelaysResponse{
+		From: from,
+		To:   to,
+	}
+
+	// TODO: enforce Today as maximum value for To parameter
+	from, to, requested, err := r.adjustRequestedTimePeriod(from, to)
+	if err != nil {
+		return resp, err
+	}
+
+	// Get today's date in day-only format
+	now := time.Now()
+	_, today, _ := AdjustTimePeriod(now, now)
+
+	r.rwMutex.RLock()
+	defer r.rwMutex.RUnlock()
+
+	resp.DayCoverage = r.dayCoverage(from, to, today)
+	notes := timePeriodNotes(from, to, today, requested)
+	from, requested, resp.Notes = r.clampToAvailableData(from, to, requested)
+	resp.Notes = append(notes, resp.Notes...)
+
+	var total RelayCounts
+	for _, day := range r.daysWithin(from, to) {
+		for _, count := range r.dailyUsage[day] {
+			total = total.Add(count)
+		}
+	}
+
+	if today.Equal(to) || today.Before(to) {
+		for _, count := range r.todaysUsage {
+			total = total.Add(count)
+		}
+	}
+
+	resp.Count = total
+	resp.From = from
+	resp.To = to
+	resp.RequestedTimePeriod = requested
+
+	return resp, nil
+}
+
+// PlanRelays returns the relay counts aggregated by the plan type of the applications' portal apps.
+//
+//	Applications not found in any portal app are reported under UnknownPlanType.
+func (r *relayMeter) PlanRelays(ctx context.Context, from, to time.Time) ([]PlanRelaysResponse, error) {
+	defer r.logSlowQuery("PlanRelays", time.Now(), slog.Time("from", from), slog.Time("to", to))
+	r.Logger.Info("apiserver: Received PlanRelays request",
+		slog.Time("from", from),
+		slog.Time("to", to),
+	)
+
+	from, to, requested, err := r.adjustRequestedTimePeriod(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get today's date in day-only format
+	now := time.Now()
+	_, today, _ := AdjustTimePeriod(now, now)
+
+	r.rwMutex.RLock()
+	defer r.rwMutex.RUnlock()
+
+	totals := make(map[types.PayPlanType]RelayCounts)
+	add := func(counts map[types.PortalAppPublicKey]RelayCounts) {
+		for app, count := range counts {
+			plan, ok := r.appPlans[app]
+			if !ok {
+				plan = UnknownPlanType
+			}
+
+			total := totals[plan]
+			total = total.Add(count)
+			totals[plan] = total
+		}
+	}
+
+	for _, day := range r.daysWithin(from, to) {
+		add(r.dailyUsage[day])
+	}
+
+	if today.Equal(to) || today.Before(to) {
+		add(r.todaysUsage)
+	}
+
+	resp := []PlanRelaysResponse{}
+	for plan, total := range totals {
+		resp = append(resp, PlanRelaysResponse{
+			PlanType: plan,
+			Count:    total,
+			From:     from,
+			To:       to,
 
-	// TODO: Add a 'Notes' []string field to output: to provide an explanation when the input 'from' or 'to' parameters are corrected.
-	if today.Equal(to) || today.Before(to) {
-		for curentOrigin, count := range r.todaysOriginUsage {
-			if strings.Contains(string(curentOrigin), string(origin)) {
-				resp = OriginClassificationsResponse{
-					Origin: origin,
-					Count:  count,
-					To:     to,
-					From:   from,
-				}
-				break
-			}
-		}
+			RequestedTimePeriod: requested,
+		})
 	}
+	sort.Slice(resp, func(i, j int) bool {
+		return resp[i].PlanType < resp[j].PlanType
+	})
 
 	return resp, nil
 }
 
-// TODO: refactor the common processing done by both AppRelays and UserRelays
-func (r *relayMeter) UserRelays(ctx context.Context, userID types.UserID, from, to time.Time) (UserRelaysResponse, error) {
-	r.Logger.Info("apiserver: Received UserRelays request",
-		slog.String("userID", string(userID)),
+// TotalRelaysByDay returns the network relay counts for each day in the specified time period.
+//
+//	Days without any traffic are included with a zero count. Days after today are not included.
+func (r *relayMeter) TotalRelaysByDay(ctx context.Context, from, to time.Time) ([]DailyRelaysResponse, error) {
+	defer r.logSlowQuery("TotalRelaysByDay", time.Now(), slog.Time("from", from), slog.Time("to", to))
+	r.Logger.Info("apiserver: Received TotalRelaysByDay request",
 		slog.Time("from", from),
 		slog.Time("to", to),
 	)
-	resp := UserRelaysResponse{
-		From: from,
-		To:   to,
-		User: userID,
-	}
 
-	// TODO: enforce MaxArchiveAge on From parameter
-	// TODO: enforce Today as maximum value for To parameter
-	from, to, err := AdjustTimePeriod(from, to)
+	from, to, _, err := r.adjustRequestedTimePeriod(from, to)
 	if err != nil {
-		return resp, err
+		return nil, err
 	}
 
 	// Get today's date in day-only format
 	now := time.Now()
-	_, today, _ := AdjustTimePeriod(now, now)
-
-	appPubKeys, err := r.Backend.UserPortalAppPubKeys(ctx, userID)
-	if err != nil {
-		r.Logger.Warn("Error getting user applications processing UserRelays request",
-			slog.String("error", err.Error()),
-			slog.String("userID", string(userID)),
-			slog.Time("to", to),
-		)
-		return resp, err
-	}
+	today, _, _ := AdjustTimePeriod(now, now)
 
 	r.rwMutex.RLock()
 	defer r.rwMutex.RUnlock()
 
-	var total RelayCounts
-	for day, counts := range r.dailyUsage {
-		// Note: Equal is not tested for 'to' parameter, as it is already adjusted to the start of the day after the specified date.
-		if (day.After(from) || day.Equal(from)) && day.Before(to) {
-			for _, app := range appPubKeys {
-				total.Success += counts[app].Success
-				total.Failure += counts[app].Failure
-			}
+	// Days are matched using their formatted date, as the stored timestamps may use a different location
+	totals := make(map[string]RelayCounts)
+	for _, day := range r.daysWithin(from, to) {
+		total := totals[day.Format(dayFormat)]
+		for _, count := range r.dailyUsage[day] {
+			total = total.Add(count)
 		}
+		totals[day.Format(dayFormat)] = total
 	}
 
-	// TODO: Add a 'Notes' []string field to output: to provide an explanation when the input 'from' or 'to' parameters are corrected.
-	if today.Equal(to) || today.Before(to) {
-		for _, app := range appPubKeys {
-			total.Success += r.todaysUsage[app].Success
-			total.Failure += r.todaysUsage[app].Failure
-		}
+	var todaysTotal RelayCounts
+	for _, count := range r.todaysUsage {
+		todaysTotal = todaysTotal.Add(count)
+	}
+	totals[today.Format(dayFormat)] = todaysTotal
+
+	resp := []DailyRelaysResponse{}
+	for day := from; day.Before(to) && !day.After(today); day = startOfNextDay(day, dayLocation) {
+		resp = append(resp, DailyRelaysResponse{
+			Day:   day,
+			Count: totals[day.Format(dayFormat)],
+		})
 	}
-
-	resp.Count = total
-	resp.From = from
-	resp.To = to
-	resp.PublicKeys = appPubKeys
 
 	return resp, nil
 }
 
-func (r *relayMeter) TotalRelays(ctx context.Context, from, to time.Time) (TotalRelaysResponse, error) {
-	r.Logger.Info("apiserver: Received TotalRelays request",
+// AppErrors returns the failed relays count of an application for each day in the specified time period.
+//
+//	Days without any failures are included with a zero count, and today's count is the partial count so far. Days after today are not included.
+func (r *relayMeter) AppErrors(ctx context.Context, appPubKey types.PortalAppPublicKey, from, to time.Time) ([]DailyErrorsResponse, error) {
+	defer r.logSlowQuery("AppErrors", time.Now(), slog.String("appPubKey", string(appPubKey)), slog.Time("from", from), slog.Time("to", to))
+	r.Logger.Info("apiserver: Received AppErrors request",
+		slog.String("appPubKey", string(appPubKey)),
 		slog.Time("from", from),
 		slog.Time("to", to),
 	)
-	resp := TotalRelaysResponse{
-		From: from,
-		To:   to,
-	}
 
-	// TODO: enforce MaxArchiveAge on From parameter
-	// TODO: enforce Today as maximum value for To parameter
-	from, to, err := AdjustTimePeriod(from, to)
+	from, to, _, err := r.adjustRequestedTimePeriod(from, to)
 	if err != nil {
-		return resp, err
+		return nil, err
 	}
 
 	// Get today's date in day-only format
 	now := time.Now()
-	_, today, _ := AdjustTimePeriod(now, now)
+	today, _, _ := AdjustTimePeriod(now, now)
 
 	r.rwMutex.RLock()
 	defer r.rwMutex.RUnlock()
 
-	var total RelayCounts
-	for day, counts := range r.dailyUsage {
-		// Note: Equal is not tested for 'to' parameter, as it is already adjusted to the start of the day after the specified date.
-		if (day.After(from) || day.Equal(from)) && day.Before(to) {
-			for _, count := range counts {
-				total.Success += count.Success
-				total.Failure += count.Failure
-			}
-		}
+	// Days are matched using their formatted date, as the stored timestamps may use a different location
+	failures := make(map[string]int64)
+	for _, day := range r.daysWithin(from, to) {
+		failures[day.Format(dayFormat)] += r.dailyUsage[day][appPubKey].Failure
 	}
-
-	// TODO: Add a 'Notes' []string field to output: to provide an explanation when the input 'from' or 'to' parameters are corrected.
-	if today.Equal(to) || today.Before(to) {
-		for _, count := range r.todaysUsage {
-			total.Success += count.Success
-			total.Failure += count.Failure
-		}
+	failures[today.Format(dayFormat)] = r.todaysUsage[appPubKey].Failure
+
+	resp := []DailyErrorsResponse{}
+	for day := from; day.Before(to) && !day.After(today); day = startOfNextDay(day, dayLocation) {
+		resp = append(resp, DailyErrorsResponse{
+			Day:     day,
+			Failure: failures[day.Format(dayFormat)],
+		})
 	}
 
-	resp.Count = total
-	resp.From = from
-	resp.To = to
-
 	return resp, nil
 }
 
 // PortalAppRelays returns the metrics for all applications of a portal app (AKA portalAppID)
 func (r *relayMeter) PortalAppRelays(ctx context.Context, portalAppID types.PortalAppID, from, to time.Time) (PortalAppRelaysResponse, error) {
+	defer r.logSlowQuery("PortalAppRelays", time.Now(), slog.String("portalAppID", string(portalAppID)), slog.Time("from", from), slog.Time("to", to))
 	r.Logger.Info("apiserver: Received PortalAppRelays request",
 		slog.String("portalAppID", string(portalAppID)),
 		slog.Time("from", from),
@@ -667,9 +2116,8 @@ func (r *relayMeter) PortalAppRelays(ctx context.Context, portalAppID types.Port
 		PortalAppID: portalAppID,
 	}
 
-	// TODO: enforce MaxArchiveAge on From parameter
 	// TODO: enforce Today as maximum value for To parameter
-	from, to, err := AdjustTimePeriod(from, to)
+	from, to, requested, err := r.adjustRequestedTimePeriod(from, to)
 	if err != nil {
 		return resp, err
 	}
@@ -692,6 +2140,13 @@ func (r *relayMeter) PortalAppRelays(ctx context.Context, portalAppID types.Port
 		return resp, ErrPortalAppNotFound
 	}
 
+	return r.portalAppRelays(portalAppID, portalApp, from, to, today, requested), nil
+}
+
+// portalAppRelays returns the metrics of the portal app's applications over the adjusted time period
+func (r *relayMeter) portalAppRelays(portalAppID types.PortalAppID, portalApp *types.PortalApp, from, to, today time.Time, requested RequestedTimePeriod) PortalAppRelaysResponse {
+	resp := PortalAppRelaysResponse{PortalAppID: portalAppID}
+
 	var appPubKeys []types.PortalAppPublicKey
 	for _, app := range portalApp.AATs {
 		key := aatPubKey(app)
@@ -703,59 +2158,188 @@ func (r *relayMeter) PortalAppRelays(ctx context.Context, portalAppID types.Port
 	r.rwMutex.RLock()
 	defer r.rwMutex.RUnlock()
 
+	resp.DayCoverage = r.dayCoverage(from, to, today)
+	notes := timePeriodNotes(from, to, today, requested)
+	from, requested, resp.Notes = r.clampToAvailableData(from, to, requested)
+	resp.Notes = append(notes, resp.Notes...)
+
 	var total RelayCounts
-	for day, counts := range r.dailyUsage {
-		// Note: Equal is not tested for 'to' parameter, as it is already adjusted to the start of the day after the specified date.
-		if (day.After(from) || day.Equal(from)) && day.Before(to) {
-			for _, app := range appPubKeys {
-				total.Success += counts[app].Success
-				total.Failure += counts[app].Failure
-			}
+	for _, day := range r.daysWithin(from, to) {
+		counts := r.dailyUsage[day]
+		for _, app := range appPubKeys {
+			total = total.Add(counts[app])
 		}
 	}
 
-	// TODO: Add a 'Notes' []string field to output: to provide an explanation when the input 'from' or 'to' parameters are corrected.
 	if today.Equal(to) || today.Before(to) {
 		for _, app := range appPubKeys {
-			total.Success += r.todaysUsage[app].Success
-			total.Failure += r.todaysUsage[app].Failure
+			total = total.Add(r.todaysUsage[app])
 		}
 	}
 
 	resp.Count = total
 	resp.From = from
 	resp.To = to
+	resp.RequestedTimePeriod = requested
 	resp.PublicKeys = appPubKeys
 
+	return resp
+}
+
+// PortalAppsRelays returns the metrics for each of the specified portal apps, in the order of the supplied IDs.
+//
+//	An unknown portal app does not fail the whole batch: its entry has no relays, and a note reporting it was not found.
+func (r *relayMeter) PortalAppsRelays(ctx context.Context, portalAppIDs []types.PortalAppID, from, to time.Time) ([]PortalAppRelaysResponse, error) {
+	defer r.logSlowQuery("PortalAppsRelays", time.Now(), slog.Int("portalAppIDs", len(portalAppIDs)), slog.Time("from", from), slog.Time("to", to))
+	r.Logger.Info("apiserver: Received PortalAppsRelays request",
+		slog.Int("portalAppIDs", len(portalAppIDs)),
+		slog.Time("from", from),
+		slog.Time("to", to),
+	)
+
+	adjustedFrom, adjustedTo, requested, err := r.adjustRequestedTimePeriod(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := []PortalAppRelaysResponse{}
+	for _, portalAppID := range portalAppIDs {
+		relays, err := r.PortalAppRelays(ctx, portalAppID, from, to)
+		if errors.Is(err, ErrPortalAppNotFound) {
+			relays = PortalAppRelaysResponse{
+				From:                adjustedFrom,
+				To:                  adjustedTo,
+				PortalAppID:         portalAppID,
+				Notes:               []string{fmt.Sprintf("Portal app %s not found", portalAppID)},
+				RequestedTimePeriod: requested,
+			}
+		} else if err != nil {
+			return nil, err
+		}
+		resp = append(resp, relays)
+	}
+
+	return resp, nil
+}
+
+// PortalAppOverview returns the metrics of a portal app, as returned by the dedicated endpoints:
+//   - Relays: the relay counts of the portal app's applications
+//   - Latency: today's latency of each of the portal app's applications which has latency data
+//   - Origins: the relay counts of each of the portal app's whitelisted origins which has traffic
+func (r *relayMeter) PortalAppOverview(ctx context.Context, portalAppID types.PortalAppID, from, to time.Time) (PortalAppOverviewResponse, error) {
+	defer r.logSlowQuery("PortalAppOverview", time.Now(), slog.String("portalAppID", string(portalAppID)), slog.Time("from", from), slog.Time("to", to))
+	adjustedFrom, adjustedTo, requested, err := r.adjustRequestedTimePeriod(from, to)
+	if err != nil {
+		return PortalAppOverviewResponse{}, err
+	}
+	now := time.Now()
+	_, today, _ := AdjustTimePeriod(now, now)
+
+	// The portal app is looked up once, for both its applications and its whitelisted origins
+	portalApp, err := r.Backend.PortalApp(ctx, portalAppID)
+	if err != nil {
+		return PortalAppOverviewResponse{}, err
+	}
+	if portalApp == nil {
+		return PortalAppOverviewResponse{}, ErrPortalAppNotFound
+	}
+	relays := r.portalAppRelays(portalAppID, portalApp, adjustedFrom, adjustedTo, today, requested)
+
+	resp := PortalAppOverviewResponse{
+		Relays:  relays,
+		Latency: []AppLatencyResponse{},
+		Origins: []OriginClassificationsResponse{},
+	}
+
+	for _, appPubKey := range relays.PublicKeys {
+		latency, err := r.AppLatency(ctx, appPubKey)
+		if errors.Is(err, ErrAppLatencyNotFound) {
+			continue
+		}
+		if err != nil {
+			return PortalAppOverviewResponse{}, err
+		}
+		resp.Latency = append(resp.Latency, latency)
+	}
+
+	var origins []string
+	for origin := range portalApp.Whitelists.Origins {
+		origins = append(origins, string(origin))
+	}
+	sort.Strings(origins)
+
+	for _, origin := range origins {
+		originResp, err := r.RelaysOrigin(ctx, types.PortalAppOrigin(origin), from, to)
+		if err != nil {
+			return PortalAppOverviewResponse{}, err
+		}
+		// RelaysOrigin returns zero counts for origins without any traffic
+		if originResp.Count == (RelayCounts{}) {
+			continue
+		}
+		resp.Origins = append(resp.Origins, originResp)
+	}
+
 	return resp, nil
 }
 
+// portalAppsOrCached returns the portal apps from the backend, or the cached portal apps, if any, when the backend fails.
+func (r *relayMeter) portalAppsOrCached(ctx context.Context) ([]*types.PortalApp, error) {
+	portalApps, err := r.Backend.PortalApps(ctx)
+	if err == nil {
+		r.portalAppsStale.Store(false)
+		return r.nonNilPortalApps(portalApps), nil
+	}
+
+	r.rwMutex.RLock()
+	cached := r.portalApps
+	r.rwMutex.RUnlock()
+
+	if cached == nil {
+		return nil, err
+	}
+
+	r.Logger.Warn("Serving the cached portal apps: error getting the portal apps",
+		slog.String("error", err.Error()),
+		slog.Int("portal_apps_count", len(cached)),
+	)
+	r.portalAppsStale.Store(true)
+	return cached, nil
+}
+
+// PortalAppsStale returns whether the last portal apps lookup failed, and the cached portal apps list was served instead
+func (r *relayMeter) PortalAppsStale() bool {
+	return r.portalAppsStale.Load()
+}
+
 // AllPortalAppsRelays returns the metrics for all applications of all portal apps (AKA portalAppIDs)
-func (r *relayMeter) AllPortalAppsRelays(ctx context.Context, from, to time.Time) ([]PortalAppRelaysResponse, error) {
+func (r *relayMeter) AllPortalAppsRelays(ctx context.Context, from, to time.Time, page Page) ([]PortalAppRelaysResponse, int, error) {
+	defer r.logSlowQuery("AllPortalAppsRelays", time.Now(), slog.Time("from", from), slog.Time("to", to), slog.Int("limit", page.Limit), slog.Int("offset", page.Offset))
 	r.Logger.Info("apiserver: Received AllPortalAppRelays request",
 		slog.Time("from", from),
 		slog.Time("to", to),
+		slog.Int("limit", page.Limit),
+		slog.Int("offset", page.Offset),
 	)
 
-	// TODO: enforce MaxArchiveAge on From parameter
 	// TODO: enforce Today as maximum value for To parameter
-	from, to, err := AdjustTimePeriod(from, to)
+	from, to, requested, err := r.adjustRequestedTimePeriod(from, to)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	// Get today's date in day-only format
 	now := time.Now()
 	_, today, _ := AdjustTimePeriod(now, now)
 
-	portalApps, err := r.Backend.PortalApps(ctx)
+	portalApps, err := r.portalAppsOrCached(ctx)
 	if err != nil {
 		r.Logger.Warn("Error getting portalAppID/loadbalancers applications processing AllPortalAppRelays request",
 			slog.String("error", err.Error()),
 			slog.Time("from", from),
 			slog.Time("to", to),
 		)
-		return nil, err
+		return nil, 0, err
 	}
 
 	r.rwMutex.RLock()
@@ -763,37 +2347,32 @@ func (r *relayMeter) AllPortalAppsRelays(ctx context.Context, from, to time.Time
 
 	rawResp := make(map[types.PortalAppID]PortalAppRelaysResponse)
 
-	for day, counts := range r.dailyUsage {
-		for _, portalApp := range portalApps {
-			total := rawResp[portalApp.ID].Count
-
-			var appPubKeys []types.PortalAppPublicKey
-			for _, app := range portalApp.AATs {
-				key := aatPubKey(app)
-				if key != "" {
-					appPubKeys = append(appPubKeys, key)
-				}
+	days := r.daysWithin(from, to)
+	for _, portalApp := range portalApps {
+		var appPubKeys []types.PortalAppPublicKey
+		for _, app := range portalApp.AATs {
+			key := aatPubKey(app)
+			if key != "" {
+				appPubKeys = append(appPubKeys, key)
 			}
+		}
 
-			// Note: Equal is not tested for 'to' parameter, as it is already adjusted to the start of the day after the specified date.
-			if (day.After(from) || day.Equal(from)) && day.Before(to) {
-				for _, appPubKey := range appPubKeys {
-					total.Success += counts[appPubKey].Success
-					total.Failure += counts[appPubKey].Failure
-				}
+		var total RelayCounts
+		for _, day := range days {
+			for _, appPubKey := range appPubKeys {
+				total = total.Add(r.dailyUsage[day][appPubKey])
 			}
+		}
 
-			rawResp[portalApp.ID] = PortalAppRelaysResponse{
-				PortalAppID: portalApp.ID,
-				From:        from,
-				To:          to,
-				Count:       total,
-				PublicKeys:  appPubKeys,
-			}
+		rawResp[portalApp.ID] = PortalAppRelaysResponse{
+			PortalAppID: portalApp.ID,
+			From:        from,
+			To:          to,
+			Count:       total,
+			PublicKeys:  appPubKeys,
 		}
 	}
 
-	// TODO: Add a 'Notes' []string field to output: to provide an explanation when the input 'from' or 'to' parameters are corrected.
 	if today.Equal(to) || today.Before(to) {
 		for _, portalApp := range portalApps {
 			total := rawResp[portalApp.ID].Count
@@ -807,8 +2386,7 @@ func (r *relayMeter) AllPortalAppsRelays(ctx context.Context, from, to time.Time
 			}
 
 			for _, app := range apps {
-				total.Success += r.todaysUsage[app].Success
-				total.Failure += r.todaysUsage[app].Failure
+				total = total.Add(r.todaysUsage[app])
 			}
 
 			rawResp[portalApp.ID] = PortalAppRelaysResponse{
@@ -821,20 +2399,92 @@ func (r *relayMeter) AllPortalAppsRelays(ctx context.Context, from, to time.Time
 		}
 	}
 
+	sharedKeys := sharedPublicKeys(portalApps)
+
 	resp := []PortalAppRelaysResponse{}
 
 	for _, relResp := range rawResp {
+		relResp.Notes = append(timePeriodNotes(from, to, today, requested), sharedKeysNotes(relResp.PortalAppID, relResp.PublicKeys, sharedKeys)...)
+		relResp.RequestedTimePeriod = requested
 		resp = append(resp, relResp)
 	}
 
-	return resp, nil
+	// The portal apps are sorted, so the same page holds the same portal apps across requests
+	sort.Slice(resp, func(i, j int) bool { return resp[i].PortalAppID < resp[j].PortalAppID })
+
+	return paginate(resp, page, r.RelayMeterOptions.DefaultPageLimit), len(resp), nil
+}
+
+// nonNilPortalApps returns the supplied portal apps skipping any nil entries, which the backend may return without an error.
+func (r *relayMeter) nonNilPortalApps(portalApps []*types.PortalApp) []*types.PortalApp {
+	var valid []*types.PortalApp
+	for i, portalApp := range portalApps {
+		if portalApp == nil {
+			r.Logger.Warn("Skipping nil portal app returned by the backend", slog.Int("index", i))
+			continue
+		}
+		valid = append(valid, portalApp)
+	}
+
+	return valid
+}
+
+// sharedPublicKeys returns the public keys which appear in more than one portal app, mapped to the sorted IDs of those portal apps.
+func sharedPublicKeys(portalApps []*types.PortalApp) map[types.PortalAppPublicKey][]types.PortalAppID {
+	owners := make(map[types.PortalAppPublicKey][]types.PortalAppID)
+	for _, portalApp := range portalApps {
+		seen := make(map[types.PortalAppPublicKey]bool)
+		for _, app := range portalApp.AATs {
+			key := aatPubKey(app)
+			if key == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			owners[key] = append(owners[key], portalApp.ID)
+		}
+	}
+
+	shared := make(map[types.PortalAppPublicKey][]types.PortalAppID)
+	for key, portalAppIDs := range owners {
+		if len(portalAppIDs) > 1 {
+			sort.Slice(portalAppIDs, func(i, j int) bool {
+				return portalAppIDs[i] < portalAppIDs[j]
+			})
+			shared[key] = portalAppIDs
+		}
+	}
+
+	return shared
+}
+
+// sharedKeysNotes flags the public keys of a portal app which are shared with other portal apps:
+// the relays of these keys are counted for each portal app, so the sum of all portal apps may exceed the network total.
+func sharedKeysNotes(portalAppID types.PortalAppID, publicKeys []types.PortalAppPublicKey, sharedKeys map[types.PortalAppPublicKey][]types.PortalAppID) []string {
+	var notes []string
+	for _, key := range publicKeys {
+		portalAppIDs, ok := sharedKeys[key]
+		if !ok {
+			continue
+		}
+
+		var others []string
+		for _, id := range portalAppIDs {
+			if id != portalAppID {
+				others = append(others, string(id))
+			}
+		}
+		notes = append(notes, fmt.Sprintf("Application %s is shared with portal apps: %s; its relays are counted for each of them", key, strings.Join(others, ", ")))
+	}
+	sort.Strings(notes)
+
+	return notes
 }
 
 // Starts a data loader in a go routine, to periodically load data from the backend
 //
 //	context allows stopping the data loader
 func (r *relayMeter) StartDataLoader(ctx context.Context) {
-	maxPastDays := maxArchiveAge(r.RelayMeterOptions.MaxPastDays)
+	maxPastDays := maxArchiveAge(r.servedPastDays())
 
 	load := func(max time.Duration) {
 		from := time.Now().Add(max)
@@ -851,7 +2501,7 @@ func (r *relayMeter) StartDataLoader(ctx context.Context) {
 			slog.Time("to", to),
 		)
 
-		if err := r.loadData(from, to); err != nil {
+		if err := r.coalescedLoadData(from, to); err != nil {
 			r.Logger.Warn("Error setting timespan for data loader",
 				slog.String("error", err.Error()),
 			)
@@ -863,59 +2513,212 @@ func (r *relayMeter) StartDataLoader(ctx context.Context) {
 	)
 	load(maxPastDays)
 
-	go func(maxDays time.Duration) {
-		ticker := time.NewTicker(r.RelayMeterOptions.LoadInterval)
+	go r.runDataLoader(ctx, func() { load(maxPastDays) })
+}
 
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-ticker.C:
-				load(maxDays)
-			}
+// coalescedLoadData runs loadData, recording its duration, unless a previous load is still in progress.
+//
+//	Overlapping loads are skipped rather than queued, so a slow backend does not make the data loader fall further behind.
+func (r *relayMeter) coalescedLoadData(from, to time.Time) error {
+	if !r.loading.CompareAndSwap(false, true) {
+		dataLoadsSkipped.Inc()
+		r.Logger.Warn("Previous data load still in progress, skipping data load...")
+		return nil
+	}
+	defer r.loading.Store(false)
+
+	start := time.Now()
+	err := r.loadData(from, to)
+	duration := time.Since(start)
+
+	dataLoadDuration.Observe(duration.Seconds())
+	if interval := r.RelayMeterOptions.LoadInterval; interval > 0 && duration > interval {
+		r.Logger.Warn("Data load took longer than the load interval",
+			slog.Duration("duration", duration),
+			slog.Duration("load_interval", interval),
+		)
+	}
+
+	return err
+}
+
+// runDataLoader runs the load function on every tick of the load interval, recording a heartbeat after each run.
+//
+//	The data loader is restarted if the load function panics.
+func (r *relayMeter) runDataLoader(ctx context.Context, load func()) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			r.Logger.Error("Data loader panicked, restarting...",
+				slog.Any("panic", rec),
+			)
+			go r.runDataLoader(ctx, load)
+		}
+	}()
+
+	ticker := time.NewTicker(r.RelayMeterOptions.LoadInterval)
+	defer ticker.Stop()
+
+	r.heartbeat()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			load()
+			r.heartbeat()
+		}
+	}
+}
+
+func (r *relayMeter) heartbeat() {
+	r.rwMutex.Lock()
+	defer r.rwMutex.Unlock()
+
+	r.lastLoaderTick = time.Now()
+}
+
+// CheckDataLoader returns an error if the data loader has not completed a run in the last two load intervals
+func (r *relayMeter) CheckDataLoader() error {
+	r.rwMutex.RLock()
+	defer r.rwMutex.RUnlock()
+
+	if r.lastLoaderTick.IsZero() {
+		return fmt.Errorf("%w: not started", ErrDataLoaderStalled)
+	}
+
+	if since := time.Since(r.lastLoaderTick); since > 2*r.RelayMeterOptions.LoadInterval {
+		return fmt.Errorf("%w: last run %s ago", ErrDataLoaderStalled, since.Round(time.Millisecond))
+	}
+
+	return nil
+}
+
+// StaleData returns a note for each of the cached relay counts served stale, i.e. not refreshed within a load interval of their TTL lapsing.
+//
+//	The last loaded metrics keep being served when the data loader fails, e.g. during a backend outage, so clients can flag them instead.
+func (r *relayMeter) StaleData() []string {
+	r.rwMutex.RLock()
+	defer r.rwMutex.RUnlock()
+
+	// Nothing is served before the first load, which is reported by the readiness check instead
+	if r.generation == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	grace := r.RelayMeterOptions.LoadInterval
+
+	var notes []string
+	if now.After(r.dailyTTL.Add(grace)) {
+		notes = append(notes, fmt.Sprintf("Daily metrics are stale: their refresh was due at %s", r.dailyTTL.UTC().Format(time.RFC3339)))
+	}
+	if now.After(r.todaysTTL.Add(grace)) {
+		notes = append(notes, fmt.Sprintf("Today's metrics are stale: their refresh was due at %s", r.todaysTTL.UTC().Format(time.RFC3339)))
+	}
+
+	return notes
+}
+
+// timePeriodNotes explains the adjustments of the supplied time period, as reported by requested, to the adjusted one: e.g. a 'to'
+// moved to the start of the next day to include the whole day. today is the start of the day after today, as used by the meter queries.
+func timePeriodNotes(from, to, today time.Time, requested RequestedTimePeriod) []string {
+	var notes []string
+	switch {
+	case requested.FromClamped:
+		notes = append(notes, fmt.Sprintf("From adjusted to the first day of the archived metrics %s", from.Format(dayFormat)))
+	case requested.RequestedFrom != nil:
+		notes = append(notes, fmt.Sprintf("From adjusted to the start of day %s", from.Format(dayFormat)))
+	}
+
+	if requested.RequestedTo != nil {
+		notes = append(notes, fmt.Sprintf("To adjusted to the start of day %s, to include the whole of %s", to.Format(dayFormat), to.AddDate(0, 0, -1).Format(dayFormat)))
+		if !to.Before(today) {
+			notes = append(notes, todayIncludedNote)
+		}
+	}
+
+	return notes
+}
+
+// adjustRequestedTimePeriod adjusts the time period using AdjustTimePeriod, and returns the supplied values which differ from the adjusted ones.
+//
+//	The start of the time period is clamped to the first day of the archived time period, i.e. MaxPastDays.
+func (r *relayMeter) adjustRequestedTimePeriod(from, to time.Time) (time.Time, time.Time, RequestedTimePeriod, error) {
+	adjustedFrom, adjustedTo, err := AdjustTimePeriod(from, to)
+	if err != nil {
+		return adjustedFrom, adjustedTo, RequestedTimePeriod{}, err
+	}
+
+	// No metrics are kept before the archived time period, so an earlier start would silently count zero relays
+	var clamped bool
+	if first := startOfDay(time.Now().Add(maxArchiveAge(r.RelayMeterOptions.MaxPastDays)), dayLocation); adjustedFrom.Before(first) {
+		adjustedFrom, clamped = first, !from.IsZero()
+		if adjustedTo.Before(first) {
+			adjustedTo = first
+		}
+	}
+
+	requested := func(supplied, adjusted time.Time) *time.Time {
+		if supplied.IsZero() || supplied.Equal(adjusted) {
+			return nil
 		}
-	}(maxPastDays)
+		return &supplied
+	}
+
+	return adjustedFrom, adjustedTo, RequestedTimePeriod{
+		RequestedFrom: requested(from, adjustedFrom),
+		RequestedTo:   requested(to, adjustedTo),
+		FromClamped:   clamped,
+	}, nil
 }
 
 // AdjustTimePeriod sets the two parameters, i.e. from and to, according to the following rules:
 //   - From is adjusted to the start of the day that it originally specifies
 //   - To is adjusted to the start of the next day from the day it originally specifies
 func AdjustTimePeriod(from, to time.Time) (time.Time, time.Time, error) {
-	// TODO: refactor: there is some duplication in the function
-	getDefault := func(parameter time.Time, defaultValue time.Time) (time.Time, error) {
+	getDefault := func(parameter time.Time, defaultValue time.Time) time.Time {
 		if parameter.Equal(time.Time{}) {
-			return time.Parse(dayFormat, defaultValue.Format(dayFormat))
+			return startOfDay(defaultValue, dayLocation)
 		}
-		return parameter, nil
+		return parameter
 	}
 
-	var err error
 	// TODO: set default from parameter to the actual MaxPastDays passed to the meter, i.e. r.RelayMeterOptions.MaxPastDays
-	from, err = getDefault(from, time.Now().Add(-24*time.Hour*time.Duration(MAX_PAST_DAYS_METRICS_DEFAULT_DAYS)))
-	if err != nil {
-		return time.Time{}, time.Time{}, err
-	}
+	from = getDefault(from, time.Now().AddDate(0, 0, -MAX_PAST_DAYS_METRICS_DEFAULT_DAYS))
 
 	// Missing 'to' is set to include today
-	to, err = getDefault(to, time.Now())
-	if err != nil {
-		return time.Time{}, time.Time{}, err
-	}
+	to = getDefault(to, time.Now())
 
 	if !from.Before(to) && !from.Equal(to) {
-		return time.Time{}, time.Time{}, fmt.Errorf("Invalid timespan: %v -- %v", from, to)
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: %v -- %v", ErrInvalidTimespan, from, to)
 	}
 
-	from, err = time.Parse(dayFormat, from.Format(dayFormat))
-	if err != nil {
-		return time.Time{}, time.Time{}, err
+	return startOfDay(from, dayLocation), startOfNextDay(to, dayLocation), nil
+}
+
+// servedPastDays returns the time period of the daily metrics loaded into memory: the served time period, if set and shorter than the archived one.
+func (r *relayMeter) servedPastDays() time.Duration {
+	archived := r.RelayMeterOptions.MaxPastDays
+	if archived == 0 {
+		archived = 24 * time.Hour * time.Duration(MAX_PAST_DAYS_METRICS_DEFAULT_DAYS)
 	}
 
-	to, err = time.Parse(dayFormat, to.Format(dayFormat))
-	if err != nil {
-		return time.Time{}, time.Time{}, err
+	if served := r.RelayMeterOptions.ServedPastDays; served > 0 && served < archived {
+		return served
+	}
+	return r.RelayMeterOptions.MaxPastDays
+}
+
+// Limits returns the archived and served time periods, resolving the defaults of unset options
+func (r *relayMeter) Limits() LimitsResponse {
+	archived := -maxArchiveAge(r.RelayMeterOptions.MaxPastDays)
+	served := -maxArchiveAge(r.servedPastDays())
+
+	return LimitsResponse{
+		MaxPastDays:    int(archived / (24 * time.Hour)),
+		ServedPastDays: int(served / (24 * time.Hour)),
+		EarliestFrom:   startOfDay(time.Now().Add(-served), dayLocation),
 	}
-	return from, to.AddDate(0, 0, 1), nil
 }
 
 func maxArchiveAge(maxPastDays time.Duration) time.Duration {
@@ -925,12 +2728,7 @@ func maxArchiveAge(maxPastDays time.Duration) time.Duration {
 	return time.Duration(-1) * maxPastDays
 }
 
+// aatPubKey returns the normalized public key of the AAT, so the apps of portal apps match the metrics whatever the case of their keys
 func aatPubKey(appAAT types.AAT) types.PortalAppPublicKey {
-	portalAppKey := appAAT.PublicKey
-
-	if portalAppKey != "" {
-		return portalAppKey
-	}
-
-	return ""
+	return NormalizePublicKey(appAAT.PublicKey)
 }
@@ -1,11 +1,15 @@
 package api
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"sort"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -16,6 +20,52 @@ import (
 	"github.com/pokt-foundation/utils-go/numbers"
 )
 
+func TestRelayCountsArithmetic(t *testing.T) {
+	testCases := []struct {
+		name     string
+		counts   []RelayCounts
+		expected RelayCounts
+	}{
+		{
+			name:     "Empty slice sums to zero counts",
+			expected: RelayCounts{},
+		},
+		{
+			name:     "Single counts are returned unchanged",
+			counts:   []RelayCounts{{Success: 3, Failure: 1}},
+			expected: RelayCounts{Success: 3, Failure: 1},
+		},
+		{
+			name:     "Successes and failures are summed separately",
+			counts:   []RelayCounts{{Success: 3, Failure: 1}, {Success: 10}, {Failure: 7}, {}},
+			expected: RelayCounts{Success: 13, Failure: 8},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, Sum(tc.counts)); diff != "" {
+				t.Errorf("unexpected sum (-want +got):\n%s", diff)
+			}
+
+			var total RelayCounts
+			for _, count := range tc.counts {
+				total = total.Add(count)
+			}
+			if diff := cmp.Diff(tc.expected, total); diff != "" {
+				t.Errorf("unexpected addition (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	if got := (RelayCounts{Success: 1, Failure: 2}).Add(RelayCounts{Success: 3, Failure: 4}); got != (RelayCounts{Success: 4, Failure: 6}) {
+		t.Errorf("Expected Add not to mix successes and failures, got: %+v", got)
+	}
+	if got := (RelayCounts{Success: 4, Failure: 6}).Sub(RelayCounts{Success: 3, Failure: 4}); got != (RelayCounts{Success: 1, Failure: 2}) {
+		t.Errorf("Expected Sub not to mix successes and failures, got: %+v", got)
+	}
+}
+
 func TestUserRelays(t *testing.T) {
 	now, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
 	usageData := fakeDailyMetrics()
@@ -34,14 +84,22 @@ func TestUserRelays(t *testing.T) {
 			from: now.AddDate(0, 0, -6),
 			to:   now,
 			expected: UserRelaysResponse{
-				From:       now.AddDate(0, 0, -6),
-				To:         now.AddDate(0, 0, 1),
-				User:       "user1",
-				PublicKeys: []types.PortalAppPublicKey{"app1", "app2", "app3"},
+				From:                now.AddDate(0, 0, -6),
+				To:                  now.AddDate(0, 0, 1),
+				RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now)},
+				Notes:               []string{toAdjustedNote(now), todayIncludedNote},
+				DayCoverage:         DayCoverage{DaysWithData: 7, DaysRequested: 7},
+				User:                "user1",
+				PublicKeys:          []types.PortalAppPublicKey{"app1", "app2", "app3"},
 				Count: RelayCounts{
 					Success: 6*(2+1) + 50 + 30,
 					Failure: 6*(3+5) + 40 + 70,
 				},
+				PerApp: map[types.PortalAppPublicKey]RelayCounts{
+					"app1": {Success: 6*2 + 50, Failure: 6*3 + 40},
+					"app2": {Success: 6*1 + 30, Failure: 6*5 + 70},
+					"app3": {},
+				},
 			},
 		},
 		{
@@ -50,14 +108,22 @@ func TestUserRelays(t *testing.T) {
 			from: now.AddDate(0, 0, -6),
 			to:   now.AddDate(0, 0, -2),
 			expected: UserRelaysResponse{
-				From:       now.AddDate(0, 0, -6),
-				To:         now.AddDate(0, 0, -1),
-				User:       "user1",
-				PublicKeys: []types.PortalAppPublicKey{"app1", "app2", "app3"},
+				From:                now.AddDate(0, 0, -6),
+				To:                  now.AddDate(0, 0, -1),
+				RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now.AddDate(0, 0, -2))},
+				Notes:               []string{toAdjustedNote(now.AddDate(0, 0, -2))},
+				DayCoverage:         DayCoverage{DaysWithData: 5, DaysRequested: 5},
+				User:                "user1",
+				PublicKeys:          []types.PortalAppPublicKey{"app1", "app2", "app3"},
 				Count: RelayCounts{
 					Success: 5 * (2 + 1),
 					Failure: 5 * (3 + 5),
 				},
+				PerApp: map[types.PortalAppPublicKey]RelayCounts{
+					"app1": {Success: 5 * 2, Failure: 5 * 3},
+					"app2": {Success: 5 * 1, Failure: 5 * 5},
+					"app3": {},
+				},
 			},
 		},
 		{
@@ -66,14 +132,22 @@ func TestUserRelays(t *testing.T) {
 			from: now,
 			to:   now,
 			expected: UserRelaysResponse{
-				From:       now,
-				To:         now.AddDate(0, 0, 1),
-				User:       "user1",
-				PublicKeys: []types.PortalAppPublicKey{"app1", "app2", "app3"},
+				From:                now,
+				To:                  now.AddDate(0, 0, 1),
+				RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now)},
+				Notes:               []string{toAdjustedNote(now), todayIncludedNote},
+				DayCoverage:         DayCoverage{DaysWithData: 1, DaysRequested: 1},
+				User:                "user1",
+				PublicKeys:          []types.PortalAppPublicKey{"app1", "app2", "app3"},
 				Count: RelayCounts{
 					Success: 50 + 30,
 					Failure: 40 + 70,
 				},
+				PerApp: map[types.PortalAppPublicKey]RelayCounts{
+					"app1": {Success: 50, Failure: 40},
+					"app2": {Success: 30, Failure: 70},
+					"app3": {},
+				},
 			},
 		},
 	}
@@ -105,6 +179,143 @@ func TestUserRelays(t *testing.T) {
 	}
 }
 
+func TestAdjustRequestedTimePeriod(t *testing.T) {
+	day := time.Date(2022, time.July, 20, 0, 0, 0, 0, time.UTC)
+	// The day is kept within the archived time period
+	meter := &relayMeter{RelayMeterOptions: RelayMeterOptions{MaxPastDays: time.Since(day) + 24*time.Hour}}
+
+	testCases := []struct {
+		name         string
+		from         time.Time
+		to           time.Time
+		expected     RequestedTimePeriod
+		expectedJSON string
+	}{
+		{
+			name: "Both supplied values are returned when adjusted",
+			from: day.Add(5 * time.Hour),
+			to:   day.Add(26 * time.Hour),
+			expected: RequestedTimePeriod{
+				RequestedFrom: timePtr(day.Add(5 * time.Hour)),
+				RequestedTo:   timePtr(day.Add(26 * time.Hour)),
+			},
+			expectedJSON: `{"RequestedFrom":"2022-07-20T05:00:00Z","RequestedTo":"2022-07-21T02:00:00Z"}`,
+		},
+		{
+			name:         "Supplied values equal to the adjusted ones are omitted",
+			from:         day,
+			to:           day.AddDate(0, 0, 1),
+			expected:     RequestedTimePeriod{RequestedTo: timePtr(day.AddDate(0, 0, 1))},
+			expectedJSON: `{"RequestedTo":"2022-07-21T00:00:00Z"}`,
+		},
+		{
+			name:         "Missing values are omitted",
+			expectedJSON: `{}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, got, err := meter.adjustRequestedTimePeriod(tc.from, tc.to)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+
+			gotJSON, err := json.Marshal(got)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if string(gotJSON) != tc.expectedJSON {
+				t.Errorf("Expected JSON: %s, got: %s", tc.expectedJSON, gotJSON)
+			}
+		})
+	}
+}
+
+func TestAdjustRequestedTimePeriodArchiveAge(t *testing.T) {
+	today, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
+	firstArchivedDay := today.AddDate(0, 0, -10)
+	meter := &relayMeter{RelayMeterOptions: RelayMeterOptions{MaxPastDays: 10 * 24 * time.Hour}}
+
+	testCases := []struct {
+		name              string
+		from              time.Time
+		to                time.Time
+		expectedFrom      time.Time
+		expectedTo        time.Time
+		expectedRequested RequestedTimePeriod
+	}{
+		{
+			name:         "From within the archive age is kept",
+			from:         today.AddDate(0, 0, -3),
+			to:           today,
+			expectedFrom: today.AddDate(0, 0, -3),
+			expectedTo:   today.AddDate(0, 0, 1),
+			expectedRequested: RequestedTimePeriod{
+				RequestedTo: timePtr(today),
+			},
+		},
+		{
+			name:         "From older than the archive age is clamped",
+			from:         today.AddDate(0, 0, -40),
+			to:           today,
+			expectedFrom: firstArchivedDay,
+			expectedTo:   today.AddDate(0, 0, 1),
+			expectedRequested: RequestedTimePeriod{
+				RequestedFrom: timePtr(today.AddDate(0, 0, -40)),
+				RequestedTo:   timePtr(today),
+				FromClamped:   true,
+			},
+		},
+		{
+			name:         "Time period before the archive age is empty",
+			from:         today.AddDate(0, 0, -40),
+			to:           today.AddDate(0, 0, -20),
+			expectedFrom: firstArchivedDay,
+			expectedTo:   firstArchivedDay,
+			expectedRequested: RequestedTimePeriod{
+				RequestedFrom: timePtr(today.AddDate(0, 0, -40)),
+				RequestedTo:   timePtr(today.AddDate(0, 0, -20)),
+				FromClamped:   true,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			from, to, requested, err := meter.adjustRequestedTimePeriod(tc.from, tc.to)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if !from.Equal(tc.expectedFrom) || !to.Equal(tc.expectedTo) {
+				t.Errorf("Expected time period: %v -- %v, got: %v -- %v", tc.expectedFrom, tc.expectedTo, from, to)
+			}
+			if diff := cmp.Diff(tc.expectedRequested, requested); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	// The clamped start is returned as the From of the responses
+	meter.Backend = &fakeBackend{usage: fakeDailyMetrics(), todaysUsage: fakeTodaysMetrics()}
+	meter.Logger = logger.New()
+	if err := meter.loadData(firstArchivedDay, today); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp, err := meter.AppSLO(context.Background(), "app1", 0.99, today.AddDate(0, 0, -40), today)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !resp.From.Equal(firstArchivedDay) || !resp.FromClamped {
+		t.Errorf("Expected the clamped From: %v, got: %v, clamped: %t", firstArchivedDay, resp.From, resp.FromClamped)
+	}
+}
+
 func TestTotalRelays(t *testing.T) {
 	now, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
 	usageData := fakeDailyMetrics()
@@ -121,8 +332,11 @@ func TestTotalRelays(t *testing.T) {
 			from: now.AddDate(0, 0, -6),
 			to:   now,
 			expected: TotalRelaysResponse{
-				From: now.AddDate(0, 0, -6),
-				To:   now.AddDate(0, 0, 1),
+				From:                now.AddDate(0, 0, -6),
+				To:                  now.AddDate(0, 0, 1),
+				RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now)},
+				Notes:               []string{toAdjustedNote(now), todayIncludedNote},
+				DayCoverage:         DayCoverage{DaysWithData: 7, DaysRequested: 7},
 				Count: RelayCounts{
 					Success: 6*(2+1+5) + 50 + 30 + 500,
 					Failure: 6*(3+5+7) + 40 + 70 + 700,
@@ -134,8 +348,11 @@ func TestTotalRelays(t *testing.T) {
 			from: now.AddDate(0, 0, -6),
 			to:   now.AddDate(0, 0, -2),
 			expected: TotalRelaysResponse{
-				From: now.AddDate(0, 0, -6),
-				To:   now.AddDate(0, 0, -1),
+				From:                now.AddDate(0, 0, -6),
+				To:                  now.AddDate(0, 0, -1),
+				RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now.AddDate(0, 0, -2))},
+				Notes:               []string{toAdjustedNote(now.AddDate(0, 0, -2))},
+				DayCoverage:         DayCoverage{DaysWithData: 5, DaysRequested: 5},
 				Count: RelayCounts{
 					Success: 5 * (2 + 1 + 5),
 					Failure: 5 * (3 + 5 + 7),
@@ -147,8 +364,11 @@ func TestTotalRelays(t *testing.T) {
 			from: now,
 			to:   now,
 			expected: TotalRelaysResponse{
-				From: now,
-				To:   now.AddDate(0, 0, 1),
+				From:                now,
+				To:                  now.AddDate(0, 0, 1),
+				RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now)},
+				Notes:               []string{toAdjustedNote(now), todayIncludedNote},
+				DayCoverage:         DayCoverage{DaysWithData: 1, DaysRequested: 1},
 				Count: RelayCounts{
 					Success: 50 + 30 + 500,
 					Failure: 40 + 70 + 700,
@@ -180,159 +400,156 @@ func TestTotalRelays(t *testing.T) {
 	}
 }
 
-func TestAppRelays(t *testing.T) {
+func TestPlanRelays(t *testing.T) {
 	now, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
-	usageData := fakeDailyMetrics()
-	todaysUsage := fakeTodaysMetrics()
-	requestedApp := types.PortalAppPublicKey("app1")
 
 	testCases := []struct {
-		name                string
-		from                time.Time
-		to                  time.Time
-		usageData           map[time.Time]map[types.PortalAppPublicKey]int64
-		todaysUsage         map[types.PortalAppPublicKey]int64
-		expected            AppRelaysResponse
-		expectedErr         error
-		expectedTodaysCalls int
+		name     string
+		from     time.Time
+		to       time.Time
+		expected []PlanRelaysResponse
 	}{
 		{
-			name: "Correct count is returned",
-			from: now.AddDate(0, 0, -5),
-			to:   now.AddDate(0, 0, -1),
-			expected: AppRelaysResponse{
-				PublicKey: requestedApp,
-				From:      now.AddDate(0, 0, -5),
-				To:        now,
-				Count: RelayCounts{
-					Success: 5 * 2,
-					Failure: 5 * 3,
+			name: "Correct summary for each plan type",
+			from: now.AddDate(0, 0, -6),
+			to:   now,
+			expected: []PlanRelaysResponse{
+				{
+					PlanType:            types.FreetierV0,
+					From:                now.AddDate(0, 0, -6),
+					To:                  now.AddDate(0, 0, 1),
+					RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now)},
+					Count:               RelayCounts{Success: 6*2 + 50, Failure: 6*3 + 40},
 				},
-			},
-		},
-		{
-			name: "From and To parameters are adjusted to start of the specifed day and the next day, respectively",
-			from: time.Now().AddDate(0, 0, -5),
-			to:   time.Now().AddDate(0, 0, -1),
-			expected: AppRelaysResponse{
-				PublicKey: "app1",
-				From:      now.AddDate(0, 0, -5),
-				To:        now,
-				Count: RelayCounts{
-					Success: 5 * 2,
-					Failure: 5 * 3,
+				{
+					PlanType:            types.PayAsYouGoV0,
+					From:                now.AddDate(0, 0, -6),
+					To:                  now.AddDate(0, 0, 1),
+					RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now)},
+					Count:               RelayCounts{Success: 6*1 + 30, Failure: 6*5 + 70},
 				},
-			},
-		},
-		{
-			name: "Equal values are allowed for From and To parameters (to cover a single day)",
-			from: now.AddDate(0, 0, -3),
-			to:   now.AddDate(0, 0, -3),
-			expected: AppRelaysResponse{
-				PublicKey: "app1",
-				From:      now.AddDate(0, 0, -3),
-				To:        now.AddDate(0, 0, -2),
-				Count: RelayCounts{
-					Success: 1 * 2,
-					Failure: 1 * 3,
+				{
+					PlanType:            UnknownPlanType,
+					From:                now.AddDate(0, 0, -6),
+					To:                  now.AddDate(0, 0, 1),
+					RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now)},
+					Count:               RelayCounts{Success: 6*5 + 500, Failure: 6*7 + 700},
 				},
 			},
 		},
 		{
-			name: "Today's metrics are added to previous days' usage data",
-			from: now.AddDate(0, 0, -3),
-			to:   now,
-			todaysUsage: map[types.PortalAppPublicKey]int64{
-				"app1": 50,
-				"app2": 30,
-			},
-			expected: AppRelaysResponse{
-				PublicKey: "app1",
-				From:      now.AddDate(0, 0, -3),
-				To:        now.AddDate(0, 0, 1),
-				Count: RelayCounts{
-					Success: 3*2 + 50,
-					Failure: 3*3 + 40,
+			name: "Correct summary for each plan type excluding today",
+			from: now.AddDate(0, 0, -2),
+			to:   now.AddDate(0, 0, -1),
+			expected: []PlanRelaysResponse{
+				{
+					PlanType:            types.FreetierV0,
+					From:                now.AddDate(0, 0, -2),
+					To:                  now,
+					RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now.AddDate(0, 0, -1))},
+					Count:               RelayCounts{Success: 2 * 2, Failure: 2 * 3},
 				},
-			},
-		},
-		{
-			name: "Only today's data is included when from and to parameters point to today",
-			from: now,
-			to:   now,
-			expected: AppRelaysResponse{
-				PublicKey: "app1",
-				From:      now,
-				To:        now.AddDate(0, 0, 1),
-				Count: RelayCounts{
-					Success: 50,
-					Failure: 40,
+				{
+					PlanType:            types.PayAsYouGoV0,
+					From:                now.AddDate(0, 0, -2),
+					To:                  now,
+					RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now.AddDate(0, 0, -1))},
+					Count:               RelayCounts{Success: 2 * 1, Failure: 2 * 5},
 				},
-			},
-		},
-		{
-			name: "Today's metrics are not included when the 'to' parameter does not include today",
-			from: time.Now().AddDate(0, 0, -3),
-			to:   time.Now().AddDate(0, 0, -1),
-			expected: AppRelaysResponse{
-				PublicKey: "app1",
-				From:      now.AddDate(0, 0, -3),
-				To:        now,
-				Count: RelayCounts{
-					Success: 3 * 2,
-					Failure: 3 * 3,
+				{
+					PlanType:            UnknownPlanType,
+					From:                now.AddDate(0, 0, -2),
+					To:                  now,
+					RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now.AddDate(0, 0, -1))},
+					Count:               RelayCounts{Success: 2 * 5, Failure: 2 * 7},
 				},
 			},
 		},
-		{
-			name: "Today's metrics are included when the 'to' parameter is after today",
-			from: time.Now().AddDate(0, 0, -3),
-			to:   time.Now().AddDate(0, 0, 2),
-			expected: AppRelaysResponse{
-				PublicKey: "app1",
-				From:      now.AddDate(0, 0, -3),
-				To:        now.AddDate(0, 0, 3),
-				Count: RelayCounts{
-					Success: 3*2 + 50,
-					Failure: 3*3 + 40,
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			fakeBackend := fakeBackend{
+				usage:       fakeDailyMetrics(),
+				todaysUsage: fakeTodaysMetrics(),
+				portalApps: map[types.PortalAppID]*types.PortalApp{
+					"portal_app_1": {
+						ID:           "portal_app_1",
+						AATs:         map[types.ProtocolAppID]types.AAT{"app1": {PublicKey: "app1"}},
+						LegacyFields: types.LegacyFields{PlanType: types.FreetierV0},
+					},
+					"portal_app_2": {
+						ID:           "portal_app_2",
+						AATs:         map[types.ProtocolAppID]types.AAT{"app2": {PublicKey: "app2"}},
+						LegacyFields: types.LegacyFields{PlanType: types.PayAsYouGoV0},
+					},
 				},
-			},
-		},
+			}
+
+			relayMeter := NewRelayMeter(context.Background(), &fakeBackend, &fakeDriver{}, logger.New(), RelayMeterOptions{LoadInterval: 100 * time.Millisecond})
+			time.Sleep(200 * time.Millisecond)
+			got, err := relayMeter.PlanRelays(context.Background(), tc.from, tc.to)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTotalRelaysByDay(t *testing.T) {
+	now, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
+	usageData := fakeDailyMetrics()
+	// No traffic on the third day before today
+	delete(usageData, now.AddDate(0, 0, -3))
+	todaysUsage := fakeTodaysMetrics()
+
+	dayCount := RelayCounts{Success: 2 + 1 + 5, Failure: 3 + 5 + 7}
+	todaysCount := RelayCounts{Success: 50 + 30 + 500, Failure: 40 + 70 + 700}
+
+	testCases := []struct {
+		name     string
+		from     time.Time
+		to       time.Time
+		expected []DailyRelaysResponse
+	}{
 		{
-			name: "Only today's metrics are included when the timespan only includes today",
-			from: time.Now(),
-			to:   time.Now().AddDate(0, 0, 2),
-			expected: AppRelaysResponse{
-				PublicKey: "app1",
-				From:      now,
-				To:        now.AddDate(0, 0, 3),
-				Count: RelayCounts{
-					Success: 50,
-					Failure: 40,
-				},
+			name: "Days without traffic are returned with a zero count",
+			from: now.AddDate(0, 0, -8),
+			to:   now,
+			expected: []DailyRelaysResponse{
+				{Day: now.AddDate(0, 0, -8)},
+				{Day: now.AddDate(0, 0, -7)},
+				{Day: now.AddDate(0, 0, -6), Count: dayCount},
+				{Day: now.AddDate(0, 0, -5), Count: dayCount},
+				{Day: now.AddDate(0, 0, -4), Count: dayCount},
+				{Day: now.AddDate(0, 0, -3)},
+				{Day: now.AddDate(0, 0, -2), Count: dayCount},
+				{Day: now.AddDate(0, 0, -1), Count: dayCount},
+				{Day: now, Count: todaysCount},
 			},
 		},
 		{
-			name: "Missing parameters' default values",
-			expected: AppRelaysResponse{
-				PublicKey: "app1",
-				From:      now.AddDate(0, 0, -30),
-				To:        now.AddDate(0, 0, 1),
-				Count: RelayCounts{
-					Success: 6*2 + 50,
-					Failure: 6*3 + 40,
-				},
+			name: "Today is excluded if not in the time period",
+			from: now.AddDate(0, 0, -2),
+			to:   now.AddDate(0, 0, -1),
+			expected: []DailyRelaysResponse{
+				{Day: now.AddDate(0, 0, -2), Count: dayCount},
+				{Day: now.AddDate(0, 0, -1), Count: dayCount},
 			},
 		},
 		{
-			name:        "Invalid timespan is rejected",
-			from:        now.AddDate(0, 0, -1),
-			to:          now.AddDate(0, 0, -2),
-			expectedErr: fmt.Errorf("Invalid timespan"),
-			expected: AppRelaysResponse{
-				PublicKey: "app1",
-				From:      now.AddDate(0, 0, -1),
-				To:        now.AddDate(0, 0, -2),
+			name: "Days after today are not returned",
+			from: now.AddDate(0, 0, -1),
+			to:   now.AddDate(0, 0, 3),
+			expected: []DailyRelaysResponse{
+				{Day: now.AddDate(0, 0, -1), Count: dayCount},
+				{Day: now, Count: todaysCount},
 			},
 		},
 	}
@@ -348,14 +565,9 @@ func TestAppRelays(t *testing.T) {
 
 			relayMeter := NewRelayMeter(context.Background(), &fakeBackend, &fakeDriver{}, logger.New(), RelayMeterOptions{LoadInterval: 100 * time.Millisecond})
 			time.Sleep(200 * time.Millisecond)
-			got, err := relayMeter.AppRelays(context.Background(), requestedApp, tc.from, tc.to)
+			got, err := relayMeter.TotalRelaysByDay(context.Background(), tc.from, tc.to)
 			if err != nil {
-				if tc.expectedErr == nil {
-					t.Fatalf("Unexpected error: %v", err)
-				}
-				if !strings.Contains(err.Error(), tc.expectedErr.Error()) {
-					t.Fatalf("Expected error to contain: %q, got: %v", tc.expectedErr.Error(), err)
-				}
+				t.Fatalf("Unexpected error: %v", err)
 			}
 
 			if diff := cmp.Diff(tc.expected, got); diff != "" {
@@ -365,336 +577,2621 @@ func TestAppRelays(t *testing.T) {
 	}
 }
 
-func TestAllAppsRelays(t *testing.T) {
+func TestAppErrors(t *testing.T) {
+	now, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
+	usageData := map[time.Time]map[types.PortalAppPublicKey]RelayCounts{
+		now.AddDate(0, 0, -3): {"app1": {Success: 10, Failure: 4}, "app2": {Success: 5, Failure: 1}},
+		now.AddDate(0, 0, -2): {"app1": {Success: 10}, "app2": {Success: 5, Failure: 2}},
+		now.AddDate(0, 0, -1): {"app1": {Success: 10, Failure: 7}},
+	}
+	todaysUsage := map[types.PortalAppPublicKey]RelayCounts{
+		"app1": {Success: 3, Failure: 2},
+	}
+
+	testCases := []struct {
+		name     string
+		app      types.PortalAppPublicKey
+		from     time.Time
+		to       time.Time
+		expected []DailyErrorsResponse
+	}{
+		{
+			name: "Days without errors are returned with a zero count",
+			app:  "app1",
+			from: now.AddDate(0, 0, -4),
+			to:   now,
+			expected: []DailyErrorsResponse{
+				{Day: now.AddDate(0, 0, -4)},
+				{Day: now.AddDate(0, 0, -3), Failure: 4},
+				{Day: now.AddDate(0, 0, -2)},
+				{Day: now.AddDate(0, 0, -1), Failure: 7},
+				{Day: now, Failure: 2},
+			},
+		},
+		{
+			name: "Today's partial count is zero for an app without traffic today",
+			app:  "app2",
+			from: now.AddDate(0, 0, -2),
+			to:   now,
+			expected: []DailyErrorsResponse{
+				{Day: now.AddDate(0, 0, -2), Failure: 2},
+				{Day: now.AddDate(0, 0, -1)},
+				{Day: now},
+			},
+		},
+		{
+			name: "Days after today are not returned",
+			app:  "app1",
+			from: now.AddDate(0, 0, -1),
+			to:   now.AddDate(0, 0, 3),
+			expected: []DailyErrorsResponse{
+				{Day: now.AddDate(0, 0, -1), Failure: 7},
+				{Day: now, Failure: 2},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			fakeBackend := fakeBackend{
+				usage:       usageData,
+				todaysUsage: todaysUsage,
+			}
+
+			relayMeter := NewRelayMeter(context.Background(), &fakeBackend, &fakeDriver{}, logger.New(), RelayMeterOptions{LoadInterval: 100 * time.Millisecond})
+			time.Sleep(200 * time.Millisecond)
+			got, err := relayMeter.AppErrors(context.Background(), tc.app, tc.from, tc.to)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRelayRate(t *testing.T) {
+	day := time.Date(2022, time.July, 20, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name     string
+		count    RelayCounts
+		from     time.Time
+		to       time.Time
+		now      time.Time
+		expected *RelayRate
+	}{
+		{
+			name:     "Rate over past days uses the whole time period",
+			count:    RelayCounts{Success: 172800, Failure: 8640},
+			from:     day,
+			to:       day.AddDate(0, 0, 2),
+			now:      day.AddDate(0, 0, 5),
+			expected: &RelayRate{SuccessRate: 1, FailureRate: 0.05},
+		},
+		{
+			name:     "Rate over a time period including today only uses the elapsed part of today",
+			count:    RelayCounts{Success: 86400 + 21600, Failure: 0},
+			from:     day,
+			to:       day.AddDate(0, 0, 2),
+			now:      day.AddDate(0, 0, 1).Add(6 * time.Hour),
+			expected: &RelayRate{SuccessRate: 1},
+		},
+		{
+			name:     "Rate is rounded to a bounded precision",
+			count:    RelayCounts{Success: 1, Failure: 2},
+			from:     day,
+			to:       day.AddDate(0, 0, 1),
+			now:      day.AddDate(0, 0, 5),
+			expected: &RelayRate{SuccessRate: 0.000012, FailureRate: 0.000023},
+		},
+		{
+			name:     "Rate is zero for an empty time period",
+			count:    RelayCounts{Success: 10},
+			from:     day,
+			to:       day.AddDate(0, 0, 1),
+			now:      day,
+			expected: &RelayRate{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, relayRate(tc.count, tc.from, tc.to, tc.now)); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCompareAppsRelays(t *testing.T) {
+	now, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
+	usageData := map[time.Time]map[types.PortalAppPublicKey]RelayCounts{
+		now.AddDate(0, 0, -2): {"app1": {Success: 30, Failure: 10}, "app2": {Success: 10}, "app3": {}},
+		now.AddDate(0, 0, -1): {"app1": {Success: 10}, "app2": {Success: 10, Failure: 10}},
+	}
+	todaysUsage := map[types.PortalAppPublicKey]RelayCounts{
+		"app1": {Success: 10},
+	}
+	ratio := func(r float64) *float64 { return &r }
+	appRelays := func(app types.PortalAppPublicKey, count RelayCounts) AppRelaysResponse {
+		return AppRelaysResponse{
+			PublicKey:           app,
+			From:                now.AddDate(0, 0, -2),
+			To:                  now.AddDate(0, 0, 1),
+			Count:               count,
+			RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now)},
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		appA     types.PortalAppPublicKey
+		appB     types.PortalAppPublicKey
+		expected AppsComparisonResponse
+	}{
+		{
+			name: "Delta and ratio of two apps with traffic",
+			appA: "app1",
+			appB: "app2",
+			expected: AppsComparisonResponse{
+				A:     appRelays("app1", RelayCounts{Success: 50, Failure: 10}),
+				B:     appRelays("app2", RelayCounts{Success: 20, Failure: 10}),
+				Delta: RelayCounts{Success: 30},
+				Ratio: ratio(2),
+			},
+		},
+		{
+			name: "Ratio is omitted for a zero traffic app B",
+			appA: "app1",
+			appB: "app3",
+			expected: AppsComparisonResponse{
+				A:     appRelays("app1", RelayCounts{Success: 50, Failure: 10}),
+				B:     appRelays("app3", RelayCounts{}),
+				Delta: RelayCounts{Success: 50, Failure: 10},
+			},
+		},
+		{
+			name: "Ratio is zero for a zero traffic app A",
+			appA: "app3",
+			appB: "app1",
+			expected: AppsComparisonResponse{
+				A:     appRelays("app3", RelayCounts{}),
+				B:     appRelays("app1", RelayCounts{Success: 50, Failure: 10}),
+				Delta: RelayCounts{Success: -50, Failure: -10},
+				Ratio: ratio(0),
+			},
+		},
+		{
+			name: "Unknown app is compared with zero counts and a note",
+			appA: "app2",
+			appB: "unknown",
+			expected: AppsComparisonResponse{
+				A:     appRelays("app2", RelayCounts{Success: 20, Failure: 10}),
+				B:     appRelays("unknown", RelayCounts{}),
+				Delta: RelayCounts{Success: 20, Failure: 10},
+				Notes: []string{"Application unknown has no metrics: its relay counts are zero"},
+			},
+		},
+	}
+
+	fakeBackend := fakeBackend{
+		usage:       usageData,
+		todaysUsage: todaysUsage,
+	}
+	relayMeter := NewRelayMeter(context.Background(), &fakeBackend, &fakeDriver{}, logger.New(), RelayMeterOptions{LoadInterval: 100 * time.Millisecond})
+	time.Sleep(200 * time.Millisecond)
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := relayMeter.CompareAppsRelays(context.Background(), tc.appA, tc.appB, now.AddDate(0, 0, -2), now)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAppSLO(t *testing.T) {
+	now, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
+	usageData := map[time.Time]map[types.PortalAppPublicKey]RelayCounts{
+		now.AddDate(0, 0, -1): {"app1": {Success: 990, Failure: 5}, "app2": {Success: 900, Failure: 100}, "app3": {}},
+	}
+	todaysUsage := map[types.PortalAppPublicKey]RelayCounts{
+		"app1": {Success: 0, Failure: 5},
+	}
+	rate := func(r float64) *float64 { return &r }
+	met := func(m bool) *bool { return &m }
+	slo := func(app types.PortalAppPublicKey, count RelayCounts) AppSLOResponse {
+		return AppSLOResponse{
+			PublicKey:           app,
+			From:                now.AddDate(0, 0, -1),
+			To:                  now.AddDate(0, 0, 1),
+			Count:               count,
+			Target:              0.99,
+			RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now)},
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		app      types.PortalAppPublicKey
+		expected func() AppSLOResponse
+	}{
+		{
+			name: "Target is met by a success rate equal to it",
+			app:  "app1",
+			expected: func() AppSLOResponse {
+				resp := slo("app1", RelayCounts{Success: 990, Failure: 10})
+				resp.SuccessRate, resp.Met = rate(0.99), met(true)
+				return resp
+			},
+		},
+		{
+			name: "Target is missed by a lower success rate",
+			app:  "app2",
+			expected: func() AppSLOResponse {
+				resp := slo("app2", RelayCounts{Success: 900, Failure: 100})
+				resp.SuccessRate, resp.Met = rate(0.9), met(false)
+				return resp
+			},
+		},
+		{
+			name: "Success rate is undefined for an app without traffic",
+			app:  "app3",
+			expected: func() AppSLOResponse {
+				resp := slo("app3", RelayCounts{})
+				resp.Notes = []string{"Application app3 has no relays: its success rate is undefined"}
+				return resp
+			},
+		},
+	}
+
+	fakeBackend := fakeBackend{
+		usage:       usageData,
+		todaysUsage: todaysUsage,
+	}
+	relayMeter := NewRelayMeter(context.Background(), &fakeBackend, &fakeDriver{}, logger.New(), RelayMeterOptions{LoadInterval: 100 * time.Millisecond})
+	time.Sleep(200 * time.Millisecond)
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := relayMeter.AppSLO(context.Background(), tc.app, 0.99, now.AddDate(0, 0, -1), now)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tc.expected(), got); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAppHealth(t *testing.T) {
+	now, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
+	meter := &relayMeter{
+		Backend: &fakeBackend{
+			usage: map[time.Time]map[types.PortalAppPublicKey]RelayCounts{
+				now.AddDate(0, 0, -1): {"app1": {Success: 70, Failure: 5}, "app2": {Success: 50}, "app3": {Success: 10}},
+			},
+			todaysUsage: map[types.PortalAppPublicKey]RelayCounts{"app1": {Success: 20, Failure: 5}},
+			todaysLatency: map[types.PortalAppPublicKey][]Latency{
+				"app1": {
+					{Time: now, Latency: 0.2, Percentiles: &LatencyPercentiles{P50: 0.1, P95: 0.5, P99: 0.9}},
+					{Time: now.Add(time.Hour), Latency: 0.4, Percentiles: &LatencyPercentiles{P50: 0.3, P95: 0.7, P99: 1.2}},
+				},
+				"app3": {{Time: now, Latency: 0.3}},
+			},
+		},
+		Logger: logger.New(),
+	}
+	if err := meter.loadData(now.AddDate(0, 0, -7), now); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	value := func(v float64) *float64 { return &v }
+	testCases := []struct {
+		name     string
+		app      types.PortalAppPublicKey
+		from     time.Time
+		to       time.Time
+		expected AppHealthResponse
+	}{
+		{
+			name: "Relays are combined with the latencies",
+			app:  "app1",
+			from: now.AddDate(0, 0, -1),
+			to:   now,
+			expected: AppHealthResponse{
+				PublicKey:           "app1",
+				From:                now.AddDate(0, 0, -1),
+				To:                  now.AddDate(0, 0, 1),
+				Count:               RelayCounts{Success: 90, Failure: 10},
+				SuccessRate:         value(0.9),
+				AvgLatency:          value(0.3),
+				P95Latency:          value(0.7),
+				RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now)},
+			},
+		},
+		{
+			name: "App with relays but no latency samples",
+			app:  "app2",
+			from: now.AddDate(0, 0, -1),
+			to:   now,
+			expected: AppHealthResponse{
+				PublicKey:           "app2",
+				From:                now.AddDate(0, 0, -1),
+				To:                  now.AddDate(0, 0, 1),
+				Count:               RelayCounts{Success: 50},
+				SuccessRate:         value(1),
+				Notes:               []string{"Application app2 has no latencies today"},
+				RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now)},
+			},
+		},
+		{
+			name: "App with latencies without percentiles",
+			app:  "app3",
+			from: now.AddDate(0, 0, -1),
+			to:   now,
+			expected: AppHealthResponse{
+				PublicKey:           "app3",
+				From:                now.AddDate(0, 0, -1),
+				To:                  now.AddDate(0, 0, 1),
+				Count:               RelayCounts{Success: 10},
+				SuccessRate:         value(1),
+				AvgLatency:          value(0.3),
+				Notes:               []string{"Application app3 has no latency percentiles today"},
+				RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now)},
+			},
+		},
+		{
+			name: "Latencies are omitted for a time period before today",
+			app:  "app1",
+			from: now.AddDate(0, 0, -1),
+			to:   now.AddDate(0, 0, -1),
+			expected: AppHealthResponse{
+				PublicKey:           "app1",
+				From:                now.AddDate(0, 0, -1),
+				To:                  now,
+				Count:               RelayCounts{Success: 70, Failure: 5},
+				SuccessRate:         value(0.933333),
+				Notes:               []string{"Latencies are only held for today, which is not within the time period"},
+				RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now.AddDate(0, 0, -1))},
+			},
+		},
+		{
+			name: "Success rate is undefined for an app without relays",
+			app:  "app4",
+			from: now.AddDate(0, 0, -1),
+			to:   now,
+			expected: AppHealthResponse{
+				PublicKey:           "app4",
+				From:                now.AddDate(0, 0, -1),
+				To:                  now.AddDate(0, 0, 1),
+				Notes:               []string{"Application app4 has no relays: its success rate is undefined", "Application app4 has no latencies today"},
+				RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now)},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := meter.AppHealth(context.Background(), tc.app, tc.from, tc.to)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAppRank(t *testing.T) {
+	now, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
+	usageData := map[time.Time]map[types.PortalAppPublicKey]RelayCounts{
+		now.AddDate(0, 0, -5): {"app5": {Success: 1000}},
+		now.AddDate(0, 0, -1): {"app1": {Success: 90, Failure: 10}, "app2": {Success: 60, Failure: 40}, "app3": {Success: 100}, "app4": {Success: 5}},
+	}
+	todaysUsage := map[types.PortalAppPublicKey]RelayCounts{
+		"app4": {Success: 10, Failure: 50},
+	}
+
+	testCases := []struct {
+		name          string
+		app           types.PortalAppPublicKey
+		by            OriginOrder
+		expectedCount RelayCounts
+		expectedRank  int
+		expectedTotal int
+		expectedErr   error
+	}{
+		{
+			name:          "Apps with the same total relays share the same rank",
+			app:           "app2",
+			expectedCount: RelayCounts{Success: 60, Failure: 40},
+			expectedRank:  1,
+			expectedTotal: 4,
+		},
+		{
+			name:          "Rank is one more than the apps with strictly more relays",
+			app:           "app4",
+			expectedCount: RelayCounts{Success: 15, Failure: 50},
+			expectedRank:  4,
+			expectedTotal: 4,
+		},
+		{
+			name:          "Apps are ranked by the specified relay count",
+			app:           "app1",
+			by:            OriginsBySuccess,
+			expectedCount: RelayCounts{Success: 90, Failure: 10},
+			expectedRank:  2,
+			expectedTotal: 4,
+		},
+		{
+			name:          "Apps tied on failures share the same rank",
+			app:           "app3",
+			by:            OriginsByFailure,
+			expectedCount: RelayCounts{Success: 100},
+			expectedRank:  4,
+			expectedTotal: 4,
+		},
+		{
+			name:          "App without relays over the time period is ranked last with zero relays",
+			app:           "app5",
+			expectedCount: RelayCounts{},
+			expectedRank:  5,
+			expectedTotal: 5,
+		},
+		{
+			name:        "Unknown app is not ranked",
+			app:         "app6",
+			expectedErr: AppNotFound,
+		},
+	}
+
+	fakeBackend := fakeBackend{
+		usage:       usageData,
+		todaysUsage: todaysUsage,
+	}
+	relayMeter := NewRelayMeter(context.Background(), &fakeBackend, &fakeDriver{}, logger.New(), RelayMeterOptions{LoadInterval: 100 * time.Millisecond})
+	time.Sleep(200 * time.Millisecond)
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := relayMeter.AppRank(context.Background(), tc.app, now.AddDate(0, 0, -1), now, tc.by)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("Expected error: %v, got: %v", tc.expectedErr, err)
+			}
+			if tc.expectedErr != nil {
+				return
+			}
+
+			expected := AppRankResponse{
+				PublicKey:           tc.app,
+				From:                now.AddDate(0, 0, -1),
+				To:                  now.AddDate(0, 0, 1),
+				Count:               tc.expectedCount,
+				By:                  tc.by,
+				Rank:                tc.expectedRank,
+				Total:               tc.expectedTotal,
+				RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now)},
+			}
+			if diff := cmp.Diff(expected, got); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestActiveApps(t *testing.T) {
+	now, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
+	usageData := map[time.Time]map[types.PortalAppPublicKey]RelayCounts{
+		now.AddDate(0, 0, -5): {"app4": {Success: 10}},
+		now.AddDate(0, 0, -2): {"app2": {Failure: 5}, "app3": {}},
+		now.AddDate(0, 0, -1): {"app1": {Success: 10}, "app3": {}},
+	}
+	todaysUsage := map[types.PortalAppPublicKey]RelayCounts{
+		"app5": {Success: 1},
+		"app6": {},
+	}
+
+	testCases := []struct {
+		name     string
+		from     time.Time
+		to       time.Time
+		expected []types.PortalAppPublicKey
+	}{
+		{
+			name:     "Apps with daily or todays relays are active, sorted by public key",
+			from:     now.AddDate(0, 0, -2),
+			to:       now,
+			expected: []types.PortalAppPublicKey{"app1", "app2", "app5"},
+		},
+		{
+			name:     "Apps with relays outside the time period are not active",
+			from:     now.AddDate(0, 0, -2),
+			to:       now.AddDate(0, 0, -2),
+			expected: []types.PortalAppPublicKey{"app2"},
+		},
+		{
+			name:     "No active apps over a time period without relays",
+			from:     now.AddDate(0, 0, -4),
+			to:       now.AddDate(0, 0, -3),
+			expected: []types.PortalAppPublicKey{},
+		},
+	}
+
+	fakeBackend := fakeBackend{
+		usage:       usageData,
+		todaysUsage: todaysUsage,
+	}
+	relayMeter := NewRelayMeter(context.Background(), &fakeBackend, &fakeDriver{}, logger.New(), RelayMeterOptions{LoadInterval: 100 * time.Millisecond})
+	time.Sleep(200 * time.Millisecond)
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := relayMeter.ActiveApps(context.Background(), tc.from, tc.to)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAppsFullyFailing(t *testing.T) {
+	now, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
+	usageData := map[time.Time]map[types.PortalAppPublicKey]RelayCounts{
+		now.AddDate(0, 0, -3): {"app1": {Success: 1}, "app2": {Failure: 50}},
+		now.AddDate(0, 0, -2): {"app1": {Failure: 20}, "app2": {Failure: 50}, "app3": {Failure: 5}, "app4": {Success: 10, Failure: 500}},
+		now.AddDate(0, 0, -1): {"app1": {Failure: 20}, "app3": {Failure: 5}, "app5": {}},
+	}
+	todaysUsage := map[types.PortalAppPublicKey]RelayCounts{
+		"app3": {Failure: 90},
+	}
+
+	testCases := []struct {
+		name      string
+		from      time.Time
+		to        time.Time
+		minVolume int64
+		expected  []AppRelaysResponse
+	}{
+		{
+			name: "Apps without successful relays over the time period, sorted by descending failures",
+			from: now.AddDate(0, 0, -2),
+			to:   now,
+			expected: []AppRelaysResponse{
+				{PublicKey: "app3", From: now.AddDate(0, 0, -2), To: now.AddDate(0, 0, 1), Count: RelayCounts{Failure: 100}},
+				{PublicKey: "app2", From: now.AddDate(0, 0, -2), To: now.AddDate(0, 0, 1), Count: RelayCounts{Failure: 50}},
+				{PublicKey: "app1", From: now.AddDate(0, 0, -2), To: now.AddDate(0, 0, 1), Count: RelayCounts{Failure: 40}},
+			},
+		},
+		{
+			name: "Apps with a successful relay anywhere in the time period are only partially failing",
+			from: now.AddDate(0, 0, -3),
+			to:   now.AddDate(0, 0, -1),
+			expected: []AppRelaysResponse{
+				{PublicKey: "app2", From: now.AddDate(0, 0, -3), To: now, Count: RelayCounts{Failure: 100}},
+				{PublicKey: "app3", From: now.AddDate(0, 0, -3), To: now, Count: RelayCounts{Failure: 10}},
+			},
+		},
+		{
+			name:      "Apps below the minimum volume are excluded",
+			from:      now.AddDate(0, 0, -2),
+			to:        now,
+			minVolume: 50,
+			expected: []AppRelaysResponse{
+				{PublicKey: "app3", From: now.AddDate(0, 0, -2), To: now.AddDate(0, 0, 1), Count: RelayCounts{Failure: 100}},
+				{PublicKey: "app2", From: now.AddDate(0, 0, -2), To: now.AddDate(0, 0, 1), Count: RelayCounts{Failure: 50}},
+			},
+		},
+		{
+			name:     "No failing apps over a time period without relays",
+			from:     now.AddDate(0, 0, -6),
+			to:       now.AddDate(0, 0, -5),
+			expected: []AppRelaysResponse{},
+		},
+	}
+
+	fakeBackend := fakeBackend{
+		usage:       usageData,
+		todaysUsage: todaysUsage,
+	}
+	relayMeter := NewRelayMeter(context.Background(), &fakeBackend, &fakeDriver{}, logger.New(), RelayMeterOptions{LoadInterval: 100 * time.Millisecond})
+	time.Sleep(200 * time.Millisecond)
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := relayMeter.AppsFullyFailing(context.Background(), tc.from, tc.to, tc.minVolume)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			// The 'to' parameter is always adjusted to the start of the next day
+			for i := range tc.expected {
+				tc.expected[i].RequestedTimePeriod = RequestedTimePeriod{RequestedTo: timePtr(tc.to)}
+			}
+
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAppRawDailyRows(t *testing.T) {
+	now, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
+	backend := &fakeBackend{
+		usage: map[time.Time]map[types.PortalAppPublicKey]RelayCounts{
+			now.AddDate(0, 0, -3): {"app1": {Success: 3, Failure: 1}, "app2": {Success: 5}},
+			now.AddDate(0, 0, -2): {"app2": {Success: 7}},
+			now.AddDate(0, 0, -1): {"app1": {Success: 2, Failure: 4}},
+		},
+	}
+
+	testCases := []struct {
+		name     string
+		from     time.Time
+		to       time.Time
+		backend  *fakeBackend
+		expected AppRawDailyRowsResponse
+		err      error
+	}{
+		{
+			name:    "Stored rows of the app are returned in ascending order of day",
+			from:    now.AddDate(0, 0, -3),
+			to:      now,
+			backend: backend,
+			expected: AppRawDailyRowsResponse{
+				PublicKey: "app1",
+				From:      now.AddDate(0, 0, -3),
+				To:        now.AddDate(0, 0, 1),
+				Rows: []DailyRow{
+					{Day: now.AddDate(0, 0, -3), Success: 3, Failure: 1},
+					{Day: now.AddDate(0, 0, -1), Success: 2, Failure: 4},
+				},
+				RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now)},
+			},
+		},
+		{
+			name:    "Rows are limited to the time period",
+			from:    now.AddDate(0, 0, -2),
+			to:      now.AddDate(0, 0, -2),
+			backend: backend,
+			expected: AppRawDailyRowsResponse{
+				PublicKey:           "app1",
+				From:                now.AddDate(0, 0, -2),
+				To:                  now.AddDate(0, 0, -1),
+				Rows:                []DailyRow{},
+				RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now.AddDate(0, 0, -2))},
+			},
+		},
+		{
+			name:    "Backend errors are returned",
+			from:    now.AddDate(0, 0, -3),
+			to:      now,
+			backend: &fakeBackend{err: errors.New("connection refused")},
+			err:     errors.New("connection refused"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// The rows are read from the backend without loading any data into the cache
+			meter := &relayMeter{Backend: tc.backend, Logger: logger.New()}
+
+			got, err := meter.AppRawDailyRows(context.Background(), "app1", tc.from, tc.to)
+			if fmt.Sprint(err) != fmt.Sprint(tc.err) {
+				t.Fatalf("Expected error: %v, got: %v", tc.err, err)
+			}
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCoverage(t *testing.T) {
+	now, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
+	coveredDays := []time.Time{
+		now.AddDate(0, 0, -10),
+		now.AddDate(0, 0, -6),
+		now.AddDate(0, 0, -5),
+		now.AddDate(0, 0, -4),
+		now.AddDate(0, 0, -2),
+		now.AddDate(0, 0, -1),
+	}
+
+	testCases := []struct {
+		name     string
+		from     time.Time
+		to       time.Time
+		expected CoverageResponse
+	}{
+		{
+			name: "Consecutive days are merged into ranges around the gaps",
+			from: now.AddDate(0, 0, -10),
+			to:   now,
+			expected: CoverageResponse{
+				From: now.AddDate(0, 0, -10),
+				To:   now.AddDate(0, 0, 1),
+				Days: []DayRange{
+					{From: now.AddDate(0, 0, -10), To: now.AddDate(0, 0, -10)},
+					{From: now.AddDate(0, 0, -6), To: now.AddDate(0, 0, -4)},
+					{From: now.AddDate(0, 0, -2), To: now.AddDate(0, 0, -1)},
+				},
+				RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now)},
+			},
+		},
+		{
+			name: "Ranges are limited to the time period",
+			from: now.AddDate(0, 0, -5),
+			to:   now.AddDate(0, 0, -2),
+			expected: CoverageResponse{
+				From: now.AddDate(0, 0, -5),
+				To:   now.AddDate(0, 0, -1),
+				Days: []DayRange{
+					{From: now.AddDate(0, 0, -5), To: now.AddDate(0, 0, -4)},
+					{From: now.AddDate(0, 0, -2), To: now.AddDate(0, 0, -2)},
+				},
+				RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now.AddDate(0, 0, -2))},
+			},
+		},
+		{
+			name: "No ranges for a time period without collected days",
+			from: now.AddDate(0, 0, -9),
+			to:   now.AddDate(0, 0, -7),
+			expected: CoverageResponse{
+				From:                now.AddDate(0, 0, -9),
+				To:                  now.AddDate(0, 0, -6),
+				Days:                []DayRange{},
+				RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now.AddDate(0, 0, -7))},
+			},
+		},
+	}
+
+	fakeBackend := fakeBackend{coveredDays: coveredDays}
+	relayMeter := NewRelayMeter(context.Background(), &fakeBackend, &fakeDriver{}, logger.New(), RelayMeterOptions{LoadInterval: 100 * time.Millisecond})
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := relayMeter.Coverage(context.Background(), tc.from, tc.to)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAppRelays(t *testing.T) {
+	now, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
+	current := time.Now()
+	usageData := fakeDailyMetrics()
+	todaysUsage := fakeTodaysMetrics()
+	requestedApp := types.PortalAppPublicKey("app1")
+
+	testCases := []struct {
+		name                string
+		from                time.Time
+		to                  time.Time
+		usageData           map[time.Time]map[types.PortalAppPublicKey]int64
+		todaysUsage         map[types.PortalAppPublicKey]int64
+		expected            AppRelaysResponse
+		expectedErr         error
+		expectedTodaysCalls int
+	}{
+		{
+			name: "Correct count is returned",
+			from: now.AddDate(0, 0, -5),
+			to:   now.AddDate(0, 0, -1),
+			expected: AppRelaysResponse{
+				PublicKey:           requestedApp,
+				From:                now.AddDate(0, 0, -5),
+				To:                  now,
+				RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now.AddDate(0, 0, -1))},
+				DayCoverage:         DayCoverage{DaysWithData: 5, DaysRequested: 5},
+				Notes:               []string{toAdjustedNote(now.AddDate(0, 0, -1))},
+				Count: RelayCounts{
+					Success: 5 * 2,
+					Failure: 5 * 3,
+				},
+			},
+		},
+		{
+			name: "From and To parameters are adjusted to start of the specifed day and the next day, respectively",
+			from: current.AddDate(0, 0, -5),
+			to:   current.AddDate(0, 0, -1),
+			expected: AppRelaysResponse{
+				PublicKey:           "app1",
+				From:                now.AddDate(0, 0, -5),
+				To:                  now,
+				RequestedTimePeriod: RequestedTimePeriod{RequestedFrom: timePtr(current.AddDate(0, 0, -5)), RequestedTo: timePtr(current.AddDate(0, 0, -1))},
+				DayCoverage:         DayCoverage{DaysWithData: 5, DaysRequested: 5},
+				Notes:               []string{fromAdjustedNote(now.AddDate(0, 0, -5)), toAdjustedNote(now.AddDate(0, 0, -1))},
+				Count: RelayCounts{
+					Success: 5 * 2,
+					Failure: 5 * 3,
+				},
+			},
+		},
+		{
+			name: "Equal values are allowed for From and To parameters (to cover a single day)",
+			from: now.AddDate(0, 0, -3),
+			to:   now.AddDate(0, 0, -3),
+			expected: AppRelaysResponse{
+				PublicKey:           "app1",
+				From:                now.AddDate(0, 0, -3),
+				To:                  now.AddDate(0, 0, -2),
+				RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now.AddDate(0, 0, -3))},
+				DayCoverage:         DayCoverage{DaysWithData: 1, DaysRequested: 1},
+				Notes:               []string{toAdjustedNote(now.AddDate(0, 0, -3))},
+				Count: RelayCounts{
+					Success: 1 * 2,
+					Failure: 1 * 3,
+				},
+			},
+		},
+		{
+			name: "Today's metrics are added to previous days' usage data",
+			from: now.AddDate(0, 0, -3),
+			to:   now,
+			todaysUsage: map[types.PortalAppPublicKey]int64{
+				"app1": 50,
+				"app2": 30,
+			},
+			expected: AppRelaysResponse{
+				PublicKey:           "app1",
+				From:                now.AddDate(0, 0, -3),
+				To:                  now.AddDate(0, 0, 1),
+				RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now)},
+				DayCoverage:         DayCoverage{DaysWithData: 4, DaysRequested: 4},
+				Notes:               []string{toAdjustedNote(now), todayIncludedNote},
+				Count: RelayCounts{
+					Success: 3*2 + 50,
+					Failure: 3*3 + 40,
+				},
+			},
+		},
+		{
+			name: "Only today's data is included when from and to parameters point to today",
+			from: now,
+			to:   now,
+			expected: AppRelaysResponse{
+				PublicKey:           "app1",
+				From:                now,
+				To:                  now.AddDate(0, 0, 1),
+				RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now)},
+				DayCoverage:         DayCoverage{DaysWithData: 1, DaysRequested: 1},
+				Notes:               []string{toAdjustedNote(now), todayIncludedNote},
+				Count: RelayCounts{
+					Success: 50,
+					Failure: 40,
+				},
+			},
+		},
+		{
+			name: "Today's metrics are not included when the 'to' parameter does not include today",
+			from: current.AddDate(0, 0, -3),
+			to:   current.AddDate(0, 0, -1),
+			expected: AppRelaysResponse{
+				PublicKey:           "app1",
+				From:                now.AddDate(0, 0, -3),
+				To:                  now,
+				RequestedTimePeriod: RequestedTimePeriod{RequestedFrom: timePtr(current.AddDate(0, 0, -3)), RequestedTo: timePtr(current.AddDate(0, 0, -1))},
+				DayCoverage:         DayCoverage{DaysWithData: 3, DaysRequested: 3},
+				Notes:               []string{fromAdjustedNote(now.AddDate(0, 0, -3)), toAdjustedNote(now.AddDate(0, 0, -1))},
+				Count: RelayCounts{
+					Success: 3 * 2,
+					Failure: 3 * 3,
+				},
+			},
+		},
+		{
+			name: "Today's metrics are included when the 'to' parameter is after today",
+			from: current.AddDate(0, 0, -3),
+			to:   current.AddDate(0, 0, 2),
+			expected: AppRelaysResponse{
+				PublicKey:           "app1",
+				From:                now.AddDate(0, 0, -3),
+				To:                  now.AddDate(0, 0, 3),
+				RequestedTimePeriod: RequestedTimePeriod{RequestedFrom: timePtr(current.AddDate(0, 0, -3)), RequestedTo: timePtr(current.AddDate(0, 0, 2))},
+				DayCoverage:         DayCoverage{DaysWithData: 4, DaysRequested: 6},
+				Notes:               []string{fromAdjustedNote(now.AddDate(0, 0, -3)), toAdjustedNote(now.AddDate(0, 0, 2)), todayIncludedNote},
+				Count: RelayCounts{
+					Success: 3*2 + 50,
+					Failure: 3*3 + 40,
+				},
+			},
+		},
+		{
+			name: "Only today's metrics are included when the timespan only includes today",
+			from: current,
+			to:   current.AddDate(0, 0, 2),
+			expected: AppRelaysResponse{
+				PublicKey:           "app1",
+				From:                now,
+				To:                  now.AddDate(0, 0, 3),
+				RequestedTimePeriod: RequestedTimePeriod{RequestedFrom: timePtr(current), RequestedTo: timePtr(current.AddDate(0, 0, 2))},
+				DayCoverage:         DayCoverage{DaysWithData: 1, DaysRequested: 3},
+				Notes:               []string{fromAdjustedNote(now), toAdjustedNote(now.AddDate(0, 0, 2)), todayIncludedNote},
+				Count: RelayCounts{
+					Success: 50,
+					Failure: 40,
+				},
+			},
+		},
+		{
+			name: "Missing parameters' default values",
+			expected: AppRelaysResponse{
+				PublicKey:           "app1",
+				From:                now.AddDate(0, 0, -6),
+				To:                  now.AddDate(0, 0, 1),
+				RequestedTimePeriod: RequestedTimePeriod{RequestedFrom: timePtr(now.AddDate(0, 0, -30))},
+				DayCoverage:         DayCoverage{DaysWithData: 7, DaysRequested: 31},
+				Notes:               []string{fmt.Sprintf("Metrics are only available from %s: the time period was shortened to start then", now.AddDate(0, 0, -6).Format(time.RFC3339))},
+				Count: RelayCounts{
+					Success: 6*2 + 50,
+					Failure: 6*3 + 40,
+				},
+			},
+		},
+		{
+			name: "From preceding the earliest collected day is clamped to it",
+			from: now.AddDate(0, 0, -10),
+			to:   now.AddDate(0, 0, -5),
+			expected: AppRelaysResponse{
+				PublicKey: "app1",
+				From:      now.AddDate(0, 0, -6),
+				To:        now.AddDate(0, 0, -4),
+				RequestedTimePeriod: RequestedTimePeriod{
+					RequestedFrom: timePtr(now.AddDate(0, 0, -10)),
+					RequestedTo:   timePtr(now.AddDate(0, 0, -5)),
+				},
+				DayCoverage: DayCoverage{DaysWithData: 2, DaysRequested: 6},
+				Notes: []string{
+					toAdjustedNote(now.AddDate(0, 0, -5)),
+					fmt.Sprintf("Metrics are only available from %s: the time period was shortened to start then", now.AddDate(0, 0, -6).Format(time.RFC3339)),
+				},
+				Count: RelayCounts{
+					Success: 2 * 2,
+					Failure: 2 * 3,
+				},
+			},
+		},
+		{
+			name:        "Invalid timespan is rejected",
+			from:        now.AddDate(0, 0, -1),
+			to:          now.AddDate(0, 0, -2),
+			expectedErr: fmt.Errorf("Invalid timespan"),
+			expected: AppRelaysResponse{
+				PublicKey: "app1",
+				From:      now.AddDate(0, 0, -1),
+				To:        now.AddDate(0, 0, -2),
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			fakeBackend := fakeBackend{
+				usage:       usageData,
+				todaysUsage: todaysUsage,
+			}
+
+			relayMeter := NewRelayMeter(context.Background(), &fakeBackend, &fakeDriver{}, logger.New(), RelayMeterOptions{LoadInterval: 100 * time.Millisecond})
+			time.Sleep(200 * time.Millisecond)
+			got, err := relayMeter.AppRelays(context.Background(), requestedApp, tc.from, tc.to)
+			if err != nil {
+				if tc.expectedErr == nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if !strings.Contains(err.Error(), tc.expectedErr.Error()) {
+					t.Fatalf("Expected error to contain: %q, got: %v", tc.expectedErr.Error(), err)
+				}
+			}
+
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestClampToAvailableData(t *testing.T) {
+	now, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
+	earliest := now.AddDate(0, 0, -6)
+	note := fmt.Sprintf("Metrics are only available from %s", earliest.Format(time.RFC3339))
+
+	testCases := []struct {
+		name              string
+		dailyUsage        map[time.Time]map[types.PortalAppPublicKey]RelayCounts
+		from              time.Time
+		to                time.Time
+		requested         RequestedTimePeriod
+		expectedFrom      time.Time
+		expectedRequested RequestedTimePeriod
+		expectedNotes     []string
+	}{
+		{
+			name:         "Time period starting after the earliest collected day is not changed",
+			dailyUsage:   fakeDailyMetrics(),
+			from:         now.AddDate(0, 0, -3),
+			to:           now,
+			expectedFrom: now.AddDate(0, 0, -3),
+		},
+		{
+			name:              "From preceding the earliest collected day is clamped to it",
+			dailyUsage:        fakeDailyMetrics(),
+			from:              now.AddDate(0, 0, -20),
+			to:                now,
+			expectedFrom:      earliest,
+			expectedRequested: RequestedTimePeriod{RequestedFrom: timePtr(now.AddDate(0, 0, -20))},
+			expectedNotes:     []string{note + ": the time period was shortened to start then"},
+		},
+		{
+			name:              "Supplied from is reported as requested",
+			dailyUsage:        fakeDailyMetrics(),
+			from:              now.AddDate(0, 0, -20),
+			to:                now,
+			requested:         RequestedTimePeriod{RequestedFrom: timePtr(now.AddDate(0, 0, -20).Add(time.Hour))},
+			expectedFrom:      earliest,
+			expectedRequested: RequestedTimePeriod{RequestedFrom: timePtr(now.AddDate(0, 0, -20).Add(time.Hour))},
+			expectedNotes:     []string{note + ": the time period was shortened to start then"},
+		},
+		{
+			name:          "Time period ending before the earliest collected day is only noted",
+			dailyUsage:    fakeDailyMetrics(),
+			from:          now.AddDate(0, 0, -20),
+			to:            now.AddDate(0, 0, -10),
+			expectedFrom:  now.AddDate(0, 0, -20),
+			expectedNotes: []string{note},
+		},
+		{
+			name:         "Time period is not changed without daily metrics",
+			from:         now.AddDate(0, 0, -20),
+			to:           now,
+			expectedFrom: now.AddDate(0, 0, -20),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			meter := &relayMeter{dailyUsage: tc.dailyUsage}
+
+			from, requested, notes := meter.clampToAvailableData(tc.from, tc.to, tc.requested)
+			if !from.Equal(tc.expectedFrom) {
+				t.Errorf("Expected from: %v, got: %v", tc.expectedFrom, from)
+			}
+			if diff := cmp.Diff(tc.expectedRequested, requested); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedNotes, notes); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTimePeriodNotes(t *testing.T) {
+	day := time.Date(2022, time.July, 20, 0, 0, 0, 0, time.UTC)
+	today := day.AddDate(0, 0, 1)
+
+	testCases := []struct {
+		name      string
+		from      time.Time
+		to        time.Time
+		requested RequestedTimePeriod
+		expected  []string
+	}{
+		{
+			name: "No notes are added when no parameter was adjusted",
+			from: day.AddDate(0, 0, -3),
+			to:   today,
+		},
+		{
+			name:      "From adjusted to the start of its day is noted",
+			from:      day.AddDate(0, 0, -3),
+			to:        today,
+			requested: RequestedTimePeriod{RequestedFrom: timePtr(day.AddDate(0, 0, -3).Add(time.Hour))},
+			expected:  []string{"From adjusted to the start of day 2022-07-17"},
+		},
+		{
+			name:      "From clamped to the archived metrics is noted",
+			from:      day.AddDate(0, 0, -3),
+			to:        today,
+			requested: RequestedTimePeriod{RequestedFrom: timePtr(day.AddDate(-1, 0, 0)), FromClamped: true},
+			expected:  []string{"From adjusted to the first day of the archived metrics 2022-07-17"},
+		},
+		{
+			name:      "To adjusted to the next day is noted",
+			from:      day.AddDate(0, 0, -3),
+			to:        day.AddDate(0, 0, -1),
+			requested: RequestedTimePeriod{RequestedTo: timePtr(day.AddDate(0, 0, -2))},
+			expected:  []string{"To adjusted to the start of day 2022-07-19, to include the whole of 2022-07-18"},
+		},
+		{
+			name:      "To including today is noted",
+			from:      day.AddDate(0, 0, -3),
+			to:        today,
+			requested: RequestedTimePeriod{RequestedFrom: timePtr(day.AddDate(0, 0, -3).Add(time.Hour)), RequestedTo: timePtr(day)},
+			expected: []string{
+				"From adjusted to the start of day 2022-07-17",
+				"To adjusted to the start of day 2022-07-21, to include the whole of 2022-07-20",
+				todayIncludedNote,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, timePeriodNotes(tc.from, tc.to, today, tc.requested)); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDayCoverage(t *testing.T) {
+	now, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
+	today := now.AddDate(0, 0, 1)
+
+	withGap := fakeDailyMetrics()
+	delete(withGap, now.AddDate(0, 0, -3))
+
+	testCases := []struct {
+		name        string
+		dailyUsage  map[time.Time]map[types.PortalAppPublicKey]RelayCounts
+		todaysUsage map[types.PortalAppPublicKey]RelayCounts
+		from        time.Time
+		to          time.Time
+		expected    DayCoverage
+	}{
+		{
+			name:        "Fully covered time period",
+			dailyUsage:  fakeDailyMetrics(),
+			todaysUsage: fakeTodaysMetrics(),
+			from:        now.AddDate(0, 0, -6),
+			to:          now.AddDate(0, 0, 1),
+			expected:    DayCoverage{DaysWithData: 7, DaysRequested: 7},
+		},
+		{
+			name:        "Time period starting before the earliest collected day is partially covered",
+			dailyUsage:  fakeDailyMetrics(),
+			todaysUsage: fakeTodaysMetrics(),
+			from:        now.AddDate(0, 0, -30),
+			to:          now.AddDate(0, 0, 1),
+			expected:    DayCoverage{DaysWithData: 7, DaysRequested: 31},
+		},
+		{
+			name:       "Days missing from the collected metrics are not covered",
+			dailyUsage: withGap,
+			from:       now.AddDate(0, 0, -6),
+			to:         now,
+			expected:   DayCoverage{DaysWithData: 5, DaysRequested: 6},
+		},
+		{
+			name:       "Today is not covered before its metrics are loaded",
+			dailyUsage: fakeDailyMetrics(),
+			from:       now.AddDate(0, 0, -1),
+			to:         now.AddDate(0, 0, 1),
+			expected:   DayCoverage{DaysWithData: 1, DaysRequested: 2},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			meter := &relayMeter{dailyUsage: tc.dailyUsage, todaysUsage: tc.todaysUsage}
+
+			if diff := cmp.Diff(tc.expected, meter.dayCoverage(tc.from, tc.to, today)); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDaysWithin(t *testing.T) {
+	now, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
+	dailyUsage := fakeDailyMetrics()
+
+	testCases := []struct {
+		name      string
+		dailyDays []time.Time
+		from      time.Time
+		to        time.Time
+		expected  []time.Time
+	}{
+		{
+			name:      "Only the days within the time period are returned",
+			dailyDays: sortedDays(dailyUsage),
+			from:      now.AddDate(0, 0, -3),
+			to:        now.AddDate(0, 0, -1),
+			expected:  []time.Time{now.AddDate(0, 0, -3), now.AddDate(0, 0, -2)},
+		},
+		{
+			name:     "Days are returned in ascending order without the index",
+			from:     now.AddDate(0, 0, -30),
+			to:       now.AddDate(0, 0, 1),
+			expected: []time.Time{now.AddDate(0, 0, -6), now.AddDate(0, 0, -5), now.AddDate(0, 0, -4), now.AddDate(0, 0, -3), now.AddDate(0, 0, -2), now.AddDate(0, 0, -1)},
+		},
+		{
+			name:      "Time period without daily metrics has no days",
+			dailyDays: sortedDays(dailyUsage),
+			from:      now.AddDate(0, 0, -20),
+			to:        now.AddDate(0, 0, -10),
+			expected:  []time.Time{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			meter := &relayMeter{dailyUsage: dailyUsage, dailyDays: tc.dailyDays}
+
+			if diff := cmp.Diff(tc.expected, meter.daysWithin(tc.from, tc.to)); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// BenchmarkAppRelayCounts measures a 1-day query on a 90-day cache, which should only iterate the requested day
+func BenchmarkAppRelayCounts(b *testing.B) {
+	now, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
+
+	dailyUsage := make(map[time.Time]map[types.PortalAppPublicKey]RelayCounts)
+	for i := 1; i <= 90; i++ {
+		counts := make(map[types.PortalAppPublicKey]RelayCounts)
+		for app := 0; app < 1000; app++ {
+			counts[types.PortalAppPublicKey(fmt.Sprintf("app%d", app))] = RelayCounts{Success: int64(app), Failure: 1}
+		}
+		dailyUsage[now.AddDate(0, 0, -i)] = counts
+	}
+	meter := &relayMeter{dailyUsage: dailyUsage, dailyDays: sortedDays(dailyUsage)}
+
+	from, to := now.AddDate(0, 0, -1), now
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		meter.rwMutex.RLock()
+		meter.appRelayCounts("app1", from, to, now.AddDate(0, 0, 1))
+		meter.rwMutex.RUnlock()
+	}
+}
+
+func TestAllAppsRelays(t *testing.T) {
+	now, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
+	current := time.Now()
+	usageData := fakeDailyMetrics()
+	todaysUsage := fakeTodaysMetrics()
+
+	testCases := []struct {
+		name                string
+		from                time.Time
+		to                  time.Time
+		usageData           map[time.Time]map[types.PortalAppPublicKey]int64
+		todaysUsage         map[types.PortalAppPublicKey]int64
+		expected            map[types.PortalAppPublicKey]AppRelaysResponse
+		expectedErr         error
+		expectedTodaysCalls int
+	}{
+		{
+			name: "Correct count is returned",
+			from: now.AddDate(0, 0, -5),
+			to:   now.AddDate(0, 0, -1),
+			expected: map[types.PortalAppPublicKey]AppRelaysResponse{
+				"app1": {
+					PublicKey:           "app1",
+					From:                now.AddDate(0, 0, -5),
+					To:                  now,
+					RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now.AddDate(0, 0, -1))},
+					Notes:               []string{toAdjustedNote(now.AddDate(0, 0, -1))},
+					Count: RelayCounts{
+						Success: 10,
+						Failure: 15,
+					},
+				},
+				"app2": {
+					PublicKey:           "app2",
+					From:                now.AddDate(0, 0, -5),
+					To:                  now,
+					RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now.AddDate(0, 0, -1))},
+					Notes:               []string{toAdjustedNote(now.AddDate(0, 0, -1))},
+					Count: RelayCounts{
+						Success: 5,
+						Failure: 25,
+					},
+				},
+				"app4": {
+					PublicKey:           "app4",
+					From:                now.AddDate(0, 0, -5),
+					To:                  now,
+					RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now.AddDate(0, 0, -1))},
+					Notes:               []string{toAdjustedNote(now.AddDate(0, 0, -1))},
+					Count: RelayCounts{
+						Success: 25,
+						Failure: 35,
+					},
+				},
+			},
+		},
+		{
+			name: "From and To parameters are adjusted to start of the specifed day and the next day, respectively",
+			from: current.AddDate(0, 0, -5),
+			to:   current.AddDate(0, 0, -1),
+			expected: map[types.PortalAppPublicKey]AppRelaysResponse{
+				"app1": {
+					PublicKey:           "app1",
+					From:                now.AddDate(0, 0, -5),
+					To:                  now,
+					RequestedTimePeriod: RequestedTimePeriod{RequestedFrom: timePtr(current.AddDate(0, 0, -5)), RequestedTo: timePtr(current.AddDate(0, 0, -1))},
+					Notes:               []string{fromAdjustedNote(now.AddDate(0, 0, -5)), toAdjustedNote(now.AddDate(0, 0, -1))},
+					Count: RelayCounts{
+						Success: 10,
+						Failure: 15,
+					},
+				},
+				"app2": {
+					PublicKey:           "app2",
+					From:                now.AddDate(0, 0, -5),
+					To:                  now,
+					RequestedTimePeriod: RequestedTimePeriod{RequestedFrom: timePtr(current.AddDate(0, 0, -5)), RequestedTo: timePtr(current.AddDate(0, 0, -1))},
+					Notes:               []string{fromAdjustedNote(now.AddDate(0, 0, -5)), toAdjustedNote(now.AddDate(0, 0, -1))},
+					Count: RelayCounts{
+						Success: 5,
+						Failure: 25,
+					},
+				},
+				"app4": {
+					PublicKey:           "app4",
+					From:                now.AddDate(0, 0, -5),
+					To:                  now,
+					RequestedTimePeriod: RequestedTimePeriod{RequestedFrom: timePtr(current.AddDate(0, 0, -5)), RequestedTo: timePtr(current.AddDate(0, 0, -1))},
+					Notes:               []string{fromAdjustedNote(now.AddDate(0, 0, -5)), toAdjustedNote(now.AddDate(0, 0, -1))},
+					Count: RelayCounts{
+						Success: 25,
+						Failure: 35,
+					},
+				},
+			},
+		},
+		{
+			name: "Equal values are allowed for From and To parameters (to cover a single day)",
+			from: now.AddDate(0, 0, -3),
+			to:   now.AddDate(0, 0, -3),
+			expected: map[types.PortalAppPublicKey]AppRelaysResponse{
+				"app1": {
+					PublicKey:           "app1",
+					From:                now.AddDate(0, 0, -3),
+					To:                  now.AddDate(0, 0, -2),
+					RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now.AddDate(0, 0, -3))},
+					Notes:               []string{toAdjustedNote(now.AddDate(0, 0, -3))},
+					Count: RelayCounts{
+						Success: 2,
+						Failure: 3,
+					},
+				},
+				"app2": {
+					PublicKey:           "app2",
+					From:                now.AddDate(0, 0, -3),
+					To:                  now.AddDate(0, 0, -2),
+					RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now.AddDate(0, 0, -3))},
+					Notes:               []string{toAdjustedNote(now.AddDate(0, 0, -3))},
+					Count: RelayCounts{
+						Success: 1,
+						Failure: 5,
+					},
+				},
+				"app4": {
+					PublicKey:           "app4",
+					From:                now.AddDate(0, 0, -3),
+					To:                  now.AddDate(0, 0, -2),
+					RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now.AddDate(0, 0, -3))},
+					Notes:               []string{toAdjustedNote(now.AddDate(0, 0, -3))},
+					Count: RelayCounts{
+						Success: 5,
+						Failure: 7,
+					},
+				},
+			},
+		},
+		{
+			name: "Today's metrics are added to previous days' usage data",
+			from: now.AddDate(0, 0, -3),
+			to:   now,
+			todaysUsage: map[types.PortalAppPublicKey]int64{
+				"app1": 50,
+				"app2": 30,
+			},
+			expected: map[types.PortalAppPublicKey]AppRelaysResponse{
+				"app1": {
+					PublicKey:           "app1",
+					From:                now.AddDate(0, 0, -3),
+					To:                  now.AddDate(0, 0, 1),
+					RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now)},
+					Notes:               []string{toAdjustedNote(now), todayIncludedNote},
+					Count: RelayCounts{
+						Success: 56,
+						Failure: 49,
+					},
+				},
+				"app2": {
+					PublicKey:           "app2",
+					From:                now.AddDate(0, 0, -3),
+					To:                  now.AddDate(0, 0, 1),
+					RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now)},
+					Notes:               []string{toAdjustedNote(now), todayIncludedNote},
+					Count: RelayCounts{
+						Success: 33,
+						Failure: 85,
+					},
+				},
+				"app4": {
+					PublicKey:           "app4",
+					From:                now.AddDate(0, 0, -3),
+					To:                  now.AddDate(0, 0, 1),
+					RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now)},
+					Notes:               []string{toAdjustedNote(now), todayIncludedNote},
+					Count: RelayCounts{
+						Success: 515,
+						Failure: 721,
+					},
+				},
+			},
+		},
+		{
+			name: "Only today's data is included when from and to parameters point to today",
+			from: now,
+			to:   now,
+			expected: map[types.PortalAppPublicKey]AppRelaysResponse{
+				"app1": {
+					PublicKey:           "app1",
+					From:                now,
+					To:                  now.AddDate(0, 0, 1),
+					RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now)},
+					Notes:               []string{toAdjustedNote(now), todayIncludedNote},
+					Count: RelayCounts{
+						Success: 50,
+						Failure: 40,
+					},
+				},
+				"app2": {
+					PublicKey:           "app2",
+					From:                now,
+					To:                  now.AddDate(0, 0, 1),
+					RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now)},
+					Notes:               []string{toAdjustedNote(now), todayIncludedNote},
+					Count: RelayCounts{
+						Success: 30,
+						Failure: 70,
+					},
+				},
+				"app4": {
+					PublicKey:           "app4",
+					From:                now,
+					To:                  now.AddDate(0, 0, 1),
+					RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now)},
+					Notes:               []string{toAdjustedNote(now), todayIncludedNote},
+					Count: RelayCounts{
+						Success: 500,
+						Failure: 700,
+					},
+				},
+			},
+		},
+		{
+			name: "Today's metrics are not included when the 'to' parameter does not include today",
+			from: current.AddDate(0, 0, -3),
+			to:   current.AddDate(0, 0, -1),
+			expected: map[types.PortalAppPublicKey]AppRelaysResponse{
+				"app1": {
+					PublicKey:           "app1",
+					From:                now.AddDate(0, 0, -3),
+					To:                  now,
+					RequestedTimePeriod: RequestedTimePeriod{RequestedFrom: timePtr(current.AddDate(0, 0, -3)), RequestedTo: timePtr(current.AddDate(0, 0, -1))},
+					Notes:               []string{fromAdjustedNote(now.AddDate(0, 0, -3)), toAdjustedNote(now.AddDate(0, 0, -1))},
+					Count: RelayCounts{
+						Success: 6,
+						Failure: 9,
+					},
+				},
+				"app2": {
+					PublicKey:           "app2",
+					From:                now.AddDate(0, 0, -3),
+					To:                  now,
+					RequestedTimePeriod: RequestedTimePeriod{RequestedFrom: timePtr(current.AddDate(0, 0, -3)), RequestedTo: timePtr(current.AddDate(0, 0, -1))},
+					Notes:               []string{fromAdjustedNote(now.AddDate(0, 0, -3)), toAdjustedNote(now.AddDate(0, 0, -1))},
+					Count: RelayCounts{
+						Success: 3,
+						Failure: 15,
+					},
+				},
+				"app4": {
+					PublicKey:           "app4",
+					From:                now.AddDate(0, 0, -3),
+					To:                  now,
+					RequestedTimePeriod: RequestedTimePeriod{RequestedFrom: timePtr(current.AddDate(0, 0, -3)), RequestedTo: timePtr(current.AddDate(0, 0, -1))},
+					Notes:               []string{fromAdjustedNote(now.AddDate(0, 0, -3)), toAdjustedNote(now.AddDate(0, 0, -1))},
+					Count: RelayCounts{
+						Success: 15,
+						Failure: 21,
+					},
+				},
+			},
+		},
+		{
+			name: "Today's metrics are included when the 'to' parameter is after today",
+			from: current.AddDate(0, 0, -3),
+			to:   current.AddDate(0, 0, 2),
+			expected: map[types.PortalAppPublicKey]AppRelaysResponse{
+				"app1": {
+					PublicKey:           "app1",
+					From:                now.AddDate(0, 0, -3),
+					To:                  now.AddDate(0, 0, 3),
+					RequestedTimePeriod: RequestedTimePeriod{RequestedFrom: timePtr(current.AddDate(0, 0, -3)), RequestedTo: timePtr(current.AddDate(0, 0, 2))},
+					Notes:               []string{fromAdjustedNote(now.AddDate(0, 0, -3)), toAdjustedNote(now.AddDate(0, 0, 2)), todayIncludedNote},
+					Count: RelayCounts{
+						Success: 56,
+						Failure: 49,
+					},
+				},
+				"app2": {
+					PublicKey:           "app2",
+					From:                now.AddDate(0, 0, -3),
+					To:                  now.AddDate(0, 0, 3),
+					RequestedTimePeriod: RequestedTimePeriod{RequestedFrom: timePtr(current.AddDate(0, 0, -3)), RequestedTo: timePtr(current.AddDate(0, 0, 2))},
+					Notes:               []string{fromAdjustedNote(now.AddDate(0, 0, -3)), toAdjustedNote(now.AddDate(0, 0, 2)), todayIncludedNote},
+					Count: RelayCounts{
+						Success: 33,
+						Failure: 85,
+					},
+				},
+				"app4": {
+					PublicKey:           "app4",
+					From:                now.AddDate(0, 0, -3),
+					To:                  now.AddDate(0, 0, 3),
+					RequestedTimePeriod: RequestedTimePeriod{RequestedFrom: timePtr(current.AddDate(0, 0, -3)), RequestedTo: timePtr(current.AddDate(0, 0, 2))},
+					Notes:               []string{fromAdjustedNote(now.AddDate(0, 0, -3)), toAdjustedNote(now.AddDate(0, 0, 2)), todayIncludedNote},
+					Count: RelayCounts{
+						Success: 515,
+						Failure: 721,
+					},
+				},
+			},
+		},
+		{
+			name: "Only today's metrics are included when the timespan only includes today",
+			from: current,
+			to:   current.AddDate(0, 0, 2),
+			expected: map[types.PortalAppPublicKey]AppRelaysResponse{
+				"app1": {
+					PublicKey:           "app1",
+					From:                now,
+					To:                  now.AddDate(0, 0, 3),
+					RequestedTimePeriod: RequestedTimePeriod{RequestedFrom: timePtr(current), RequestedTo: timePtr(current.AddDate(0, 0, 2))},
+					Notes:               []string{fromAdjustedNote(now), toAdjustedNote(now.AddDate(0, 0, 2)), todayIncludedNote},
+					Count: RelayCounts{
+						Success: 50,
+						Failure: 40,
+					},
+				},
+				"app2": {
+					PublicKey:           "app2",
+					From:                now,
+					To:                  now.AddDate(0, 0, 3),
+					RequestedTimePeriod: RequestedTimePeriod{RequestedFrom: timePtr(current), RequestedTo: timePtr(current.AddDate(0, 0, 2))},
+					Notes:               []string{fromAdjustedNote(now), toAdjustedNote(now.AddDate(0, 0, 2)), todayIncludedNote},
+					Count: RelayCounts{
+						Success: 30,
+						Failure: 70,
+					},
+				},
+				"app4": {
+					PublicKey:           "app4",
+					From:                now,
+					To:                  now.AddDate(0, 0, 3),
+					RequestedTimePeriod: RequestedTimePeriod{RequestedFrom: timePtr(current), RequestedTo: timePtr(current.AddDate(0, 0, 2))},
+					Notes:               []string{fromAdjustedNote(now), toAdjustedNote(now.AddDate(0, 0, 2)), todayIncludedNote},
+					Count: RelayCounts{
+						Success: 500,
+						Failure: 700,
+					},
+				},
+			},
+		},
+		{
+			name: "Missing parameters' default values",
+			expected: map[types.PortalAppPublicKey]AppRelaysResponse{
+				"app1": {
+					PublicKey: "app1",
+					From:      now.AddDate(0, 0, -30),
+					To:        now.AddDate(0, 0, 1),
+					Count: RelayCounts{
+						Success: 62,
+						Failure: 58,
+					},
+				},
+				"app2": {
+					PublicKey: "app2",
+					From:      now.AddDate(0, 0, -30),
+					To:        now.AddDate(0, 0, 1),
+					Count: RelayCounts{
+						Success: 36,
+						Failure: 100,
+					},
+				},
+				"app4": {
+					PublicKey: "app4",
+					From:      now.AddDate(0, 0, -30),
+					To:        now.AddDate(0, 0, 1),
+					Count: RelayCounts{
+						Success: 530,
+						Failure: 742,
+					},
+				},
+			},
+		},
+		{
+			name:        "Invalid timespan is rejected",
+			from:        now.AddDate(0, 0, -1),
+			to:          now.AddDate(0, 0, -2),
+			expectedErr: fmt.Errorf("Invalid timespan"),
+			expected:    map[types.PortalAppPublicKey]AppRelaysResponse{},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			fakeBackend := fakeBackend{
+				usage:       usageData,
+				todaysUsage: todaysUsage,
+			}
+
+			relayMeter := NewRelayMeter(context.Background(), &fakeBackend, &fakeDriver{}, logger.New(), RelayMeterOptions{LoadInterval: 100 * time.Millisecond})
+			time.Sleep(200 * time.Millisecond)
+			rawGot, _, err := relayMeter.AllAppsRelays(context.Background(), tc.from, tc.to, Page{})
+			if err != nil {
+				if tc.expectedErr == nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if !strings.Contains(err.Error(), tc.expectedErr.Error()) {
+					t.Fatalf("Expected error to contain: %q, got: %v", tc.expectedErr.Error(), err)
+				}
+			}
+
+			// Need to convert it to map to be able to compare
+			got := make(map[types.PortalAppPublicKey]AppRelaysResponse, len(rawGot))
+
+			for _, relResp := range rawGot {
+				got[relResp.PublicKey] = relResp
+			}
+
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5}
+
+	testCases := []struct {
+		name         string
+		page         Page
+		defaultLimit int
+		expected     []int
+	}{
+		{
+			name:     "All items are returned without a limit",
+			expected: []int{1, 2, 3, 4, 5},
+		},
+		{
+			name:     "Items are limited",
+			page:     Page{Limit: 2},
+			expected: []int{1, 2},
+		},
+		{
+			name:     "Offset items are skipped",
+			page:     Page{Limit: 2, Offset: 2},
+			expected: []int{3, 4},
+		},
+		{
+			name:     "Last page is partial",
+			page:     Page{Limit: 2, Offset: 4},
+			expected: []int{5},
+		},
+		{
+			name:     "Page past the last item is empty",
+			page:     Page{Limit: 2, Offset: 5},
+			expected: []int{},
+		},
+		{
+			name:     "Remaining items are returned after the offset without a limit",
+			page:     Page{Offset: 3},
+			expected: []int{4, 5},
+		},
+		{
+			name:         "Default limit applies without a limit",
+			defaultLimit: 3,
+			expected:     []int{1, 2, 3},
+		},
+		{
+			name:         "Requested limit overrides the default limit",
+			page:         Page{Limit: 4},
+			defaultLimit: 3,
+			expected:     []int{1, 2, 3, 4},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, paginate(items, tc.page, tc.defaultLimit)); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAllAppsRelaysPagination(t *testing.T) {
+	now, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
+	todaysUsage := fakeTodaysMetrics()
+	todaysUsage["app3"] = RelayCounts{Success: 3}
+	meter := &relayMeter{
+		Backend: &fakeBackend{
+			usage:       fakeDailyMetrics(),
+			todaysUsage: todaysUsage,
+			portalApps: map[types.PortalAppID]*types.PortalApp{
+				"portal_app_2": {ID: "portal_app_2", AATs: map[types.ProtocolAppID]types.AAT{"p2": {PublicKey: "app2"}}},
+				"portal_app_1": {ID: "portal_app_1", AATs: map[types.ProtocolAppID]types.AAT{"p1": {PublicKey: "app1"}}},
+				"portal_app_3": {ID: "portal_app_3", AATs: map[types.ProtocolAppID]types.AAT{"p3": {PublicKey: "app3"}}},
+			},
+		},
+		Logger:            logger.New(),
+		RelayMeterOptions: RelayMeterOptions{DefaultPageLimit: 3},
+	}
+	if err := meter.loadData(now.AddDate(0, 0, -7), now); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	appKeys := func(page Page) ([]types.PortalAppPublicKey, int) {
+		resp, total, err := meter.AllAppsRelays(context.Background(), now.AddDate(0, 0, -3), now, page)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		keys := []types.PortalAppPublicKey{}
+		for _, app := range resp {
+			keys = append(keys, app.PublicKey)
+		}
+		return keys, total
+	}
+
+	// The apps are sorted by public key, so repeated requests return the same pages
+	for i := 0; i < 10; i++ {
+		for _, tc := range []struct {
+			page     Page
+			expected []types.PortalAppPublicKey
+		}{
+			{page: Page{}, expected: []types.PortalAppPublicKey{"app1", "app2", "app3"}},
+			{page: Page{Limit: 2, Offset: 2}, expected: []types.PortalAppPublicKey{"app3", "app4"}},
+			{page: Page{Limit: 2, Offset: 4}, expected: []types.PortalAppPublicKey{}},
+		} {
+			keys, total := appKeys(tc.page)
+			if total != 4 {
+				t.Fatalf("Expected a total of 4 apps, got: %d", total)
+			}
+			if diff := cmp.Diff(tc.expected, keys); diff != "" {
+				t.Fatalf("unexpected value for page %+v (-want +got):\n%s", tc.page, diff)
+			}
+		}
+	}
+
+	resp, total, err := meter.AllPortalAppsRelays(context.Background(), now, now, Page{Limit: 2, Offset: 1})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if total != 3 || len(resp) != 2 || resp[0].PortalAppID != "portal_app_2" || resp[1].PortalAppID != "portal_app_3" {
+		t.Errorf("Expected portal_app_2 and portal_app_3 of 3 portal apps, got: %v of %d", resp, total)
+	}
+}
+
+func TestAllAppsRelaysEffectiveFrom(t *testing.T) {
 	now, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
-	usageData := fakeDailyMetrics()
 	todaysUsage := fakeTodaysMetrics()
+	// A brand new app, only active today
+	todaysUsage["app9"] = RelayCounts{Success: 9, Failure: 1}
+
+	testCases := []struct {
+		name              string
+		appsEffectiveFrom bool
+		expectedFrom      map[types.PortalAppPublicKey]time.Time
+	}{
+		{
+			name: "From is the start of the time period by default",
+			expectedFrom: map[types.PortalAppPublicKey]time.Time{
+				"app1": now.AddDate(0, 0, -10),
+				"app2": now.AddDate(0, 0, -10),
+				"app4": now.AddDate(0, 0, -10),
+				"app9": now.AddDate(0, 0, -10),
+			},
+		},
+		{
+			name:              "From is the first day with data when enabled",
+			appsEffectiveFrom: true,
+			expectedFrom: map[types.PortalAppPublicKey]time.Time{
+				"app1": now.AddDate(0, 0, -6),
+				"app2": now.AddDate(0, 0, -6),
+				"app4": now.AddDate(0, 0, -6),
+				"app9": now,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			fakeBackend := fakeBackend{
+				usage:       fakeDailyMetrics(),
+				todaysUsage: todaysUsage,
+			}
+
+			relayMeter := NewRelayMeter(context.Background(), &fakeBackend, &fakeDriver{}, logger.New(), RelayMeterOptions{
+				LoadInterval:      100 * time.Millisecond,
+				AppsEffectiveFrom: tc.appsEffectiveFrom,
+			})
+			time.Sleep(200 * time.Millisecond)
+			rawGot, _, err := relayMeter.AllAppsRelays(context.Background(), now.AddDate(0, 0, -10), now, Page{})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			got := make(map[types.PortalAppPublicKey]time.Time, len(rawGot))
+			for _, relResp := range rawGot {
+				got[relResp.PublicKey] = relResp.From
+				if !relResp.To.Equal(now.AddDate(0, 0, 1)) {
+					t.Errorf("Expected 'to' for %s: %v, got: %v", relResp.PublicKey, now.AddDate(0, 0, 1), relResp.To)
+				}
+			}
+
+			if diff := cmp.Diff(tc.expectedFrom, got); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAllAppsRelaysOutsideTimePeriod(t *testing.T) {
+	now, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
+	meter := &relayMeter{
+		Logger: logger.New(),
+		dailyUsage: map[time.Time]map[types.PortalAppPublicKey]RelayCounts{
+			now.AddDate(0, 0, -5): {"app1": {Success: 5}},
+			now.AddDate(0, 0, -2): {"app2": {Success: 2, Failure: 1}},
+		},
+	}
+
+	got, total, err := meter.AllAppsRelays(context.Background(), now.AddDate(0, 0, -3), now.AddDate(0, 0, -1), Page{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// app1 only has metrics before the time period
+	if total != 1 || len(got) != 1 || got[0].PublicKey != "app2" {
+		t.Fatalf("Expected only app2 to be listed, got %d apps: %+v", total, got)
+	}
+	if got[0].Count != (RelayCounts{Success: 2, Failure: 1}) {
+		t.Errorf("Expected the counts of app2 within the time period, got: %+v", got[0].Count)
+	}
+}
+
+func TestAppLatency(t *testing.T) {
+	todaysLatency := fakeTodaysLatency()
+	errBackendFailure := errors.New("backend error")
 
 	testCases := []struct {
 		name                string
-		from                time.Time
-		to                  time.Time
-		usageData           map[time.Time]map[types.PortalAppPublicKey]int64
-		todaysUsage         map[types.PortalAppPublicKey]int64
-		expected            map[types.PortalAppPublicKey]AppRelaysResponse
+		requestedApp        types.PortalAppPublicKey
+		expected            AppLatencyResponse
+		backendErr          error
 		expectedErr         error
 		expectedTodaysCalls int
 	}{
 		{
-			name: "Correct count is returned",
-			from: now.AddDate(0, 0, -5),
-			to:   now.AddDate(0, 0, -1),
-			expected: map[types.PortalAppPublicKey]AppRelaysResponse{
+			name:         "Correct latency data is returned",
+			requestedApp: "app1",
+			expected: AppLatencyResponse{
+				PublicKey:    "app1",
+				From:         todaysLatency["app1"][0].Time,
+				To:           todaysLatency["app1"][len(todaysLatency["app1"])-1].Time,
+				DailyLatency: todaysLatency["app1"],
+			},
+		},
+		{
+			name:         "Returns error if requested app not found",
+			requestedApp: "app42",
+			expectedErr:  ErrAppLatencyNotFound,
+		},
+		{
+			name:         "Backend service error",
+			requestedApp: "app1",
+			backendErr:   errBackendFailure,
+			expectedErr:  ErrAppLatencyNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			fakeBackend := fakeBackend{
+				todaysLatency: todaysLatency,
+				err:           tc.backendErr,
+			}
+
+			relayMeter := NewRelayMeter(context.Background(), &fakeBackend, &fakeDriver{}, logger.New(), RelayMeterOptions{LoadInterval: 100 * time.Millisecond})
+			time.Sleep(200 * time.Millisecond)
+			got, err := relayMeter.AppLatency(context.Background(), tc.requestedApp)
+			if err != nil {
+				if tc.expectedErr == nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if !strings.Contains(err.Error(), tc.expectedErr.Error()) {
+					t.Fatalf("Expected error to contain: %q, got: %v", tc.expectedErr.Error(), err)
+				}
+			}
+
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAppLatencyConcurrentReaders(t *testing.T) {
+	// The latencies are cached in reverse order, so sorting the cached slice in place would modify it
+	latencies := fakeTodaysLatency()["app1"]
+	reversed := make([]Latency, len(latencies))
+	for i, latency := range latencies {
+		reversed[len(latencies)-1-i] = latency
+	}
+	cached := make([]Latency, len(reversed))
+	copy(cached, reversed)
+
+	now := time.Now()
+	meter := &relayMeter{
+		Backend: &fakeBackend{
+			usage:         fakeDailyMetrics(),
+			todaysUsage:   fakeTodaysMetrics(),
+			todaysLatency: map[types.PortalAppPublicKey][]Latency{"app1": cached},
+		},
+		Logger: logger.New(),
+	}
+	if err := meter.loadData(now.AddDate(0, 0, -7), now); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Today's metrics are reloaded while the latencies are read
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 10; i++ {
+			if err := meter.loadData(now.AddDate(0, 0, -7), now); err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}
+	}()
+	for i := 0; i < 10; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			if _, err := meter.AppLatency(context.Background(), "app1"); err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := meter.AllAppsLatencies(context.Background(), 0); err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := meter.AppHealth(context.Background(), "app1", now, now); err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if diff := cmp.Diff(reversed, cached); diff != "" {
+		t.Errorf("Expected the cached latencies not to be sorted in place (-want +got):\n%s", diff)
+	}
+}
+
+func TestAllAppsLatencies(t *testing.T) {
+	todaysLatency := fakeTodaysLatency()
+	errBackendFailure := errors.New("backend error")
+
+	testCases := []struct {
+		name                string
+		todaysLatency       map[types.PortalAppPublicKey][]Latency
+		expected            map[types.PortalAppPublicKey]AppLatencyResponse
+		emptyLatencySlice   bool
+		expectedErr         error
+		backendErr          error
+		expectedTodaysCalls int
+	}{
+		{
+			name: "Correct latency data is returned",
+			expected: map[types.PortalAppPublicKey]AppLatencyResponse{
 				"app1": {
-					PublicKey: "app1",
-					From:      now.AddDate(0, 0, -5),
-					To:        now,
-					Count: RelayCounts{
-						Success: 10,
-						Failure: 15,
-					},
+					PublicKey:    "app1",
+					From:         todaysLatency["app1"][0].Time,
+					To:           todaysLatency["app1"][len(todaysLatency["app1"])-1].Time,
+					DailyLatency: todaysLatency["app1"],
 				},
 				"app2": {
-					PublicKey: "app2",
-					From:      now.AddDate(0, 0, -5),
-					To:        now,
-					Count: RelayCounts{
-						Success: 5,
-						Failure: 25,
-					},
+					PublicKey:    "app2",
+					From:         todaysLatency["app2"][0].Time,
+					To:           todaysLatency["app2"][len(todaysLatency["app2"])-1].Time,
+					DailyLatency: todaysLatency["app2"],
 				},
 				"app4": {
-					PublicKey: "app4",
-					From:      now.AddDate(0, 0, -5),
-					To:        now,
-					Count: RelayCounts{
-						Success: 25,
-						Failure: 35,
-					},
+					PublicKey:    "app4",
+					From:         todaysLatency["app4"][0].Time,
+					To:           todaysLatency["app4"][len(todaysLatency["app4"])-1].Time,
+					DailyLatency: todaysLatency["app4"],
 				},
 			},
 		},
 		{
-			name: "From and To parameters are adjusted to start of the specifed day and the next day, respectively",
-			from: time.Now().AddDate(0, 0, -5),
-			to:   time.Now().AddDate(0, 0, -1),
-			expected: map[types.PortalAppPublicKey]AppRelaysResponse{
-				"app1": {
-					PublicKey: "app1",
-					From:      now.AddDate(0, 0, -5),
-					To:        now,
-					Count: RelayCounts{
-						Success: 10,
-						Failure: 15,
-					},
-				},
-				"app2": {
-					PublicKey: "app2",
-					From:      now.AddDate(0, 0, -5),
-					To:        now,
-					Count: RelayCounts{
-						Success: 5,
-						Failure: 25,
-					},
-				},
-				"app4": {
-					PublicKey: "app4",
-					From:      now.AddDate(0, 0, -5),
-					To:        now,
-					Count: RelayCounts{
-						Success: 25,
-						Failure: 35,
-					},
+			name:        "Backend service error",
+			backendErr:  errBackendFailure,
+			expectedErr: errBackendFailure,
+			expected:    map[types.PortalAppPublicKey]AppLatencyResponse{},
+		},
+		{
+			name:              "Empty latency response",
+			backendErr:        nil,
+			expectedErr:       nil,
+			emptyLatencySlice: true,
+			expected:          map[types.PortalAppPublicKey]AppLatencyResponse{},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			// The latencies are copied, as the data loaders of the previous test cases may still be reading them
+			latencies := make(map[types.PortalAppPublicKey][]Latency, len(todaysLatency))
+			for key, latency := range todaysLatency {
+				latencies[key] = latency
+				if tc.emptyLatencySlice {
+					latencies[key] = []Latency{}
+				}
+			}
+
+			fakeBackend := fakeBackend{
+				todaysLatency: latencies,
+				err:           tc.backendErr,
+			}
+
+			relayMeter := NewRelayMeter(context.Background(), &fakeBackend, &fakeDriver{}, logger.New(), RelayMeterOptions{LoadInterval: 100 * time.Millisecond})
+			time.Sleep(200 * time.Millisecond)
+			rawGot, err := relayMeter.AllAppsLatencies(context.Background(), 0)
+
+			if err != nil {
+				if tc.expectedErr == nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if !strings.Contains(err.Error(), tc.expectedErr.Error()) {
+					t.Fatalf("Expected error to contain: %q, got: %v", tc.expectedErr.Error(), err)
+				}
+			}
+
+			// Need to convert it to map to be able to compare
+			got := make(map[types.PortalAppPublicKey]AppLatencyResponse, len(rawGot))
+
+			for _, relResp := range rawGot {
+				got[relResp.PublicKey] = relResp
+			}
+
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAllAppsLatenciesLimit(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Hour)
+	series := func(points int, last time.Time) []Latency {
+		var latencies []Latency
+		for i := points - 1; i >= 0; i-- {
+			latencies = append(latencies, Latency{Time: last.Add(-time.Duration(i) * time.Hour), Latency: 0.1})
+		}
+		return latencies
+	}
+
+	todaysLatency := map[types.PortalAppPublicKey][]Latency{
+		"complete": series(24, now),
+		"sparse":   series(2, now),
+		"stale":    series(12, now.Add(-6*time.Hour)),
+		"recent":   series(12, now),
+	}
+
+	testCases := []struct {
+		name     string
+		limit    int
+		expected []types.PortalAppPublicKey
+	}{
+		{
+			name:     "All apps are returned without a limit",
+			expected: []types.PortalAppPublicKey{"complete", "recent", "sparse", "stale"},
+		},
+		{
+			name:     "All apps are returned under the limit",
+			limit:    10,
+			expected: []types.PortalAppPublicKey{"complete", "recent", "sparse", "stale"},
+		},
+		{
+			name:     "Sparsest app is dropped first",
+			limit:    3,
+			expected: []types.PortalAppPublicKey{"complete", "recent", "stale"},
+		},
+		{
+			name:     "Apps with older data are dropped on equally complete series",
+			limit:    2,
+			expected: []types.PortalAppPublicKey{"complete", "recent"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			relayMeter := &relayMeter{
+				Logger:        logger.New(),
+				todaysLatency: todaysLatency,
+			}
+
+			got, err := relayMeter.AllAppsLatencies(context.Background(), tc.limit)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var gotKeys []types.PortalAppPublicKey
+			for _, latency := range got {
+				gotKeys = append(gotKeys, latency.PublicKey)
+			}
+
+			if diff := cmp.Diff(tc.expected, sortPublicKeys(gotKeys)); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPortalAppRelays(t *testing.T) {
+	now, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
+	usageData := fakeDailyMetrics()
+	todaysUsage := fakeTodaysMetrics()
+	errBackendFailure := errors.New("backend error")
+
+	testCases := []struct {
+		name       string
+		portalApp  types.PortalAppID
+		from       time.Time
+		to         time.Time
+		backendErr error
+
+		expected    PortalAppRelaysResponse
+		expectedErr error
+	}{
+		{
+			name:        "PortalApp not found error",
+			expectedErr: ErrPortalAppNotFound,
+		},
+		{
+			name:        "Backend service error",
+			backendErr:  errBackendFailure,
+			expectedErr: errBackendFailure,
+		},
+		{
+			name:      "Correct summary for a portalApp",
+			portalApp: "portal_app_1",
+			from:      now.AddDate(0, 0, -6),
+			to:        now,
+			expected: PortalAppRelaysResponse{
+				From:                now.AddDate(0, 0, -6),
+				To:                  now.AddDate(0, 0, 1),
+				RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now)},
+				Notes:               []string{toAdjustedNote(now), todayIncludedNote},
+				DayCoverage:         DayCoverage{DaysWithData: 7, DaysRequested: 7},
+				PortalAppID:         "portal_app_1",
+				PublicKeys:          []types.PortalAppPublicKey{"app1", "app2", "app3"},
+				Count: RelayCounts{
+					Success: 6*(2+1) + 50 + 30,
+					Failure: 6*(3+5) + 40 + 70,
 				},
 			},
 		},
 		{
-			name: "Equal values are allowed for From and To parameters (to cover a single day)",
-			from: now.AddDate(0, 0, -3),
-			to:   now.AddDate(0, 0, -3),
-			expected: map[types.PortalAppPublicKey]AppRelaysResponse{
-				"app1": {
-					PublicKey: "app1",
-					From:      now.AddDate(0, 0, -3),
-					To:        now.AddDate(0, 0, -2),
-					Count: RelayCounts{
-						Success: 2,
-						Failure: 3,
-					},
-				},
-				"app2": {
-					PublicKey: "app2",
-					From:      now.AddDate(0, 0, -3),
-					To:        now.AddDate(0, 0, -2),
-					Count: RelayCounts{
-						Success: 1,
-						Failure: 5,
-					},
-				},
-				"app4": {
-					PublicKey: "app4",
-					From:      now.AddDate(0, 0, -3),
-					To:        now.AddDate(0, 0, -2),
-					Count: RelayCounts{
-						Success: 5,
-						Failure: 7,
-					},
+			name:      "Correct summary for a portalApp excluding today",
+			portalApp: "portal_app_1",
+			from:      now.AddDate(0, 0, -6),
+			to:        now.AddDate(0, 0, -2),
+			expected: PortalAppRelaysResponse{
+				From:                now.AddDate(0, 0, -6),
+				To:                  now.AddDate(0, 0, -1),
+				RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now.AddDate(0, 0, -2))},
+				Notes:               []string{toAdjustedNote(now.AddDate(0, 0, -2))},
+				DayCoverage:         DayCoverage{DaysWithData: 5, DaysRequested: 5},
+				PortalAppID:         "portal_app_1",
+				PublicKeys:          []types.PortalAppPublicKey{"app1", "app2", "app3"},
+				Count: RelayCounts{
+					Success: 5 * (2 + 1),
+					Failure: 5 * (3 + 5),
 				},
 			},
 		},
 		{
-			name: "Today's metrics are added to previous days' usage data",
-			from: now.AddDate(0, 0, -3),
-			to:   now,
-			todaysUsage: map[types.PortalAppPublicKey]int64{
-				"app1": 50,
-				"app2": 30,
-			},
-			expected: map[types.PortalAppPublicKey]AppRelaysResponse{
-				"app1": {
-					PublicKey: "app1",
-					From:      now.AddDate(0, 0, -3),
-					To:        now.AddDate(0, 0, 1),
-					Count: RelayCounts{
-						Success: 56,
-						Failure: 49,
-					},
-				},
-				"app2": {
-					PublicKey: "app2",
-					From:      now.AddDate(0, 0, -3),
-					To:        now.AddDate(0, 0, 1),
-					Count: RelayCounts{
-						Success: 33,
-						Failure: 85,
-					},
+			name:      "Correct summary for a portalApp on todays metrics",
+			portalApp: "portal_app_1",
+			from:      now,
+			to:        now,
+			expected: PortalAppRelaysResponse{
+				From:                now,
+				To:                  now.AddDate(0, 0, 1),
+				RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now)},
+				Notes:               []string{toAdjustedNote(now), todayIncludedNote},
+				DayCoverage:         DayCoverage{DaysWithData: 1, DaysRequested: 1},
+				PortalAppID:         "portal_app_1",
+				PublicKeys:          []types.PortalAppPublicKey{"app1", "app2", "app3"},
+				Count: RelayCounts{
+					Success: 50 + 30,
+					Failure: 40 + 70,
 				},
-				"app4": {
-					PublicKey: "app4",
-					From:      now.AddDate(0, 0, -3),
-					To:        now.AddDate(0, 0, 1),
-					Count: RelayCounts{
-						Success: 515,
-						Failure: 721,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			fakeBackend := fakeBackend{
+				usage:       usageData,
+				todaysUsage: todaysUsage,
+				portalApps: map[types.PortalAppID]*types.PortalApp{
+					"portal_app_1": {
+						AATs: map[types.ProtocolAppID]types.AAT{
+							"app1": {PublicKey: "app1"},
+							"app2": {PublicKey: "app2"},
+							"app3": {PublicKey: "app3"},
+						},
 					},
-				},
-			},
-		},
-		{
-			name: "Only today's data is included when from and to parameters point to today",
-			from: now,
-			to:   now,
-			expected: map[types.PortalAppPublicKey]AppRelaysResponse{
-				"app1": {
-					PublicKey: "app1",
-					From:      now,
-					To:        now.AddDate(0, 0, 1),
-					Count: RelayCounts{
-						Success: 50,
-						Failure: 40,
+					"portal_app_2": {
+						AATs: map[types.ProtocolAppID]types.AAT{
+							"app4": {PublicKey: "app4"},
+							"app5": {PublicKey: "app5"},
+							"app6": {PublicKey: "app6"},
+						},
 					},
 				},
-				"app2": {
-					PublicKey: "app2",
-					From:      now,
-					To:        now.AddDate(0, 0, 1),
-					Count: RelayCounts{
-						Success: 30,
-						Failure: 70,
-					},
+				err: tc.backendErr,
+			}
+
+			relayMeter := NewRelayMeter(context.Background(), &fakeBackend, &fakeDriver{}, logger.New(), RelayMeterOptions{LoadInterval: 100 * time.Millisecond})
+			time.Sleep(200 * time.Millisecond)
+			got, err := relayMeter.PortalAppRelays(context.Background(), tc.portalApp, tc.from, tc.to)
+			if err != nil && !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("Expected error: %v, got: %v", tc.expectedErr, err)
+			}
+
+			got.PublicKeys = sortPublicKeys(got.PublicKeys)
+
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPortalAppsRelays(t *testing.T) {
+	now, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
+
+	backend := &fakeBackend{
+		usage:       fakeDailyMetrics(),
+		todaysUsage: fakeTodaysMetrics(),
+		portalApps: map[types.PortalAppID]*types.PortalApp{
+			"portal_app_1": {
+				AATs: map[types.ProtocolAppID]types.AAT{
+					"app1": {PublicKey: "app1"},
+					"app2": {PublicKey: "app2"},
+					"app3": {PublicKey: "app3"},
 				},
-				"app4": {
-					PublicKey: "app4",
-					From:      now,
-					To:        now.AddDate(0, 0, 1),
-					Count: RelayCounts{
-						Success: 500,
-						Failure: 700,
-					},
+			},
+			"portal_app_2": {
+				AATs: map[types.ProtocolAppID]types.AAT{
+					"app4": {PublicKey: "app4"},
 				},
 			},
 		},
+	}
+
+	meter := NewRelayMeter(context.Background(), backend, &fakeDriver{}, logger.New(), RelayMeterOptions{LoadInterval: 100 * time.Millisecond})
+	time.Sleep(200 * time.Millisecond)
+
+	got, err := meter.PortalAppsRelays(context.Background(), []types.PortalAppID{"portal_app_1", "unknown", "portal_app_2"}, now, now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for i := range got {
+		got[i].PublicKeys = sortPublicKeys(got[i].PublicKeys)
+	}
+
+	requested := RequestedTimePeriod{RequestedTo: timePtr(now)}
+	notes := []string{toAdjustedNote(now), todayIncludedNote}
+	expected := []PortalAppRelaysResponse{
 		{
-			name: "Today's metrics are not included when the 'to' parameter does not include today",
-			from: time.Now().AddDate(0, 0, -3),
-			to:   time.Now().AddDate(0, 0, -1),
-			expected: map[types.PortalAppPublicKey]AppRelaysResponse{
-				"app1": {
-					PublicKey: "app1",
-					From:      now.AddDate(0, 0, -3),
-					To:        now,
-					Count: RelayCounts{
-						Success: 6,
-						Failure: 9,
-					},
-				},
-				"app2": {
-					PublicKey: "app2",
-					From:      now.AddDate(0, 0, -3),
-					To:        now,
-					Count: RelayCounts{
-						Success: 3,
-						Failure: 15,
-					},
+			From:                now,
+			To:                  now.AddDate(0, 0, 1),
+			RequestedTimePeriod: requested,
+			DayCoverage:         DayCoverage{DaysWithData: 1, DaysRequested: 1},
+			PortalAppID:         "portal_app_1",
+			PublicKeys:          []types.PortalAppPublicKey{"app1", "app2", "app3"},
+			Notes:               notes,
+			Count:               RelayCounts{Success: 50 + 30, Failure: 40 + 70},
+		},
+		{
+			From:                now,
+			To:                  now.AddDate(0, 0, 1),
+			RequestedTimePeriod: requested,
+			PortalAppID:         "unknown",
+			Notes:               []string{"Portal app unknown not found"},
+		},
+		{
+			From:                now,
+			To:                  now.AddDate(0, 0, 1),
+			RequestedTimePeriod: requested,
+			DayCoverage:         DayCoverage{DaysWithData: 1, DaysRequested: 1},
+			PortalAppID:         "portal_app_2",
+			PublicKeys:          []types.PortalAppPublicKey{"app4"},
+			Notes:               notes,
+			Count:               backend.todaysUsage["app4"],
+		},
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("unexpected value (-want +got):\n%s", diff)
+	}
+
+	// Errors other than unknown portal apps still fail the batch
+	failingMeter := &relayMeter{Backend: &fakeBackend{err: errors.New("backend error")}, Logger: logger.New()}
+	if _, err := failingMeter.PortalAppsRelays(context.Background(), []types.PortalAppID{"portal_app_1"}, now, now); err == nil {
+		t.Errorf("Expected the backend error to fail the batch")
+	}
+}
+
+func TestPortalAppOverview(t *testing.T) {
+	now, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
+
+	fakeBackend := fakeBackend{
+		usage:             fakeDailyMetrics(),
+		todaysUsage:       fakeTodaysMetrics(),
+		todaysLatency:     fakeTodaysLatency(),
+		todaysOriginUsage: fakeTodaysMetricsByOrigin(),
+		portalApps: map[types.PortalAppID]*types.PortalApp{
+			"portal_app_1": {
+				ID: "portal_app_1",
+				AATs: map[types.ProtocolAppID]types.AAT{
+					"app1": {PublicKey: "app1"},
+					"app2": {PublicKey: "app2"},
+					"app3": {PublicKey: "app3"},
 				},
-				"app4": {
-					PublicKey: "app4",
-					From:      now.AddDate(0, 0, -3),
-					To:        now,
-					Count: RelayCounts{
-						Success: 15,
-						Failure: 21,
+				Whitelists: types.Whitelists{
+					Origins: map[types.Origin]struct{}{
+						"origin2": {},
+						"origin1": {},
+						"origin3": {},
 					},
 				},
 			},
 		},
+	}
+
+	relayMeter := NewRelayMeter(context.Background(), &fakeBackend, &fakeDriver{}, logger.New(), RelayMeterOptions{LoadInterval: 100 * time.Millisecond})
+	time.Sleep(200 * time.Millisecond)
+
+	lookups := fakeBackend.portalAppLookups()
+	got, err := relayMeter.PortalAppOverview(context.Background(), "portal_app_1", now.AddDate(0, 0, -6), now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if calls := fakeBackend.portalAppLookups() - lookups; calls != 1 {
+		t.Errorf("Expected the portal app to be looked up once, got %d lookups", calls)
+	}
+
+	requested := RequestedTimePeriod{RequestedTo: timePtr(now)}
+	notes := []string{toAdjustedNote(now), todayIncludedNote}
+	expectedRelays := PortalAppRelaysResponse{
+		From:                now.AddDate(0, 0, -6),
+		To:                  now.AddDate(0, 0, 1),
+		RequestedTimePeriod: requested,
+		DayCoverage:         DayCoverage{DaysWithData: 7, DaysRequested: 7},
+		PortalAppID:         "portal_app_1",
+		PublicKeys:          []types.PortalAppPublicKey{"app1", "app2", "app3"},
+		Notes:               notes,
+		Count: RelayCounts{
+			Success: 98,
+			Failure: 158,
+		},
+	}
+	got.Relays.PublicKeys = sortPublicKeys(got.Relays.PublicKeys)
+	if diff := cmp.Diff(expectedRelays, got.Relays); diff != "" {
+		t.Errorf("unexpected relays (-want +got):\n%s", diff)
+	}
+
+	// app3 has no latency data
+	var latencyKeys []types.PortalAppPublicKey
+	for _, latency := range got.Latency {
+		if len(latency.DailyLatency) == 0 {
+			t.Errorf("Expected latency data for %s", latency.PublicKey)
+		}
+		latencyKeys = append(latencyKeys, latency.PublicKey)
+	}
+	if diff := cmp.Diff([]types.PortalAppPublicKey{"app1", "app2"}, sortPublicKeys(latencyKeys)); diff != "" {
+		t.Errorf("unexpected latency applications (-want +got):\n%s", diff)
+	}
+
+	// origin3 has no traffic
+	expectedOrigins := []OriginClassificationsResponse{
+		{Origin: "origin1", Count: RelayCounts{Success: 50, Failure: 40}, From: now.AddDate(0, 0, -6), To: now.AddDate(0, 0, 1), RequestedTimePeriod: requested, Notes: notes},
+		{Origin: "origin2", Count: RelayCounts{Success: 30, Failure: 70}, From: now.AddDate(0, 0, -6), To: now.AddDate(0, 0, 1), RequestedTimePeriod: requested, Notes: notes},
+	}
+	if diff := cmp.Diff(expectedOrigins, got.Origins); diff != "" {
+		t.Errorf("unexpected origins (-want +got):\n%s", diff)
+	}
+
+	if _, err := relayMeter.PortalAppOverview(context.Background(), "unknown", now, now); !errors.Is(err, ErrPortalAppNotFound) {
+		t.Errorf("Expected error: %v, got: %v", ErrPortalAppNotFound, err)
+	}
+}
+
+func TestAllPortalAppsRelays(t *testing.T) {
+	now, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
+	usageData := fakeDailyMetrics()
+	todaysUsage := fakeTodaysMetrics()
+	errBackendFailure := errors.New("backend error")
+
+	testCases := []struct {
+		name       string
+		from       time.Time
+		to         time.Time
+		backendErr error
+
+		expected    map[types.PortalAppID]PortalAppRelaysResponse
+		expectedErr error
+	}{
+		{
+			name:        "Backend service error",
+			backendErr:  errBackendFailure,
+			expectedErr: errBackendFailure,
+			expected:    map[types.PortalAppID]PortalAppRelaysResponse{},
+		},
 		{
-			name: "Today's metrics are included when the 'to' parameter is after today",
-			from: time.Now().AddDate(0, 0, -3),
-			to:   time.Now().AddDate(0, 0, 2),
-			expected: map[types.PortalAppPublicKey]AppRelaysResponse{
-				"app1": {
-					PublicKey: "app1",
-					From:      now.AddDate(0, 0, -3),
-					To:        now.AddDate(0, 0, 3),
-					Count: RelayCounts{
-						Success: 56,
-						Failure: 49,
-					},
-				},
-				"app2": {
-					PublicKey: "app2",
-					From:      now.AddDate(0, 0, -3),
-					To:        now.AddDate(0, 0, 3),
+			name: "Correct summary for portalApps",
+			from: now.AddDate(0, 0, -6),
+			to:   now,
+			expected: map[types.PortalAppID]PortalAppRelaysResponse{
+				"portal_app_1": {
+					From:                now.AddDate(0, 0, -6),
+					To:                  now.AddDate(0, 0, 1),
+					RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now)},
+					Notes:               []string{toAdjustedNote(now), todayIncludedNote},
+					PortalAppID:         "portal_app_1",
+					PublicKeys:          []types.PortalAppPublicKey{"app1", "app2", "app3"},
 					Count: RelayCounts{
-						Success: 33,
-						Failure: 85,
+						Success: 98,
+						Failure: 158,
 					},
 				},
-				"app4": {
-					PublicKey: "app4",
-					From:      now.AddDate(0, 0, -3),
-					To:        now.AddDate(0, 0, 3),
+				"portal_app_2": {
+					From:                now.AddDate(0, 0, -6),
+					To:                  now.AddDate(0, 0, 1),
+					RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now)},
+					Notes:               []string{toAdjustedNote(now), todayIncludedNote},
+					PortalAppID:         "portal_app_2",
+					PublicKeys:          []types.PortalAppPublicKey{"app4", "app5", "app6"},
 					Count: RelayCounts{
-						Success: 515,
-						Failure: 721,
+						Success: 530,
+						Failure: 742,
 					},
 				},
 			},
 		},
 		{
-			name: "Only today's metrics are included when the timespan only includes today",
-			from: time.Now(),
-			to:   time.Now().AddDate(0, 0, 2),
-			expected: map[types.PortalAppPublicKey]AppRelaysResponse{
-				"app1": {
-					PublicKey: "app1",
-					From:      now,
-					To:        now.AddDate(0, 0, 3),
+			name: "Correct summary for portalApps excluding today",
+			from: now.AddDate(0, 0, -6),
+			to:   now.AddDate(0, 0, -2),
+			expected: map[types.PortalAppID]PortalAppRelaysResponse{
+				"portal_app_1": {
+					From:                now.AddDate(0, 0, -6),
+					To:                  now.AddDate(0, 0, -1),
+					RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now.AddDate(0, 0, -2))},
+					Notes:               []string{toAdjustedNote(now.AddDate(0, 0, -2))},
+					PortalAppID:         "portal_app_1",
+					PublicKeys:          []types.PortalAppPublicKey{"app1", "app2", "app3"},
 					Count: RelayCounts{
-						Success: 50,
+						Success: 15,
 						Failure: 40,
 					},
 				},
-				"app2": {
-					PublicKey: "app2",
-					From:      now,
-					To:        now.AddDate(0, 0, 3),
-					Count: RelayCounts{
-						Success: 30,
-						Failure: 70,
-					},
-				},
-				"app4": {
-					PublicKey: "app4",
-					From:      now,
-					To:        now.AddDate(0, 0, 3),
+				"portal_app_2": {
+					From:                now.AddDate(0, 0, -6),
+					To:                  now.AddDate(0, 0, -1),
+					RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now.AddDate(0, 0, -2))},
+					Notes:               []string{toAdjustedNote(now.AddDate(0, 0, -2))},
+					PortalAppID:         "portal_app_2",
+					PublicKeys:          []types.PortalAppPublicKey{"app4", "app5", "app6"},
 					Count: RelayCounts{
-						Success: 500,
-						Failure: 700,
+						Success: 25,
+						Failure: 35,
 					},
 				},
 			},
 		},
 		{
-			name: "Missing parameters' default values",
-			expected: map[types.PortalAppPublicKey]AppRelaysResponse{
-				"app1": {
-					PublicKey: "app1",
-					From:      now.AddDate(0, 0, -30),
-					To:        now.AddDate(0, 0, 1),
-					Count: RelayCounts{
-						Success: 62,
-						Failure: 58,
-					},
-				},
-				"app2": {
-					PublicKey: "app2",
-					From:      now.AddDate(0, 0, -30),
-					To:        now.AddDate(0, 0, 1),
+			name: "Correct summary for portalApps on todays metrics",
+			from: now,
+			to:   now,
+			expected: map[types.PortalAppID]PortalAppRelaysResponse{
+				"portal_app_1": {
+					From:                now,
+					To:                  now.AddDate(0, 0, 1),
+					RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now)},
+					Notes:               []string{toAdjustedNote(now), todayIncludedNote},
+					PortalAppID:         "portal_app_1",
+					PublicKeys:          []types.PortalAppPublicKey{"app1", "app2", "app3"},
 					Count: RelayCounts{
-						Success: 36,
-						Failure: 100,
+						Success: 80,
+						Failure: 110,
 					},
 				},
-				"app4": {
-					PublicKey: "app4",
-					From:      now.AddDate(0, 0, -30),
-					To:        now.AddDate(0, 0, 1),
+				"portal_app_2": {
+					From:                now,
+					To:                  now.AddDate(0, 0, 1),
+					RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now)},
+					Notes:               []string{toAdjustedNote(now), todayIncludedNote},
+					PortalAppID:         "portal_app_2",
+					PublicKeys:          []types.PortalAppPublicKey{"app4", "app5", "app6"},
 					Count: RelayCounts{
-						Success: 530,
-						Failure: 742,
+						Success: 500,
+						Failure: 700,
 					},
 				},
 			},
 		},
-		{
-			name:        "Invalid timespan is rejected",
-			from:        now.AddDate(0, 0, -1),
-			to:          now.AddDate(0, 0, -2),
-			expectedErr: fmt.Errorf("Invalid timespan"),
-			expected:    map[types.PortalAppPublicKey]AppRelaysResponse{},
-		},
 	}
 
 	for _, tc := range testCases {
@@ -704,25 +3201,42 @@ func TestAllAppsRelays(t *testing.T) {
 			fakeBackend := fakeBackend{
 				usage:       usageData,
 				todaysUsage: todaysUsage,
+				portalApps: map[types.PortalAppID]*types.PortalApp{
+					"portal_app_1": {
+						ID: "portal_app_1",
+						AATs: map[types.ProtocolAppID]types.AAT{
+							"app1": {PublicKey: "app1"},
+							"app2": {PublicKey: "app2"},
+							"app3": {PublicKey: "app3"},
+						},
+					},
+					"portal_app_2": {
+						ID: "portal_app_2",
+						AATs: map[types.ProtocolAppID]types.AAT{
+							"app4": {PublicKey: "app4"},
+							"app5": {PublicKey: "app5"},
+							"app6": {PublicKey: "app6"},
+						},
+					},
+				},
+				err: tc.backendErr,
 			}
-
-			relayMeter := NewRelayMeter(context.Background(), &fakeBackend, &fakeDriver{}, logger.New(), RelayMeterOptions{LoadInterval: 100 * time.Millisecond})
-			time.Sleep(200 * time.Millisecond)
-			rawGot, err := relayMeter.AllAppsRelays(context.Background(), tc.from, tc.to)
-			if err != nil {
-				if tc.expectedErr == nil {
-					t.Fatalf("Unexpected error: %v", err)
-				}
-				if !strings.Contains(err.Error(), tc.expectedErr.Error()) {
-					t.Fatalf("Expected error to contain: %q, got: %v", tc.expectedErr.Error(), err)
-				}
+
+			relayMeter := NewRelayMeter(context.Background(), &fakeBackend, &fakeDriver{}, logger.New(), RelayMeterOptions{LoadInterval: 100 * time.Millisecond})
+			time.Sleep(200 * time.Millisecond)
+			rawGot, _, err := relayMeter.AllPortalAppsRelays(context.Background(), tc.from, tc.to, Page{})
+			if err != nil && !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("Expected error: %v, got: %v", tc.expectedErr, err)
 			}
 
 			// Need to convert it to map to be able to compare
-			got := make(map[types.PortalAppPublicKey]AppRelaysResponse, len(rawGot))
+			got := make(map[types.PortalAppID]PortalAppRelaysResponse, len(rawGot))
 
 			for _, relResp := range rawGot {
-				got[relResp.PublicKey] = relResp
+				sort.Slice(relResp.PublicKeys, func(i, j int) bool {
+					return relResp.PublicKeys[i] < relResp.PublicKeys[j]
+				})
+				got[relResp.PortalAppID] = relResp
 			}
 
 			if diff := cmp.Diff(tc.expected, got); diff != "" {
@@ -732,487 +3246,812 @@ func TestAllAppsRelays(t *testing.T) {
 	}
 }
 
-func TestAppLatency(t *testing.T) {
-	todaysLatency := fakeTodaysLatency()
-	errBackendFailure := errors.New("backend error")
+func TestAllPortalAppsRelaysSharedKeys(t *testing.T) {
+	now, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
 
-	testCases := []struct {
-		name                string
-		requestedApp        types.PortalAppPublicKey
-		expected            AppLatencyResponse
-		backendErr          error
-		expectedErr         error
-		expectedTodaysCalls int
-	}{
-		{
-			name:         "Correct latency data is returned",
-			requestedApp: "app1",
-			expected: AppLatencyResponse{
-				PublicKey:    "app1",
-				From:         todaysLatency["app1"][0].Time,
-				To:           todaysLatency["app1"][len(todaysLatency["app1"])-1].Time,
-				DailyLatency: todaysLatency["app1"],
+	fakeBackend := fakeBackend{
+		usage:       fakeDailyMetrics(),
+		todaysUsage: fakeTodaysMetrics(),
+		portalApps: map[types.PortalAppID]*types.PortalApp{
+			"portal_app_1": {
+				ID: "portal_app_1",
+				AATs: map[types.ProtocolAppID]types.AAT{
+					"app1": {PublicKey: "app1"},
+					"app2": {PublicKey: "app2"},
+				},
+			},
+			"portal_app_2": {
+				ID: "portal_app_2",
+				AATs: map[types.ProtocolAppID]types.AAT{
+					"app2": {PublicKey: "app2"},
+					"app4": {PublicKey: "app4"},
+				},
+			},
+		},
+	}
+
+	relayMeter := NewRelayMeter(context.Background(), &fakeBackend, &fakeDriver{}, logger.New(), RelayMeterOptions{LoadInterval: 100 * time.Millisecond})
+	time.Sleep(200 * time.Millisecond)
+	rawGot, _, err := relayMeter.AllPortalAppsRelays(context.Background(), now, now, Page{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := map[types.PortalAppID]PortalAppRelaysResponse{
+		"portal_app_1": {
+			From:                now,
+			To:                  now.AddDate(0, 0, 1),
+			PortalAppID:         "portal_app_1",
+			PublicKeys:          []types.PortalAppPublicKey{"app1", "app2"},
+			Count:               RelayCounts{Success: 50 + 30, Failure: 40 + 70},
+			Notes:               []string{toAdjustedNote(now), todayIncludedNote, "Application app2 is shared with portal apps: portal_app_2; its relays are counted for each of them"},
+			RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now)},
+		},
+		"portal_app_2": {
+			From:                now,
+			To:                  now.AddDate(0, 0, 1),
+			PortalAppID:         "portal_app_2",
+			PublicKeys:          []types.PortalAppPublicKey{"app2", "app4"},
+			Count:               RelayCounts{Success: 30 + 500, Failure: 70 + 700},
+			Notes:               []string{toAdjustedNote(now), todayIncludedNote, "Application app2 is shared with portal apps: portal_app_1; its relays are counted for each of them"},
+			RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now)},
+		},
+	}
+
+	got := make(map[types.PortalAppID]PortalAppRelaysResponse, len(rawGot))
+	for _, relResp := range rawGot {
+		relResp.PublicKeys = sortPublicKeys(relResp.PublicKeys)
+		got[relResp.PortalAppID] = relResp
+	}
+
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("unexpected value (-want +got):\n%s", diff)
+	}
+}
+
+func TestAllPortalAppsRelaysNilPortalApp(t *testing.T) {
+	now, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
+
+	fakeBackend := fakeBackend{
+		usage:       fakeDailyMetrics(),
+		todaysUsage: fakeTodaysMetrics(),
+		portalApps: map[types.PortalAppID]*types.PortalApp{
+			"portal_app_1": {
+				ID: "portal_app_1",
+				AATs: map[types.ProtocolAppID]types.AAT{
+					"app1": {PublicKey: "app1"},
+				},
 			},
+			"portal_app_nil": nil,
 		},
+	}
+
+	relayMeter := NewRelayMeter(context.Background(), &fakeBackend, &fakeDriver{}, logger.New(), RelayMeterOptions{LoadInterval: 100 * time.Millisecond})
+	time.Sleep(200 * time.Millisecond)
+	got, _, err := relayMeter.AllPortalAppsRelays(context.Background(), now, now, Page{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []PortalAppRelaysResponse{
 		{
-			name:         "Returns error if requested app not found",
-			requestedApp: "app42",
-			expectedErr:  ErrAppLatencyNotFound,
+			From:                now,
+			To:                  now.AddDate(0, 0, 1),
+			PortalAppID:         "portal_app_1",
+			PublicKeys:          []types.PortalAppPublicKey{"app1"},
+			Count:               RelayCounts{Success: 50, Failure: 40},
+			RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now)},
+			Notes:               []string{toAdjustedNote(now), todayIncludedNote},
+		},
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("unexpected value (-want +got):\n%s", diff)
+	}
+}
+
+func TestNormalizeKeys(t *testing.T) {
+	fakeDay := time.Date(2022, time.July, 20, 0, 0, 0, 0, &time.Location{})
+
+	counts := map[time.Time]map[types.PortalAppPublicKey]RelayCounts{
+		fakeDay: {
+			"2585504A028B138B4B535D2351BC45260A3DE9CD66305A854049D1A5143392A8": {Success: 3, Failure: 1}, // pragma: allowlist secret
+			"2585504a028b138b4b535d2351bc45260a3de9cd66305a854049d1a5143392a8": {Success: 4, Failure: 2}, // pragma: allowlist secret
+			"2585504a028b138b4b535d2351bc45260a3de9cd66305a854049d1a5143392a9": {Success: 5},             // pragma: allowlist secret
+		},
+	}
+	expectedCounts := map[time.Time]map[types.PortalAppPublicKey]RelayCounts{
+		fakeDay: {
+			"2585504a028b138b4b535d2351bc45260a3de9cd66305a854049d1a5143392a8": {Success: 7, Failure: 3}, // pragma: allowlist secret
+			"2585504a028b138b4b535d2351bc45260a3de9cd66305a854049d1a5143392a9": {Success: 5},             // pragma: allowlist secret
+		},
+	}
+	if diff := cmp.Diff(expectedCounts, NormalizeDailyRelayCountsKeys(counts)); diff != "" {
+		t.Errorf("unexpected value (-want +got):\n%s", diff)
+	}
+
+	latencies := map[types.PortalAppPublicKey][]Latency{
+		"2585504A028B138B4B535D2351BC45260A3DE9CD66305A854049D1A5143392A8": {{Time: fakeDay, Latency: 0.1}}, // pragma: allowlist secret
+	}
+	expectedLatencies := map[types.PortalAppPublicKey][]Latency{
+		"2585504a028b138b4b535d2351bc45260a3de9cd66305a854049d1a5143392a8": {{Time: fakeDay, Latency: 0.1}}, // pragma: allowlist secret
+	}
+	if diff := cmp.Diff(expectedLatencies, NormalizeLatencyKeys(latencies)); diff != "" {
+		t.Errorf("unexpected value (-want +got):\n%s", diff)
+	}
+}
+
+func TestMixedCaseStoredKeys(t *testing.T) {
+	now, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
+	// The metrics stored before the keys were normalized keep their case
+	mixedCase := types.PortalAppPublicKey("2585504A028B138B4B535D2351BC45260A3DE9CD66305A854049D1A5143392A8") // pragma: allowlist secret
+	lowercase := NormalizePublicKey(mixedCase)
+
+	backend := &fakeBackend{
+		usage: map[time.Time]map[types.PortalAppPublicKey]RelayCounts{
+			now.AddDate(0, 0, -1): {mixedCase: {Success: 3, Failure: 1}},
+		},
+		todaysUsage:   map[types.PortalAppPublicKey]RelayCounts{mixedCase: {Success: 5, Failure: 2}},
+		todaysLatency: map[types.PortalAppPublicKey][]Latency{mixedCase: {{Time: now, Latency: 0.1}}},
+		portalApps: map[types.PortalAppID]*types.PortalApp{
+			"portal_app_1": {ID: "portal_app_1", AATs: map[types.ProtocolAppID]types.AAT{"app1": {PublicKey: mixedCase}}},
+		},
+	}
+	meter := &relayMeter{Backend: backend, Logger: logger.New(), RelayMeterOptions: RelayMeterOptions{OptionalOriginData: true}}
+	if err := meter.loadData(now.AddDate(0, 0, -7), now); err != nil {
+		t.Fatalf("Unexpected error loading data: %v", err)
+	}
+
+	expectedCount := RelayCounts{Success: 8, Failure: 3}
+	appRelays, err := meter.AppRelays(context.Background(), lowercase, now.AddDate(0, 0, -1), now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(expectedCount, appRelays.Count); diff != "" {
+		t.Errorf("unexpected app relays (-want +got):\n%s", diff)
+	}
+
+	portalAppRelays, err := meter.PortalAppRelays(context.Background(), "portal_app_1", now.AddDate(0, 0, -1), now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(expectedCount, portalAppRelays.Count); diff != "" {
+		t.Errorf("unexpected portal app relays (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]types.PortalAppPublicKey{lowercase}, portalAppRelays.PublicKeys); diff != "" {
+		t.Errorf("unexpected portal app keys (-want +got):\n%s", diff)
+	}
+
+	latency, err := meter.AppLatency(context.Background(), lowercase)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(latency.DailyLatency) != 1 {
+		t.Errorf("Expected 1 latency, got: %d", len(latency.DailyLatency))
+	}
+}
+
+func TestAllPortalAppsRelaysCachedPortalApps(t *testing.T) {
+	now, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
+	errPHD := errors.New("PHD unreachable")
+
+	expected := []PortalAppRelaysResponse{
+		{
+			From:                now,
+			To:                  now.AddDate(0, 0, 1),
+			PortalAppID:         "portal_app_1",
+			PublicKeys:          []types.PortalAppPublicKey{"app1"},
+			Count:               RelayCounts{Success: 50, Failure: 40},
+			RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now)},
+			Notes:               []string{toAdjustedNote(now), todayIncludedNote},
 		},
+	}
+
+	testCases := []struct {
+		name            string
+		cachePortalApps bool
+		expected        []PortalAppRelaysResponse
+		expectedErr     error
+	}{
 		{
-			name:         "Backend service error",
-			requestedApp: "app1",
-			backendErr:   errBackendFailure,
-			expectedErr:  ErrAppLatencyNotFound,
+			name:            "Cached portal apps are served while PHD is unreachable",
+			cachePortalApps: true,
+			expected:        expected,
+		},
+		{
+			name:        "PHD errors are returned without cached portal apps",
+			expectedErr: errPHD,
 		},
 	}
 
 	for _, tc := range testCases {
-		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
-			t.Parallel()
-			fakeBackend := fakeBackend{
-				todaysLatency: todaysLatency,
-				err:           tc.backendErr,
+			backend := &fakeBackend{
+				usage:             fakeDailyMetrics(),
+				todaysUsage:       fakeTodaysMetrics(),
+				todaysOriginUsage: fakeTodaysMetricsByOrigin(),
+				todaysLatency:     fakeTodaysLatency(),
+				portalApps: map[types.PortalAppID]*types.PortalApp{
+					"portal_app_1": {ID: "portal_app_1", AATs: map[types.ProtocolAppID]types.AAT{"app1": {PublicKey: "app1"}}},
+				},
 			}
-
-			relayMeter := NewRelayMeter(context.Background(), &fakeBackend, &fakeDriver{}, logger.New(), RelayMeterOptions{LoadInterval: 100 * time.Millisecond})
-			time.Sleep(200 * time.Millisecond)
-			got, err := relayMeter.AppLatency(context.Background(), tc.requestedApp)
-			if err != nil {
-				if tc.expectedErr == nil {
-					t.Fatalf("Unexpected error: %v", err)
-				}
-				if !strings.Contains(err.Error(), tc.expectedErr.Error()) {
-					t.Fatalf("Expected error to contain: %q, got: %v", tc.expectedErr.Error(), err)
-				}
+			meter := &relayMeter{Backend: backend, Logger: logger.New(), RelayMeterOptions: RelayMeterOptions{CachePortalApps: tc.cachePortalApps}}
+			if err := meter.loadData(now.AddDate(0, 0, -7), now); err != nil {
+				t.Fatalf("Unexpected error loading data: %v", err)
 			}
 
+			backend.portalAppsErr = errPHD
+			got, _, err := meter.AllPortalAppsRelays(context.Background(), now, now, Page{})
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("Expected error: %v, got: %v", tc.expectedErr, err)
+			}
 			if diff := cmp.Diff(tc.expected, got); diff != "" {
 				t.Errorf("unexpected value (-want +got):\n%s", diff)
 			}
+			if stale := meter.PortalAppsStale(); stale != tc.cachePortalApps {
+				t.Errorf("Expected stale portal apps: %t, got: %t", tc.cachePortalApps, stale)
+			}
+
+			// Once PHD is reachable again the portal apps are no longer reported stale
+			backend.portalAppsErr = nil
+			if _, _, err := meter.AllPortalAppsRelays(context.Background(), now, now, Page{}); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if meter.PortalAppsStale() {
+				t.Errorf("Expected the portal apps not to be reported stale")
+			}
 		})
 	}
 }
 
-func TestAllAppsLatencies(t *testing.T) {
-	todaysLatency := fakeTodaysLatency()
-	errBackendFailure := errors.New("backend error")
+func TestStartDataLoader(t *testing.T) {
+	now, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
 
 	testCases := []struct {
-		name                string
-		todaysLatency       map[types.PortalAppPublicKey][]Latency
-		expected            map[types.PortalAppPublicKey]AppLatencyResponse
-		emptyLatencySlice   bool
-		expectedErr         error
-		backendErr          error
-		expectedTodaysCalls int
+		name           string
+		maxArchiveAge  time.Duration
+		servedPastDays time.Duration
+		expectedFrom   time.Time
+		expectedTo     time.Time
 	}{
 		{
-			name: "Correct latency data is returned",
-			expected: map[types.PortalAppPublicKey]AppLatencyResponse{
-				"app1": {
-					PublicKey:    "app1",
-					From:         todaysLatency["app1"][0].Time,
-					To:           todaysLatency["app1"][len(todaysLatency["app1"])-1].Time,
-					DailyLatency: todaysLatency["app1"],
-				},
-				"app2": {
-					PublicKey:    "app2",
-					From:         todaysLatency["app2"][0].Time,
-					To:           todaysLatency["app2"][len(todaysLatency["app2"])-1].Time,
-					DailyLatency: todaysLatency["app2"],
-				},
-				"app4": {
-					PublicKey:    "app4",
-					From:         todaysLatency["app4"][0].Time,
-					To:           todaysLatency["app4"][len(todaysLatency["app4"])-1].Time,
-					DailyLatency: todaysLatency["app4"],
-				},
-			},
+			name:         "Default maxArchiveAge is applied",
+			expectedFrom: now.AddDate(0, 0, -1*MAX_PAST_DAYS_METRICS_DEFAULT_DAYS),
+			expectedTo:   now,
 		},
 		{
-			name:        "Backend service error",
-			backendErr:  errBackendFailure,
-			expectedErr: errBackendFailure,
-			expected:    map[types.PortalAppPublicKey]AppLatencyResponse{},
+			name:          "The specified maxArchiveAge takes effect",
+			maxArchiveAge: 24 * 5 * time.Hour,
+			expectedFrom:  now.AddDate(0, 0, -5),
+			expectedTo:    now,
 		},
 		{
-			name:              "Empty latency response",
-			backendErr:        nil,
-			expectedErr:       nil,
-			emptyLatencySlice: true,
-			expected:          map[types.PortalAppPublicKey]AppLatencyResponse{},
+			name:           "Only the served time period is loaded into memory",
+			maxArchiveAge:  24 * 90 * time.Hour,
+			servedPastDays: 24 * 7 * time.Hour,
+			expectedFrom:   now.AddDate(0, 0, -7),
+			expectedTo:     now,
+		},
+		{
+			name:           "Served time period is limited to the archived one",
+			maxArchiveAge:  24 * 5 * time.Hour,
+			servedPastDays: 24 * 7 * time.Hour,
+			expectedFrom:   now.AddDate(0, 0, -5),
+			expectedTo:     now,
+		},
+		{
+			name:           "Served time period applies with the default maxArchiveAge",
+			servedPastDays: 24 * 7 * time.Hour,
+			expectedFrom:   now.AddDate(0, 0, -7),
+			expectedTo:     now,
 		},
 	}
 
 	for _, tc := range testCases {
-		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
-			if tc.emptyLatencySlice == true {
-				for key := range todaysLatency {
-					todaysLatency[key] = []Latency{}
-				}
-			}
-
-			fakeBackend := fakeBackend{
-				todaysLatency: todaysLatency,
-				err:           tc.backendErr,
-			}
-
-			relayMeter := NewRelayMeter(context.Background(), &fakeBackend, &fakeDriver{}, logger.New(), RelayMeterOptions{LoadInterval: 100 * time.Millisecond})
-			time.Sleep(200 * time.Millisecond)
-			rawGot, err := relayMeter.AllAppsLatencies(context.Background())
-
-			if err != nil {
-				if tc.expectedErr == nil {
-					t.Fatalf("Unexpected error: %v", err)
-				}
-				if !strings.Contains(err.Error(), tc.expectedErr.Error()) {
-					t.Fatalf("Expected error to contain: %q, got: %v", tc.expectedErr.Error(), err)
-				}
+			fakeBackend := fakeBackend{}
+			meter := &relayMeter{
+				Backend: &fakeBackend,
+				Logger:  logger.New(),
+				RelayMeterOptions: RelayMeterOptions{
+					LoadInterval:   1 * time.Second,
+					MaxPastDays:    tc.maxArchiveAge,
+					ServedPastDays: tc.servedPastDays,
+				},
 			}
 
-			// Need to convert it to map to be able to compare
-			got := make(map[types.PortalAppPublicKey]AppLatencyResponse, len(rawGot))
+			ctx, cancel := context.WithCancel(context.Background())
+			go func() {
+				meter.StartDataLoader(ctx)
+			}()
+			// We only need the initial data loader run
+			time.Sleep(10 * time.Millisecond)
+			cancel()
 
-			for _, relResp := range rawGot {
-				got[relResp.PublicKey] = relResp
+			if _, from := fakeBackend.dailyCalls(); !tc.expectedFrom.Equal(from) {
+				t.Errorf("Expected 'from' to be: %v, got: %v", tc.expectedFrom, from)
 			}
 
-			if diff := cmp.Diff(tc.expected, got); diff != "" {
-				t.Errorf("unexpected value (-want +got):\n%s", diff)
-			}
 		})
 	}
 }
 
-func TestPortalAppRelays(t *testing.T) {
+func TestLimits(t *testing.T) {
 	now, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
-	usageData := fakeDailyMetrics()
-	todaysUsage := fakeTodaysMetrics()
-	errBackendFailure := errors.New("backend error")
 
 	testCases := []struct {
-		name       string
-		portalApp  types.PortalAppID
-		from       time.Time
-		to         time.Time
-		backendErr error
-
-		expected    PortalAppRelaysResponse
-		expectedErr error
+		name           string
+		maxArchiveAge  time.Duration
+		servedPastDays time.Duration
+		expected       LimitsResponse
 	}{
-		{
-			name:        "PortalApp not found error",
-			expectedErr: ErrPortalAppNotFound,
-		},
-		{
-			name:        "Backend service error",
-			backendErr:  errBackendFailure,
-			expectedErr: errBackendFailure,
-		},
-		{
-			name:      "Correct summary for a portalApp",
-			portalApp: "portal_app_1",
-			from:      now.AddDate(0, 0, -6),
-			to:        now,
-			expected: PortalAppRelaysResponse{
-				From:        now.AddDate(0, 0, -6),
-				To:          now.AddDate(0, 0, 1),
-				PortalAppID: "portal_app_1",
-				PublicKeys:  []types.PortalAppPublicKey{"app1", "app2", "app3"},
-				Count: RelayCounts{
-					Success: 6*(2+1) + 50 + 30,
-					Failure: 6*(3+5) + 40 + 70,
-				},
+		{
+			name: "Default maxArchiveAge is reported",
+			expected: LimitsResponse{
+				MaxPastDays:    MAX_PAST_DAYS_METRICS_DEFAULT_DAYS,
+				ServedPastDays: MAX_PAST_DAYS_METRICS_DEFAULT_DAYS,
+				EarliestFrom:   now.AddDate(0, 0, -1*MAX_PAST_DAYS_METRICS_DEFAULT_DAYS),
 			},
 		},
 		{
-			name:      "Correct summary for a portalApp excluding today",
-			portalApp: "portal_app_1",
-			from:      now.AddDate(0, 0, -6),
-			to:        now.AddDate(0, 0, -2),
-			expected: PortalAppRelaysResponse{
-				From:        now.AddDate(0, 0, -6),
-				To:          now.AddDate(0, 0, -1),
-				PortalAppID: "portal_app_1",
-				PublicKeys:  []types.PortalAppPublicKey{"app1", "app2", "app3"},
-				Count: RelayCounts{
-					Success: 5 * (2 + 1),
-					Failure: 5 * (3 + 5),
-				},
+			name:          "The specified maxArchiveAge is reported",
+			maxArchiveAge: 24 * 90 * time.Hour,
+			expected: LimitsResponse{
+				MaxPastDays:    90,
+				ServedPastDays: 90,
+				EarliestFrom:   now.AddDate(0, 0, -90),
 			},
 		},
 		{
-			name:      "Correct summary for a portalApp on todays metrics",
-			portalApp: "portal_app_1",
-			from:      now,
-			to:        now,
-			expected: PortalAppRelaysResponse{
-				From:        now,
-				To:          now.AddDate(0, 0, 1),
-				PortalAppID: "portal_app_1",
-				PublicKeys:  []types.PortalAppPublicKey{"app1", "app2", "app3"},
-				Count: RelayCounts{
-					Success: 50 + 30,
-					Failure: 40 + 70,
-				},
+			name:           "The served time period limits the earliest day",
+			maxArchiveAge:  24 * 90 * time.Hour,
+			servedPastDays: 24 * 7 * time.Hour,
+			expected: LimitsResponse{
+				MaxPastDays:    90,
+				ServedPastDays: 7,
+				EarliestFrom:   now.AddDate(0, 0, -7),
+			},
+		},
+		{
+			name:           "Served time period is limited to the archived one",
+			maxArchiveAge:  24 * 5 * time.Hour,
+			servedPastDays: 24 * 7 * time.Hour,
+			expected: LimitsResponse{
+				MaxPastDays:    5,
+				ServedPastDays: 5,
+				EarliestFrom:   now.AddDate(0, 0, -5),
 			},
 		},
 	}
 
 	for _, tc := range testCases {
-		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
-			t.Parallel()
-			fakeBackend := fakeBackend{
-				usage:       usageData,
-				todaysUsage: todaysUsage,
-				portalApps: map[types.PortalAppID]*types.PortalApp{
-					"portal_app_1": {
-						AATs: map[types.ProtocolAppID]types.AAT{
-							"app1": {PublicKey: "app1"},
-							"app2": {PublicKey: "app2"},
-							"app3": {PublicKey: "app3"},
-						},
-					},
-					"portal_app_2": {
-						AATs: map[types.ProtocolAppID]types.AAT{
-							"app4": {PublicKey: "app4"},
-							"app5": {PublicKey: "app5"},
-							"app6": {PublicKey: "app6"},
-						},
-					},
+			meter := &relayMeter{
+				RelayMeterOptions: RelayMeterOptions{
+					MaxPastDays:    tc.maxArchiveAge,
+					ServedPastDays: tc.servedPastDays,
 				},
-				err: tc.backendErr,
-			}
-
-			relayMeter := NewRelayMeter(context.Background(), &fakeBackend, &fakeDriver{}, logger.New(), RelayMeterOptions{LoadInterval: 100 * time.Millisecond})
-			time.Sleep(200 * time.Millisecond)
-			got, err := relayMeter.PortalAppRelays(context.Background(), tc.portalApp, tc.from, tc.to)
-			if err != nil && !errors.Is(err, tc.expectedErr) {
-				t.Fatalf("Expected error: %v, got: %v", tc.expectedErr, err)
 			}
 
-			got.PublicKeys = sortPublicKeys(got.PublicKeys)
-
-			if diff := cmp.Diff(tc.expected, got); diff != "" {
+			if diff := cmp.Diff(tc.expected, meter.Limits()); diff != "" {
 				t.Errorf("unexpected value (-want +got):\n%s", diff)
 			}
 		})
 	}
 }
 
-func TestAllPortalAppsRelays(t *testing.T) {
+func TestDataGeneration(t *testing.T) {
+	meter := &relayMeter{
+		Backend: &fakeBackend{
+			usage:       fakeDailyMetrics(),
+			todaysUsage: fakeTodaysMetrics(),
+		},
+		Logger: logger.New(),
+	}
+
+	if got := meter.DataGeneration(); got != 0 {
+		t.Fatalf("Expected generation 0 before loading data, got: %d", got)
+	}
+
+	for i := 1; i <= 2; i++ {
+		if err := meter.loadData(time.Now().AddDate(0, 0, -7), time.Now()); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if got := meter.DataGeneration(); got != uint64(i) {
+			t.Errorf("Expected generation %d, got: %d", i, got)
+		}
+	}
+}
+
+func TestLoadDataTodaysTTLs(t *testing.T) {
+	backend := &fakeBackend{
+		usage:             fakeDailyMetrics(),
+		todaysUsage:       fakeTodaysMetrics(),
+		todaysOriginUsage: fakeTodaysMetricsByOrigin(),
+		todaysLatency:     fakeTodaysLatency(),
+	}
+	meter := &relayMeter{
+		Backend: backend,
+		Logger:  logger.New(),
+		RelayMeterOptions: RelayMeterOptions{
+			DailyMetricsTTL:  time.Hour,
+			TodaysMetricsTTL: time.Hour,
+			TodaysLatencyTTL: time.Second,
+		},
+	}
+
+	load := func() {
+		if err := meter.loadData(time.Now().AddDate(0, 0, -7), time.Now()); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	load()
+	load()
+	if todaysCalls, latencyCalls := backend.todaysCalls(); todaysCalls != 1 || latencyCalls != 1 {
+		t.Fatalf("Expected 1 load of todays metrics and latencies before their TTLs expire, got: %d and %d", todaysCalls, latencyCalls)
+	}
+
+	// Only the latencies are reloaded once their TTL expires
+	time.Sleep(1100 * time.Millisecond)
+	load()
+	todaysCalls, latencyCalls := backend.todaysCalls()
+	if latencyCalls != 2 {
+		t.Errorf("Expected 2 loads of todays latencies, got: %d", latencyCalls)
+	}
+	if todaysCalls != 1 {
+		t.Errorf("Expected 1 load of todays metrics, got: %d", todaysCalls)
+	}
+}
+
+func TestWriteHTTPSourceRelayCounts(t *testing.T) {
 	now, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
-	usageData := fakeDailyMetrics()
-	todaysUsage := fakeTodaysMetrics()
-	errBackendFailure := errors.New("backend error")
+	driver := &fakeDriver{}
+	meter := &relayMeter{
+		Backend: &fakeBackend{
+			usage:       fakeDailyMetrics(),
+			todaysUsage: fakeTodaysMetrics(),
+		},
+		Driver: driver,
+		Logger: logger.New(),
+	}
 
-	testCases := []struct {
-		name       string
-		from       time.Time
-		to         time.Time
-		backendErr error
+	if err := meter.loadData(now.AddDate(0, 0, -7), now); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
 
-		expected    map[types.PortalAppID]PortalAppRelaysResponse
-		expectedErr error
-	}{
-		{
-			name:        "Backend service error",
-			backendErr:  errBackendFailure,
-			expectedErr: errBackendFailure,
-			expected:    map[types.PortalAppID]PortalAppRelaysResponse{},
+	counts := []HTTPSourceRelayCount{
+		{AppPublicKey: "app1", Day: time.Now(), Success: 5, Error: 1},
+		{AppPublicKey: "app9", Day: time.Now(), Success: 7, Error: 2},
+		{AppPublicKey: "app1", Day: now.AddDate(0, 0, -1), Success: 1000, Error: 1000},
+	}
+	if err := meter.WriteHTTPSourceRelayCounts(context.Background(), counts); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if diff := cmp.Diff(counts, driver.written); diff != "" {
+		t.Errorf("unexpected written counts (-want +got):\n%s", diff)
+	}
+
+	expected := map[types.PortalAppPublicKey]RelayCounts{
+		"app1": {Success: 50 + 5, Failure: 40 + 1},
+		"app9": {Success: 7, Failure: 2},
+	}
+	for app, expectedCount := range expected {
+		got, err := meter.AppRelays(context.Background(), app, now, now)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if diff := cmp.Diff(expectedCount, got.Count); diff != "" {
+			t.Errorf("unexpected count for %s (-want +got):\n%s", app, diff)
+		}
+	}
+
+	// The counts returned by the backend must not be modified
+	if diff := cmp.Diff(fakeTodaysMetrics(), meter.Backend.(*fakeBackend).todaysUsage); diff != "" {
+		t.Errorf("unexpected change to backend data (-want +got):\n%s", diff)
+	}
+}
+
+func TestWriteHTTPSourceRelayCountsReload(t *testing.T) {
+	now, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
+	backend := &fakeBackend{
+		usage:       fakeDailyMetrics(),
+		todaysUsage: fakeTodaysMetrics(),
+	}
+	meter := &relayMeter{Backend: backend, Driver: &fakeDriver{}, Logger: logger.New()}
+
+	// reload loads today's metrics again, as the data loader does once they expire
+	reload := func() {
+		meter.todaysTTL = time.Time{}
+		if err := meter.loadData(now.AddDate(0, 0, -7), now); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	expectCount := func(expected RelayCounts) {
+		got, err := meter.AppRelays(context.Background(), "app1", now, now)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if diff := cmp.Diff(expected, got.Count); diff != "" {
+			t.Errorf("unexpected count (-want +got):\n%s", diff)
+		}
+	}
+
+	reload()
+	counts := []HTTPSourceRelayCount{{AppPublicKey: "app1", Day: time.Now(), Success: 5, Error: 1}}
+	if err := meter.WriteHTTPSourceRelayCounts(context.Background(), counts); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := RelayCounts{Success: 50 + 5, Failure: 40 + 1}
+
+	// The backend does not return the uploaded counts until the collector writes them
+	reload()
+	expectCount(expected)
+
+	// Once written by the collector, the uploaded counts are not counted twice
+	collected := fakeTodaysMetrics()
+	collected["app1"] = expected
+	backend.todaysUsage = collected
+	reload()
+	expectCount(expected)
+}
+
+func TestStaleData(t *testing.T) {
+	backend := &fakeBackend{
+		usage:       fakeDailyMetrics(),
+		todaysUsage: fakeTodaysMetrics(),
+	}
+	meter := &relayMeter{
+		Backend: backend,
+		Logger:  logger.New(),
+		RelayMeterOptions: RelayMeterOptions{
+			LoadInterval:     100 * time.Millisecond,
+			DailyMetricsTTL:  time.Second,
+			TodaysMetricsTTL: time.Second,
+		},
+	}
+	if notes := meter.StaleData(); notes != nil {
+		t.Fatalf("Expected no stale data before the first load, got: %v", notes)
+	}
+
+	if err := meter.loadData(time.Now().AddDate(0, 0, -7), time.Now()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if notes := meter.StaleData(); notes != nil {
+		t.Fatalf("Expected no stale data after a load, got: %v", notes)
+	}
+
+	// The backend outage prevents refreshing the metrics once their TTL lapses
+	backend.err = errors.New("backend outage")
+	time.Sleep(1200 * time.Millisecond)
+	if err := meter.loadData(time.Now().AddDate(0, 0, -7), time.Now()); err == nil {
+		t.Fatalf("Expected the backend outage to fail the load")
+	}
+
+	if notes := meter.StaleData(); len(notes) != 2 {
+		t.Errorf("Expected stale daily and today's metrics, got: %v", notes)
+	}
+}
+
+func TestConcurrentLoadData(t *testing.T) {
+	delay := 300 * time.Millisecond
+	meter := &relayMeter{
+		Backend: &fakeBackend{
+			usage:             fakeDailyMetrics(),
+			todaysUsage:       fakeTodaysMetrics(),
+			todaysOriginUsage: fakeTodaysMetricsByOrigin(),
+			todaysLatency:     fakeTodaysLatency(),
+			delay:             delay,
+		},
+		Logger: logger.New(),
+	}
+
+	start := time.Now()
+	if err := meter.loadData(time.Now().AddDate(0, 0, -7), time.Now()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The four backend calls run concurrently, so the load takes about as long as the slowest one
+	if duration := time.Since(start); duration >= 2*delay {
+		t.Errorf("Expected the load to take about %v, got: %v", delay, duration)
+	}
+	if meter.isEmpty() {
+		t.Errorf("Expected all the metrics to be loaded")
+	}
+}
+
+func TestLoadDataPartialFailure(t *testing.T) {
+	todaysErr := errors.New("todays usage query failed")
+	meter := &relayMeter{
+		Backend: &fakeBackend{
+			usage:             fakeDailyMetrics(),
+			todaysUsage:       fakeTodaysMetrics(),
+			todaysOriginUsage: fakeTodaysMetricsByOrigin(),
+			todaysLatency:     fakeTodaysLatency(),
+			todaysUsageErr:    todaysErr,
 		},
+		Logger: logger.New(),
+	}
+
+	if err := meter.loadData(time.Now().AddDate(0, 0, -7), time.Now()); !errors.Is(err, todaysErr) {
+		t.Fatalf("Expected error: %v, got: %v", todaysErr, err)
+	}
+
+	// The metrics which were loaded are applied, while today's relay counts are left to be retried
+	if diff := cmp.Diff(fakeDailyMetrics(), meter.dailyUsage); diff != "" {
+		t.Errorf("unexpected daily metrics (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(fakeTodaysMetricsByOrigin(), meter.todaysOriginUsage); diff != "" {
+		t.Errorf("unexpected todays origin metrics (-want +got):\n%s", diff)
+	}
+	if meter.todaysUsage != nil {
+		t.Errorf("Expected todays metrics not to be applied, got: %v", meter.todaysUsage)
+	}
+	if !meter.todaysTTL.IsZero() {
+		t.Errorf("Expected todays metrics TTL not to be updated, got: %v", meter.todaysTTL)
+	}
+}
+
+func TestOptionalOriginData(t *testing.T) {
+	testCases := []struct {
+		name               string
+		options            RelayMeterOptions
+		expectedEmpty      bool
+		expectedTodayLoads int
+	}{
 		{
-			name: "Correct summary for portalApps",
-			from: now.AddDate(0, 0, -6),
-			to:   now,
-			expected: map[types.PortalAppID]PortalAppRelaysResponse{
-				"portal_app_1": {
-					From:        now.AddDate(0, 0, -6),
-					To:          now.AddDate(0, 0, 1),
-					PortalAppID: "portal_app_1",
-					PublicKeys:  []types.PortalAppPublicKey{"app1", "app2", "app3"},
-					Count: RelayCounts{
-						Success: 98,
-						Failure: 158,
-					},
-				},
-				"portal_app_2": {
-					From:        now.AddDate(0, 0, -6),
-					To:          now.AddDate(0, 0, 1),
-					PortalAppID: "portal_app_2",
-					PublicKeys:  []types.PortalAppPublicKey{"app4", "app5", "app6"},
-					Count: RelayCounts{
-						Success: 530,
-						Failure: 742,
-					},
-				},
-			},
+			name:               "Meter without origin metrics has no data by default",
+			expectedEmpty:      true,
+			expectedTodayLoads: 2,
 		},
 		{
-			name: "Correct summary for portalApps excluding today",
-			from: now.AddDate(0, 0, -6),
-			to:   now.AddDate(0, 0, -2),
-			expected: map[types.PortalAppID]PortalAppRelaysResponse{
-				"portal_app_1": {
-					From:        now.AddDate(0, 0, -6),
-					To:          now.AddDate(0, 0, -1),
-					PortalAppID: "portal_app_1",
-					PublicKeys:  []types.PortalAppPublicKey{"app1", "app2", "app3"},
-					Count: RelayCounts{
-						Success: 15,
-						Failure: 40,
-					},
-				},
-				"portal_app_2": {
-					From:        now.AddDate(0, 0, -6),
-					To:          now.AddDate(0, 0, -1),
-					PortalAppID: "portal_app_2",
-					PublicKeys:  []types.PortalAppPublicKey{"app4", "app5", "app6"},
-					Count: RelayCounts{
-						Success: 25,
-						Failure: 35,
-					},
-				},
-			},
+			name:               "Meter without origin metrics has data if they are optional",
+			options:            RelayMeterOptions{OptionalOriginData: true},
+			expectedTodayLoads: 1,
 		},
 		{
-			name: "Correct summary for portalApps on todays metrics",
-			from: now,
-			to:   now,
-			expected: map[types.PortalAppID]PortalAppRelaysResponse{
-				"portal_app_1": {
-					From:        now,
-					To:          now.AddDate(0, 0, 1),
-					PortalAppID: "portal_app_1",
-					PublicKeys:  []types.PortalAppPublicKey{"app1", "app2", "app3"},
-					Count: RelayCounts{
-						Success: 80,
-						Failure: 110,
-					},
-				},
-				"portal_app_2": {
-					From:        now,
-					To:          now.AddDate(0, 0, 1),
-					PortalAppID: "portal_app_2",
-					PublicKeys:  []types.PortalAppPublicKey{"app4", "app5", "app6"},
-					Count: RelayCounts{
-						Success: 500,
-						Failure: 700,
-					},
-				},
-			},
+			name:               "Meter without origin metrics or latencies has data if both are optional",
+			options:            RelayMeterOptions{OptionalOriginData: true, OptionalLatencyData: true},
+			expectedTodayLoads: 1,
 		},
 	}
 
 	for _, tc := range testCases {
-		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
-			t.Parallel()
-			fakeBackend := fakeBackend{
-				usage:       usageData,
-				todaysUsage: todaysUsage,
-				portalApps: map[types.PortalAppID]*types.PortalApp{
-					"portal_app_1": {
-						ID: "portal_app_1",
-						AATs: map[types.ProtocolAppID]types.AAT{
-							"app1": {PublicKey: "app1"},
-							"app2": {PublicKey: "app2"},
-							"app3": {PublicKey: "app3"},
-						},
-					},
-					"portal_app_2": {
-						ID: "portal_app_2",
-						AATs: map[types.ProtocolAppID]types.AAT{
-							"app4": {PublicKey: "app4"},
-							"app5": {PublicKey: "app5"},
-							"app6": {PublicKey: "app6"},
-						},
-					},
-				},
-				err: tc.backendErr,
+			// Origin sampling is disabled, so the backend has no origin metrics
+			backend := &fakeBackend{
+				usage:         fakeDailyMetrics(),
+				todaysUsage:   fakeTodaysMetrics(),
+				todaysLatency: fakeTodaysLatency(),
 			}
-
-			relayMeter := NewRelayMeter(context.Background(), &fakeBackend, &fakeDriver{}, logger.New(), RelayMeterOptions{LoadInterval: 100 * time.Millisecond})
-			time.Sleep(200 * time.Millisecond)
-			rawGot, err := relayMeter.AllPortalAppsRelays(context.Background(), tc.from, tc.to)
-			if err != nil && !errors.Is(err, tc.expectedErr) {
-				t.Fatalf("Expected error: %v, got: %v", tc.expectedErr, err)
+			if tc.options.OptionalLatencyData {
+				backend.todaysLatency = nil
+			}
+			meter := &relayMeter{
+				Backend:           backend,
+				Logger:            logger.New(),
+				RelayMeterOptions: tc.options,
 			}
 
-			// Need to convert it to map to be able to compare
-			got := make(map[types.PortalAppID]PortalAppRelaysResponse, len(rawGot))
+			for i := 0; i < 2; i++ {
+				if err := meter.loadData(time.Now().AddDate(0, 0, -7), time.Now()); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+			}
 
-			for _, relResp := range rawGot {
-				sort.Slice(relResp.PublicKeys, func(i, j int) bool {
-					return relResp.PublicKeys[i] < relResp.PublicKeys[j]
-				})
-				got[relResp.PortalAppID] = relResp
+			if empty := meter.isEmpty(); empty != tc.expectedEmpty {
+				t.Errorf("Expected the meter to be empty: %t, got: %t", tc.expectedEmpty, empty)
+			}
+			// A meter with data reloads today's metrics only once their TTL lapses
+			if todaysCalls, _ := backend.todaysCalls(); todaysCalls != tc.expectedTodayLoads {
+				t.Errorf("Expected %d loads of todays metrics, got: %d", tc.expectedTodayLoads, todaysCalls)
 			}
+		})
+	}
+}
+
+func TestCoalescedLoadData(t *testing.T) {
+	backend := &fakeBackend{stall: make(chan struct{})}
+	meter := &relayMeter{
+		Backend:           backend,
+		Logger:            logger.New(),
+		RelayMeterOptions: RelayMeterOptions{LoadInterval: 20 * time.Millisecond},
+	}
+	from, to := time.Now().AddDate(0, 0, -7), time.Now()
+
+	// The first call to the backend is not stalled
+	if err := meter.coalescedLoadData(from, to); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- meter.coalescedLoadData(from, to)
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	// The stalled load is still in progress, so an overlapping load is skipped without calling the backend
+	if err := meter.coalescedLoadData(from, to); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	close(backend.stall)
+	if err := <-done; err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if calls, _ := backend.dailyCalls(); calls != 2 {
+		t.Errorf("Expected 2 calls to the backend, got: %d", calls)
+	}
 
-			if diff := cmp.Diff(tc.expected, got); diff != "" {
-				t.Errorf("unexpected value (-want +got):\n%s", diff)
-			}
-		})
+	// Loads run again once the stalled one has completed
+	if err := meter.coalescedLoadData(from, to); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if calls, _ := backend.dailyCalls(); calls != 3 {
+		t.Errorf("Expected 3 calls to the backend, got: %d", calls)
 	}
 }
 
-func TestStartDataLoader(t *testing.T) {
-	now, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
-
+func TestCheckDataLoader(t *testing.T) {
 	testCases := []struct {
 		name          string
-		maxArchiveAge time.Duration
-		expectedFrom  time.Time
-		expectedTo    time.Time
+		backend       *fakeBackend
+		expectedErr   error
+		expectedCalls int
 	}{
 		{
-			name:         "Default maxArchiveAge is applied",
-			expectedFrom: now.AddDate(0, 0, -1*MAX_PAST_DAYS_METRICS_DEFAULT_DAYS),
-			expectedTo:   now,
+			name:          "Running data loader is healthy",
+			backend:       &fakeBackend{},
+			expectedCalls: 5,
 		},
 		{
-			name:          "The specified maxArchiveAge takes effect",
-			maxArchiveAge: 24 * 5 * time.Hour,
-			expectedFrom:  now.AddDate(0, 0, -5),
-			expectedTo:    now,
+			name:        "Stalled data loader is reported",
+			backend:     &fakeBackend{stall: make(chan struct{})},
+			expectedErr: ErrDataLoaderStalled,
+		},
+		{
+			name:          "Data loader is restarted after a panic",
+			backend:       &fakeBackend{panicOnCall: 2},
+			expectedCalls: 5,
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			fakeBackend := fakeBackend{}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
 			meter := &relayMeter{
-				Backend: &fakeBackend,
-				Logger:  logger.New(),
-				RelayMeterOptions: RelayMeterOptions{
-					LoadInterval: 1 * time.Second,
-					MaxPastDays:  tc.maxArchiveAge,
-				},
+				Backend:           tc.backend,
+				Logger:            logger.New(),
+				RelayMeterOptions: RelayMeterOptions{LoadInterval: 20 * time.Millisecond},
+			}
+			if err := meter.CheckDataLoader(); !errors.Is(err, ErrDataLoaderStalled) {
+				t.Fatalf("Expected error before starting the data loader: %v, got: %v", ErrDataLoaderStalled, err)
 			}
 
-			ctx, cancel := context.WithCancel(context.Background())
-			go func() {
-				meter.StartDataLoader(ctx)
-			}()
-			// We only need the initial data loader run
-			time.Sleep(10 * time.Millisecond)
-			cancel()
+			meter.StartDataLoader(ctx)
+			time.Sleep(200 * time.Millisecond)
 
-			if !tc.expectedFrom.Equal(fakeBackend.dailyMetricsFrom) {
-				t.Errorf("Expected 'from' to be: %v, got: %v", tc.expectedFrom, fakeBackend.dailyMetricsFrom)
+			err := meter.CheckDataLoader()
+			if tc.backend.stall != nil {
+				close(tc.backend.stall)
+			}
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("Expected error: %v, got: %v", tc.expectedErr, err)
+			}
+			if calls, _ := tc.backend.dailyCalls(); calls < tc.expectedCalls {
+				t.Errorf("Expected at least %d data loader runs, got: %d", tc.expectedCalls, calls)
 			}
-
 		})
 	}
 }
 
 func TestAllRelaysOrigin(t *testing.T) {
 	now, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
+	current := time.Now()
 	todaysUsage := fakeTodaysMetricsByOrigin()
+	todayOnlyNotes := []string{fmt.Sprintf("Only today's origin metrics are available: counts cover %s -- %s", now.Format(time.RFC3339), now.AddDate(0, 0, 1).Format(time.RFC3339))}
 
 	testCases := []struct {
 		name                string
@@ -1229,27 +4068,30 @@ func TestAllRelaysOrigin(t *testing.T) {
 			to:   now,
 			expected: map[types.PortalAppOrigin]OriginClassificationsResponse{
 				"origin1": {
-					Origin: "origin1",
-					From:   now,
-					To:     now.AddDate(0, 0, 1),
+					Origin:              "origin1",
+					From:                now,
+					To:                  now.AddDate(0, 0, 1),
+					RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now)},
 					Count: RelayCounts{
 						Success: 50,
 						Failure: 40,
 					},
 				},
 				"origin2": {
-					Origin: "origin2",
-					From:   now,
-					To:     now.AddDate(0, 0, 1),
+					Origin:              "origin2",
+					From:                now,
+					To:                  now.AddDate(0, 0, 1),
+					RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now)},
 					Count: RelayCounts{
 						Success: 30,
 						Failure: 70,
 					},
 				},
 				"origin4": {
-					Origin: "origin4",
-					From:   now,
-					To:     now.AddDate(0, 0, 1),
+					Origin:              "origin4",
+					From:                now,
+					To:                  now.AddDate(0, 0, 1),
+					RequestedTimePeriod: RequestedTimePeriod{RequestedTo: timePtr(now)},
 					Count: RelayCounts{
 						Success: 500,
 						Failure: 700,
@@ -1258,32 +4100,38 @@ func TestAllRelaysOrigin(t *testing.T) {
 			},
 		},
 		{
-			name: "Only today's metrics are included when the timespan only includes today",
-			from: time.Now(),
-			to:   time.Now().AddDate(0, 0, 2),
+			name: "Entries report today as their time period when the timespan extends past today",
+			from: current,
+			to:   current.AddDate(0, 0, 2),
 			expected: map[types.PortalAppOrigin]OriginClassificationsResponse{
 				"origin1": {
-					Origin: "origin1",
-					From:   now,
-					To:     now.AddDate(0, 0, 3),
+					Origin:              "origin1",
+					From:                now,
+					To:                  now.AddDate(0, 0, 1),
+					RequestedTimePeriod: RequestedTimePeriod{RequestedFrom: timePtr(current), RequestedTo: timePtr(current.AddDate(0, 0, 2))},
+					Notes:               todayOnlyNotes,
 					Count: RelayCounts{
 						Success: 50,
 						Failure: 40,
 					},
 				},
 				"origin2": {
-					Origin: "origin2",
-					From:   now,
-					To:     now.AddDate(0, 0, 3),
+					Origin:              "origin2",
+					From:                now,
+					To:                  now.AddDate(0, 0, 1),
+					RequestedTimePeriod: RequestedTimePeriod{RequestedFrom: timePtr(current), RequestedTo: timePtr(current.AddDate(0, 0, 2))},
+					Notes:               todayOnlyNotes,
 					Count: RelayCounts{
 						Success: 30,
 						Failure: 70,
 					},
 				},
 				"origin4": {
-					Origin: "origin4",
-					From:   now,
-					To:     now.AddDate(0, 0, 3),
+					Origin:              "origin4",
+					From:                now,
+					To:                  now.AddDate(0, 0, 1),
+					RequestedTimePeriod: RequestedTimePeriod{RequestedFrom: timePtr(current), RequestedTo: timePtr(current.AddDate(0, 0, 2))},
+					Notes:               todayOnlyNotes,
 					Count: RelayCounts{
 						Success: 500,
 						Failure: 700,
@@ -1292,12 +4140,13 @@ func TestAllRelaysOrigin(t *testing.T) {
 			},
 		},
 		{
-			name: "Missing parameters' default values",
+			name: "Entries report today as their time period for the default timespan",
 			expected: map[types.PortalAppOrigin]OriginClassificationsResponse{
 				"origin1": {
 					Origin: "origin1",
-					From:   now.AddDate(0, 0, -30),
+					From:   now,
 					To:     now.AddDate(0, 0, 1),
+					Notes:  todayOnlyNotes,
 					Count: RelayCounts{
 						Success: 50,
 						Failure: 40,
@@ -1305,8 +4154,9 @@ func TestAllRelaysOrigin(t *testing.T) {
 				},
 				"origin2": {
 					Origin: "origin2",
-					From:   now.AddDate(0, 0, -30),
+					From:   now,
 					To:     now.AddDate(0, 0, 1),
+					Notes:  todayOnlyNotes,
 					Count: RelayCounts{
 						Success: 30,
 						Failure: 70,
@@ -1314,8 +4164,9 @@ func TestAllRelaysOrigin(t *testing.T) {
 				},
 				"origin4": {
 					Origin: "origin4",
-					From:   now.AddDate(0, 0, -30),
+					From:   now,
 					To:     now.AddDate(0, 0, 1),
+					Notes:  todayOnlyNotes,
 					Count: RelayCounts{
 						Success: 500,
 						Failure: 700,
@@ -1342,7 +4193,7 @@ func TestAllRelaysOrigin(t *testing.T) {
 
 			relayMeter := NewRelayMeter(context.Background(), &fakeBackend, &fakeDriver{}, logger.New(), RelayMeterOptions{LoadInterval: 100 * time.Millisecond})
 			time.Sleep(200 * time.Millisecond)
-			rawGot, err := relayMeter.AllRelaysOrigin(context.Background(), tc.from, tc.to)
+			rawGot, err := relayMeter.AllRelaysOrigin(context.Background(), tc.from, tc.to, 0, "")
 			if err != nil {
 				if tc.expectedErr == nil {
 					t.Fatalf("Unexpected error: %v", err)
@@ -1366,51 +4217,432 @@ func TestAllRelaysOrigin(t *testing.T) {
 	}
 }
 
+func TestRankOrigins(t *testing.T) {
+	origins := []OriginClassificationsResponse{
+		{Origin: "origin1", Count: RelayCounts{Success: 10, Failure: 50}},
+		{Origin: "origin2", Count: RelayCounts{Success: 40, Failure: 1}},
+		{Origin: "origin3", Count: RelayCounts{Success: 30, Failure: 20}},
+		{Origin: "origin4", Count: RelayCounts{Success: 30, Failure: 5}},
+		{Origin: "origin5", Count: RelayCounts{Success: 1, Failure: 1}},
+	}
+
+	testCases := []struct {
+		name     string
+		limit    int
+		by       OriginOrder
+		expected []types.PortalAppOrigin
+	}{
+		{
+			name:     "Top origins by traffic",
+			limit:    2,
+			expected: []types.PortalAppOrigin{"origin1", "origin3"},
+		},
+		{
+			name:     "Top origins by success, with ties ranked by origin",
+			limit:    3,
+			by:       OriginsBySuccess,
+			expected: []types.PortalAppOrigin{"origin2", "origin3", "origin4"},
+		},
+		{
+			name:     "Top origins by failure",
+			limit:    1,
+			by:       OriginsByFailure,
+			expected: []types.PortalAppOrigin{"origin1"},
+		},
+		{
+			name:     "All origins are ranked without a limit",
+			by:       OriginsByFailure,
+			expected: []types.PortalAppOrigin{"origin1", "origin3", "origin4", "origin2", "origin5"},
+		},
+		{
+			name:     "Limit above the number of origins returns all of them",
+			limit:    10,
+			by:       OriginsBySuccess,
+			expected: []types.PortalAppOrigin{"origin2", "origin3", "origin4", "origin1", "origin5"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []types.PortalAppOrigin
+			for _, origin := range rankOrigins(origins, tc.limit, tc.by) {
+				got = append(got, origin.Origin)
+			}
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestOriginFailureAvailability(t *testing.T) {
+	now, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
+
+	testCases := []struct {
+		name                string
+		originUsage         map[types.PortalAppOrigin]RelayCounts
+		expectedUnavailable bool
+	}{
+		{
+			name: "Zero failures are genuine when other origins report failures",
+			originUsage: map[types.PortalAppOrigin]RelayCounts{
+				"origin1": {Success: 50},
+				"origin2": {Success: 30, Failure: 70},
+			},
+		},
+		{
+			name: "Failures are unavailable when no origin reports failures",
+			originUsage: map[types.PortalAppOrigin]RelayCounts{
+				"origin1": {Success: 50},
+				"origin2": {Success: 30},
+			},
+			expectedUnavailable: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			meter := &relayMeter{todaysOriginUsage: tc.originUsage, Logger: logger.New()}
+
+			var expectedNotes []string
+			if tc.expectedUnavailable {
+				expectedNotes = []string{originFailuresUnavailableNote}
+			}
+
+			origin, err := meter.RelaysOrigin(context.Background(), "origin1", now, now)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if origin.FailureUnavailable != tc.expectedUnavailable {
+				t.Errorf("Expected unavailable failures: %t, got: %t", tc.expectedUnavailable, origin.FailureUnavailable)
+			}
+			// A single origin also explains the adjustment of the 'to' parameter
+			if diff := cmp.Diff(append([]string{toAdjustedNote(now), todayIncludedNote}, expectedNotes...), origin.Notes); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+
+			origins, err := meter.AllRelaysOrigin(context.Background(), now, now, 0, "")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			for _, origin := range origins {
+				if origin.FailureUnavailable != tc.expectedUnavailable {
+					t.Errorf("Expected unavailable failures of %s: %t, got: %t", origin.Origin, tc.expectedUnavailable, origin.FailureUnavailable)
+				}
+				if diff := cmp.Diff(expectedNotes, origin.Notes); diff != "" {
+					t.Errorf("unexpected value (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
+
+func TestRelaysOriginExactMatch(t *testing.T) {
+	now, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
+	meter := &relayMeter{
+		todaysOriginUsage: map[types.PortalAppOrigin]RelayCounts{
+			"origin1":      {Success: 1, Failure: 1},
+			"origin10":     {Success: 10, Failure: 10},
+			"origin100":    {Success: 100, Failure: 100},
+			"app.test1.io": {Success: 5},
+			"app.test2.io": {Success: 7},
+		},
+		Logger: logger.New(),
+	}
+
+	testCases := []struct {
+		name     string
+		origin   types.PortalAppOrigin
+		expected RelayCounts
+	}{
+		{
+			name:     "Origin is not matched by origins containing it",
+			origin:   "origin1",
+			expected: RelayCounts{Success: 1, Failure: 1},
+		},
+		{
+			name:     "Origin contained in other origins is matched exactly",
+			origin:   "origin10",
+			expected: RelayCounts{Success: 10, Failure: 10},
+		},
+		{
+			name:   "Origin without metrics has zero counts",
+			origin: "app.test",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// The map iteration order varies, so the origin is requested repeatedly to verify the match is deterministic
+			for i := 0; i < 20; i++ {
+				got, err := meter.RelaysOrigin(context.Background(), tc.origin, now, now)
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if got.Origin != tc.origin {
+					t.Fatalf("Expected origin: %s, got: %s", tc.origin, got.Origin)
+				}
+				if diff := cmp.Diff(tc.expected, got.Count); diff != "" {
+					t.Fatalf("unexpected value (-want +got):\n%s", diff)
+				}
+				if !got.From.Equal(now) || !got.To.Equal(now.AddDate(0, 0, 1)) {
+					t.Fatalf("Expected time period: %v -- %v, got: %v -- %v", now, now.AddDate(0, 0, 1), got.From, got.To)
+				}
+			}
+		})
+	}
+}
+
+func TestAllRelaysOriginEmpty(t *testing.T) {
+	now, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
+	relayMeter := NewRelayMeter(context.Background(), &fakeBackend{}, &fakeDriver{}, logger.New(), RelayMeterOptions{LoadInterval: 100 * time.Millisecond})
+	time.Sleep(200 * time.Millisecond)
+
+	got, err := relayMeter.AllRelaysOrigin(context.Background(), now, now, 0, "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got == nil || len(got) != 0 {
+		t.Errorf("Expected an empty, non-nil, list of origins, got: %#v", got)
+	}
+
+	_, err = relayMeter.AllRelaysOrigin(context.Background(), now, now.AddDate(0, 0, -1), 0, "")
+	if !errors.Is(err, ErrInvalidTimespan) {
+		t.Errorf("Expected error: %v, got: %v", ErrInvalidTimespan, err)
+	}
+}
+
+func TestSinglePastDay(t *testing.T) {
+	now, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
+	day := now.AddDate(0, 0, -2)
+	meter := &relayMeter{
+		Backend: &fakeBackend{
+			usage:             fakeDailyMetrics(),
+			todaysUsage:       fakeTodaysMetrics(),
+			todaysOriginUsage: fakeTodaysMetricsByOrigin(),
+			todaysLatency:     fakeTodaysLatency(),
+			userApps:          map[types.UserID][]types.PortalAppPublicKey{"user1": {"app1", "app2"}},
+		},
+		Logger: logger.New(),
+	}
+	if err := meter.loadData(now.AddDate(0, 0, -7), now); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	type period struct {
+		From, To time.Time
+		Count    RelayCounts
+	}
+	// The single day is the time period of every endpoint: from == to requests the whole day, excluding today's metrics
+	expectedPeriod := func(count RelayCounts) period { return period{From: day, To: day.AddDate(0, 0, 1), Count: count} }
+
+	testCases := []struct {
+		name     string
+		get      func() (period, error)
+		expected period
+	}{
+		{
+			name: "App relays",
+			get: func() (period, error) {
+				resp, err := meter.AppRelays(context.Background(), "app1", day, day)
+				return period{resp.From, resp.To, resp.Count}, err
+			},
+			expected: expectedPeriod(RelayCounts{Success: 2, Failure: 3}),
+		},
+		{
+			name: "All apps relays",
+			get: func() (period, error) {
+				resp, _, err := meter.AllAppsRelays(context.Background(), day, day, Page{})
+				var total period
+				for _, app := range resp {
+					total = period{app.From, app.To, total.Count.Add(app.Count)}
+				}
+				return total, err
+			},
+			expected: expectedPeriod(RelayCounts{Success: 2 + 1 + 5, Failure: 3 + 5 + 7}),
+		},
+		{
+			name: "User relays",
+			get: func() (period, error) {
+				resp, err := meter.UserRelays(context.Background(), "user1", day, day)
+				return period{resp.From, resp.To, resp.Count}, err
+			},
+			expected: expectedPeriod(RelayCounts{Success: 2 + 1, Failure: 3 + 5}),
+		},
+		{
+			name: "Total relays",
+			get: func() (period, error) {
+				resp, err := meter.TotalRelays(context.Background(), day, day)
+				return period{resp.From, resp.To, resp.Count}, err
+			},
+			expected: expectedPeriod(RelayCounts{Success: 2 + 1 + 5, Failure: 3 + 5 + 7}),
+		},
+		{
+			// Only today's origin metrics are stored, so a past day has no origin counts
+			name: "Relays of an origin",
+			get: func() (period, error) {
+				resp, err := meter.RelaysOrigin(context.Background(), "origin1", day, day)
+				return period{resp.From, resp.To, resp.Count}, err
+			},
+			expected: expectedPeriod(RelayCounts{}),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.get()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("All origins relays", func(t *testing.T) {
+		got, err := meter.AllRelaysOrigin(context.Background(), day, day, 0, "")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(got) != 0 {
+			t.Errorf("Expected no origins for a past day, got: %v", got)
+		}
+	})
+}
+
 type fakeBackend struct {
-	usage              map[time.Time]map[types.PortalAppPublicKey]RelayCounts
-	err                error
-	todaysUsage        map[types.PortalAppPublicKey]RelayCounts
-	todaysOriginUsage  map[types.PortalAppOrigin]RelayCounts
-	todaysLatency      map[types.PortalAppPublicKey][]Latency
-	userApps           map[types.UserID][]types.PortalAppPublicKey
+	usage             map[time.Time]map[types.PortalAppPublicKey]RelayCounts
+	err               error
+	todaysUsage       map[types.PortalAppPublicKey]RelayCounts
+	todaysOriginUsage map[types.PortalAppOrigin]RelayCounts
+	todaysLatency     map[types.PortalAppPublicKey][]Latency
+	userApps          map[types.UserID][]types.PortalAppPublicKey
+	// mu guards the recorded calls below, as the data loader calls the backend concurrently
+	mu                 sync.Mutex
 	todaysMetricsCalls int
 	todaysLatencyCalls int
 	dailyMetricsCalls  int
 	dailyMetricsFrom   time.Time
 	dailyMetricsTo     time.Time
+	portalAppCalls     int
 
 	portalApps map[types.PortalAppID]*types.PortalApp
+	// portalAppsErr, if set, is returned by PortalApps, e.g. to simulate PHD being unreachable
+	portalAppsErr error
+
+	// coveredDays are the days with daily metrics returned by CoveredDays, if within the requested time period
+	coveredDays []time.Time
+
+	// panicOnCall makes the specified call to DailyUsage panic
+	panicOnCall int
+	// stall, if set, blocks the calls to DailyUsage after the first one until it is closed
+	stall chan struct{}
+	// delay, if set, is the duration of each call for daily and today's metrics, e.g. to simulate slow queries
+	delay time.Duration
+	// todaysUsageErr, if set, is returned by TodaysUsage, so only the load of today's relay counts fails
+	todaysUsageErr error
+	// userAppsDelay, if set, is the duration of each call for the apps of a user, e.g. to simulate a slow PHD
+	userAppsDelay time.Duration
 }
 
 func (f *fakeBackend) DailyUsage(from, to time.Time) (map[time.Time]map[types.PortalAppPublicKey]RelayCounts, error) {
+	f.mu.Lock()
 	f.dailyMetricsCalls++
+	calls := f.dailyMetricsCalls
 	f.dailyMetricsFrom = from
 	f.dailyMetricsTo = to
+	f.mu.Unlock()
+
+	if calls == f.panicOnCall {
+		panic("backend failure")
+	}
+	if f.stall != nil && calls > 1 {
+		<-f.stall
+	}
+	time.Sleep(f.delay)
 	return f.usage, f.err
 }
 
+// dailyCalls returns the number of calls to DailyUsage, and the start of the time period of the last one
+func (f *fakeBackend) dailyCalls() (int, time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.dailyMetricsCalls, f.dailyMetricsFrom
+}
+
+// todaysCalls returns the number of calls to TodaysUsage and TodaysLatency
+func (f *fakeBackend) todaysCalls() (int, int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.todaysMetricsCalls, f.todaysLatencyCalls
+}
+
 func (f *fakeBackend) TodaysUsage() (map[types.PortalAppPublicKey]RelayCounts, error) {
+	f.mu.Lock()
 	f.todaysMetricsCalls++
+	f.mu.Unlock()
+	time.Sleep(f.delay)
+	if f.todaysUsageErr != nil {
+		return nil, f.todaysUsageErr
+	}
 	return f.todaysUsage, f.err
 }
 
 func (f *fakeBackend) TodaysLatency() (map[types.PortalAppPublicKey][]Latency, error) {
+	f.mu.Lock()
 	f.todaysLatencyCalls++
+	f.mu.Unlock()
+	time.Sleep(f.delay)
 	return f.todaysLatency, f.err
 }
 
 func (f *fakeBackend) TodaysOriginUsage() (map[types.PortalAppOrigin]RelayCounts, error) {
+	time.Sleep(f.delay)
 	return f.todaysOriginUsage, nil
 }
 
+func (f *fakeBackend) CoveredDays(from, to time.Time) ([]time.Time, error) {
+	var days []time.Time
+	for _, day := range f.coveredDays {
+		if !day.Before(from) && !day.After(to) {
+			days = append(days, day)
+		}
+	}
+	return days, f.err
+}
+
+func (f *fakeBackend) RawDailyRows(appPubKey types.PortalAppPublicKey, from, to time.Time) ([]DailyRow, error) {
+	rows := []DailyRow{}
+	for day, counts := range f.usage {
+		count, ok := counts[appPubKey]
+		if ok && !day.Before(from) && !day.After(to) {
+			rows = append(rows, DailyRow{Day: day, Success: count.Success, Failure: count.Failure})
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Day.Before(rows[j].Day) })
+	return rows, f.err
+}
+
 func (f *fakeBackend) UserPortalAppPubKeys(ctx context.Context, user types.UserID) ([]types.PortalAppPublicKey, error) {
+	time.Sleep(f.userAppsDelay)
 	return f.userApps[user], nil
 }
 
 func (f *fakeBackend) PortalApp(ctx context.Context, portalAppID types.PortalAppID) (*types.PortalApp, error) {
+	f.mu.Lock()
+	f.portalAppCalls++
+	f.mu.Unlock()
 	return f.portalApps[portalAppID], f.err
 }
 
+// portalAppLookups returns the number of calls to PortalApp
+func (f *fakeBackend) portalAppLookups() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.portalAppCalls
+}
+
 func (f *fakeBackend) PortalApps(ctx context.Context) ([]*types.PortalApp, error) {
 	var lbs []*types.PortalApp
 
@@ -1418,6 +4650,9 @@ func (f *fakeBackend) PortalApps(ctx context.Context) ([]*types.PortalApp, error
 		lbs = append(lbs, lb)
 	}
 
+	if f.portalAppsErr != nil {
+		return nil, f.portalAppsErr
+	}
 	return lbs, f.err
 }
 
@@ -1472,12 +4707,30 @@ func fakeTodaysLatency() map[types.PortalAppPublicKey][]Latency {
 	}
 }
 
-type fakeDriver struct{}
+type fakeDriver struct {
+	written []HTTPSourceRelayCount
+}
 
 func (d *fakeDriver) WriteHTTPSourceRelayCounts(ctx context.Context, counts []HTTPSourceRelayCount) error {
+	d.written = append(d.written, counts...)
 	return nil
 }
 
+// timePtr returns a pointer to the supplied time, for building the expected requested time periods
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+// fromAdjustedNote returns the note explaining the adjustment of a 'from' parameter to the start of the specified day
+func fromAdjustedNote(day time.Time) string {
+	return fmt.Sprintf("From adjusted to the start of day %s", day.Format(dayFormat))
+}
+
+// toAdjustedNote returns the note explaining the adjustment of a 'to' parameter on the specified day
+func toAdjustedNote(day time.Time) string {
+	return fmt.Sprintf("To adjusted to the start of day %s, to include the whole of %s", day.AddDate(0, 0, 1).Format(dayFormat), day.Format(dayFormat))
+}
+
 // sortPublicKeys sorts a slice of types.PortalAppPublicKey for comparison in tests
 func sortPublicKeys(publicKeys []types.PortalAppPublicKey) []types.PortalAppPublicKey {
 	if len(publicKeys) == 0 {
@@ -1500,3 +4753,75 @@ func sortPublicKeys(publicKeys []types.PortalAppPublicKey) []types.PortalAppPubl
 
 	return sortedKeys
 }
+
+func TestSlowQueryLog(t *testing.T) {
+	testCases := []struct {
+		name          string
+		threshold     time.Duration
+		delay         time.Duration
+		expectedWarns int
+	}{
+		{
+			name:          "Query slower than the threshold is logged",
+			threshold:     10 * time.Millisecond,
+			delay:         50 * time.Millisecond,
+			expectedWarns: 1,
+		},
+		{
+			name:      "Query faster than the threshold is not logged",
+			threshold: time.Second,
+		},
+		{
+			name:  "Queries are not logged without a threshold",
+			delay: 50 * time.Millisecond,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			now, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
+			var logs bytes.Buffer
+			meter := &relayMeter{
+				Backend: &fakeBackend{
+					usage:         fakeDailyMetrics(),
+					todaysUsage:   fakeTodaysMetrics(),
+					userApps:      map[types.UserID][]types.PortalAppPublicKey{"user1": {"app1", "app2"}},
+					userAppsDelay: tc.delay,
+				},
+				Logger:            &logger.Logger{Logger: slog.New(slog.NewJSONHandler(&logs, nil))},
+				RelayMeterOptions: RelayMeterOptions{SlowQueryThreshold: tc.threshold},
+			}
+			if err := meter.loadData(now.AddDate(0, 0, -7), now); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			from, to := now.AddDate(0, 0, -3), now
+			if _, err := meter.UserRelays(context.Background(), "user1", from, to); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var warns []map[string]any
+			decoder := json.NewDecoder(&logs)
+			for decoder.More() {
+				var entry map[string]any
+				if err := decoder.Decode(&entry); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if entry["msg"] == "Slow meter query" {
+					warns = append(warns, entry)
+				}
+			}
+
+			if len(warns) != tc.expectedWarns {
+				t.Fatalf("Expected %d slow query warnings, got: %d", tc.expectedWarns, len(warns))
+			}
+			if tc.expectedWarns == 0 {
+				return
+			}
+			if warns[0]["level"] != "WARN" || warns[0]["query"] != "UserRelays" || warns[0]["userID"] != "user1" ||
+				warns[0]["from"] != from.Format(time.RFC3339) || warns[0]["to"] != to.Format(time.RFC3339) {
+				t.Errorf("Expected a warning with the query's parameters, got: %v", warns[0])
+			}
+		})
+	}
+}
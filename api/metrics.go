@@ -0,0 +1,114 @@
+package api
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	authFailureMissingHeader = "missing_header"
+	authFailureInvalidKey    = "invalid_key"
+
+	cacheDaily    = "daily"
+	cacheToday    = "today"
+	cacheOrigin   = "origin"
+	cacheLatency  = "latency"
+	cacheAppPlans = "app_plans"
+
+	queryDailyUsage        = "daily_usage"
+	queryTodaysUsage       = "todays_usage"
+	queryTodaysLatency     = "todays_latency"
+	queryTodaysOriginUsage = "todays_origin_usage"
+	queryPortalApps        = "portal_apps"
+
+	relaysSuccess = "success"
+	relaysFailure = "failure"
+)
+
+var (
+	authFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "relay_meter",
+		Name:      "auth_failures_total",
+		Help:      "Number of requests rejected for failed authorization, by reason: missing_header or invalid_key",
+	}, []string{"reason"})
+
+	cacheEntries = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "relay_meter",
+		Name:      "cache_entries",
+		Help:      "Number of entries of the in-memory caches, by cache: daily, today, origin, latency or app_plans",
+	}, []string{"cache"})
+
+	cacheEstimatedBytes = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "relay_meter",
+		Name:      "cache_estimated_bytes",
+		Help:      "Rough estimate of the memory used by the in-memory caches, in bytes",
+	})
+
+	dataLoadDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "relay_meter",
+		Name:      "data_load_duration_seconds",
+		Help:      "Duration of the data loader runs: loads longer than the load interval make the data loader fall behind",
+		Buckets:   []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+	})
+
+	dataLoadsSkipped = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "relay_meter",
+		Name:      "data_loads_skipped_total",
+		Help:      "Number of data loads skipped because the previous one was still in progress",
+	})
+
+	dataLoadLastSuccess = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "relay_meter",
+		Name:      "data_load_last_success_timestamp_seconds",
+		Help:      "Unix time of the last data load which completed without errors",
+	})
+
+	backendQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "relay_meter",
+		Name:      "backend_query_duration_seconds",
+		Help:      "Duration of the backend queries of the data loader, by query: daily_usage, todays_usage, todays_latency, todays_origin_usage or portal_apps",
+		Buckets:   []float64{0.01, 0.05, 0.1, 0.5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"query"})
+
+	todaysRelays = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "relay_meter",
+		Name:      "todays_relays",
+		Help:      "Number of relays served today, as last loaded by the data loader, by result: success or failure",
+	}, []string{"result"})
+)
+
+// cacheUpdates are the Unix times, in nanoseconds, at which the daily and today's relay counts were last loaded
+var cacheUpdates = map[string]*atomic.Int64{
+	cacheDaily: {},
+	cacheToday: {},
+}
+
+func init() {
+	for cache, updated := range cacheUpdates {
+		updated := updated
+		promauto.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace:   "relay_meter",
+			Name:        "cache_age_seconds",
+			Help:        "Time since the relay counts of the cache were last loaded, by cache: daily or today. NaN until the first load",
+			ConstLabels: prometheus.Labels{"cache": cache},
+		}, func() float64 {
+			nanos := updated.Load()
+			if nanos == 0 {
+				return math.NaN()
+			}
+			return time.Since(time.Unix(0, nanos)).Seconds()
+		})
+	}
+}
+
+// observeQuery returns a function recording the duration of the backend query since observeQuery was called
+func observeQuery(query string) func() {
+	start := time.Now()
+	return func() {
+		backendQueryDuration.WithLabelValues(query).Observe(time.Since(start).Seconds())
+	}
+}
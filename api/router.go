@@ -0,0 +1,90 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// routeHandler serves a request matched by a route: param is the path's submatch, e.g. an application public key, if any.
+type routeHandler func(w http.ResponseWriter, req *http.Request, log *slog.Logger, param string)
+
+type route struct {
+	method  string
+	path    *regexp.Regexp
+	handler routeHandler
+}
+
+// router dispatches each request to the first route, in order of registration, matching both its method and its path.
+//
+//	Routes are registered in groups sharing a path prefix, e.g. an API version such as /v1, so a new version
+//	of the API can be added as a separate group without affecting the existing routes.
+type router struct {
+	routes []route
+}
+
+// routeGroup registers routes whose path patterns are relative to the group's prefix
+type routeGroup struct {
+	router *router
+	prefix string
+}
+
+func (r *router) group(prefix string) *routeGroup {
+	return &routeGroup{router: r, prefix: prefix}
+}
+
+// handle registers a route: the pattern is matched against the path following the group's prefix.
+//
+//	Patterns without a trailing '$' also match any path starting with them.
+func (g *routeGroup) handle(method, pattern string, handler routeHandler) {
+	g.router.routes = append(g.router.routes, route{
+		method:  method,
+		path:    regexp.MustCompile("^" + regexp.QuoteMeta(g.prefix) + pattern),
+		handler: handler,
+	})
+}
+
+// match returns the handler of the first route matching the request, and its URL-decoded path parameter.
+//
+//	The escaped path is matched, so an encoded slash, e.g. in an origin, does not split the path parameter.
+//	If no route matches the request, the methods allowed for its path, if any, are returned instead.
+func (r *router) match(req *http.Request) (routeHandler, string, []string) {
+	allowed := make(map[string]bool)
+	for _, route := range r.routes {
+		matches := route.path.FindStringSubmatch(req.URL.EscapedPath())
+		if matches == nil {
+			continue
+		}
+		if route.method != req.Method {
+			allowed[route.method] = true
+			continue
+		}
+
+		var param string
+		if len(matches) > 1 {
+			param = matches[1]
+			// The escaped path was already validated when the request's URL was parsed
+			if unescaped, err := url.PathUnescape(param); err == nil {
+				param = unescaped
+			}
+		}
+		return route.handler, param, nil
+	}
+
+	var methods []string
+	for method := range allowed {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	return nil, "", methods
+}
+
+// methodNotAllowed responds to a request for a path which is only served for other methods
+func methodNotAllowed(w http.ResponseWriter, allowed []string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+}
@@ -0,0 +1,298 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pokt-foundation/portal-http-db/v2/types"
+	"github.com/pokt-foundation/utils-go/logger"
+)
+
+func TestRoutes(t *testing.T) {
+	uploadInput, _ := json.Marshal([]HTTPSourceRelayCountInput{{AppPublicKey: "21", Success: 21, Error: 7}})
+	batchInput, _ := json.Marshal([]types.PortalAppID{"portal_app_1", "portal_app_2"})
+
+	testCases := []struct {
+		name               string
+		method             string
+		path               string
+		reqInput           []byte
+		expectedCall       string
+		expectedStatusCode int
+		expectedAllow      string
+	}{
+		{
+			name:               "Health check",
+			method:             http.MethodGet,
+			path:               HEALTH_CHECK_PATH,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Readiness check",
+			method:             http.MethodGet,
+			path:               READINESS_CHECK_PATH,
+			expectedCall:       "CheckDataLoader",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Cache stats",
+			method:             http.MethodGet,
+			path:               DEBUG_CACHE_PATH,
+			expectedCall:       "CacheStats",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "App relays",
+			method:             http.MethodGet,
+			path:               "/v1/relays/apps/app_1",
+			expectedCall:       "AppRelays",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "App errors",
+			method:             http.MethodGet,
+			path:               "/v1/relays/apps/app_1/errors",
+			expectedCall:       "AppErrors",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Active apps",
+			method:             http.MethodGet,
+			path:               "/v1/relays/apps/active",
+			expectedCall:       "ActiveApps",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Fully failing apps",
+			method:             http.MethodGet,
+			path:               "/v1/relays/apps/failing",
+			expectedCall:       "AppsFullyFailing",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "App SLO",
+			method:             http.MethodGet,
+			path:               "/v1/relays/apps/app_1/slo?target=0.99",
+			expectedCall:       "AppSLO",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "App health",
+			method:             http.MethodGet,
+			path:               "/v1/relays/apps/app_1/health",
+			expectedCall:       "AppHealth",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "All apps relays",
+			method:             http.MethodGet,
+			path:               "/v1/relays/apps",
+			expectedCall:       "AllAppsRelays",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "All apps relays serves any path under its prefix",
+			method:             http.MethodGet,
+			path:               "/v1/relays/apps/app_1/unknown",
+			expectedCall:       "AllAppsRelays",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "User relays",
+			method:             http.MethodGet,
+			path:               "/v1/relays/users/user_1",
+			expectedCall:       "UserRelays",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Portal app relays",
+			method:             http.MethodGet,
+			path:               "/v1/relays/endpoints/portal_app_1",
+			expectedCall:       "PortalAppRelays",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Portal apps batch relays",
+			method:             http.MethodPost,
+			path:               "/v1/relays/endpoints/batch",
+			reqInput:           batchInput,
+			expectedCall:       "PortalAppsRelays",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Portal apps batch path is served as a portal app for GET requests",
+			method:             http.MethodGet,
+			path:               "/v1/relays/endpoints/batch",
+			expectedCall:       "PortalAppRelays",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "All portal apps relays",
+			method:             http.MethodGet,
+			path:               "/v1/relays/endpoints",
+			expectedCall:       "AllPortalAppsRelays",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Portal app overview",
+			method:             http.MethodGet,
+			path:               "/v1/portal-apps/portal_app_1/overview",
+			expectedCall:       "PortalAppOverview",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Total relays",
+			method:             http.MethodGet,
+			path:               "/v1/relays",
+			expectedCall:       "TotalRelays",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Total relays by day",
+			method:             http.MethodGet,
+			path:               "/v1/relays?byDay=true",
+			expectedCall:       "TotalRelaysByDay",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Relays by plan",
+			method:             http.MethodGet,
+			path:               "/v1/relays/by-plan",
+			expectedCall:       "PlanRelays",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Compare apps relays",
+			method:             http.MethodGet,
+			path:               "/v1/relays/compare?a=app_1&b=app_2",
+			expectedCall:       "CompareAppsRelays",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "All origins classification",
+			method:             http.MethodGet,
+			path:               "/v1/relays/origin-classification",
+			expectedCall:       "AllRelaysOrigin",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Origin classification",
+			method:             http.MethodGet,
+			path:               "/v1/relays/origin-classification/https:%2F%2Fportal.pokt.network",
+			expectedCall:       "RelaysOrigin",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "App latency",
+			method:             http.MethodGet,
+			path:               "/v1/latency/apps/app_1",
+			expectedCall:       "AppLatency",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "All apps latency",
+			method:             http.MethodGet,
+			path:               "/v1/latency/apps",
+			expectedCall:       "AllAppsLatencies",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Coverage",
+			method:             http.MethodGet,
+			path:               "/v1/coverage",
+			expectedCall:       "Coverage",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "App raw daily rows",
+			method:             http.MethodGet,
+			path:               "/v1/debug/apps/app_1/rows",
+			expectedCall:       "AppRawDailyRows",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Limits",
+			method:             http.MethodGet,
+			path:               "/v1/limits",
+			expectedCall:       "Limits",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Upload relay counts",
+			method:             http.MethodPost,
+			path:               "/v1/relays/counts",
+			reqInput:           uploadInput,
+			expectedCall:       "WriteHTTPSourceRelayCounts",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Relay counts path is served as total relays for GET requests",
+			method:             http.MethodGet,
+			path:               "/v1/relays/counts",
+			expectedCall:       "TotalRelays",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Method not allowed for a read endpoint",
+			method:             http.MethodPost,
+			path:               "/v1/relays/apps/app_1",
+			expectedStatusCode: http.StatusMethodNotAllowed,
+			expectedAllow:      http.MethodGet,
+		},
+		{
+			name:               "Method not allowed for the upload endpoint",
+			method:             http.MethodPut,
+			path:               "/v1/relays/counts",
+			expectedStatusCode: http.StatusMethodNotAllowed,
+			expectedAllow:      "GET, POST",
+		},
+		{
+			name:               "Upload path with a suffix is not served",
+			method:             http.MethodPost,
+			path:               "/v1/relays/counts/unknown",
+			expectedStatusCode: http.StatusMethodNotAllowed,
+			expectedAllow:      http.MethodGet,
+		},
+		{
+			name:               "Unknown version",
+			method:             http.MethodGet,
+			path:               "/v9/relays",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Unknown path",
+			method:             http.MethodGet,
+			path:               "/v1/unknown",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			meter := &fakeRelayMeter{
+				allClassificationsResponse: []OriginClassificationsResponse{{Origin: "https://portal.pokt.network"}},
+			}
+			httpServer := GetHttpServer(context.Background(), meter, logger.New(), map[string]bool{"dummy": true}, ServerOptions{})
+
+			req := httptest.NewRequest(tc.method, "http://relay-meter.pokt.network"+tc.path, bytes.NewBuffer(tc.reqInput))
+			req.Header.Add("Authorization", "dummy")
+			w := httptest.NewRecorder()
+
+			httpServer(w, req)
+
+			if w.Code != tc.expectedStatusCode {
+				t.Errorf("Expected status code: %d, got: %d", tc.expectedStatusCode, w.Code)
+			}
+			if meter.called != tc.expectedCall {
+				t.Errorf("Expected meter call: %q, got: %q", tc.expectedCall, meter.called)
+			}
+			if allow := w.Header().Get("Allow"); allow != tc.expectedAllow {
+				t.Errorf("Expected Allow header: %q, got: %q", tc.expectedAllow, allow)
+			}
+		})
+	}
+}
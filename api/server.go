@@ -8,35 +8,94 @@ import (
 	"log/slog"
 	"net/http"
 	"regexp"
+	"runtime/debug"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/pokt-foundation/portal-http-db/v2/types"
 	"github.com/pokt-foundation/utils-go/logger"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 const (
-	DATE_LAYOUT              = time.RFC3339
-	PARAMETER_FROM           = "from"
-	PARAMETER_TO             = "to"
-	HEALTH_CHECK_PATH string = "/healthz"
+	DATE_LAYOUT                            = time.RFC3339
+	PARAMETER_FROM                         = "from"
+	PARAMETER_TO                           = "to"
+	PARAMETER_STRICT                       = "strict"
+	PARAMETER_BY_DAY                       = "byDay"
+	PARAMETER_EXCLUDE_ORIGIN               = "excludeOrigins"
+	PARAMETER_LIMIT                        = "limit"
+	PARAMETER_OFFSET                       = "offset"
+	PARAMETER_BY                           = "by"
+	PARAMETER_MIN_VOLUME                   = "minVolume"
+	PARAMETER_ANONYMIZE                    = "anonymize"
+	PARAMETER_RATE                         = "rate"
+	PARAMETER_BREAKDOWN                    = "breakdown"
+	PARAMETER_EXCLUDE_PARTIAL_TODAY        = "excludePartialToday"
+	PARAMETER_END_EXCLUSIVE                = "endExclusive"
+	PARAMETER_TZ                           = "tz"
+	PARAMETER_APP_A                        = "a"
+	PARAMETER_APP_B                        = "b"
+	PARAMETER_TARGET                       = "target"
+	PARAMETER_VERSION                      = "v"
+	PARAMETER_FORMAT                       = "format"
+	FORMAT_NDJSON                          = "ndjson"
+	PARAMETER_INT_ENCODING                 = "intEncoding"
+	INT_ENCODING_NUMBER                    = "number"
+	INT_ENCODING_STRING                    = "string"
+	NDJSON_CONTENT_TYPE                    = "application/x-ndjson"
+	HEADER_VERSION                         = "X-Api-Version"
+	HEADER_DATA_STALE                      = "X-Data-Stale"
+	HEADER_PORTAL_DATA_STALE               = "X-Portal-Data-Stale"
+	HEADER_TOTAL_COUNT                     = "X-Total-Count"
+	ENVELOPE_VERSION                       = "2"
+	COMPACT_ENVELOPE_VERSION               = "3"
+	MAX_BATCH_PORTAL_APPS                  = 100
+	HEALTH_CHECK_PATH               string = "/healthz"
+	READINESS_CHECK_PATH            string = "/readyz"
+	DEBUG_CACHE_PATH                string = "/debug/cache"
+	METRICS_PATH                    string = "/metrics"
 )
 
-var (
-	// TODO: should we limit the length of application public key or userID in the path regexp?
-	appsRelaysPath    = regexp.MustCompile(`^/v1/relays/apps/([[:alnum:]_]+)$`)
-	allAppsRelaysPath = regexp.MustCompile(`^/v1/relays/apps`)
-	usersRelaysPath   = regexp.MustCompile(`^/v1/relays/users/([[:alnum:]_]+)$`)
+// API versions are served under their own path prefix, e.g. /v1/relays
+const API_V1_PREFIX = "/v1"
+
+// Path patterns of the API routes, relative to the prefix of their API version
+const (
+	// TODO: should we limit the length of userID in the path regexp?
+	appsRelaysPath    = `/relays/apps/([[:alnum:]_]+)$`
+	appErrorsPath     = `/relays/apps/([[:alnum:]_]+)/errors$`
+	appSLOPath        = `/relays/apps/([[:alnum:]_]+)/slo$`
+	appHealthPath     = `/relays/apps/([[:alnum:]_]+)/health$`
+	appRankPath       = `/relays/apps/([[:alnum:]_]+)/rank$`
+	allAppsRelaysPath = `/relays/apps`
+	activeAppsPath    = `/relays/apps/active$`
+	failingAppsPath   = `/relays/apps/failing$`
+	usersRelaysPath   = `/relays/users/([[:alnum:]_]+)$`
 	// TODO: should we change the path from endpoints to portal_apps?
-	lbRelaysPath            = regexp.MustCompile(`^/v1/relays/endpoints/([[:alnum:]_]+)$`)
-	allLbsRelaysPath        = regexp.MustCompile(`^/v1/relays/endpoints`)
-	totalRelaysPath         = regexp.MustCompile(`^/v1/relays`)
-	originUsagePath         = regexp.MustCompile(`^/v1/relays/origin-classification`)
-	specificOriginUsagePath = regexp.MustCompile(`^/v1/relays/origin-classification/([[:alnum:]_].*)`)
-	appsLatencyPath         = regexp.MustCompile(`^/v1/latency/apps/([[:alnum:]|_]+)$`)
-	allAppsLatencyPath      = regexp.MustCompile(`^/v1/latency/apps`)
-	relayCountsPath         = regexp.MustCompile(`^/v1/relays/counts`)
+	lbRelaysPath            = `/relays/endpoints/([[:alnum:]_]+)$`
+	lbsBatchRelaysPath      = `/relays/endpoints/batch$`
+	allLbsRelaysPath        = `/relays/endpoints`
+	portalAppOverviewPath   = `/portal-apps/([[:alnum:]_]+)/overview$`
+	totalRelaysPath         = `/relays`
+	compareAppsRelaysPath   = `/relays/compare$`
+	plansRelaysPath         = `/relays/by-plan$`
+	originUsagePath         = `/relays/origin-classification$`
+	specificOriginUsagePath = `/relays/origin-classification/([^/]+)$`
+	appsLatencyPath         = `/latency/apps/([[:alnum:]|_]+)$`
+	allAppsLatencyPath      = `/latency/apps`
+	relayCountsPath         = `/relays/counts$`
+	coveragePath            = `/coverage$`
+	limitsPath              = `/limits$`
+	appRawDailyRowsPath     = `/debug/apps/([[:alnum:]_]+)/rows$`
+)
+
+var (
+	// appPubKeyFormat matches a valid application public key, i.e. 64 hex characters
+	appPubKeyFormat = regexp.MustCompile(`^[[:xdigit:]]{64}$`)
 
 	mutex sync.Mutex
 )
@@ -49,10 +108,125 @@ var (
 	InvalidRequest ApiError = fmt.Errorf("Invalid request")
 )
 
+// ServerOptions configures the behavior of the HTTP server returned by GetHttpServer
+type ServerOptions struct {
+	// ValidateAppKeys rejects, with a 400, requests for application public keys that are not 64 hex characters.
+	//	It is disabled by default to allow the shorter keys used by test fixtures.
+	ValidateAppKeys bool
+	// ReadOnly rejects, with a 503, the requests writing relay counts, e.g. during maintenance windows.
+	//	Read requests are served as usual.
+	ReadOnly bool
+	// StatusClassification classifies the per status relay counts of the uploaded relay counts, if any, as successful or failed.
+	StatusClassification StatusClassification
+	// Anonymizer anonymizes the application public keys of the responses to the requests using AnonymizedAPIKeys,
+	//	or requesting it using anonymize=true. Without a secret, the requests to be anonymized are rejected.
+	Anonymizer Anonymizer
+	// AnonymizedAPIKeys are the API keys, e.g. of externally shared dashboards, whose responses never include raw application public keys.
+	//	They must also be listed in the API keys to be authorized.
+	AnonymizedAPIKeys map[string]bool
+	// APIKeys, if set, are the authorized API keys instead of the keys supplied to GetHttpServer, so they can be reloaded while serving.
+	APIKeys *APIKeys
+}
+
 type ErrorResponse struct {
 	Message string
 }
 
+type ListResponseMeta struct {
+	From       time.Time `json:"From"`
+	To         time.Time `json:"To"`
+	Total      int       `json:"Total"`
+	Generation uint64    `json:"Generation"`
+	// Notes explains any caveats on the listed data, e.g. stale metrics served during a backend outage
+	Notes []string `json:"Notes,omitempty"`
+}
+
+// ListResponse is the envelope returned by list endpoints when requested, i.e. using either the v=2 query parameter
+// or the X-Api-Version: 2 header. The bare list is returned otherwise.
+//
+//	Version 3 returns the same envelope, with the AllAppsRelays entries omitting the time period already reported in Meta.
+type ListResponse[T any] struct {
+	Data []T              `json:"Data"`
+	Meta ListResponseMeta `json:"Meta"`
+}
+
+// CompactAppRelaysResponse is an entry of the compact AllAppsRelays envelope, i.e. version 3: the time period shared
+// by all the entries is only returned once, in the envelope's Meta.
+type CompactAppRelaysResponse struct {
+	Count     RelayCounts              `json:"Count"`
+	PublicKey types.PortalAppPublicKey `json:"Application"`
+	// From is only set if the app's time period starts later than the envelope's, see RelayMeterOptions.AppsEffectiveFrom
+	From *time.Time `json:"From,omitempty"`
+	// Rate is only set if requested, i.e. using rate=true
+	Rate  *RelayRate `json:"Rate,omitempty"`
+	Notes []string   `json:"Notes,omitempty"`
+}
+
+func versionRequested(req *http.Request, version string) bool {
+	return req.URL.Query().Get(PARAMETER_VERSION) == version || req.Header.Get(HEADER_VERSION) == version
+}
+
+func envelopeRequested(req *http.Request) bool {
+	return versionRequested(req, ENVELOPE_VERSION) || versionRequested(req, COMPACT_ENVELOPE_VERSION)
+}
+
+func compactEnvelopeRequested(req *http.Request) bool {
+	return versionRequested(req, COMPACT_ENVELOPE_VERSION)
+}
+
+// compactAppsRelays returns the entries of the compact AllAppsRelays envelope, keeping only the start of the apps' time periods
+// which differ from the envelope's.
+func compactAppsRelays(resp []AppRelaysResponse, from time.Time) []CompactAppRelaysResponse {
+	compact := make([]CompactAppRelaysResponse, 0, len(resp))
+	for _, app := range resp {
+		entry := CompactAppRelaysResponse{
+			Count:     app.Count,
+			PublicKey: app.PublicKey,
+			Rate:      app.Rate,
+			Notes:     app.Notes,
+		}
+		if !app.From.Equal(from) {
+			appFrom := app.From
+			entry.From = &appFrom
+		}
+		compact = append(compact, entry)
+	}
+	return compact
+}
+
+// listResponse wraps the items returned by the meter in a ListResponse, if requested.
+func listResponse[T any](meter RelayMeter, req *http.Request, from, to time.Time, items []T) (any, error) {
+	return listPageResponse(meter, req, from, to, items, len(items))
+}
+
+// listPageResponse wraps a page of the items in a ListResponse, if requested: total is the number of items of all the pages.
+func listPageResponse[T any](meter RelayMeter, req *http.Request, from, to time.Time, items []T, total int) (any, error) {
+	if !envelopeRequested(req) {
+		return items, nil
+	}
+
+	// The envelope reports the time period served by the meter, e.g. clamped to the archived metrics, as its items do
+	from, to, err := meter.ServedTimePeriod(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	if items == nil {
+		items = []T{}
+	}
+
+	return ListResponse[T]{
+		Data: items,
+		Meta: ListResponseMeta{
+			From:       from,
+			To:         to,
+			Total:      total,
+			Generation: meter.DataGeneration(),
+			Notes:      meter.StaleData(),
+		},
+	}, nil
+}
+
 func healthCheck(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	_, err := w.Write([]byte("Relay Meter up and running!"))
@@ -61,46 +235,310 @@ func healthCheck(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func readinessCheck(meter RelayMeter, l *logger.Logger, w http.ResponseWriter, r *http.Request) {
+	if err := meter.CheckDataLoader(); err != nil {
+		l.Warn("Readiness check failed",
+			slog.String("error", err.Error()),
+		)
+		http.Error(w, fmt.Sprintf("Relay Meter not ready: %v", err), http.StatusServiceUnavailable)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "Relay Meter ready")
+}
+
+// cacheStats serves the size of the in-memory caches, to detect when the dataset outgrows them
+func cacheStats(meter RelayMeter, l *logger.Logger, w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(meter.CacheStats()); err != nil {
+		l.Warn("Error writing cache stats",
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+func limits(meter RelayMeter, l *logger.Logger, w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(meter.Limits()); err != nil {
+		l.Warn("Error writing limits",
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+// rateRequested returns true if the relay rates are requested, i.e. using rate=true
+func rateRequested(req *http.Request) bool {
+	return req.URL.Query().Get(PARAMETER_RATE) == "true"
+}
+
 func handleAppRelays(ctx context.Context, meter RelayMeter, l *logger.Logger, appPubKey types.PortalAppPublicKey, w http.ResponseWriter, req *http.Request) {
 	meterEndpoint := func(from, to time.Time) (any, error) {
-		return meter.AppRelays(ctx, appPubKey, from, to)
+		resp, err := meter.AppRelays(ctx, appPubKey, from, to)
+		if err == nil && rateRequested(req) {
+			resp.Rate = relayRate(resp.Count, resp.From, resp.To, time.Now())
+		}
+		return resp, err
+	}
+	handleEndpoint(ctx, l, meterEndpoint, w, req, PARAMETER_RATE)
+}
+
+func handleCompareAppsRelays(ctx context.Context, meter RelayMeter, l *logger.Logger, options ServerOptions, w http.ResponseWriter, req *http.Request) {
+	meterEndpoint := func(from, to time.Time) (any, error) {
+		var apps []types.PortalAppPublicKey
+		for _, param := range []string{PARAMETER_APP_A, PARAMETER_APP_B} {
+			appPubKey := req.URL.Query().Get(param)
+			if appPubKey == "" {
+				return nil, fmt.Errorf("%w: missing %s parameter", InvalidRequest, param)
+			}
+			if err := checkAppPubKey(options, appPubKey); err != nil {
+				return nil, fmt.Errorf("%w: %v", InvalidRequest, err)
+			}
+			apps = append(apps, NormalizePublicKey(types.PortalAppPublicKey(appPubKey)))
+		}
+		return meter.CompareAppsRelays(ctx, apps[0], apps[1], from, to)
+	}
+	handleEndpoint(ctx, l, meterEndpoint, w, req, PARAMETER_APP_A, PARAMETER_APP_B)
+}
+
+func handleAppSLO(ctx context.Context, meter RelayMeter, l *logger.Logger, appPubKey types.PortalAppPublicKey, w http.ResponseWriter, req *http.Request) {
+	meterEndpoint := func(from, to time.Time) (any, error) {
+		rawTarget := req.URL.Query().Get(PARAMETER_TARGET)
+		if rawTarget == "" {
+			return nil, fmt.Errorf("%w: missing %s parameter", InvalidRequest, PARAMETER_TARGET)
+		}
+		target, err := strconv.ParseFloat(rawTarget, 64)
+		if err != nil || target <= 0 || target > 1 {
+			return nil, fmt.Errorf("%w: invalid %s parameter, expected a success rate between 0 and 1: %s", InvalidRequest, PARAMETER_TARGET, rawTarget)
+		}
+		return meter.AppSLO(ctx, appPubKey, target, from, to)
+	}
+	handleEndpoint(ctx, l, meterEndpoint, w, req, PARAMETER_TARGET)
+}
+
+func handleAppHealth(ctx context.Context, meter RelayMeter, l *logger.Logger, appPubKey types.PortalAppPublicKey, w http.ResponseWriter, req *http.Request) {
+	meterEndpoint := func(from, to time.Time) (any, error) {
+		return meter.AppHealth(ctx, appPubKey, from, to)
 	}
 	handleEndpoint(ctx, l, meterEndpoint, w, req)
 }
 
-func handleAllAppsRelays(ctx context.Context, meter RelayMeter, l *logger.Logger, w http.ResponseWriter, req *http.Request) {
+func handleAppRank(ctx context.Context, meter RelayMeter, l *logger.Logger, appPubKey types.PortalAppPublicKey, w http.ResponseWriter, req *http.Request) {
+	meterEndpoint := func(from, to time.Time) (any, error) {
+		by, err := orderParameter(req)
+		if err != nil {
+			return nil, err
+		}
+		return meter.AppRank(ctx, appPubKey, from, to, by)
+	}
+	handleEndpoint(ctx, l, meterEndpoint, w, req, PARAMETER_BY)
+}
+
+func handleActiveApps(ctx context.Context, meter RelayMeter, l *logger.Logger, w http.ResponseWriter, req *http.Request) {
 	meterEndpoint := func(from, to time.Time) (any, error) {
-		return meter.AllAppsRelays(ctx, from, to)
+		return meter.ActiveApps(ctx, from, to)
 	}
 	handleEndpoint(ctx, l, meterEndpoint, w, req)
 }
 
-func handleUserRelays(ctx context.Context, meter RelayMeter, l *logger.Logger, userID types.UserID, w http.ResponseWriter, req *http.Request) {
+func handleAppsFullyFailing(ctx context.Context, meter RelayMeter, l *logger.Logger, w http.ResponseWriter, req *http.Request) {
+	meterEndpoint := func(from, to time.Time) (any, error) {
+		var minVolume int64
+		if rawMinVolume := req.URL.Query().Get(PARAMETER_MIN_VOLUME); rawMinVolume != "" {
+			var err error
+			minVolume, err = strconv.ParseInt(rawMinVolume, 10, 64)
+			if err != nil || minVolume < 0 {
+				return nil, fmt.Errorf("%w: invalid %s parameter: %s", InvalidRequest, PARAMETER_MIN_VOLUME, rawMinVolume)
+			}
+		}
+		resp, err := meter.AppsFullyFailing(ctx, from, to, minVolume)
+		if err != nil {
+			return nil, err
+		}
+		return listResponse(meter, req, from, to, resp)
+	}
+	handleEndpoint(ctx, l, meterEndpoint, w, req, PARAMETER_VERSION, PARAMETER_MIN_VOLUME)
+}
+
+func handleAppRawDailyRows(ctx context.Context, meter RelayMeter, l *logger.Logger, appPubKey types.PortalAppPublicKey, w http.ResponseWriter, req *http.Request) {
+	meterEndpoint := func(from, to time.Time) (any, error) {
+		return meter.AppRawDailyRows(ctx, appPubKey, from, to)
+	}
+	handleEndpoint(ctx, l, meterEndpoint, w, req)
+}
+
+func handleCoverage(ctx context.Context, meter RelayMeter, l *logger.Logger, w http.ResponseWriter, req *http.Request) {
 	meterEndpoint := func(from, to time.Time) (any, error) {
-		return meter.UserRelays(ctx, userID, from, to)
+		return meter.Coverage(ctx, from, to)
 	}
 	handleEndpoint(ctx, l, meterEndpoint, w, req)
 }
 
+func handleAppErrors(ctx context.Context, meter RelayMeter, l *logger.Logger, appPubKey types.PortalAppPublicKey, w http.ResponseWriter, req *http.Request) {
+	meterEndpoint := func(from, to time.Time) (any, error) {
+		return meter.AppErrors(ctx, appPubKey, from, to)
+	}
+	handleEndpoint(ctx, l, meterEndpoint, w, req)
+}
+
+func handleAllAppsRelays(ctx context.Context, meter RelayMeter, l *logger.Logger, w http.ResponseWriter, req *http.Request) {
+	if req.URL.Query().Get(PARAMETER_FORMAT) == FORMAT_NDJSON {
+		meterEndpoint := func(from, to time.Time) ([]AppRelaysResponse, error) {
+			resp, total, err := allAppsRelays(ctx, meter, req, from, to)
+			if err != nil {
+				return nil, err
+			}
+			w.Header().Set(HEADER_TOTAL_COUNT, strconv.Itoa(total))
+			return resp, nil
+		}
+		handleStreamEndpoint(ctx, l, meterEndpoint, w, req, PARAMETER_VERSION, PARAMETER_FORMAT, PARAMETER_RATE, PARAMETER_LIMIT, PARAMETER_OFFSET)
+		return
+	}
+
+	meterEndpoint := func(from, to time.Time) (any, error) {
+		resp, total, err := allAppsRelays(ctx, meter, req, from, to)
+		if err != nil {
+			return nil, err
+		}
+		w.Header().Set(HEADER_TOTAL_COUNT, strconv.Itoa(total))
+		if compactEnvelopeRequested(req) {
+			envelopeFrom, _, err := meter.ServedTimePeriod(from, to)
+			if err != nil {
+				return nil, err
+			}
+			return listPageResponse(meter, req, from, to, compactAppsRelays(resp, envelopeFrom), total)
+		}
+		return listPageResponse(meter, req, from, to, resp, total)
+	}
+	handleEndpoint(ctx, l, meterEndpoint, w, req, PARAMETER_VERSION, PARAMETER_FORMAT, PARAMETER_RATE, PARAMETER_LIMIT, PARAMETER_OFFSET)
+}
+
+// allAppsRelays returns the requested page of the relays of all apps, including their rates if requested, and the total number of apps
+func allAppsRelays(ctx context.Context, meter RelayMeter, req *http.Request, from, to time.Time) ([]AppRelaysResponse, int, error) {
+	page, err := pageParameters(req)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	resp, total, err := meter.AllAppsRelays(ctx, from, to, page)
+	if err != nil || !rateRequested(req) {
+		return resp, total, err
+	}
+
+	now := time.Now()
+	for i := range resp {
+		resp[i].Rate = relayRate(resp[i].Count, resp[i].From, resp[i].To, now)
+	}
+	return resp, total, nil
+}
+
+func handleUserRelays(ctx context.Context, meter RelayMeter, l *logger.Logger, userID types.UserID, w http.ResponseWriter, req *http.Request) {
+	meterEndpoint := func(from, to time.Time) (any, error) {
+		resp, err := meter.UserRelays(ctx, userID, from, to)
+		if err == nil && rateRequested(req) {
+			resp.Rate = relayRate(resp.Count, resp.From, resp.To, time.Now())
+		}
+		// The single total is returned by default
+		if req.URL.Query().Get(PARAMETER_BREAKDOWN) != "true" {
+			resp.PerApp = nil
+		}
+		return resp, err
+	}
+	handleEndpoint(ctx, l, meterEndpoint, w, req, PARAMETER_RATE, PARAMETER_BREAKDOWN)
+}
+
 func handlePortalAppRelays(ctx context.Context, meter RelayMeter, l *logger.Logger, portalAppID types.PortalAppID, w http.ResponseWriter, req *http.Request) {
 	meterEndpoint := func(from, to time.Time) (any, error) {
-		return meter.PortalAppRelays(ctx, portalAppID, from, to)
+		resp, err := meter.PortalAppRelays(ctx, portalAppID, from, to)
+		if err == nil && rateRequested(req) {
+			resp.Rate = relayRate(resp.Count, resp.From, resp.To, time.Now())
+		}
+		return resp, err
+	}
+	handleEndpoint(ctx, l, meterEndpoint, w, req, PARAMETER_RATE)
+}
+
+// handlePortalAppsRelays serves the relays of the portal apps whose IDs are supplied as a JSON array in the request's body.
+// At most MAX_BATCH_PORTAL_APPS IDs are accepted per request.
+func handlePortalAppsRelays(ctx context.Context, meter RelayMeter, l *logger.Logger, w http.ResponseWriter, req *http.Request) {
+	var portalAppIDs []types.PortalAppID
+	if err := json.NewDecoder(req.Body).Decode(&portalAppIDs); err != nil {
+		l.Warn("Invalid input",
+			slog.String("error", err.Error()),
+		)
+		http.Error(w, fmt.Sprintf("Invalid input: %v", err), http.StatusBadRequest)
+		return
+	}
+	if len(portalAppIDs) > MAX_BATCH_PORTAL_APPS {
+		l.Warn("Too many portal apps requested",
+			slog.Int("count", len(portalAppIDs)),
+		)
+		http.Error(w, fmt.Sprintf("Invalid input: at most %d portal app IDs are accepted, got %d", MAX_BATCH_PORTAL_APPS, len(portalAppIDs)), http.StatusBadRequest)
+		return
+	}
+
+	meterEndpoint := func(from, to time.Time) (any, error) {
+		return meter.PortalAppsRelays(ctx, portalAppIDs, from, to)
 	}
 	handleEndpoint(ctx, l, meterEndpoint, w, req)
 }
 
-func handleAllPortalAppsRelays(ctx context.Context, meter RelayMeter, l *logger.Logger, w http.ResponseWriter, req *http.Request) {
+func handlePortalAppOverview(ctx context.Context, meter RelayMeter, l *logger.Logger, portalAppID types.PortalAppID, w http.ResponseWriter, req *http.Request) {
 	meterEndpoint := func(from, to time.Time) (any, error) {
-		return meter.AllPortalAppsRelays(ctx, from, to)
+		return meter.PortalAppOverview(ctx, portalAppID, from, to)
 	}
 	handleEndpoint(ctx, l, meterEndpoint, w, req)
 }
 
+func handleAllPortalAppsRelays(ctx context.Context, meter RelayMeter, l *logger.Logger, w http.ResponseWriter, req *http.Request) {
+	meterEndpoint := func(from, to time.Time) (any, error) {
+		page, err := pageParameters(req)
+		if err != nil {
+			return nil, err
+		}
+		resp, total, err := meter.AllPortalAppsRelays(ctx, from, to, page)
+		if err != nil {
+			return nil, err
+		}
+		w.Header().Set(HEADER_TOTAL_COUNT, strconv.Itoa(total))
+		// The last-known portal apps are served if PHD is unreachable, flagged so clients know the mapping may be outdated
+		if meter.PortalAppsStale() {
+			w.Header().Set(HEADER_PORTAL_DATA_STALE, "true")
+		}
+		if rateRequested(req) {
+			now := time.Now()
+			for i := range resp {
+				resp[i].Rate = relayRate(resp[i].Count, resp[i].From, resp[i].To, now)
+			}
+		}
+		return listPageResponse(meter, req, from, to, resp, total)
+	}
+	handleEndpoint(ctx, l, meterEndpoint, w, req, PARAMETER_VERSION, PARAMETER_RATE, PARAMETER_LIMIT, PARAMETER_OFFSET)
+}
+
+func handlePlansRelays(ctx context.Context, meter RelayMeter, l *logger.Logger, w http.ResponseWriter, req *http.Request) {
+	meterEndpoint := func(from, to time.Time) (any, error) {
+		resp, err := meter.PlanRelays(ctx, from, to)
+		if err != nil {
+			return nil, err
+		}
+		return listResponse(meter, req, from, to, resp)
+	}
+	handleEndpoint(ctx, l, meterEndpoint, w, req, PARAMETER_VERSION)
+}
+
 func handleTotalRelays(ctx context.Context, meter RelayMeter, l *logger.Logger, w http.ResponseWriter, req *http.Request) {
 	meterEndpoint := func(from, to time.Time) (any, error) {
-		return meter.TotalRelays(ctx, from, to)
+		if req.URL.Query().Get(PARAMETER_BY_DAY) == "true" {
+			return meter.TotalRelaysByDay(ctx, from, to)
+		}
+		resp, err := meter.TotalRelays(ctx, from, to)
+		if err == nil && rateRequested(req) {
+			resp.Rate = relayRate(resp.Count, resp.From, resp.To, time.Now())
+		}
+		return resp, err
 	}
-	handleEndpoint(ctx, l, meterEndpoint, w, req)
+	handleEndpoint(ctx, l, meterEndpoint, w, req, PARAMETER_BY_DAY, PARAMETER_RATE)
 }
 
 func handleSpecificOriginClassification(ctx context.Context, meter RelayMeter, l *logger.Logger, origin types.PortalAppOrigin, w http.ResponseWriter, req *http.Request) {
@@ -112,26 +550,190 @@ func handleSpecificOriginClassification(ctx context.Context, meter RelayMeter, l
 
 func handleOriginClassification(ctx context.Context, meter RelayMeter, l *logger.Logger, w http.ResponseWriter, req *http.Request) {
 	meterEndpoint := func(from, to time.Time) (any, error) {
-		return meter.AllRelaysOrigin(ctx, from, to)
+		limit, err := limitParameter(req)
+		if err != nil {
+			return nil, err
+		}
+
+		by, err := orderParameter(req)
+		if err != nil {
+			return nil, err
+		}
+
+		// The excluded origins are removed after the top ones are selected, so as many more origins are requested to return limit origins
+		excluded := len(excludedOrigins(req))
+		meterLimit := limit
+		if limit > 0 {
+			meterLimit += excluded
+		}
+
+		resp, err := meter.AllRelaysOrigin(ctx, from, to, meterLimit, by)
+		if err != nil {
+			return nil, err
+		}
+		// An empty list of origins is returned as [], rather than null
+		if resp == nil {
+			resp = []OriginClassificationsResponse{}
+		}
+
+		resp = excludeOrigins(resp, req)
+		if limit > 0 && len(resp) > limit {
+			resp = resp[:limit]
+		}
+		return listResponse(meter, req, from, to, resp)
 	}
-	handleEndpoint(ctx, l, meterEndpoint, w, req)
+	handleEndpoint(ctx, l, meterEndpoint, w, req, PARAMETER_VERSION, PARAMETER_EXCLUDE_ORIGIN, PARAMETER_LIMIT, PARAMETER_BY)
+}
+
+// orderParameter returns the relay count to rank by, i.e. the value of the by query parameter: the total relays if not set
+func orderParameter(req *http.Request) (OriginOrder, error) {
+	by := OriginOrder(req.URL.Query().Get(PARAMETER_BY))
+	if by != "" && by != OriginsBySuccess && by != OriginsByFailure {
+		return "", fmt.Errorf("%w: invalid %s parameter: %s, expected: %s or %s", InvalidRequest, PARAMETER_BY, by, OriginsBySuccess, OriginsByFailure)
+	}
+	return by, nil
+}
+
+// limitParameter returns the value of the limit query parameter, or 0, i.e. no limit, if not set
+func limitParameter(req *http.Request) (int, error) {
+	rawLimit := req.URL.Query().Get(PARAMETER_LIMIT)
+	if rawLimit == "" {
+		return 0, nil
+	}
+
+	limit, err := strconv.Atoi(rawLimit)
+	if err != nil || limit < 0 {
+		return 0, fmt.Errorf("%w: invalid %s parameter: %s", InvalidRequest, PARAMETER_LIMIT, rawLimit)
+	}
+	return limit, nil
+}
+
+// pageParameters returns the page selected by the limit and offset query parameters: the first items if offset is not set
+func pageParameters(req *http.Request) (Page, error) {
+	limit, err := limitParameter(req)
+	if err != nil {
+		return Page{}, err
+	}
+
+	var offset int
+	if rawOffset := req.URL.Query().Get(PARAMETER_OFFSET); rawOffset != "" {
+		offset, err = strconv.Atoi(rawOffset)
+		if err != nil || offset < 0 {
+			return Page{}, fmt.Errorf("%w: invalid %s parameter: %s", InvalidRequest, PARAMETER_OFFSET, rawOffset)
+		}
+	}
+
+	return Page{Limit: limit, Offset: offset}, nil
+}
+
+// excludedOrigins returns the origins listed, comma-separated, in the excludeOrigins query parameter
+func excludedOrigins(req *http.Request) map[types.PortalAppOrigin]bool {
+	excluded := make(map[types.PortalAppOrigin]bool)
+	for _, origin := range strings.Split(req.URL.Query().Get(PARAMETER_EXCLUDE_ORIGIN), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			excluded[types.PortalAppOrigin(origin)] = true
+		}
+	}
+	return excluded
+}
+
+// excludeOrigins removes the origins listed, comma-separated, in the excludeOrigins query parameter
+func excludeOrigins(origins []OriginClassificationsResponse, req *http.Request) []OriginClassificationsResponse {
+	excluded := excludedOrigins(req)
+	if len(excluded) == 0 {
+		return origins
+	}
+
+	filtered := []OriginClassificationsResponse{}
+	for _, origin := range origins {
+		if !excluded[origin.Origin] {
+			filtered = append(filtered, origin)
+		}
+	}
+
+	return filtered
 }
 
 func handleAppLatency(ctx context.Context, meter RelayMeter, l *logger.Logger, appPubKey types.PortalAppPublicKey, w http.ResponseWriter, req *http.Request) {
 	meterEndpoint := func(from, to time.Time) (any, error) {
-		return meter.AppLatency(ctx, appPubKey)
+		loc, err := timezoneParameter(req)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := meter.AppLatency(ctx, appPubKey)
+		if err != nil {
+			return nil, err
+		}
+		return latencyInLocation(resp, loc), nil
 	}
-	handleEndpoint(ctx, l, meterEndpoint, w, req)
+	handleEndpoint(ctx, l, meterEndpoint, w, req, PARAMETER_TZ)
 }
 
 func handleAllAppsLatency(ctx context.Context, meter RelayMeter, l *logger.Logger, w http.ResponseWriter, req *http.Request) {
 	meterEndpoint := func(from, to time.Time) (any, error) {
-		return meter.AllAppsLatencies(ctx)
+		limit, err := limitParameter(req)
+		if err != nil {
+			return nil, err
+		}
+		loc, err := timezoneParameter(req)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := meter.AllAppsLatencies(ctx, limit)
+		if err != nil {
+			return nil, err
+		}
+		for i := range resp {
+			resp[i] = latencyInLocation(resp[i], loc)
+		}
+		return resp, nil
 	}
-	handleEndpoint(ctx, l, meterEndpoint, w, req)
+	handleEndpoint(ctx, l, meterEndpoint, w, req, PARAMETER_LIMIT, PARAMETER_TZ)
+}
+
+// timezoneParameter returns the time zone named by the tz query parameter, e.g. America/New_York, or nil if not specified
+func timezoneParameter(req *http.Request) (*time.Location, error) {
+	rawTZ := req.URL.Query().Get(PARAMETER_TZ)
+	if rawTZ == "" {
+		return nil, nil
+	}
+
+	loc, err := time.LoadLocation(rawTZ)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid %s parameter: %s", InvalidRequest, PARAMETER_TZ, rawTZ)
+	}
+	return loc, nil
+}
+
+// latencyInLocation returns the latency response with its times reported in the specified time zone, if any.
+//
+//	Only the reported zone changes, i.e. the times still refer to the same instants, and the meter's latencies are not modified.
+func latencyInLocation(resp AppLatencyResponse, loc *time.Location) AppLatencyResponse {
+	if loc == nil {
+		return resp
+	}
+
+	dailyLatency := make([]Latency, len(resp.DailyLatency))
+	for i, latency := range resp.DailyLatency {
+		dailyLatency[i] = latency
+		dailyLatency[i].Time = latency.Time.In(loc)
+	}
+
+	resp.DailyLatency = dailyLatency
+	resp.From = resp.From.In(loc)
+	resp.To = resp.To.In(loc)
+	return resp
 }
 
-func handleUploadRelayCounts(ctx context.Context, meter RelayMeter, l *logger.Logger, w http.ResponseWriter, req *http.Request) {
+func handleUploadRelayCounts(ctx context.Context, meter RelayMeter, l *logger.Logger, classification StatusClassification, w http.ResponseWriter, req *http.Request) {
+	if err := checkStrictParameters(req); err != nil {
+		l.Warn("Invalid query parameters",
+			slog.String("error", err.Error()),
+		)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	decoder := json.NewDecoder(req.Body)
 
 	var inCounts []HTTPSourceRelayCountInput
@@ -148,11 +750,12 @@ func handleUploadRelayCounts(ctx context.Context, meter RelayMeter, l *logger.Lo
 	now := time.Now()
 	var counts []HTTPSourceRelayCount
 	for _, incount := range inCounts {
+		classified := classification.Classify(incount.StatusCounts)
 		counts = append(counts, HTTPSourceRelayCount{
-			AppPublicKey: incount.AppPublicKey,
+			AppPublicKey: NormalizePublicKey(incount.AppPublicKey),
 			Day:          now,
-			Success:      incount.Success,
-			Error:        incount.Error,
+			Success:      incount.Success + classified.Success,
+			Error:        incount.Error + classified.Failure,
 		})
 	}
 
@@ -176,42 +779,37 @@ func handleUploadRelayCounts(ctx context.Context, meter RelayMeter, l *logger.Lo
 	fmt.Fprintf(w, "counters added")
 }
 
-func handleEndpoint(ctx context.Context, l *logger.Logger, meterEndpoint func(from, to time.Time) (any, error), w http.ResponseWriter, req *http.Request) {
+// handleEndpoint serves a meter endpoint. extraParams lists the query parameters, besides 'from' and 'to',
+// which are recognized by the endpoint: it is only used to reject unknown parameters on strict requests.
+func handleEndpoint(ctx context.Context, l *logger.Logger, meterEndpoint func(from, to time.Time) (any, error), w http.ResponseWriter, req *http.Request, extraParams ...string) {
 	log := l.With(slog.Group("request", "host", req.Host, "method", req.Method, "url", req.URL))
 	w.Header().Add("Content-Type", "application/json")
 
-	from, to, err := timePeriod(req)
-	if err != nil {
-		log.Warn("Invalid timespan",
-			slog.String("error", err.Error()),
-		)
-		http.Error(w, fmt.Sprintf("Invalid timespan: %v", err), http.StatusBadRequest)
+	from, to, ok := endpointTimePeriod(log, w, req, extraParams...)
+	if !ok {
+		return
+	}
+	stringInts, ok := endpointIntEncoding(log, w, req)
+	if !ok {
 		return
 	}
 
 	// TODO: separate Internal errors from Request errors using custom errors returned by the meter service
 	meterResponse, meterErr := meterEndpoint(from, to)
 	if meterErr != nil {
-		errLogger := l.With(slog.String("error", meterErr.Error()))
-
-		switch {
-		case meterErr != nil && errors.Is(meterErr, InvalidRequest):
-			errLogger.Warn("Invalid request")
-			http.Error(w, fmt.Sprintf("Bad request: %v", meterErr), http.StatusBadRequest)
-		case meterErr != nil && errors.Is(meterErr, AppNotFound):
-			errLogger.Warn("Invalid request: application not found")
-			http.Error(w, fmt.Sprintf("Bad request: %v", meterErr), http.StatusBadRequest)
-		case meterErr != nil && errors.Is(meterErr, ErrPortalAppNotFound):
-			errLogger.Warn("Invalid request: load balancer not found")
-			http.Error(w, fmt.Sprintf("Bad request: %v", meterErr), http.StatusNotFound)
-		default:
-			errLogger.Warn("Internal server error")
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
-		}
+		writeMeterError(l, w, meterErr)
 		return
 	}
 
-	bytes, err := json.Marshal(meterResponse)
+	if anonymizer, ok := requestAnonymizer(req); ok {
+		meterResponse = anonymizer.Anonymize(meterResponse)
+	}
+
+	marshal := json.Marshal
+	if stringInts {
+		marshal = marshalStringIntegers
+	}
+	bytes, err := marshal(meterResponse)
 	if err != nil {
 		log.Warn("Internal error marshalling response",
 			slog.String("error", err.Error()),
@@ -224,6 +822,220 @@ func handleEndpoint(ctx context.Context, l *logger.Logger, meterEndpoint func(fr
 	fmt.Fprint(w, string(bytes))
 }
 
+// handleStreamEndpoint writes the items returned by the meter as newline delimited JSON, i.e. one JSON object per line,
+// encoding each item straight to the response and flushing it, so the client receives the items as they are written
+// instead of a single buffer holding the whole response.
+//
+//	Any error is returned with its status code only if it occurs before the first item is written.
+func handleStreamEndpoint[T any](ctx context.Context, l *logger.Logger, meterEndpoint func(from, to time.Time) ([]T, error), w http.ResponseWriter, req *http.Request, extraParams ...string) {
+	log := l.With(slog.Group("request", "host", req.Host, "method", req.Method, "url", req.URL))
+
+	from, to, ok := endpointTimePeriod(log, w, req, extraParams...)
+	if !ok {
+		return
+	}
+	stringInts, ok := endpointIntEncoding(log, w, req)
+	if !ok {
+		return
+	}
+
+	items, err := meterEndpoint(from, to)
+	if err != nil {
+		writeMeterError(l, w, err)
+		return
+	}
+
+	w.Header().Add("Content-Type", NDJSON_CONTENT_TYPE)
+	w.WriteHeader(http.StatusOK)
+
+	anonymizer, anonymize := requestAnonymizer(req)
+	encoder := json.NewEncoder(w)
+	// Flushing is skipped if the response writer does not support it, e.g. the items are then sent as the buffer fills up
+	flusher := http.NewResponseController(w)
+	for _, item := range items {
+		if err := ctx.Err(); err != nil {
+			log.Warn("Streaming response interrupted",
+				slog.String("error", err.Error()),
+			)
+			return
+		}
+		var encoded any = item
+		if anonymize {
+			encoded = anonymizer.Anonymize(item)
+		}
+		if stringInts {
+			line, err := marshalStringIntegers(encoded)
+			if err != nil {
+				log.Warn("Internal error streaming response",
+					slog.String("error", err.Error()),
+				)
+				return
+			}
+			encoded = json.RawMessage(line)
+		}
+		if err := encoder.Encode(encoded); err != nil {
+			log.Warn("Internal error streaming response",
+				slog.String("error", err.Error()),
+			)
+			return
+		}
+		if err := flusher.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			log.Warn("Internal error streaming response",
+				slog.String("error", err.Error()),
+			)
+			return
+		}
+	}
+}
+
+// endpointTimePeriod returns the time period of the request, after verifying its query parameters.
+//
+//	A bad request response is written, and false returned, if the parameters are invalid.
+func endpointTimePeriod(log *slog.Logger, w http.ResponseWriter, req *http.Request, extraParams ...string) (time.Time, time.Time, bool) {
+	if err := checkStrictParameters(req, append(extraParams, PARAMETER_FROM, PARAMETER_TO, PARAMETER_EXCLUDE_PARTIAL_TODAY, PARAMETER_END_EXCLUSIVE, PARAMETER_ANONYMIZE, PARAMETER_INT_ENCODING)...); err != nil {
+		log.Warn("Invalid query parameters",
+			slog.String("error", err.Error()),
+		)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return time.Time{}, time.Time{}, false
+	}
+
+	from, to, err := timePeriod(req)
+	if err == nil && req.URL.Query().Get(PARAMETER_END_EXCLUSIVE) == "true" {
+		to, err = endExclusive(from, to)
+	}
+	if err == nil && req.URL.Query().Get(PARAMETER_EXCLUDE_PARTIAL_TODAY) == "true" {
+		to, err = excludePartialToday(from, to, time.Now())
+	}
+	if err != nil {
+		log.Warn("Invalid timespan",
+			slog.String("error", err.Error()),
+		)
+		http.Error(w, fmt.Sprintf("Invalid timespan: %v", err), http.StatusBadRequest)
+		return time.Time{}, time.Time{}, false
+	}
+
+	return from, to, true
+}
+
+// endpointIntEncoding returns whether the integers of the response are to be encoded as strings.
+//
+//	A bad request response is written, and false returned as ok, if the encoding is invalid.
+func endpointIntEncoding(log *slog.Logger, w http.ResponseWriter, req *http.Request) (stringInts bool, ok bool) {
+	stringInts, err := stringIntegersRequested(req)
+	if err != nil {
+		log.Warn("Invalid query parameters",
+			slog.String("error", err.Error()),
+		)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false, false
+	}
+	return stringInts, true
+}
+
+// excludePartialToday returns the 'to' parameter limited to yesterday, so only the finalized daily metrics are included.
+//
+//	An error is returned if the time period starts today, as it would then have no finalized metrics.
+func excludePartialToday(from, to, now time.Time) (time.Time, error) {
+	yesterday := startOfDay(now.In(dayLocation), dayLocation).AddDate(0, 0, -1)
+	if !to.IsZero() && to.Before(yesterday) {
+		return to, nil
+	}
+	if startOfDay(from, dayLocation).After(yesterday) {
+		return time.Time{}, fmt.Errorf("%s excludes the whole time period starting at %v", PARAMETER_EXCLUDE_PARTIAL_TODAY, from)
+	}
+
+	return yesterday, nil
+}
+
+// endExclusive returns the 'to' parameter as the day of the last instant before it, so the time period ends right before 'to'.
+//
+//	By default 'to' names the last day of the time period, i.e. the day of 'to' is included whatever its time:
+//	a 'to' of 2022-07-21T00:00:00Z includes the relays of July 21st. With endExclusive=true the same 'to' only
+//	includes the relays up to the end of July 20th. The metrics are daily, so a 'to' within a day still includes that day.
+//	An error is returned if the time period ends before it starts.
+func endExclusive(from, to time.Time) (time.Time, error) {
+	if to.IsZero() {
+		return to, nil
+	}
+	if !from.IsZero() && !to.After(from) {
+		return time.Time{}, fmt.Errorf("%s excludes the whole time period: %v -- %v", PARAMETER_END_EXCLUSIVE, from, to)
+	}
+
+	return startOfDay(to.Add(-time.Nanosecond), dayLocation), nil
+}
+
+// recoverPanic responds with an internal server error to a request whose handler panicked, so the server keeps serving other requests.
+//
+//	It must be deferred by the function serving the request.
+func recoverPanic(log *slog.Logger, w http.ResponseWriter) {
+	rec := recover()
+	if rec == nil {
+		return
+	}
+
+	log.Error("Request handler panicked",
+		slog.Any("panic", rec),
+		slog.String("stack", string(debug.Stack())),
+	)
+	http.Error(w, "Internal server error", http.StatusInternalServerError)
+}
+
+// writeMeterError writes the response matching the error returned by the meter
+func writeMeterError(l *logger.Logger, w http.ResponseWriter, meterErr error) {
+	errLogger := l.With(slog.String("error", meterErr.Error()))
+
+	switch {
+	case errors.Is(meterErr, InvalidRequest), errors.Is(meterErr, ErrInvalidTimespan):
+		errLogger.Warn("Invalid request")
+		http.Error(w, fmt.Sprintf("Bad request: %v", meterErr), http.StatusBadRequest)
+	case errors.Is(meterErr, AppNotFound):
+		errLogger.Warn("Invalid request: application not found")
+		http.Error(w, fmt.Sprintf("Bad request: %v", meterErr), http.StatusBadRequest)
+	case errors.Is(meterErr, ErrPortalAppNotFound):
+		errLogger.Warn("Invalid request: load balancer not found")
+		http.Error(w, fmt.Sprintf("Bad request: %v", meterErr), http.StatusNotFound)
+	default:
+		errLogger.Warn("Internal server error")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// checkStrictParameters returns an error listing the query parameters not included in the known parameters,
+// if the request has enabled the strict mode, i.e. strict=true. Unknown parameters are ignored otherwise.
+func checkStrictParameters(req *http.Request, known ...string) error {
+	query := req.URL.Query()
+	if query.Get(PARAMETER_STRICT) != "true" {
+		return nil
+	}
+
+	allowed := map[string]bool{PARAMETER_STRICT: true}
+	for _, param := range known {
+		allowed[param] = true
+	}
+
+	var unknown []string
+	for param := range query {
+		if !allowed[param] {
+			unknown = append(unknown, param)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+
+	sort.Strings(unknown)
+	return fmt.Errorf("Unknown query parameters: %s", strings.Join(unknown, ", "))
+}
+
+// checkAppPubKey returns an error if application public keys are validated and the supplied key has an invalid format.
+func checkAppPubKey(options ServerOptions, appPubKey string) error {
+	if !options.ValidateAppKeys || appPubKeyFormat.MatchString(appPubKey) {
+		return nil
+	}
+	return fmt.Errorf("Invalid application public key: %s", appPubKey)
+}
+
 func timePeriod(req *http.Request) (time.Time, time.Time, error) {
 	parse := func(s string) (time.Time, error) {
 		return time.Parse(DATE_LAYOUT, s)
@@ -255,94 +1067,172 @@ func timePeriod(req *http.Request) (time.Time, time.Time, error) {
 	return from, to, nil
 }
 
+// requiresAPIKey returns true if the requests to the path must supply an authorized API key:
+// the API versions and the cache stats, while the health checks and metrics are served to any client.
+func requiresAPIKey(path string) bool {
+	return strings.HasPrefix(path, API_V1_PREFIX) || path == DEBUG_CACHE_PATH
+}
+
 // TODO: Return 404 on Application not found error
 // TODO: Return 304, i.e. Not Modified, if relevant
 // TODO: 'Accepts' Header in the request
 // serves: /relays/apps
-func GetHttpServer(ctx context.Context, meter RelayMeter, l *logger.Logger, apiKeys map[string]bool) func(w http.ResponseWriter, req *http.Request) {
-	match := func(r *regexp.Regexp, p string) string {
-		matches := r.FindStringSubmatch(p)
-		if len(matches) != 2 {
-			return ""
+func GetHttpServer(ctx context.Context, meter RelayMeter, l *logger.Logger, apiKeys map[string]bool, options ServerOptions) func(w http.ResponseWriter, req *http.Request) {
+	invalidAppPubKey := func(log *slog.Logger, appPubKey string, w http.ResponseWriter) bool {
+		err := checkAppPubKey(options, appPubKey)
+		if err == nil {
+			return false
 		}
-		return matches[1]
+		log.Warn("Invalid application public key",
+			slog.String("error", err.Error()),
+		)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return true
 	}
 
-	return func(w http.ResponseWriter, req *http.Request) {
-		log := l.With(slog.Group("request", "host", req.Host, "method", req.Method, "url", req.URL))
-
-		if strings.HasPrefix(req.URL.Path, "/v1") && !apiKeys[req.Header.Get("Authorization")] {
-			w.WriteHeader(http.StatusUnauthorized)
-			_, err := w.Write([]byte("Unauthorized"))
-			if err != nil {
-				log.Error("Write in Unauthorized request failed")
+	// appHandler validates the application public key of the path before serving the request
+	appHandler := func(handle func(ctx context.Context, meter RelayMeter, l *logger.Logger, appPubKey types.PortalAppPublicKey, w http.ResponseWriter, req *http.Request)) routeHandler {
+		return func(w http.ResponseWriter, req *http.Request, log *slog.Logger, appPubKey string) {
+			if invalidAppPubKey(log, appPubKey, w) {
+				return
 			}
+			handle(ctx, meter, l, NormalizePublicKey(types.PortalAppPublicKey(appPubKey)), w, req)
+		}
+	}
 
-			return
+	// listHandler serves the requests whose path has no parameters
+	listHandler := func(handle func(ctx context.Context, meter RelayMeter, l *logger.Logger, w http.ResponseWriter, req *http.Request)) routeHandler {
+		return func(w http.ResponseWriter, req *http.Request, _ *slog.Logger, _ string) {
+			handle(ctx, meter, l, w, req)
 		}
+	}
 
-		if req.Method == http.MethodGet {
-			if req.URL.Path == HEALTH_CHECK_PATH {
-				healthCheck(w, req)
-				return
-			}
+	r := &router{}
 
-			if appPubKey := match(appsRelaysPath, req.URL.Path); appPubKey != "" {
-				handleAppRelays(ctx, meter, l, types.PortalAppPublicKey(appPubKey), w, req)
-				return
-			}
+	root := r.group("")
+	root.handle(http.MethodGet, HEALTH_CHECK_PATH+"$", func(w http.ResponseWriter, req *http.Request, _ *slog.Logger, _ string) {
+		healthCheck(w, req)
+	})
+	root.handle(http.MethodGet, READINESS_CHECK_PATH+"$", func(w http.ResponseWriter, req *http.Request, _ *slog.Logger, _ string) {
+		readinessCheck(meter, l, w, req)
+	})
+	// The cache stats expose the meter's internal state, so they require an API key as the API versions
+	root.handle(http.MethodGet, DEBUG_CACHE_PATH+"$", func(w http.ResponseWriter, req *http.Request, _ *slog.Logger, _ string) {
+		cacheStats(meter, l, w, req)
+	})
+	// The metrics are scraped without an API key, as the other paths outside of the API versions
+	metricsHandler := promhttp.Handler()
+	root.handle(http.MethodGet, METRICS_PATH+"$", func(w http.ResponseWriter, req *http.Request, _ *slog.Logger, _ string) {
+		metricsHandler.ServeHTTP(w, req)
+	})
 
-			if userID := match(usersRelaysPath, req.URL.Path); userID != "" {
-				handleUserRelays(ctx, meter, l, types.UserID(userID), w, req)
-				return
-			}
+	// The order of registration matters: a path is served by the first matching route,
+	// so the routes with parameters must precede the routes matching their path's prefix.
+	v1 := r.group(API_V1_PREFIX)
+	// Registered before the app relays path, which would otherwise serve them as the relays of apps named "active" and "failing"
+	v1.handle(http.MethodGet, activeAppsPath, listHandler(handleActiveApps))
+	v1.handle(http.MethodGet, failingAppsPath, listHandler(handleAppsFullyFailing))
+	v1.handle(http.MethodGet, appsRelaysPath, appHandler(handleAppRelays))
+	v1.handle(http.MethodGet, appErrorsPath, appHandler(handleAppErrors))
+	v1.handle(http.MethodGet, appSLOPath, appHandler(handleAppSLO))
+	v1.handle(http.MethodGet, appHealthPath, appHandler(handleAppHealth))
+	v1.handle(http.MethodGet, appRankPath, appHandler(handleAppRank))
+	v1.handle(http.MethodGet, usersRelaysPath, func(w http.ResponseWriter, req *http.Request, _ *slog.Logger, userID string) {
+		handleUserRelays(ctx, meter, l, types.UserID(userID), w, req)
+	})
+	v1.handle(http.MethodPost, lbsBatchRelaysPath, listHandler(handlePortalAppsRelays))
+	v1.handle(http.MethodGet, lbRelaysPath, func(w http.ResponseWriter, req *http.Request, _ *slog.Logger, portalAppID string) {
+		handlePortalAppRelays(ctx, meter, l, types.PortalAppID(portalAppID), w, req)
+	})
+	v1.handle(http.MethodGet, portalAppOverviewPath, func(w http.ResponseWriter, req *http.Request, _ *slog.Logger, portalAppID string) {
+		handlePortalAppOverview(ctx, meter, l, types.PortalAppID(portalAppID), w, req)
+	})
+	v1.handle(http.MethodGet, appsLatencyPath, appHandler(handleAppLatency))
+	v1.handle(http.MethodGet, allAppsRelaysPath, listHandler(handleAllAppsRelays))
+	v1.handle(http.MethodGet, allLbsRelaysPath, listHandler(handleAllPortalAppsRelays))
+	// The origin is a single path segment: origins containing slashes, e.g. https://portal.pokt.network, are sent URL-encoded
+	v1.handle(http.MethodGet, specificOriginUsagePath, func(w http.ResponseWriter, req *http.Request, _ *slog.Logger, origin string) {
+		handleSpecificOriginClassification(ctx, meter, l, types.PortalAppOrigin(origin), w, req)
+	})
+	v1.handle(http.MethodGet, originUsagePath, listHandler(handleOriginClassification))
+	v1.handle(http.MethodGet, plansRelaysPath, listHandler(handlePlansRelays))
+	v1.handle(http.MethodGet, compareAppsRelaysPath, func(w http.ResponseWriter, req *http.Request, _ *slog.Logger, _ string) {
+		handleCompareAppsRelays(ctx, meter, l, options, w, req)
+	})
+	v1.handle(http.MethodGet, totalRelaysPath, listHandler(handleTotalRelays))
+	v1.handle(http.MethodGet, allAppsLatencyPath, listHandler(handleAllAppsLatency))
+	v1.handle(http.MethodGet, coveragePath, listHandler(handleCoverage))
+	v1.handle(http.MethodGet, appRawDailyRowsPath, appHandler(handleAppRawDailyRows))
+	v1.handle(http.MethodGet, limitsPath, func(w http.ResponseWriter, req *http.Request, _ *slog.Logger, _ string) {
+		limits(meter, l, w, req)
+	})
+	v1.handle(http.MethodPost, relayCountsPath, func(w http.ResponseWriter, req *http.Request, log *slog.Logger, _ string) {
+		if options.ReadOnly {
+			log.Warn("Rejected write request in read-only mode")
+			http.Error(w, "Relay Meter is in read-only mode for maintenance, please retry later", http.StatusServiceUnavailable)
+			return
+		}
+		handleUploadRelayCounts(ctx, meter, l, options.StatusClassification, w, req)
+	})
 
-			if portalAppID := match(lbRelaysPath, req.URL.Path); portalAppID != "" {
-				handlePortalAppRelays(ctx, meter, l, types.PortalAppID(portalAppID), w, req)
-				return
-			}
+	authorizedKeys := options.APIKeys
+	if authorizedKeys == nil {
+		authorizedKeys = &APIKeys{keys: apiKeys}
+	}
 
-			if appPubKey := match(appsLatencyPath, req.URL.Path); appPubKey != "" {
-				handleAppLatency(ctx, meter, l, types.PortalAppPublicKey(appPubKey), w, req)
-				return
-			}
+	return func(w http.ResponseWriter, req *http.Request) {
+		log := l.With(slog.Group("request", "host", req.Host, "method", req.Method, "url", req.URL))
+		defer recoverPanic(log, w)
 
-			if allAppsRelaysPath.Match([]byte(req.URL.Path)) {
-				handleAllAppsRelays(ctx, meter, l, w, req)
-				return
+		if requiresAPIKey(req.URL.Path) && !authorizedKeys.Contains(req.Header.Get("Authorization")) {
+			reason := authFailureInvalidKey
+			if req.Header.Get("Authorization") == "" {
+				reason = authFailureMissingHeader
 			}
+			authFailures.WithLabelValues(reason).Inc()
 
-			if allLbsRelaysPath.Match([]byte(req.URL.Path)) {
-				handleAllPortalAppsRelays(ctx, meter, l, w, req)
-				return
+			w.WriteHeader(http.StatusUnauthorized)
+			_, err := w.Write([]byte("Unauthorized"))
+			if err != nil {
+				log.Error("Write in Unauthorized request failed")
 			}
 
-			if origin := match(specificOriginUsagePath, req.URL.Path); origin != "" {
-				handleSpecificOriginClassification(ctx, meter, l, types.PortalAppOrigin(origin), w, req)
-				return
-			}
+			return
+		}
 
-			if originUsagePath.Match([]byte(req.URL.Path)) {
-				handleOriginClassification(ctx, meter, l, w, req)
+		// The responses to the anonymized API keys are always anonymized: other clients may request it
+		anonymizedAPIKey := options.AnonymizedAPIKeys[req.Header.Get("Authorization")]
+		if anonymizedAPIKey || req.URL.Query().Get(PARAMETER_ANONYMIZE) == "true" {
+			if err := options.Anonymizer.Validate(); err != nil {
+				log.Warn("Anonymization unavailable",
+					slog.String("error", err.Error()),
+				)
+				// The anonymized API keys are never served raw keys: the server is then misconfigured
+				statusCode := http.StatusBadRequest
+				if anonymizedAPIKey {
+					statusCode = http.StatusInternalServerError
+				}
+				http.Error(w, fmt.Sprintf("Anonymization unavailable: %v", err), statusCode)
 				return
 			}
+			req = withAnonymizer(req, options.Anonymizer)
+		}
 
-			if totalRelaysPath.Match([]byte(req.URL.Path)) {
-				handleTotalRelays(ctx, meter, l, w, req)
-				return
-			}
+		// Stale metrics are still served, flagged so clients can warn their users
+		if req.Method == http.MethodGet && strings.HasPrefix(req.URL.Path, API_V1_PREFIX) && len(meter.StaleData()) > 0 {
+			w.Header().Set(HEADER_DATA_STALE, "true")
+		}
 
-			if allAppsLatencyPath.Match([]byte(req.URL.Path)) {
-				handleAllAppsLatency(ctx, meter, l, w, req)
-				return
-			}
+		handler, param, allowed := r.match(req)
+		if handler != nil {
+			handler(w, req, log, param)
+			return
 		}
 
-		if req.Method == http.MethodPost {
-			if relayCountsPath.Match([]byte(req.URL.Path)) {
-				handleUploadRelayCounts(ctx, meter, l, w, req)
-				return
-			}
+		if len(allowed) > 0 {
+			log.Warn("Method not allowed for request endpoint")
+			methodNotAllowed(w, allowed)
+			return
 		}
 
 		log.Warn("Invalid request endpoint")
@@ -1,20 +1,24 @@
 package api
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/pokt-foundation/portal-http-db/v2/types"
 	"github.com/pokt-foundation/utils-go/logger"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 func TestGetHttpServer(t *testing.T) {
@@ -38,6 +42,8 @@ func TestGetHttpServer(t *testing.T) {
 		reqInput           []byte
 		expectedStatusCode int
 		failAuth           bool
+		// listResponse is set for the endpoints responding with a list, rather than a single item
+		listResponse bool
 	}{
 		{
 			name: "Healthcheck",
@@ -83,6 +89,42 @@ func TestGetHttpServer(t *testing.T) {
 			method:             http.MethodGet,
 			expectedStatusCode: http.StatusOK,
 		},
+		{
+			name: "Total relays by day path is handled correctly",
+			url: fmt.Sprintf("http://relay-meter.pokt.network/v1/relays?from=%s&to=%s&byDay=true&strict=true",
+				url.QueryEscape(now.Format(time.RFC3339)),
+				url.QueryEscape(now.Format(time.RFC3339)),
+			),
+			method:             http.MethodGet,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: "App errors path is handled correctly",
+			url: fmt.Sprintf("http://relay-meter.pokt.network/v1/relays/apps/app_1/errors?from=%s&to=%s",
+				url.QueryEscape(now.Format(time.RFC3339)),
+				url.QueryEscape(now.Format(time.RFC3339)),
+			),
+			method:             http.MethodGet,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: "Relays by plan path is handled correctly",
+			url: fmt.Sprintf("http://relay-meter.pokt.network/v1/relays/by-plan?from=%s&to=%s",
+				url.QueryEscape(now.Format(time.RFC3339)),
+				url.QueryEscape(now.Format(time.RFC3339)),
+			),
+			method:             http.MethodGet,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: "Portal app overview path is handled correctly",
+			url: fmt.Sprintf("http://relay-meter.pokt.network/v1/portal-apps/portal_app_1/overview?from=%s&to=%s",
+				url.QueryEscape(now.Format(time.RFC3339)),
+				url.QueryEscape(now.Format(time.RFC3339)),
+			),
+			method:             http.MethodGet,
+			expectedStatusCode: http.StatusOK,
+		},
 		{
 			name: "All load balancers relays path is handled correctly",
 			url: fmt.Sprintf("http://relay-meter.pokt.network/v1/relays/endpoints?from=%s&to=%s",
@@ -100,12 +142,13 @@ func TestGetHttpServer(t *testing.T) {
 		},
 		{
 			name: "Origin usage path is handled correctly",
-			url: fmt.Sprint("http://relay-meter.pokt.network/v1/relays/origin-classification",
+			url: fmt.Sprintf("http://relay-meter.pokt.network/v1/relays/origin-classification?from=%s&to=%s",
 				url.QueryEscape(now.Format(time.RFC3339)),
 				url.QueryEscape(now.Format(time.RFC3339)),
 			),
 			method:             http.MethodGet,
 			expectedStatusCode: http.StatusOK,
+			listResponse:       true,
 		},
 		{
 			name:               "Upload relay counts is handled correctly",
@@ -124,11 +167,18 @@ func TestGetHttpServer(t *testing.T) {
 			failAuth:           true,
 			expectedStatusCode: http.StatusUnauthorized,
 		},
+		{
+			name:               "Failed authorization of the cache stats",
+			url:                "http://relay-meter.pokt.network" + DEBUG_CACHE_PATH,
+			method:             http.MethodGet,
+			failAuth:           true,
+			expectedStatusCode: http.StatusUnauthorized,
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			httpServer := GetHttpServer(context.Background(), &fakeRelayMeter{}, logger.New(), map[string]bool{"dummy": true})
+			httpServer := GetHttpServer(context.Background(), &fakeRelayMeter{}, logger.New(), map[string]bool{"dummy": true}, ServerOptions{})
 
 			req := httptest.NewRequest(tc.method, tc.url, bytes.NewBuffer(tc.reqInput))
 			if !tc.failAuth {
@@ -147,8 +197,11 @@ func TestGetHttpServer(t *testing.T) {
 			if !tc.failAuth {
 				if tc.method == http.MethodGet && req.URL.Path != HEALTH_CHECK_PATH {
 					body, _ := io.ReadAll(resp.Body)
-					var r AppRelaysResponse
-					if err := json.Unmarshal(body, &r); err != nil {
+					var r any = &AppRelaysResponse{}
+					if tc.listResponse {
+						r = &[]json.RawMessage{}
+					}
+					if err := json.Unmarshal(body, r); err != nil {
 						t.Fatalf("Unexpected error unmarhsalling the response: %v", err)
 					}
 				}
@@ -546,139 +599,2564 @@ func TestHandleAllPortalAppsRelays(t *testing.T) {
 	}
 }
 
-type fakeRelayMeter struct {
-	requestedFrom time.Time
-	requestedTo   time.Time
-	requestedApp  types.PortalAppPublicKey
+func TestStrictParameters(t *testing.T) {
+	now, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 
-	response                   AppRelaysResponse
-	allResponse                []AppRelaysResponse
-	loadbalancerRelaysResponse PortalAppRelaysResponse
-	allPortalAppsResponse      []PortalAppRelaysResponse
-	allClassificationsResponse []OriginClassificationsResponse
-	responseErr                error
-	latencyResponse            AppLatencyResponse
-	allLatencyResponse         []AppLatencyResponse
-}
+	testCases := []struct {
+		name               string
+		url                string
+		method             string
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			name: "Typo'd parameter is ignored by default",
+			url: fmt.Sprintf("http://relay-meter.pokt.network/v1/relays/apps/app?form=%s&to=%s",
+				url.QueryEscape(now.Format(time.RFC3339)),
+				url.QueryEscape(now.Format(time.RFC3339)),
+			),
+			method:             http.MethodGet,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: "Typo'd parameters are rejected in strict mode",
+			url: fmt.Sprintf("http://relay-meter.pokt.network/v1/relays/apps/app?form=%s&too=%s&strict=true",
+				url.QueryEscape(now.Format(time.RFC3339)),
+				url.QueryEscape(now.Format(time.RFC3339)),
+			),
+			method:             http.MethodGet,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       "Unknown query parameters: form, too",
+		},
+		{
+			name: "Known parameters are accepted in strict mode",
+			url: fmt.Sprintf("http://relay-meter.pokt.network/v1/relays?from=%s&to=%s&strict=true",
+				url.QueryEscape(now.Format(time.RFC3339)),
+				url.QueryEscape(now.Format(time.RFC3339)),
+			),
+			method:             http.MethodGet,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Upload relay counts rejects unknown parameters in strict mode",
+			url:                "http://relay-meter.pokt.network/v1/relays/counts?from=today&strict=true",
+			method:             http.MethodPost,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       "Unknown query parameters: from",
+		},
+	}
 
-func (f *fakeRelayMeter) AppRelays(ctx context.Context, app types.PortalAppPublicKey, from, to time.Time) (AppRelaysResponse, error) {
-	f.requestedFrom = from
-	f.requestedTo = to
-	f.requestedApp = app
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			httpServer := GetHttpServer(context.Background(), &fakeRelayMeter{}, logger.New(), map[string]bool{"dummy": true}, ServerOptions{})
 
-	return f.response, f.responseErr
-}
+			req := httptest.NewRequest(tc.method, tc.url, bytes.NewBufferString("[]"))
+			req.Header.Add("Authorization", "dummy")
+			w := httptest.NewRecorder()
 
-func (f *fakeRelayMeter) AllAppsRelays(ctx context.Context, from, to time.Time) ([]AppRelaysResponse, error) {
-	f.requestedFrom = from
-	f.requestedTo = to
+			httpServer(w, req)
 
-	return f.allResponse, f.responseErr
-}
+			resp := w.Result()
+			if resp.StatusCode != tc.expectedStatusCode {
+				t.Fatalf("Expected status code: %d, got: %d", tc.expectedStatusCode, resp.StatusCode)
+			}
 
-func (f *fakeRelayMeter) UserRelays(ctx context.Context, user types.UserID, from, to time.Time) (UserRelaysResponse, error) {
-	return UserRelaysResponse{}, nil
+			body, _ := io.ReadAll(resp.Body)
+			if !strings.Contains(string(body), tc.expectedBody) {
+				t.Errorf("Expected body to contain: %q, got: %q", tc.expectedBody, string(body))
+			}
+		})
+	}
 }
 
-func (f *fakeRelayMeter) TotalRelays(ctx context.Context, from, to time.Time) (TotalRelaysResponse, error) {
-	return TotalRelaysResponse{}, nil
-}
+func TestAppPubKeyValidation(t *testing.T) {
+	validKey := "2585504a028b138b4b535d2351bc45260a3de9cd66305a854049d1a5143392a1"
 
-func (f *fakeRelayMeter) PortalAppRelays(ctx context.Context, portalAppID types.PortalAppID, from, to time.Time) (PortalAppRelaysResponse, error) {
-	f.requestedFrom = from
-	f.requestedTo = to
-	return f.loadbalancerRelaysResponse, f.responseErr
-}
+	testCases := []struct {
+		name               string
+		path               string
+		options            ServerOptions
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			name:               "Too short key is accepted when validation is disabled",
+			path:               "/v1/relays/apps/x",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Too short key is rejected for app relays",
+			path:               "/v1/relays/apps/x",
+			options:            ServerOptions{ValidateAppKeys: true},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       "Invalid application public key: x",
+		},
+		{
+			name:               "Too short key is rejected for app latency",
+			path:               "/v1/latency/apps/test_34715cae753e67c75fbb340442e7de8e",
+			options:            ServerOptions{ValidateAppKeys: true},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       "Invalid application public key: test_34715cae753e67c75fbb340442e7de8e",
+		},
+		{
+			name:               "Non-hex key is rejected",
+			path:               "/v1/relays/apps/" + strings.Repeat("z", 64),
+			options:            ServerOptions{ValidateAppKeys: true},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Valid key is accepted",
+			path:               "/v1/relays/apps/" + validKey,
+			options:            ServerOptions{ValidateAppKeys: true},
+			expectedStatusCode: http.StatusOK,
+		},
+	}
 
-func (f *fakeRelayMeter) AllPortalAppsRelays(ctx context.Context, from, to time.Time) ([]PortalAppRelaysResponse, error) {
-	f.requestedFrom = from
-	f.requestedTo = to
-	return f.allPortalAppsResponse, f.responseErr
-}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			httpServer := GetHttpServer(context.Background(), &fakeRelayMeter{}, logger.New(), map[string]bool{"dummy": true}, tc.options)
 
-func (f *fakeRelayMeter) AllRelaysOrigin(ctx context.Context, from, to time.Time) ([]OriginClassificationsResponse, error) {
-	f.requestedFrom = from
-	f.requestedTo = to
-	return f.allClassificationsResponse, f.responseErr
-}
+			req := httptest.NewRequest(http.MethodGet, "http://relay-meter.pokt.network"+tc.path, nil)
+			req.Header.Add("Authorization", "dummy")
+			w := httptest.NewRecorder()
 
-func (f *fakeRelayMeter) RelaysOrigin(ctx context.Context, origin types.PortalAppOrigin, from, to time.Time) (OriginClassificationsResponse, error) {
-	f.requestedFrom = from
-	f.requestedTo = to
-	return f.allClassificationsResponse[0], f.responseErr
-}
+			httpServer(w, req)
 
-func (f *fakeRelayMeter) AllAppsLatencies(ctx context.Context) ([]AppLatencyResponse, error) {
-	return f.allLatencyResponse, f.responseErr
-}
+			resp := w.Result()
+			if resp.StatusCode != tc.expectedStatusCode {
+				t.Fatalf("Expected status code: %d, got: %d", tc.expectedStatusCode, resp.StatusCode)
+			}
 
-func (f *fakeRelayMeter) AppLatency(ctx context.Context, appPubKey types.PortalAppPublicKey) (AppLatencyResponse, error) {
-	f.requestedApp = appPubKey
-	return f.latencyResponse, f.responseErr
+			body, _ := io.ReadAll(resp.Body)
+			if !strings.Contains(string(body), tc.expectedBody) {
+				t.Errorf("Expected body to contain: %q, got: %q", tc.expectedBody, string(body))
+			}
+		})
+	}
 }
 
-func (f *fakeRelayMeter) WriteHTTPSourceRelayCounts(ctx context.Context, counts []HTTPSourceRelayCount) error {
-	return nil
+func TestAppPubKeyNormalization(t *testing.T) {
+	fakeMeter := fakeRelayMeter{}
+	httpServer := GetHttpServer(context.Background(), &fakeMeter, logger.New(), map[string]bool{"dummy": true}, ServerOptions{ValidateAppKeys: true})
+
+	req := httptest.NewRequest(http.MethodGet, "http://relay-meter.pokt.network/v1/relays/apps/2585504A028B138B4B535D2351BC45260A3DE9CD66305A854049D1A5143392A1", nil)
+	req.Header.Add("Authorization", "dummy")
+	w := httptest.NewRecorder()
+
+	httpServer(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code: %d, got: %d", http.StatusOK, w.Code)
+	}
+	expected := types.PortalAppPublicKey("2585504a028b138b4b535d2351bc45260a3de9cd66305a854049d1a5143392a1") // pragma: allowlist secret
+	if fakeMeter.requestedApp != expected {
+		t.Errorf("Expected app: %s, got: %s", expected, fakeMeter.requestedApp)
+	}
 }
 
-func TestTimePeriod(t *testing.T) {
-	// Convert to time.RFC3339, i.e. the maximum granularity for our routines, before using the timestamp
-	now, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+func TestAnonymizedResponses(t *testing.T) {
+	rawKey := "2585504a028b138b4b535d2351bc45260a3de9cd66305a854049d1a5143392a1" // pragma: allowlist secret
+	options := ServerOptions{
+		Anonymizer:        Anonymizer{Secret: "secret"},
+		AnonymizedAPIKeys: map[string]bool{"shared": true},
+	}
 
 	testCases := []struct {
-		name         string
-		from         string
-		to           string
-		expectedFrom time.Time
-		expectedTo   time.Time
-		expectedErr  bool
+		name             string
+		apiKey           string
+		query            string
+		expectAnonymized bool
 	}{
 		{
-			name:         "Both parameters specified correctly",
-			from:         now.Format(time.RFC3339),
-			to:           now.Add(96 * time.Hour).Format(time.RFC3339),
-			expectedFrom: now,
-			expectedTo:   now.Add(96 * time.Hour),
+			name:   "Raw keys are returned by default",
+			apiKey: "dummy",
 		},
 		{
-			name:       "Missing 'from' parameter returns empty",
-			to:         now.Format(time.RFC3339),
-			expectedTo: now,
+			name:             "Keys are anonymized on request",
+			apiKey:           "dummy",
+			query:            "?anonymize=true&strict=true",
+			expectAnonymized: true,
 		},
 		{
-			name:         "Missing 'to' parameter returns empty",
-			from:         now.Format(time.RFC3339),
-			expectedFrom: now,
+			name:             "Keys are always anonymized for the anonymized API keys",
+			apiKey:           "shared",
+			query:            "?anonymize=false",
+			expectAnonymized: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeMeter := fakeRelayMeter{
+				allResponse: []AppRelaysResponse{{PublicKey: types.PortalAppPublicKey(rawKey), Count: RelayCounts{Success: 1}}},
+			}
+			httpServer := GetHttpServer(context.Background(), &fakeMeter, logger.New(), map[string]bool{"dummy": true, "shared": true}, options)
+
+			req := httptest.NewRequest(http.MethodGet, "http://relay-meter.pokt.network/v1/relays/apps"+tc.query, nil)
+			req.Header.Add("Authorization", tc.apiKey)
+			w := httptest.NewRecorder()
+
+			httpServer(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status code: %d, got: %d", http.StatusOK, w.Code)
+			}
+			body := w.Body.String()
+			if leaked := strings.Contains(body, rawKey); leaked == tc.expectAnonymized {
+				t.Errorf("Expected raw key in the response: %t, got: %s", !tc.expectAnonymized, body)
+			}
+			if anonymized := strings.Contains(body, string(options.Anonymizer.Key(types.PortalAppPublicKey(rawKey)))); anonymized != tc.expectAnonymized {
+				t.Errorf("Expected anonymized key in the response: %t, got: %s", tc.expectAnonymized, body)
+			}
+		})
+	}
+}
+
+func TestAnonymizationWithoutSecret(t *testing.T) {
+	rawKey := "2585504a028b138b4b535d2351bc45260a3de9cd66305a854049d1a5143392a1" // pragma: allowlist secret
+	options := ServerOptions{AnonymizedAPIKeys: map[string]bool{"shared": true}}
+
+	testCases := []struct {
+		name               string
+		apiKey             string
+		query              string
+		expectedStatusCode int
+	}{
+		{
+			name:               "Raw keys are returned by default",
+			apiKey:             "dummy",
+			expectedStatusCode: http.StatusOK,
 		},
 		{
-			name:        "Invalid 'from' parameter returns error",
-			from:        "invalid-date",
-			expectedErr: true,
+			name:               "Anonymization requests are rejected",
+			apiKey:             "dummy",
+			query:              "?anonymize=true",
+			expectedStatusCode: http.StatusBadRequest,
 		},
 		{
-			name:        "Invalid 'to' parameter returns error",
-			from:        "invalid-date",
-			expectedErr: true,
+			name:               "Anonymized API keys are not served raw keys",
+			apiKey:             "shared",
+			expectedStatusCode: http.StatusInternalServerError,
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			url := fmt.Sprintf("http://relay-meter.pokt.network/v1/relays/apps/app1?from=%s&to=%s", url.QueryEscape(tc.from), url.QueryEscape(tc.to))
-			req := httptest.NewRequest("GET", url, nil)
-			gotFrom, gotTo, err := timePeriod(req)
-			if err != nil {
-				if !tc.expectedErr {
-					t.Fatalf("Unexpected error: %v", err)
-				}
-				return
+			fakeMeter := fakeRelayMeter{
+				allResponse: []AppRelaysResponse{{PublicKey: types.PortalAppPublicKey(rawKey), Count: RelayCounts{Success: 1}}},
 			}
-			if !tc.expectedFrom.Equal(gotFrom) {
-				t.Errorf("Expected: %v, got: %v", tc.expectedFrom, gotFrom)
+			httpServer := GetHttpServer(context.Background(), &fakeMeter, logger.New(), map[string]bool{"dummy": true, "shared": true}, options)
+
+			req := httptest.NewRequest(http.MethodGet, "http://relay-meter.pokt.network/v1/relays/apps"+tc.query, nil)
+			req.Header.Add("Authorization", tc.apiKey)
+			w := httptest.NewRecorder()
+
+			httpServer(w, req)
+
+			if w.Code != tc.expectedStatusCode {
+				t.Fatalf("Expected status code: %d, got: %d", tc.expectedStatusCode, w.Code)
 			}
-			if !gotTo.Equal(tc.expectedTo) {
-				t.Errorf("Expected: %v, got: %v", tc.expectedTo, gotTo)
+			if tc.expectedStatusCode != http.StatusOK && strings.Contains(w.Body.String(), rawKey) {
+				t.Errorf("Expected no raw key in the response, got: %s", w.Body.String())
 			}
 		})
 	}
 }
+
+func TestAuthFailuresMetric(t *testing.T) {
+	testCases := []struct {
+		name           string
+		authorization  string
+		expectedReason string
+	}{
+		{
+			name:           "Invalid key is counted",
+			authorization:  "bad-key",
+			expectedReason: authFailureInvalidKey,
+		},
+		{
+			name:           "Missing header is counted",
+			expectedReason: authFailureMissingHeader,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			httpServer := GetHttpServer(context.Background(), &fakeRelayMeter{}, logger.New(), map[string]bool{"dummy": true}, ServerOptions{})
+
+			before := testutil.ToFloat64(authFailures.WithLabelValues(tc.expectedReason))
+
+			req := httptest.NewRequest(http.MethodGet, "http://relay-meter.pokt.network/v1/relays", nil)
+			if tc.authorization != "" {
+				req.Header.Add("Authorization", tc.authorization)
+			}
+			w := httptest.NewRecorder()
+
+			httpServer(w, req)
+
+			if w.Result().StatusCode != http.StatusUnauthorized {
+				t.Fatalf("Expected status code: %d, got: %d", http.StatusUnauthorized, w.Result().StatusCode)
+			}
+
+			after := testutil.ToFloat64(authFailures.WithLabelValues(tc.expectedReason))
+			if after != before+1 {
+				t.Errorf("Expected %s auth failures to increment from %v, got: %v", tc.expectedReason, before, after)
+			}
+		})
+	}
+}
+
+func TestReadOnlyMode(t *testing.T) {
+	rawInput, _ := json.Marshal([]HTTPSourceRelayCountInput{{AppPublicKey: "21", Success: 21, Error: 7}})
+
+	testCases := []struct {
+		name               string
+		method             string
+		url                string
+		reqInput           []byte
+		options            ServerOptions
+		expectedStatusCode int
+	}{
+		{
+			name:               "Writes are accepted by default",
+			method:             http.MethodPost,
+			url:                "http://relay-meter.pokt.network/v1/relays/counts",
+			reqInput:           rawInput,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Writes are rejected in read-only mode",
+			method:             http.MethodPost,
+			url:                "http://relay-meter.pokt.network/v1/relays/counts",
+			reqInput:           rawInput,
+			options:            ServerOptions{ReadOnly: true},
+			expectedStatusCode: http.StatusServiceUnavailable,
+		},
+		{
+			name:               "Reads succeed in read-only mode",
+			method:             http.MethodGet,
+			url:                "http://relay-meter.pokt.network/v1/relays/apps/app",
+			options:            ServerOptions{ReadOnly: true},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Health check succeeds in read-only mode",
+			method:             http.MethodGet,
+			url:                "http://relay-meter.pokt.network" + HEALTH_CHECK_PATH,
+			options:            ServerOptions{ReadOnly: true},
+			expectedStatusCode: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			httpServer := GetHttpServer(context.Background(), &fakeRelayMeter{}, logger.New(), map[string]bool{"dummy": true}, tc.options)
+
+			req := httptest.NewRequest(tc.method, tc.url, bytes.NewBuffer(tc.reqInput))
+			req.Header.Add("Authorization", "dummy")
+			w := httptest.NewRecorder()
+
+			httpServer(w, req)
+
+			if w.Result().StatusCode != tc.expectedStatusCode {
+				t.Fatalf("Expected status code: %d, got: %d", tc.expectedStatusCode, w.Result().StatusCode)
+			}
+		})
+	}
+}
+
+func TestListResponseEnvelope(t *testing.T) {
+	now, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
+
+	meterResponse := []AppRelaysResponse{
+		{
+			PublicKey: "app1",
+			From:      now,
+			To:        now.AddDate(0, 0, 1),
+			Count:     RelayCounts{Success: 62, Failure: 58},
+		},
+		{
+			PublicKey: "app2",
+			From:      now,
+			To:        now.AddDate(0, 0, 1),
+			Count:     RelayCounts{Success: 2, Failure: 8},
+		},
+	}
+
+	testCases := []struct {
+		name             string
+		query            string
+		header           string
+		expectedEnvelope bool
+	}{
+		{
+			name: "Bare list is returned by default",
+		},
+		{
+			name:             "Envelope is returned with the v=2 query parameter",
+			query:            "&v=2",
+			expectedEnvelope: true,
+		},
+		{
+			name:             "Envelope is returned with the version header",
+			header:           ENVELOPE_VERSION,
+			expectedEnvelope: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeMeter := fakeRelayMeter{
+				allResponse: meterResponse,
+				generation:  7,
+			}
+
+			url := fmt.Sprintf("http://relay-meter.pokt.network/v1/relays/apps?from=%s&to=%s%s",
+				url.QueryEscape(now.Format(time.RFC3339)),
+				url.QueryEscape(now.Format(time.RFC3339)),
+				tc.query,
+			)
+			req := httptest.NewRequest("GET", url, nil)
+			if tc.header != "" {
+				req.Header.Add(HEADER_VERSION, tc.header)
+			}
+			w := httptest.NewRecorder()
+
+			handleAllAppsRelays(context.Background(), &fakeMeter, logger.New(), w, req)
+
+			resp := w.Result()
+			body, _ := io.ReadAll(resp.Body)
+
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("Expected status code: %d, got: %d", http.StatusOK, resp.StatusCode)
+			}
+
+			if !tc.expectedEnvelope {
+				var r []AppRelaysResponse
+				if err := json.Unmarshal(body, &r); err != nil {
+					t.Fatalf("Unexpected error unmarhsalling the response: %v", err)
+				}
+				if diff := cmp.Diff(meterResponse, r); diff != "" {
+					t.Errorf("unexpected value (-want +got):\n%s", diff)
+				}
+				return
+			}
+
+			var r ListResponse[AppRelaysResponse]
+			if err := json.Unmarshal(body, &r); err != nil {
+				t.Fatalf("Unexpected error unmarhsalling the response: %v", err)
+			}
+
+			expected := ListResponse[AppRelaysResponse]{
+				Data: meterResponse,
+				Meta: ListResponseMeta{
+					From:       now,
+					To:         now.AddDate(0, 0, 1),
+					Total:      2,
+					Generation: 7,
+				},
+			}
+			if diff := cmp.Diff(expected, r); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCompactListResponseEnvelope(t *testing.T) {
+	now, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
+	from := now.AddDate(0, 0, -2)
+	to := now.AddDate(0, 0, 1)
+	effectiveFrom := now.AddDate(0, 0, -1)
+
+	fakeMeter := fakeRelayMeter{
+		allResponse: []AppRelaysResponse{
+			{PublicKey: "app1", From: from, To: to, Count: RelayCounts{Success: 62, Failure: 58}},
+			{PublicKey: "app2", From: effectiveFrom, To: to, Count: RelayCounts{Success: 2, Failure: 8}},
+		},
+		generation: 7,
+	}
+
+	url := fmt.Sprintf("http://relay-meter.pokt.network/v1/relays/apps?from=%s&to=%s&v=3",
+		url.QueryEscape(from.Format(time.RFC3339)),
+		url.QueryEscape(now.Format(time.RFC3339)),
+	)
+	req := httptest.NewRequest("GET", url, nil)
+	w := httptest.NewRecorder()
+
+	handleAllAppsRelays(context.Background(), &fakeMeter, logger.New(), w, req)
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status code: %d, got: %d", http.StatusOK, resp.StatusCode)
+	}
+
+	var r ListResponse[map[string]any]
+	if err := json.Unmarshal(body, &r); err != nil {
+		t.Fatalf("Unexpected error unmarhsalling the response: %v", err)
+	}
+
+	expectedMeta := ListResponseMeta{From: from, To: to, Total: 2, Generation: 7}
+	if diff := cmp.Diff(expectedMeta, r.Meta); diff != "" {
+		t.Errorf("unexpected value (-want +got):\n%s", diff)
+	}
+
+	// Only the app whose time period starts later than the envelope's reports its start
+	expected := []map[string]any{
+		{"Application": "app1", "Count": map[string]any{"Success": float64(62), "Failure": float64(58)}},
+		{"Application": "app2", "Count": map[string]any{"Success": float64(2), "Failure": float64(8)}, "From": effectiveFrom.Format(time.RFC3339)},
+	}
+	if diff := cmp.Diff(expected, r.Data); diff != "" {
+		t.Errorf("unexpected value (-want +got):\n%s", diff)
+	}
+}
+
+func TestListResponseEnvelopeArchiveAge(t *testing.T) {
+	now, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
+	meter := &relayMeter{
+		Backend: &fakeBackend{
+			usage:       fakeDailyMetrics(),
+			todaysUsage: fakeTodaysMetrics(),
+		},
+		Logger:            logger.New(),
+		RelayMeterOptions: RelayMeterOptions{MaxPastDays: 3 * 24 * time.Hour},
+	}
+	if err := meter.loadData(now.AddDate(0, 0, -7), now); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	httpServer := GetHttpServer(context.Background(), meter, logger.New(), map[string]bool{"dummy": true}, ServerOptions{})
+
+	// The requested start precedes the archived metrics, so the meter clamps it to their first day
+	firstArchivedDay := now.AddDate(0, 0, -3)
+	for _, version := range []string{ENVELOPE_VERSION, COMPACT_ENVELOPE_VERSION} {
+		t.Run("v"+version, func(t *testing.T) {
+			url := fmt.Sprintf("http://relay-meter.pokt.network/v1/relays/apps?from=%s&to=%s&v=%s",
+				url.QueryEscape(now.AddDate(0, 0, -30).Format(time.RFC3339)),
+				url.QueryEscape(now.Format(time.RFC3339)),
+				version,
+			)
+			req := httptest.NewRequest(http.MethodGet, url, nil)
+			req.Header.Add("Authorization", "dummy")
+			w := httptest.NewRecorder()
+
+			httpServer(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status code: %d, got: %d", http.StatusOK, w.Code)
+			}
+
+			var r ListResponse[map[string]any]
+			if err := json.Unmarshal(w.Body.Bytes(), &r); err != nil {
+				t.Fatalf("Unexpected error unmarhsalling the response: %v", err)
+			}
+			if !r.Meta.From.Equal(firstArchivedDay) {
+				t.Errorf("Expected the envelope's from: %v, got: %v", firstArchivedDay, r.Meta.From)
+			}
+			if len(r.Data) == 0 {
+				t.Fatalf("Expected the apps' relays, got: %s", w.Body.String())
+			}
+
+			for _, item := range r.Data {
+				from, ok := item["From"]
+				// The compact entries only report a start differing from the envelope's
+				if version == COMPACT_ENVELOPE_VERSION {
+					if ok {
+						t.Errorf("Expected no from for %v, got: %v", item["Application"], from)
+					}
+					continue
+				}
+				if from != firstArchivedDay.Format(time.RFC3339) {
+					t.Errorf("Expected from: %s for %v, got: %v", firstArchivedDay.Format(time.RFC3339), item["PublicKey"], from)
+				}
+			}
+		})
+	}
+}
+
+func TestOriginClassificationExcludeOrigins(t *testing.T) {
+	origins := []OriginClassificationsResponse{
+		{Origin: "https://portal.pokt.network", Count: RelayCounts{Success: 10, Failure: 1}},
+		{Origin: "bot", Count: RelayCounts{Success: 20, Failure: 2}},
+		{Origin: "test", Count: RelayCounts{Success: 30, Failure: 3}},
+	}
+
+	testCases := []struct {
+		name     string
+		query    string
+		expected []OriginClassificationsResponse
+	}{
+		{
+			name:     "All origins are returned without exclusions",
+			expected: origins,
+		},
+		{
+			name:     "Listed origins are excluded",
+			query:    "?excludeOrigins=bot,test&strict=true",
+			expected: origins[:1],
+		},
+		{
+			name:     "Unknown and empty origins are ignored",
+			query:    "?excludeOrigins=unknown,,%20test",
+			expected: origins[:2],
+		},
+		{
+			name:     "All origins can be excluded",
+			query:    "?excludeOrigins=https://portal.pokt.network,bot,test",
+			expected: []OriginClassificationsResponse{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeMeter := fakeRelayMeter{allClassificationsResponse: origins}
+			httpServer := GetHttpServer(context.Background(), &fakeMeter, logger.New(), map[string]bool{"dummy": true}, ServerOptions{})
+
+			req := httptest.NewRequest(http.MethodGet, "http://relay-meter.pokt.network/v1/relays/origin-classification"+tc.query, nil)
+			req.Header.Add("Authorization", "dummy")
+			w := httptest.NewRecorder()
+
+			httpServer(w, req)
+
+			resp := w.Result()
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("Expected status code: %d, got: %d", http.StatusOK, resp.StatusCode)
+			}
+
+			var got []OriginClassificationsResponse
+			if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestOriginClassificationTopOrigins(t *testing.T) {
+	origins := []OriginClassificationsResponse{
+		{Origin: "origin1", Count: RelayCounts{Success: 30}},
+		{Origin: "origin2", Count: RelayCounts{Success: 20}},
+		{Origin: "origin3", Count: RelayCounts{Success: 10}},
+	}
+
+	testCases := []struct {
+		name               string
+		query              string
+		expectedStatusCode int
+		expectedLimit      int
+		expectedOrder      OriginOrder
+		expectedOrigins    []types.PortalAppOrigin
+	}{
+		{
+			name:               "All origins are returned by default",
+			expectedStatusCode: http.StatusOK,
+			expectedOrigins:    []types.PortalAppOrigin{"origin1", "origin2", "origin3"},
+		},
+		{
+			name:               "Limit and order are passed to the meter",
+			query:              "?limit=2&by=failure",
+			expectedStatusCode: http.StatusOK,
+			expectedLimit:      2,
+			expectedOrder:      OriginsByFailure,
+			expectedOrigins:    []types.PortalAppOrigin{"origin1", "origin2"},
+		},
+		{
+			name:               "Excluded origins do not count towards the limit",
+			query:              "?limit=2&excludeOrigins=origin1",
+			expectedStatusCode: http.StatusOK,
+			expectedLimit:      3,
+			expectedOrigins:    []types.PortalAppOrigin{"origin2", "origin3"},
+		},
+		{
+			name:               "Invalid limit is rejected",
+			query:              "?limit=-1",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Invalid order is rejected",
+			query:              "?by=latency",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeMeter := fakeRelayMeter{allClassificationsResponse: origins}
+			httpServer := GetHttpServer(context.Background(), &fakeMeter, logger.New(), map[string]bool{"dummy": true}, ServerOptions{})
+
+			req := httptest.NewRequest(http.MethodGet, "http://relay-meter.pokt.network/v1/relays/origin-classification"+tc.query, nil)
+			req.Header.Add("Authorization", "dummy")
+			w := httptest.NewRecorder()
+
+			httpServer(w, req)
+
+			if w.Code != tc.expectedStatusCode {
+				t.Fatalf("Expected status code: %d, got: %d", tc.expectedStatusCode, w.Code)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+			if fakeMeter.requestedLimit != tc.expectedLimit || fakeMeter.requestedOrder != tc.expectedOrder {
+				t.Errorf("Expected limit: %d and order: %q, got: %d and %q", tc.expectedLimit, tc.expectedOrder, fakeMeter.requestedLimit, fakeMeter.requestedOrder)
+			}
+
+			var resp []OriginClassificationsResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Unexpected error unmarhsalling the response: %v", err)
+			}
+			var got []types.PortalAppOrigin
+			for _, origin := range resp {
+				got = append(got, origin.Origin)
+			}
+			if diff := cmp.Diff(tc.expectedOrigins, got); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestOriginClassificationRouting(t *testing.T) {
+	testCases := []struct {
+		name               string
+		path               string
+		expectedStatusCode int
+		expectedCall       string
+		expectedOrigin     types.PortalAppOrigin
+	}{
+		{
+			name:               "Path without an origin serves all origins",
+			path:               "/v1/relays/origin-classification",
+			expectedStatusCode: http.StatusOK,
+			expectedCall:       "AllRelaysOrigin",
+		},
+		{
+			name:               "Origin is read from the path",
+			path:               "/v1/relays/origin-classification/app.test1.io",
+			expectedStatusCode: http.StatusOK,
+			expectedCall:       "RelaysOrigin",
+			expectedOrigin:     "app.test1.io",
+		},
+		{
+			name:               "Origin with encoded special characters is decoded",
+			path:               "/v1/relays/origin-classification/" + url.PathEscape("https://app.test1.io:8080/path?query=1#fragment"),
+			expectedStatusCode: http.StatusOK,
+			expectedCall:       "RelaysOrigin",
+			expectedOrigin:     "https://app.test1.io:8080/path?query=1#fragment",
+		},
+		// The paths not matching the origin routes fall through to the total relays route, which matches any path starting with /relays
+		{
+			name:               "Origin spanning several path segments is not served as an origin",
+			path:               "/v1/relays/origin-classification/app.test1.io/extra",
+			expectedStatusCode: http.StatusOK,
+			expectedCall:       "TotalRelays",
+		},
+		{
+			name:               "Path extending the origins path is not served as the origins",
+			path:               "/v1/relays/origin-classifications",
+			expectedStatusCode: http.StatusOK,
+			expectedCall:       "TotalRelays",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeMeter := fakeRelayMeter{
+				allClassificationsResponse: []OriginClassificationsResponse{{Origin: "app.test1.io"}},
+			}
+			httpServer := GetHttpServer(context.Background(), &fakeMeter, logger.New(), map[string]bool{"dummy": true}, ServerOptions{})
+
+			req := httptest.NewRequest(http.MethodGet, "http://relay-meter.pokt.network"+tc.path, nil)
+			req.Header.Add("Authorization", "dummy")
+			w := httptest.NewRecorder()
+
+			httpServer(w, req)
+
+			if w.Code != tc.expectedStatusCode {
+				t.Fatalf("Expected status code: %d, got: %d", tc.expectedStatusCode, w.Code)
+			}
+			if fakeMeter.called != tc.expectedCall {
+				t.Errorf("Expected meter call: %q, got: %q", tc.expectedCall, fakeMeter.called)
+			}
+			if fakeMeter.requestedOrigin != tc.expectedOrigin {
+				t.Errorf("Expected origin: %q, got: %q", tc.expectedOrigin, fakeMeter.requestedOrigin)
+			}
+		})
+	}
+}
+
+func TestOriginClassificationEmpty(t *testing.T) {
+	testCases := []struct {
+		name               string
+		response           []OriginClassificationsResponse
+		responseErr        error
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			name:               "Empty origins are serialized as an empty list",
+			response:           []OriginClassificationsResponse{},
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "[]",
+		},
+		{
+			name:               "Missing origins are serialized as an empty list",
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "[]",
+		},
+		{
+			name:               "Invalid timespan is rejected as a bad request",
+			responseErr:        fmt.Errorf("%w: from -- to", ErrInvalidTimespan),
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeMeter := fakeRelayMeter{allClassificationsResponse: tc.response, responseErr: tc.responseErr}
+			httpServer := GetHttpServer(context.Background(), &fakeMeter, logger.New(), map[string]bool{"dummy": true}, ServerOptions{})
+
+			req := httptest.NewRequest(http.MethodGet, "http://relay-meter.pokt.network/v1/relays/origin-classification", nil)
+			req.Header.Add("Authorization", "dummy")
+			w := httptest.NewRecorder()
+
+			httpServer(w, req)
+
+			if w.Code != tc.expectedStatusCode {
+				t.Fatalf("Expected status code: %d, got: %d", tc.expectedStatusCode, w.Code)
+			}
+			if tc.expectedBody != "" && w.Body.String() != tc.expectedBody {
+				t.Errorf("Expected body: %q, got: %q", tc.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandlerPanicRecovery(t *testing.T) {
+	fakeMeter := fakeRelayMeter{panicOnAppRelays: true}
+	httpServer := GetHttpServer(context.Background(), &fakeMeter, logger.New(), map[string]bool{"dummy": true}, ServerOptions{})
+
+	serve := func(path string) int {
+		req := httptest.NewRequest(http.MethodGet, "http://relay-meter.pokt.network"+path, nil)
+		req.Header.Add("Authorization", "dummy")
+		w := httptest.NewRecorder()
+
+		httpServer(w, req)
+		return w.Code
+	}
+
+	if code := serve("/v1/relays/apps/app_1"); code != http.StatusInternalServerError {
+		t.Fatalf("Expected status code: %d, got: %d", http.StatusInternalServerError, code)
+	}
+
+	// The server keeps serving requests after a handler panicked
+	if code := serve("/v1/relays"); code != http.StatusOK {
+		t.Errorf("Expected status code: %d, got: %d", http.StatusOK, code)
+	}
+	if code := serve("/v1/relays/apps/app_1"); code != http.StatusInternalServerError {
+		t.Errorf("Expected status code: %d, got: %d", http.StatusInternalServerError, code)
+	}
+}
+
+func TestReadinessCheck(t *testing.T) {
+	testCases := []struct {
+		name               string
+		dataLoaderErr      error
+		expectedStatusCode int
+	}{
+		{
+			name:               "Ready when the data loader is running",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Not ready when the data loader is stalled",
+			dataLoaderErr:      ErrDataLoaderStalled,
+			expectedStatusCode: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeMeter := fakeRelayMeter{dataLoaderErr: tc.dataLoaderErr}
+			httpServer := GetHttpServer(context.Background(), &fakeMeter, logger.New(), map[string]bool{"dummy": true}, ServerOptions{})
+
+			req := httptest.NewRequest(http.MethodGet, "http://relay-meter.pokt.network"+READINESS_CHECK_PATH, nil)
+			w := httptest.NewRecorder()
+
+			httpServer(w, req)
+
+			if w.Result().StatusCode != tc.expectedStatusCode {
+				t.Fatalf("Expected status code: %d, got: %d", tc.expectedStatusCode, w.Result().StatusCode)
+			}
+		})
+	}
+}
+
+type fakeRelayMeter struct {
+	requestedFrom   time.Time
+	requestedTo     time.Time
+	requestedApp    types.PortalAppPublicKey
+	requestedLimit  int
+	requestedApps   []types.PortalAppPublicKey
+	requestedTarget float64
+	requestedOrder  OriginOrder
+	requestedVolume int64
+	requestedOrigin types.PortalAppOrigin
+	requestedPage   Page
+
+	requestedPortalApps []types.PortalAppID
+
+	response                   AppRelaysResponse
+	userResponse               UserRelaysResponse
+	allResponse                []AppRelaysResponse
+	loadbalancerRelaysResponse PortalAppRelaysResponse
+	allPortalAppsResponse      []PortalAppRelaysResponse
+	allClassificationsResponse []OriginClassificationsResponse
+	responseErr                error
+	latencyResponse            AppLatencyResponse
+	allLatencyResponse         []AppLatencyResponse
+	dailyRelaysResponse        []DailyRelaysResponse
+	errorsResponse             []DailyErrorsResponse
+	plansResponse              []PlanRelaysResponse
+	overviewResponse           PortalAppOverviewResponse
+	comparisonResponse         AppsComparisonResponse
+	activeAppsResponse         []types.PortalAppPublicKey
+	coverageResponse           CoverageResponse
+	generation                 uint64
+	dataLoaderErr              error
+	staleNotes                 []string
+	portalAppsStale            bool
+
+	// panicOnAppRelays makes AppRelays panic, as a handler bug would
+	panicOnAppRelays bool
+
+	// called is the name of the last meter method called
+	called        string
+	writtenCounts []HTTPSourceRelayCount
+}
+
+func (f *fakeRelayMeter) AppRelays(ctx context.Context, app types.PortalAppPublicKey, from, to time.Time) (AppRelaysResponse, error) {
+	f.called = "AppRelays"
+	f.requestedFrom = from
+	f.requestedTo = to
+	f.requestedApp = app
+	if f.panicOnAppRelays {
+		panic("app relays failure")
+	}
+
+	return f.response, f.responseErr
+}
+
+func (f *fakeRelayMeter) CompareAppsRelays(ctx context.Context, appA, appB types.PortalAppPublicKey, from, to time.Time) (AppsComparisonResponse, error) {
+	f.called = "CompareAppsRelays"
+	f.requestedFrom = from
+	f.requestedTo = to
+	f.requestedApps = []types.PortalAppPublicKey{appA, appB}
+	return f.comparisonResponse, f.responseErr
+}
+
+func (f *fakeRelayMeter) AppHealth(ctx context.Context, appPubKey types.PortalAppPublicKey, from, to time.Time) (AppHealthResponse, error) {
+	f.called = "AppHealth"
+	f.requestedApp = appPubKey
+	f.requestedFrom = from
+	f.requestedTo = to
+
+	return AppHealthResponse{PublicKey: appPubKey}, f.responseErr
+}
+
+func (f *fakeRelayMeter) AppSLO(ctx context.Context, appPubKey types.PortalAppPublicKey, target float64, from, to time.Time) (AppSLOResponse, error) {
+	f.called = "AppSLO"
+	f.requestedApp = appPubKey
+	f.requestedTarget = target
+	f.requestedFrom = from
+	f.requestedTo = to
+	return AppSLOResponse{PublicKey: appPubKey, Target: target}, f.responseErr
+}
+
+func (f *fakeRelayMeter) AppRank(ctx context.Context, appPubKey types.PortalAppPublicKey, from, to time.Time, by OriginOrder) (AppRankResponse, error) {
+	f.called = "AppRank"
+	f.requestedApp = appPubKey
+	f.requestedOrder = by
+	f.requestedFrom = from
+	f.requestedTo = to
+	return AppRankResponse{PublicKey: appPubKey, By: by}, f.responseErr
+}
+
+func (f *fakeRelayMeter) ActiveApps(ctx context.Context, from, to time.Time) ([]types.PortalAppPublicKey, error) {
+	f.called = "ActiveApps"
+	f.requestedFrom = from
+	f.requestedTo = to
+	return f.activeAppsResponse, f.responseErr
+}
+
+func (f *fakeRelayMeter) AppsFullyFailing(ctx context.Context, from, to time.Time, minVolume int64) ([]AppRelaysResponse, error) {
+	f.called = "AppsFullyFailing"
+	f.requestedFrom = from
+	f.requestedTo = to
+	f.requestedVolume = minVolume
+	return f.allResponse, f.responseErr
+}
+
+func (f *fakeRelayMeter) Coverage(ctx context.Context, from, to time.Time) (CoverageResponse, error) {
+	f.called = "Coverage"
+	f.requestedFrom = from
+	f.requestedTo = to
+	return f.coverageResponse, f.responseErr
+}
+
+func (f *fakeRelayMeter) AllAppsRelays(ctx context.Context, from, to time.Time, page Page) ([]AppRelaysResponse, int, error) {
+	f.called = "AllAppsRelays"
+	f.requestedFrom = from
+	f.requestedTo = to
+	f.requestedPage = page
+
+	return paginate(f.allResponse, page, 0), len(f.allResponse), f.responseErr
+}
+
+func (f *fakeRelayMeter) UserRelays(ctx context.Context, user types.UserID, from, to time.Time) (UserRelaysResponse, error) {
+	f.called = "UserRelays"
+	f.requestedFrom = from
+	f.requestedTo = to
+	return f.userResponse, nil
+}
+
+func (f *fakeRelayMeter) TotalRelays(ctx context.Context, from, to time.Time) (TotalRelaysResponse, error) {
+	f.called = "TotalRelays"
+	f.requestedFrom = from
+	f.requestedTo = to
+	return TotalRelaysResponse{}, nil
+}
+
+func (f *fakeRelayMeter) PlanRelays(ctx context.Context, from, to time.Time) ([]PlanRelaysResponse, error) {
+	f.called = "PlanRelays"
+	f.requestedFrom = from
+	f.requestedTo = to
+	return f.plansResponse, f.responseErr
+}
+
+func (f *fakeRelayMeter) TotalRelaysByDay(ctx context.Context, from, to time.Time) ([]DailyRelaysResponse, error) {
+	f.called = "TotalRelaysByDay"
+	f.requestedFrom = from
+	f.requestedTo = to
+	return f.dailyRelaysResponse, f.responseErr
+}
+
+func (f *fakeRelayMeter) PortalAppRelays(ctx context.Context, portalAppID types.PortalAppID, from, to time.Time) (PortalAppRelaysResponse, error) {
+	f.called = "PortalAppRelays"
+	f.requestedFrom = from
+	f.requestedTo = to
+	return f.loadbalancerRelaysResponse, f.responseErr
+}
+
+func (f *fakeRelayMeter) PortalAppsRelays(ctx context.Context, portalAppIDs []types.PortalAppID, from, to time.Time) ([]PortalAppRelaysResponse, error) {
+	f.called = "PortalAppsRelays"
+	f.requestedFrom = from
+	f.requestedTo = to
+	f.requestedPortalApps = portalAppIDs
+	return f.allPortalAppsResponse, f.responseErr
+}
+
+func (f *fakeRelayMeter) PortalAppOverview(ctx context.Context, portalAppID types.PortalAppID, from, to time.Time) (PortalAppOverviewResponse, error) {
+	f.called = "PortalAppOverview"
+	f.requestedFrom = from
+	f.requestedTo = to
+	return f.overviewResponse, f.responseErr
+}
+
+func (f *fakeRelayMeter) AllPortalAppsRelays(ctx context.Context, from, to time.Time, page Page) ([]PortalAppRelaysResponse, int, error) {
+	f.called = "AllPortalAppsRelays"
+	f.requestedFrom = from
+	f.requestedTo = to
+	f.requestedPage = page
+	return paginate(f.allPortalAppsResponse, page, 0), len(f.allPortalAppsResponse), f.responseErr
+}
+
+func (f *fakeRelayMeter) AllRelaysOrigin(ctx context.Context, from, to time.Time, limit int, by OriginOrder) ([]OriginClassificationsResponse, error) {
+	f.called = "AllRelaysOrigin"
+	f.requestedFrom = from
+	f.requestedTo = to
+	f.requestedLimit = limit
+	f.requestedOrder = by
+	return f.allClassificationsResponse, f.responseErr
+}
+
+func (f *fakeRelayMeter) RelaysOrigin(ctx context.Context, origin types.PortalAppOrigin, from, to time.Time) (OriginClassificationsResponse, error) {
+	f.called = "RelaysOrigin"
+	f.requestedFrom = from
+	f.requestedTo = to
+	f.requestedOrigin = origin
+	return f.allClassificationsResponse[0], f.responseErr
+}
+
+func (f *fakeRelayMeter) AppErrors(ctx context.Context, appPubKey types.PortalAppPublicKey, from, to time.Time) ([]DailyErrorsResponse, error) {
+	f.called = "AppErrors"
+	f.requestedFrom = from
+	f.requestedTo = to
+	f.requestedApp = appPubKey
+	return f.errorsResponse, f.responseErr
+}
+
+func (f *fakeRelayMeter) AllAppsLatencies(ctx context.Context, limit int) ([]AppLatencyResponse, error) {
+	f.called = "AllAppsLatencies"
+	f.requestedLimit = limit
+	return f.allLatencyResponse, f.responseErr
+}
+
+func (f *fakeRelayMeter) AppLatency(ctx context.Context, appPubKey types.PortalAppPublicKey) (AppLatencyResponse, error) {
+	f.called = "AppLatency"
+	f.requestedApp = appPubKey
+	return f.latencyResponse, f.responseErr
+}
+
+func (f *fakeRelayMeter) WriteHTTPSourceRelayCounts(ctx context.Context, counts []HTTPSourceRelayCount) error {
+	f.called = "WriteHTTPSourceRelayCounts"
+	f.writtenCounts = counts
+	return nil
+}
+
+func (f *fakeRelayMeter) ServedTimePeriod(from, to time.Time) (time.Time, time.Time, error) {
+	return AdjustTimePeriod(from, to)
+}
+
+func (f *fakeRelayMeter) DataGeneration() uint64 {
+	return f.generation
+}
+
+func (f *fakeRelayMeter) AppRawDailyRows(ctx context.Context, appPubKey types.PortalAppPublicKey, from, to time.Time) (AppRawDailyRowsResponse, error) {
+	f.called = "AppRawDailyRows"
+	f.requestedApp = appPubKey
+	f.requestedFrom = from
+	f.requestedTo = to
+	return AppRawDailyRowsResponse{}, f.responseErr
+}
+
+func (f *fakeRelayMeter) Limits() LimitsResponse {
+	f.called = "Limits"
+	return LimitsResponse{}
+}
+
+func (f *fakeRelayMeter) CacheStats() CacheStats {
+	f.called = "CacheStats"
+	return CacheStats{}
+}
+
+func (f *fakeRelayMeter) CheckDataLoader() error {
+	f.called = "CheckDataLoader"
+	return f.dataLoaderErr
+}
+
+// StaleData does not record the call, as it is made alongside the call serving the request
+func (f *fakeRelayMeter) StaleData() []string {
+	return f.staleNotes
+}
+
+func (f *fakeRelayMeter) PortalAppsStale() bool {
+	return f.portalAppsStale
+}
+
+func TestStaleDataHeader(t *testing.T) {
+	staleNotes := []string{"Daily metrics are stale: their refresh was due at 2022-07-20T00:00:00Z"}
+
+	testCases := []struct {
+		name          string
+		staleNotes    []string
+		expectedStale bool
+	}{
+		{
+			name: "Fresh data is not flagged",
+		},
+		{
+			name:          "Stale data is flagged",
+			staleNotes:    staleNotes,
+			expectedStale: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeMeter := fakeRelayMeter{
+				allResponse: []AppRelaysResponse{{PublicKey: "app1"}},
+				staleNotes:  tc.staleNotes,
+			}
+			httpServer := GetHttpServer(context.Background(), &fakeMeter, logger.New(), map[string]bool{"dummy": true}, ServerOptions{})
+
+			req := httptest.NewRequest(http.MethodGet, "http://relay-meter.pokt.network/v1/relays/apps?v=2", nil)
+			req.Header.Add("Authorization", "dummy")
+			w := httptest.NewRecorder()
+
+			httpServer(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status code: %d, got: %d", http.StatusOK, w.Code)
+			}
+			if stale := w.Header().Get(HEADER_DATA_STALE) == "true"; stale != tc.expectedStale {
+				t.Errorf("Expected stale data header: %t, got: %t", tc.expectedStale, stale)
+			}
+
+			var r ListResponse[AppRelaysResponse]
+			if err := json.Unmarshal(w.Body.Bytes(), &r); err != nil {
+				t.Fatalf("Unexpected error unmarhsalling the response: %v", err)
+			}
+			if diff := cmp.Diff(tc.staleNotes, r.Meta.Notes); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPortalDataStaleHeader(t *testing.T) {
+	for _, stale := range []bool{false, true} {
+		fakeMeter := fakeRelayMeter{
+			allPortalAppsResponse: []PortalAppRelaysResponse{{PortalAppID: "portal_app_1"}},
+			portalAppsStale:       stale,
+		}
+		httpServer := GetHttpServer(context.Background(), &fakeMeter, logger.New(), map[string]bool{"dummy": true}, ServerOptions{})
+
+		req := httptest.NewRequest(http.MethodGet, "http://relay-meter.pokt.network/v1/relays/endpoints", nil)
+		req.Header.Add("Authorization", "dummy")
+		w := httptest.NewRecorder()
+
+		httpServer(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status code: %d, got: %d", http.StatusOK, w.Code)
+		}
+		if got := w.Header().Get(HEADER_PORTAL_DATA_STALE) == "true"; got != stale {
+			t.Errorf("Expected stale portal data header: %t, got: %t", stale, got)
+		}
+	}
+}
+
+func TestTimePeriod(t *testing.T) {
+	// Convert to time.RFC3339, i.e. the maximum granularity for our routines, before using the timestamp
+	now, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+	testCases := []struct {
+		name         string
+		from         string
+		to           string
+		expectedFrom time.Time
+		expectedTo   time.Time
+		expectedErr  bool
+	}{
+		{
+			name:         "Both parameters specified correctly",
+			from:         now.Format(time.RFC3339),
+			to:           now.Add(96 * time.Hour).Format(time.RFC3339),
+			expectedFrom: now,
+			expectedTo:   now.Add(96 * time.Hour),
+		},
+		{
+			name:       "Missing 'from' parameter returns empty",
+			to:         now.Format(time.RFC3339),
+			expectedTo: now,
+		},
+		{
+			name:         "Missing 'to' parameter returns empty",
+			from:         now.Format(time.RFC3339),
+			expectedFrom: now,
+		},
+		{
+			name:        "Invalid 'from' parameter returns error",
+			from:        "invalid-date",
+			expectedErr: true,
+		},
+		{
+			name:        "Invalid 'to' parameter returns error",
+			from:        "invalid-date",
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			url := fmt.Sprintf("http://relay-meter.pokt.network/v1/relays/apps/app1?from=%s&to=%s", url.QueryEscape(tc.from), url.QueryEscape(tc.to))
+			req := httptest.NewRequest("GET", url, nil)
+			gotFrom, gotTo, err := timePeriod(req)
+			if err != nil {
+				if !tc.expectedErr {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if !tc.expectedFrom.Equal(gotFrom) {
+				t.Errorf("Expected: %v, got: %v", tc.expectedFrom, gotFrom)
+			}
+			if !gotTo.Equal(tc.expectedTo) {
+				t.Errorf("Expected: %v, got: %v", tc.expectedTo, gotTo)
+			}
+		})
+	}
+}
+
+func TestAllAppsLatencyLimit(t *testing.T) {
+	testCases := []struct {
+		name               string
+		query              string
+		expectedStatusCode int
+		expectedLimit      int
+	}{
+		{
+			name:               "All apps are requested without a limit",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Limit is passed to the meter",
+			query:              "?limit=2&strict=true",
+			expectedStatusCode: http.StatusOK,
+			expectedLimit:      2,
+		},
+		{
+			name:               "Non numeric limit is rejected",
+			query:              "?limit=two",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Negative limit is rejected",
+			query:              "?limit=-1",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeMeter := fakeRelayMeter{}
+			httpServer := GetHttpServer(context.Background(), &fakeMeter, logger.New(), map[string]bool{"dummy": true}, ServerOptions{})
+
+			req := httptest.NewRequest(http.MethodGet, "http://relay-meter.pokt.network/v1/latency/apps"+tc.query, nil)
+			req.Header.Add("Authorization", "dummy")
+			w := httptest.NewRecorder()
+
+			httpServer(w, req)
+
+			resp := w.Result()
+			if resp.StatusCode != tc.expectedStatusCode {
+				t.Fatalf("Expected status code: %d, got: %d", tc.expectedStatusCode, resp.StatusCode)
+			}
+			if fakeMeter.requestedLimit != tc.expectedLimit {
+				t.Errorf("Expected limit: %d, got: %d", tc.expectedLimit, fakeMeter.requestedLimit)
+			}
+		})
+	}
+}
+
+func TestLatencyTimezone(t *testing.T) {
+	from := time.Date(2022, time.July, 20, 14, 0, 0, 0, time.UTC)
+	latency := AppLatencyResponse{
+		PublicKey: "app1",
+		From:      from,
+		To:        from.Add(time.Hour),
+		DailyLatency: []Latency{
+			{Time: from, Latency: 0.1, Percentiles: &LatencyPercentiles{P50: 0.08, P95: 0.3, P99: 0.5}},
+			{Time: from.Add(time.Hour), Latency: 0.2},
+		},
+	}
+	// The latencies' values, e.g. their percentiles, are reported unchanged in any time zone
+	expectedPercentiles := []*LatencyPercentiles{{P50: 0.08, P95: 0.3, P99: 0.5}, nil}
+
+	testCases := []struct {
+		name               string
+		path               string
+		query              string
+		expectedStatusCode int
+		expectedFrom       string
+		expectedTimes      []string
+	}{
+		{
+			name:               "Times are reported in UTC by default",
+			path:               "/v1/latency/apps/app1",
+			expectedStatusCode: http.StatusOK,
+			expectedFrom:       "2022-07-20T14:00:00Z",
+			expectedTimes:      []string{"2022-07-20T14:00:00Z", "2022-07-20T15:00:00Z"},
+		},
+		{
+			name:               "Times are reported in the requested time zone",
+			path:               "/v1/latency/apps/app1",
+			query:              "?tz=America/New_York",
+			expectedStatusCode: http.StatusOK,
+			expectedFrom:       "2022-07-20T10:00:00-04:00",
+			expectedTimes:      []string{"2022-07-20T10:00:00-04:00", "2022-07-20T11:00:00-04:00"},
+		},
+		{
+			name:               "Times of all apps are reported in the requested time zone",
+			path:               "/v1/latency/apps",
+			query:              "?tz=Asia/Kolkata&strict=true",
+			expectedStatusCode: http.StatusOK,
+			expectedFrom:       "2022-07-20T19:30:00+05:30",
+			expectedTimes:      []string{"2022-07-20T19:30:00+05:30", "2022-07-20T20:30:00+05:30"},
+		},
+		{
+			name:               "Unknown time zone is rejected",
+			path:               "/v1/latency/apps/app1",
+			query:              "?tz=Mars/Olympus_Mons",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeMeter := fakeRelayMeter{
+				latencyResponse:    latency,
+				allLatencyResponse: []AppLatencyResponse{latency},
+			}
+			httpServer := GetHttpServer(context.Background(), &fakeMeter, logger.New(), map[string]bool{"dummy": true}, ServerOptions{})
+
+			req := httptest.NewRequest(http.MethodGet, "http://relay-meter.pokt.network"+tc.path+tc.query, nil)
+			req.Header.Add("Authorization", "dummy")
+			w := httptest.NewRecorder()
+
+			httpServer(w, req)
+
+			if w.Code != tc.expectedStatusCode {
+				t.Fatalf("Expected status code: %d, got: %d", tc.expectedStatusCode, w.Code)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			var got struct {
+				From         string
+				DailyLatency []struct {
+					Time        string
+					Percentiles *LatencyPercentiles
+				}
+			}
+			body := w.Body.Bytes()
+			if tc.path == "/v1/latency/apps" {
+				var all []json.RawMessage
+				if err := json.Unmarshal(body, &all); err != nil || len(all) != 1 {
+					t.Fatalf("Unexpected response: %s", body)
+				}
+				body = all[0]
+			}
+			if err := json.Unmarshal(body, &got); err != nil {
+				t.Fatalf("Unexpected error unmarshalling the response: %v", err)
+			}
+
+			if got.From != tc.expectedFrom {
+				t.Errorf("Expected from: %s, got: %s", tc.expectedFrom, got.From)
+			}
+			var times []string
+			var percentiles []*LatencyPercentiles
+			for _, latency := range got.DailyLatency {
+				times = append(times, latency.Time)
+				percentiles = append(percentiles, latency.Percentiles)
+			}
+			if diff := cmp.Diff(tc.expectedTimes, times); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(expectedPercentiles, percentiles); diff != "" {
+				t.Errorf("unexpected percentiles (-want +got):\n%s", diff)
+			}
+
+			// The meter's latencies are not modified
+			if fakeMeter.latencyResponse.DailyLatency[0].Time.Location() != time.UTC {
+				t.Errorf("Expected the meter's latencies to be kept in UTC")
+			}
+		})
+	}
+}
+
+func TestLatencyPercentiles(t *testing.T) {
+	hour := time.Date(2022, time.July, 20, 14, 0, 0, 0, time.UTC)
+	latency := AppLatencyResponse{
+		PublicKey: "app1",
+		From:      hour,
+		To:        hour.Add(2 * time.Hour),
+		DailyLatency: []Latency{
+			{Time: hour, Latency: 0.2, Percentiles: &LatencyPercentiles{P50: 0.15, P95: 0.6, P99: 1.2}},
+			{Time: hour.Add(time.Hour), Latency: 0.3},
+		},
+	}
+
+	fakeMeter := fakeRelayMeter{latencyResponse: latency}
+	httpServer := GetHttpServer(context.Background(), &fakeMeter, logger.New(), map[string]bool{"dummy": true}, ServerOptions{})
+
+	req := httptest.NewRequest(http.MethodGet, "http://relay-meter.pokt.network/v1/latency/apps/app1", nil)
+	req.Header.Add("Authorization", "dummy")
+	w := httptest.NewRecorder()
+
+	httpServer(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code: %d, got: %d", http.StatusOK, w.Code)
+	}
+
+	var got struct {
+		DailyLatency []map[string]any
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Unexpected error unmarshalling the response: %v", err)
+	}
+	if len(got.DailyLatency) != 2 {
+		t.Fatalf("Expected 2 latencies, got: %d", len(got.DailyLatency))
+	}
+
+	// The mean latency is kept, and the percentiles are omitted unless the source provides them
+	expected := map[string]any{"P50": 0.15, "P95": 0.6, "P99": 1.2}
+	if diff := cmp.Diff(expected, got.DailyLatency[0]["Percentiles"]); diff != "" {
+		t.Errorf("unexpected value (-want +got):\n%s", diff)
+	}
+	if got.DailyLatency[0]["Latency"] != 0.2 {
+		t.Errorf("Expected the mean latency: 0.2, got: %v", got.DailyLatency[0]["Latency"])
+	}
+	if _, ok := got.DailyLatency[1]["Percentiles"]; ok {
+		t.Errorf("Expected no percentiles, got: %v", got.DailyLatency[1]["Percentiles"])
+	}
+}
+
+// flushRecorder records the number of complete lines written to the response at each flush
+type flushRecorder struct {
+	*httptest.ResponseRecorder
+	flushedLines []int
+}
+
+func (f *flushRecorder) Flush() {
+	f.flushedLines = append(f.flushedLines, bytes.Count(f.Body.Bytes(), []byte("\n")))
+	f.ResponseRecorder.Flush()
+}
+
+func TestAllAppsRelaysNDJSON(t *testing.T) {
+	from := time.Date(2022, time.July, 20, 0, 0, 0, 0, time.UTC)
+	allResponse := []AppRelaysResponse{
+		{PublicKey: "app1", From: from, To: from.AddDate(0, 0, 1), Count: RelayCounts{Success: 10, Failure: 1}},
+		{PublicKey: "app2", From: from, To: from.AddDate(0, 0, 1), Count: RelayCounts{Success: 20}},
+		{PublicKey: "app3", From: from, To: from.AddDate(0, 0, 1)},
+	}
+
+	testCases := []struct {
+		name               string
+		query              string
+		meterErr           error
+		expectedStatusCode int
+		expected           []AppRelaysResponse
+	}{
+		{
+			name:               "Each app is streamed on its own line",
+			query:              "?format=ndjson&strict=true",
+			expectedStatusCode: http.StatusOK,
+			expected:           allResponse,
+		},
+		{
+			name:               "Invalid timespan returns an error before streaming",
+			query:              "?format=ndjson&from=yesterday",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Meter error returns an error before streaming",
+			query:              "?format=ndjson",
+			meterErr:           errors.New("meter failure"),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeMeter := fakeRelayMeter{allResponse: allResponse, responseErr: tc.meterErr}
+			httpServer := GetHttpServer(context.Background(), &fakeMeter, logger.New(), map[string]bool{"dummy": true}, ServerOptions{})
+
+			req := httptest.NewRequest(http.MethodGet, "http://relay-meter.pokt.network/v1/relays/apps"+tc.query, nil)
+			req.Header.Add("Authorization", "dummy")
+			w := &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+
+			httpServer(w, req)
+
+			resp := w.Result()
+			if resp.StatusCode != tc.expectedStatusCode {
+				t.Fatalf("Expected status code: %d, got: %d", tc.expectedStatusCode, resp.StatusCode)
+			}
+			if tc.expectedStatusCode != http.StatusOK {
+				return
+			}
+
+			if contentType := resp.Header.Get("Content-Type"); contentType != NDJSON_CONTENT_TYPE {
+				t.Errorf("Expected content type: %s, got: %s", NDJSON_CONTENT_TYPE, contentType)
+			}
+
+			// Each line is flushed as soon as it is written
+			if len(w.flushedLines) != len(tc.expected) {
+				t.Fatalf("Expected %d flushes, got: %d", len(tc.expected), len(w.flushedLines))
+			}
+			for i, lines := range w.flushedLines {
+				if lines != i+1 {
+					t.Errorf("Expected %d lines written at flush %d, got: %d", i+1, i, lines)
+				}
+			}
+
+			var got []AppRelaysResponse
+			scanner := bufio.NewScanner(resp.Body)
+			for scanner.Scan() {
+				var line AppRelaysResponse
+				if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+					t.Fatalf("Unexpected error unmarshalling line %q: %v", scanner.Text(), err)
+				}
+				got = append(got, line)
+			}
+			if err := scanner.Err(); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUploadRelayCountsStatusCounts(t *testing.T) {
+	testCases := []struct {
+		name           string
+		classification StatusClassification
+		input          []HTTPSourceRelayCountInput
+		expected       []HTTPSourceRelayCount
+	}{
+		{
+			name: "Per status counts are classified using the 2xx status codes by default",
+			input: []HTTPSourceRelayCountInput{
+				{AppPublicKey: "app1", StatusCounts: map[int]int64{200: 10, 204: 2, 404: 3, 500: 1}},
+			},
+			expected: []HTTPSourceRelayCount{
+				{AppPublicKey: "app1", Success: 12, Error: 4},
+			},
+		},
+		{
+			name:           "Per status counts are classified using the configured success status codes",
+			classification: StatusClassification{SuccessStatuses: map[int]bool{200: true, 404: true}},
+			input: []HTTPSourceRelayCountInput{
+				{AppPublicKey: "app1", StatusCounts: map[int]int64{200: 10, 204: 2, 404: 3, 500: 1}},
+			},
+			expected: []HTTPSourceRelayCount{
+				{AppPublicKey: "app1", Success: 13, Error: 3},
+			},
+		},
+		{
+			name: "Per status counts are added to the success and error counts",
+			input: []HTTPSourceRelayCountInput{
+				{AppPublicKey: "app1", Success: 5, Error: 1, StatusCounts: map[int]int64{200: 10, 503: 2}},
+				{AppPublicKey: "app2", Success: 7, Error: 3},
+			},
+			expected: []HTTPSourceRelayCount{
+				{AppPublicKey: "app1", Success: 15, Error: 3},
+				{AppPublicKey: "app2", Success: 7, Error: 3},
+			},
+		},
+		{
+			name: "Mixed case public keys are normalized to lowercase",
+			input: []HTTPSourceRelayCountInput{
+				{AppPublicKey: "ABCdef01", Success: 5, Error: 1},
+				{AppPublicKey: "abcdef01", Success: 7, Error: 3},
+			},
+			expected: []HTTPSourceRelayCount{
+				{AppPublicKey: "abcdef01", Success: 5, Error: 1},
+				{AppPublicKey: "abcdef01", Success: 7, Error: 3},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeMeter := fakeRelayMeter{}
+			httpServer := GetHttpServer(context.Background(), &fakeMeter, logger.New(), map[string]bool{"dummy": true}, ServerOptions{StatusClassification: tc.classification})
+
+			body, err := json.Marshal(tc.input)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			req := httptest.NewRequest(http.MethodPost, "http://relay-meter.pokt.network/v1/relays/counts", bytes.NewBuffer(body))
+			req.Header.Add("Authorization", "dummy")
+			w := httptest.NewRecorder()
+
+			httpServer(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status code: %d, got: %d", http.StatusOK, w.Code)
+			}
+
+			// The day of the counts is set by the server
+			for i := range fakeMeter.writtenCounts {
+				fakeMeter.writtenCounts[i].Day = time.Time{}
+			}
+			if diff := cmp.Diff(tc.expected, fakeMeter.writtenCounts); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCompareAppsRelaysParameters(t *testing.T) {
+	testCases := []struct {
+		name               string
+		query              string
+		options            ServerOptions
+		expectedStatusCode int
+		expectedApps       []types.PortalAppPublicKey
+	}{
+		{
+			name:               "Both apps are passed to the meter",
+			query:              "?a=app_1&b=app_2&strict=true",
+			expectedStatusCode: http.StatusOK,
+			expectedApps:       []types.PortalAppPublicKey{"app_1", "app_2"},
+		},
+		{
+			name:               "Mixed case apps are normalized to lowercase",
+			query:              "?a=APP_1&b=App_2",
+			expectedStatusCode: http.StatusOK,
+			expectedApps:       []types.PortalAppPublicKey{"app_1", "app_2"},
+		},
+		{
+			name:               "Missing app is rejected",
+			query:              "?a=app_1",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Invalid app public key is rejected",
+			query:              "?a=app_1&b=app_2",
+			options:            ServerOptions{ValidateAppKeys: true},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeMeter := fakeRelayMeter{}
+			httpServer := GetHttpServer(context.Background(), &fakeMeter, logger.New(), map[string]bool{"dummy": true}, tc.options)
+
+			req := httptest.NewRequest(http.MethodGet, "http://relay-meter.pokt.network/v1/relays/compare"+tc.query, nil)
+			req.Header.Add("Authorization", "dummy")
+			w := httptest.NewRecorder()
+
+			httpServer(w, req)
+
+			if w.Code != tc.expectedStatusCode {
+				t.Fatalf("Expected status code: %d, got: %d", tc.expectedStatusCode, w.Code)
+			}
+			if diff := cmp.Diff(tc.expectedApps, fakeMeter.requestedApps); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAppSLOParameters(t *testing.T) {
+	testCases := []struct {
+		name               string
+		query              string
+		expectedStatusCode int
+		expectedTarget     float64
+	}{
+		{
+			name:               "Target is passed to the meter",
+			query:              "?target=0.99&strict=true",
+			expectedStatusCode: http.StatusOK,
+			expectedTarget:     0.99,
+		},
+		{
+			name:               "Missing target is rejected",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Non numeric target is rejected",
+			query:              "?target=high",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Target above 1 is rejected",
+			query:              "?target=99",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Zero target is rejected",
+			query:              "?target=0",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeMeter := fakeRelayMeter{}
+			httpServer := GetHttpServer(context.Background(), &fakeMeter, logger.New(), map[string]bool{"dummy": true}, ServerOptions{})
+
+			req := httptest.NewRequest(http.MethodGet, "http://relay-meter.pokt.network/v1/relays/apps/app_1/slo"+tc.query, nil)
+			req.Header.Add("Authorization", "dummy")
+			w := httptest.NewRecorder()
+
+			httpServer(w, req)
+
+			if w.Code != tc.expectedStatusCode {
+				t.Fatalf("Expected status code: %d, got: %d", tc.expectedStatusCode, w.Code)
+			}
+			if fakeMeter.requestedTarget != tc.expectedTarget {
+				t.Errorf("Expected target: %v, got: %v", tc.expectedTarget, fakeMeter.requestedTarget)
+			}
+		})
+	}
+}
+
+func TestAppRankParameters(t *testing.T) {
+	testCases := []struct {
+		name               string
+		query              string
+		meterErr           error
+		expectedStatusCode int
+		expectedOrder      OriginOrder
+	}{
+		{
+			name:               "Apps are ranked by their total relays by default",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Relay count to rank by is passed to the meter",
+			query:              "?by=failure&strict=true",
+			expectedStatusCode: http.StatusOK,
+			expectedOrder:      OriginsByFailure,
+		},
+		{
+			name:               "Unknown relay count is rejected",
+			query:              "?by=latency",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Unknown app is rejected",
+			meterErr:           fmt.Errorf("%w: app_1", AppNotFound),
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeMeter := fakeRelayMeter{responseErr: tc.meterErr}
+			httpServer := GetHttpServer(context.Background(), &fakeMeter, logger.New(), map[string]bool{"dummy": true}, ServerOptions{})
+
+			req := httptest.NewRequest(http.MethodGet, "http://relay-meter.pokt.network/v1/relays/apps/app_1/rank"+tc.query, nil)
+			req.Header.Add("Authorization", "dummy")
+			w := httptest.NewRecorder()
+
+			httpServer(w, req)
+
+			if w.Code != tc.expectedStatusCode {
+				t.Fatalf("Expected status code: %d, got: %d", tc.expectedStatusCode, w.Code)
+			}
+			if fakeMeter.requestedOrder != tc.expectedOrder {
+				t.Errorf("Expected order: %q, got: %q", tc.expectedOrder, fakeMeter.requestedOrder)
+			}
+		})
+	}
+}
+
+func TestAppsFullyFailingParameters(t *testing.T) {
+	testCases := []struct {
+		name               string
+		query              string
+		expectedStatusCode int
+		expectedVolume     int64
+	}{
+		{
+			name:               "Missing minimum volume defaults to no floor",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Minimum volume is passed to the meter",
+			query:              "?minVolume=100&strict=true",
+			expectedStatusCode: http.StatusOK,
+			expectedVolume:     100,
+		},
+		{
+			name:               "Non numeric minimum volume is rejected",
+			query:              "?minVolume=many",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Negative minimum volume is rejected",
+			query:              "?minVolume=-1",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeMeter := fakeRelayMeter{}
+			httpServer := GetHttpServer(context.Background(), &fakeMeter, logger.New(), map[string]bool{"dummy": true}, ServerOptions{})
+
+			req := httptest.NewRequest(http.MethodGet, "http://relay-meter.pokt.network/v1/relays/apps/failing"+tc.query, nil)
+			req.Header.Add("Authorization", "dummy")
+			w := httptest.NewRecorder()
+
+			httpServer(w, req)
+
+			if w.Code != tc.expectedStatusCode {
+				t.Fatalf("Expected status code: %d, got: %d", tc.expectedStatusCode, w.Code)
+			}
+			if fakeMeter.requestedVolume != tc.expectedVolume {
+				t.Errorf("Expected minimum volume: %d, got: %d", tc.expectedVolume, fakeMeter.requestedVolume)
+			}
+		})
+	}
+}
+
+func TestPortalAppsRelaysInput(t *testing.T) {
+	batchOfSize := func(size int) ([]types.PortalAppID, string) {
+		portalAppIDs := make([]types.PortalAppID, size)
+		for i := range portalAppIDs {
+			portalAppIDs[i] = types.PortalAppID(fmt.Sprintf("portal_app_%d", i))
+		}
+		body, _ := json.Marshal(portalAppIDs)
+		return portalAppIDs, string(body)
+	}
+	maxBatch, maxBatchBody := batchOfSize(MAX_BATCH_PORTAL_APPS)
+	_, tooLargeBatchBody := batchOfSize(MAX_BATCH_PORTAL_APPS + 1)
+
+	testCases := []struct {
+		name               string
+		body               string
+		expectedStatusCode int
+		expectedPortalApps []types.PortalAppID
+	}{
+		{
+			name:               "Portal app IDs are passed to the meter",
+			body:               `["portal_app_1", "unknown"]`,
+			expectedStatusCode: http.StatusOK,
+			expectedPortalApps: []types.PortalAppID{"portal_app_1", "unknown"},
+		},
+		{
+			name:               "Body which is not an array of IDs is rejected",
+			body:               `{"portalAppID": "portal_app_1"}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Empty body is rejected",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Batch of the maximum size is passed to the meter",
+			body:               maxBatchBody,
+			expectedStatusCode: http.StatusOK,
+			expectedPortalApps: maxBatch,
+		},
+		{
+			name:               "Batch above the maximum size is rejected",
+			body:               tooLargeBatchBody,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeMeter := fakeRelayMeter{}
+			httpServer := GetHttpServer(context.Background(), &fakeMeter, logger.New(), map[string]bool{"dummy": true}, ServerOptions{})
+
+			req := httptest.NewRequest(http.MethodPost, "http://relay-meter.pokt.network/v1/relays/endpoints/batch", strings.NewReader(tc.body))
+			req.Header.Add("Authorization", "dummy")
+			w := httptest.NewRecorder()
+
+			httpServer(w, req)
+
+			if w.Code != tc.expectedStatusCode {
+				t.Fatalf("Expected status code: %d, got: %d", tc.expectedStatusCode, w.Code)
+			}
+			if diff := cmp.Diff(tc.expectedPortalApps, fakeMeter.requestedPortalApps); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAppRelaysRate(t *testing.T) {
+	from := time.Date(2022, time.July, 20, 0, 0, 0, 0, time.UTC)
+	response := AppRelaysResponse{
+		PublicKey: "app_1",
+		From:      from,
+		To:        from.AddDate(0, 0, 1),
+		Count:     RelayCounts{Success: 864000, Failure: 86400},
+	}
+
+	testCases := []struct {
+		name     string
+		query    string
+		expected *RelayRate
+	}{
+		{
+			name: "Rate is not returned by default",
+		},
+		{
+			name:     "Rate is returned if requested",
+			query:    "?rate=true&strict=true",
+			expected: &RelayRate{SuccessRate: 10, FailureRate: 1},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeMeter := fakeRelayMeter{response: response}
+			httpServer := GetHttpServer(context.Background(), &fakeMeter, logger.New(), map[string]bool{"dummy": true}, ServerOptions{})
+
+			req := httptest.NewRequest(http.MethodGet, "http://relay-meter.pokt.network/v1/relays/apps/app_1"+tc.query, nil)
+			req.Header.Add("Authorization", "dummy")
+			w := httptest.NewRecorder()
+
+			httpServer(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status code: %d, got: %d", http.StatusOK, w.Code)
+			}
+
+			var got AppRelaysResponse
+			if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, got.Rate); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestIntEncoding(t *testing.T) {
+	from := time.Date(2022, time.July, 20, 0, 0, 0, 0, time.UTC)
+	// Counts beyond 2^53 are not exactly representable as float64
+	response := AppRelaysResponse{
+		PublicKey: "app_1",
+		From:      from,
+		To:        from.AddDate(0, 0, 1),
+		Count:     RelayCounts{Success: 1<<53 + 1, Failure: 1<<62 + 3},
+		// Whole-valued rates are still floats, so they are never quoted
+		Rate: &RelayRate{SuccessRate: 1, FailureRate: 0.5},
+	}
+
+	testCases := []struct {
+		name               string
+		query              string
+		expectedStatusCode int
+		expectedCount      string
+	}{
+		{
+			name:               "Counts are exact JSON numbers by default",
+			expectedStatusCode: http.StatusOK,
+			expectedCount:      `{"Success":9007199254740993,"Failure":4611686018427387907}`,
+		},
+		{
+			name:               "Counts are exact JSON strings if requested",
+			query:              "?intEncoding=string&strict=true",
+			expectedStatusCode: http.StatusOK,
+			expectedCount:      `{"Success":"9007199254740993","Failure":"4611686018427387907"}`,
+		},
+		{
+			name:               "Invalid encoding is rejected",
+			query:              "?intEncoding=float",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeMeter := fakeRelayMeter{response: response}
+			httpServer := GetHttpServer(context.Background(), &fakeMeter, logger.New(), map[string]bool{"dummy": true}, ServerOptions{})
+
+			req := httptest.NewRequest(http.MethodGet, "http://relay-meter.pokt.network/v1/relays/apps/app_1"+tc.query, nil)
+			req.Header.Add("Authorization", "dummy")
+			w := httptest.NewRecorder()
+
+			httpServer(w, req)
+
+			if w.Code != tc.expectedStatusCode {
+				t.Fatalf("Expected status code: %d, got: %d", tc.expectedStatusCode, w.Code)
+			}
+			if tc.expectedStatusCode != http.StatusOK {
+				return
+			}
+
+			var got struct {
+				Count json.RawMessage
+				Rate  json.RawMessage
+			}
+			if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if string(got.Count) != tc.expectedCount {
+				t.Errorf("Expected count: %s, got: %s", tc.expectedCount, got.Count)
+			}
+			if expectedRate := `{"SuccessRate":1,"FailureRate":0.5}`; string(got.Rate) != expectedRate {
+				t.Errorf("Expected rate: %s, got: %s", expectedRate, got.Rate)
+			}
+		})
+	}
+}
+
+func TestUserRelaysBreakdown(t *testing.T) {
+	from := time.Date(2022, time.July, 20, 0, 0, 0, 0, time.UTC)
+	perApp := map[types.PortalAppPublicKey]RelayCounts{
+		"app_1": {Success: 100, Failure: 5},
+		"app_2": {Success: 3},
+	}
+	response := UserRelaysResponse{
+		User:       "user_1",
+		PublicKeys: []types.PortalAppPublicKey{"app_1", "app_2"},
+		From:       from,
+		To:         from.AddDate(0, 0, 1),
+		Count:      RelayCounts{Success: 103, Failure: 5},
+		PerApp:     perApp,
+	}
+
+	testCases := []struct {
+		name     string
+		query    string
+		expected map[types.PortalAppPublicKey]RelayCounts
+	}{
+		{
+			name: "Only the total is returned by default",
+		},
+		{
+			name:     "Counts of each app are returned if requested",
+			query:    "?breakdown=true&strict=true",
+			expected: perApp,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeMeter := fakeRelayMeter{userResponse: response}
+			httpServer := GetHttpServer(context.Background(), &fakeMeter, logger.New(), map[string]bool{"dummy": true}, ServerOptions{})
+
+			req := httptest.NewRequest(http.MethodGet, "http://relay-meter.pokt.network/v1/relays/users/user_1"+tc.query, nil)
+			req.Header.Add("Authorization", "dummy")
+			w := httptest.NewRecorder()
+
+			httpServer(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status code: %d, got: %d", http.StatusOK, w.Code)
+			}
+
+			var got UserRelaysResponse
+			if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, got.PerApp); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+			if got.Count != response.Count {
+				t.Errorf("Expected the total: %v, got: %v", response.Count, got.Count)
+			}
+		})
+	}
+}
+
+func TestExcludePartialToday(t *testing.T) {
+	now := time.Date(2022, time.July, 20, 15, 30, 0, 0, time.UTC)
+	yesterday := time.Date(2022, time.July, 19, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name        string
+		from        time.Time
+		to          time.Time
+		expected    time.Time
+		expectedErr bool
+	}{
+		{
+			name:     "Missing to parameter is set to yesterday",
+			expected: yesterday,
+		},
+		{
+			name:     "To parameter of today is set to yesterday",
+			from:     yesterday.AddDate(0, 0, -3),
+			to:       now,
+			expected: yesterday,
+		},
+		{
+			name:     "To parameter after today is set to yesterday",
+			to:       now.AddDate(0, 0, 2),
+			expected: yesterday,
+		},
+		{
+			name:     "To parameter before today is not modified",
+			from:     yesterday.AddDate(0, 0, -3),
+			to:       yesterday.AddDate(0, 0, -1),
+			expected: yesterday.AddDate(0, 0, -1),
+		},
+		{
+			name:     "Time period starting yesterday is kept",
+			from:     yesterday.Add(time.Hour),
+			to:       now,
+			expected: yesterday,
+		},
+		{
+			name:        "Time period starting today is rejected",
+			from:        now,
+			to:          now,
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := excludePartialToday(tc.from, tc.to, now)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("Expected error: %t, got: %v", tc.expectedErr, err)
+			}
+			if !got.Equal(tc.expected) {
+				t.Errorf("Expected to: %v, got: %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestEndExclusive(t *testing.T) {
+	day := time.Date(2022, time.July, 20, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name        string
+		from        time.Time
+		to          time.Time
+		expected    time.Time
+		expectedErr bool
+	}{
+		{
+			name: "Missing to parameter is not modified",
+		},
+		{
+			name:     "To parameter at the start of a day excludes that day",
+			from:     day.AddDate(0, 0, -3),
+			to:       day,
+			expected: day.AddDate(0, 0, -1),
+		},
+		{
+			name:     "To parameter within a day includes that day",
+			from:     day.AddDate(0, 0, -3),
+			to:       day.Add(12 * time.Hour),
+			expected: day,
+		},
+		{
+			name:     "Time period of a single day is kept",
+			from:     day,
+			to:       day.AddDate(0, 0, 1),
+			expected: day,
+		},
+		{
+			name:        "Time period ending when it starts is rejected",
+			from:        day,
+			to:          day,
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := endExclusive(tc.from, tc.to)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("Expected error: %t, got: %v", tc.expectedErr, err)
+			}
+			if !got.Equal(tc.expected) {
+				t.Errorf("Expected to: %v, got: %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestEndExclusiveParameter(t *testing.T) {
+	from := time.Date(2022, time.July, 18, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2022, time.July, 21, 0, 0, 0, 0, time.UTC)
+	dateParams := fmt.Sprintf("from=%s&to=%s", url.QueryEscape(from.Format(time.RFC3339)), url.QueryEscape(to.Format(time.RFC3339)))
+
+	testCases := []struct {
+		name               string
+		query              string
+		expectedTo         time.Time
+		expectedStatusCode int
+	}{
+		{
+			name:               "To parameter names the last day by default",
+			query:              dateParams,
+			expectedTo:         to,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "To parameter is an exclusive end with the flag",
+			query:              dateParams + "&endExclusive=true&strict=true",
+			expectedTo:         to.AddDate(0, 0, -1),
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Time period ending when it starts is rejected with the flag",
+			query:              fmt.Sprintf("from=%s&to=%s&endExclusive=true", url.QueryEscape(to.Format(time.RFC3339)), url.QueryEscape(to.Format(time.RFC3339))),
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeMeter := fakeRelayMeter{}
+			httpServer := GetHttpServer(context.Background(), &fakeMeter, logger.New(), map[string]bool{"dummy": true}, ServerOptions{})
+
+			req := httptest.NewRequest(http.MethodGet, "http://relay-meter.pokt.network/v1/relays?"+tc.query, nil)
+			req.Header.Add("Authorization", "dummy")
+			w := httptest.NewRecorder()
+
+			httpServer(w, req)
+
+			if w.Code != tc.expectedStatusCode {
+				t.Fatalf("Expected status code: %d, got: %d", tc.expectedStatusCode, w.Code)
+			}
+			if !fakeMeter.requestedTo.Equal(tc.expectedTo) {
+				t.Errorf("Expected to: %v, got: %v", tc.expectedTo, fakeMeter.requestedTo)
+			}
+		})
+	}
+}
+
+func TestExcludePartialTodayParameter(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	yesterday := time.Date(now.Year(), now.Month(), now.Day()-1, 0, 0, 0, 0, time.UTC)
+	from := yesterday.AddDate(0, 0, -5)
+	dateParams := fmt.Sprintf("from=%s&to=%s", url.QueryEscape(from.Format(time.RFC3339)), url.QueryEscape(now.Format(time.RFC3339)))
+
+	testCases := []struct {
+		name               string
+		path               string
+		query              string
+		expectedCall       string
+		expectedTo         time.Time
+		expectedStatusCode int
+	}{
+		{
+			name:               "App relays include today without the flag",
+			path:               "/v1/relays/apps/app_1",
+			query:              dateParams,
+			expectedCall:       "AppRelays",
+			expectedTo:         now,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "App relays exclude today with the flag",
+			path:               "/v1/relays/apps/app_1",
+			query:              dateParams + "&excludePartialToday=true&strict=true",
+			expectedCall:       "AppRelays",
+			expectedTo:         yesterday,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "All apps relays exclude today with the flag",
+			path:               "/v1/relays/apps",
+			query:              dateParams + "&excludePartialToday=true",
+			expectedCall:       "AllAppsRelays",
+			expectedTo:         yesterday,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "User relays exclude today with the flag",
+			path:               "/v1/relays/users/user_1",
+			query:              dateParams + "&excludePartialToday=true",
+			expectedCall:       "UserRelays",
+			expectedTo:         yesterday,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Total relays exclude today with the flag",
+			path:               "/v1/relays",
+			query:              dateParams + "&excludePartialToday=true",
+			expectedCall:       "TotalRelays",
+			expectedTo:         yesterday,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Flag is ignored unless set to true",
+			path:               "/v1/relays",
+			query:              dateParams + "&excludePartialToday=false",
+			expectedCall:       "TotalRelays",
+			expectedTo:         now,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Time period of only today is rejected with the flag",
+			path:               "/v1/relays",
+			query:              fmt.Sprintf("from=%s&excludePartialToday=true", url.QueryEscape(now.Format(time.RFC3339))),
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeMeter := fakeRelayMeter{}
+			httpServer := GetHttpServer(context.Background(), &fakeMeter, logger.New(), map[string]bool{"dummy": true}, ServerOptions{})
+
+			req := httptest.NewRequest(http.MethodGet, "http://relay-meter.pokt.network"+tc.path+"?"+tc.query, nil)
+			req.Header.Add("Authorization", "dummy")
+			w := httptest.NewRecorder()
+
+			httpServer(w, req)
+
+			if w.Code != tc.expectedStatusCode {
+				t.Fatalf("Expected status code: %d, got: %d", tc.expectedStatusCode, w.Code)
+			}
+			if fakeMeter.called != tc.expectedCall {
+				t.Errorf("Expected meter call: %q, got: %q", tc.expectedCall, fakeMeter.called)
+			}
+			if !fakeMeter.requestedTo.Equal(tc.expectedTo) {
+				t.Errorf("Expected to: %v, got: %v", tc.expectedTo, fakeMeter.requestedTo)
+			}
+		})
+	}
+}
+
+func TestAppErrorsRouting(t *testing.T) {
+	expected := []DailyErrorsResponse{{Day: time.Date(2022, time.July, 20, 0, 0, 0, 0, time.UTC), Failure: 7}}
+	fakeMeter := fakeRelayMeter{errorsResponse: expected}
+	httpServer := GetHttpServer(context.Background(), &fakeMeter, logger.New(), map[string]bool{"dummy": true}, ServerOptions{})
+
+	req := httptest.NewRequest(http.MethodGet, "http://relay-meter.pokt.network/v1/relays/apps/app_1/errors", nil)
+	req.Header.Add("Authorization", "dummy")
+	w := httptest.NewRecorder()
+
+	httpServer(w, req)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status code: %d, got: %d", http.StatusOK, resp.StatusCode)
+	}
+	if fakeMeter.requestedApp != "app_1" {
+		t.Errorf("Expected app: app_1, got: %s", fakeMeter.requestedApp)
+	}
+
+	var got []DailyErrorsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("unexpected value (-want +got):\n%s", diff)
+	}
+}
+
+func TestPagination(t *testing.T) {
+	testCases := []struct {
+		name               string
+		path               string
+		query              string
+		expectedStatusCode int
+		expectedPage       Page
+		expectedIDs        []string
+	}{
+		{
+			name:               "All apps are returned without pagination parameters",
+			path:               "/v1/relays/apps",
+			query:              "?v=2",
+			expectedStatusCode: http.StatusOK,
+			expectedIDs:        []string{"app1", "app2", "app3"},
+		},
+		{
+			name:               "Page of the apps is returned",
+			path:               "/v1/relays/apps",
+			query:              "?v=2&limit=2&offset=1&strict=true",
+			expectedStatusCode: http.StatusOK,
+			expectedPage:       Page{Limit: 2, Offset: 1},
+			expectedIDs:        []string{"app2", "app3"},
+		},
+		{
+			name:               "Page of the portal apps is returned",
+			path:               "/v1/relays/endpoints",
+			query:              "?v=2&limit=1&offset=2",
+			expectedStatusCode: http.StatusOK,
+			expectedPage:       Page{Limit: 1, Offset: 2},
+			expectedIDs:        []string{"portal_app_3"},
+		},
+		{
+			name:               "Negative offset is rejected",
+			path:               "/v1/relays/apps",
+			query:              "?offset=-1",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Non-numeric offset is rejected",
+			path:               "/v1/relays/endpoints",
+			query:              "?offset=first",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeMeter := fakeRelayMeter{
+				allResponse: []AppRelaysResponse{{PublicKey: "app1"}, {PublicKey: "app2"}, {PublicKey: "app3"}},
+				allPortalAppsResponse: []PortalAppRelaysResponse{
+					{PortalAppID: "portal_app_1"}, {PortalAppID: "portal_app_2"}, {PortalAppID: "portal_app_3"},
+				},
+			}
+			httpServer := GetHttpServer(context.Background(), &fakeMeter, logger.New(), map[string]bool{"dummy": true}, ServerOptions{})
+
+			req := httptest.NewRequest(http.MethodGet, "http://relay-meter.pokt.network"+tc.path+tc.query, nil)
+			req.Header.Add("Authorization", "dummy")
+			w := httptest.NewRecorder()
+
+			httpServer(w, req)
+
+			if w.Code != tc.expectedStatusCode {
+				t.Fatalf("Expected status code: %d, got: %d", tc.expectedStatusCode, w.Code)
+			}
+			if tc.expectedStatusCode != http.StatusOK {
+				return
+			}
+			if fakeMeter.requestedPage != tc.expectedPage {
+				t.Errorf("Expected page: %+v, got: %+v", tc.expectedPage, fakeMeter.requestedPage)
+			}
+			if total := w.Header().Get(HEADER_TOTAL_COUNT); total != "3" {
+				t.Errorf("Expected total count header: 3, got: %q", total)
+			}
+
+			var r ListResponse[struct {
+				Application string
+				Endpoint    string
+			}]
+			if err := json.Unmarshal(w.Body.Bytes(), &r); err != nil {
+				t.Fatalf("Unexpected error unmarhsalling the response: %v", err)
+			}
+			if r.Meta.Total != 3 {
+				t.Errorf("Expected a total of 3, got: %d", r.Meta.Total)
+			}
+			var ids []string
+			for _, item := range r.Data {
+				ids = append(ids, item.Application+item.Endpoint)
+			}
+			if diff := cmp.Diff(tc.expectedIDs, ids); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	now, _ := time.Parse(dayFormat, time.Now().Format(dayFormat))
+	meter := &relayMeter{
+		Backend: &fakeBackend{
+			usage:         fakeDailyMetrics(),
+			todaysUsage:   fakeTodaysMetrics(),
+			todaysLatency: fakeTodaysLatency(),
+		},
+		Logger: logger.New(),
+	}
+	if err := meter.loadData(now.AddDate(0, 0, -7), now); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	httpServer := GetHttpServer(context.Background(), meter, logger.New(), map[string]bool{"dummy": true}, ServerOptions{})
+
+	// No API key is needed to scrape the metrics
+	req := httptest.NewRequest(http.MethodGet, "http://relay-meter.pokt.network/metrics", nil)
+	w := httptest.NewRecorder()
+
+	httpServer(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code: %d, got: %d", http.StatusOK, w.Code)
+	}
+	for _, series := range []string{
+		"relay_meter_data_load_last_success_timestamp_seconds ",
+		`relay_meter_cache_age_seconds{cache="daily"} `,
+		`relay_meter_cache_age_seconds{cache="today"} `,
+		`relay_meter_backend_query_duration_seconds_count{query="daily_usage"} `,
+		`relay_meter_backend_query_duration_seconds_count{query="todays_usage"} `,
+		`relay_meter_todays_relays{result="success"} `,
+		`relay_meter_todays_relays{result="failure"} `,
+	} {
+		if !strings.Contains(w.Body.String(), "\n"+series) {
+			t.Errorf("Expected the %q series in the metrics", series)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// StatusClassification classifies relays, by their HTTP status code, as successful or failed.
+//
+//	It allows the uploaders of relay counts to submit per status counts, leaving the definition of a successful relay to the server.
+type StatusClassification struct {
+	// SuccessStatuses are the status codes of successful relays: the 2xx status codes are used if empty.
+	SuccessStatuses map[int]bool
+}
+
+// IsSuccess returns true if the relays with the status code are counted as successful
+func (c StatusClassification) IsSuccess(status int) bool {
+	if len(c.SuccessStatuses) == 0 {
+		return status >= http.StatusOK && status < http.StatusMultipleChoices
+	}
+
+	return c.SuccessStatuses[status]
+}
+
+// Classify returns the relay counts of the per status counts
+func (c StatusClassification) Classify(statusCounts map[int]int64) RelayCounts {
+	var counts RelayCounts
+	for status, count := range statusCounts {
+		if c.IsSuccess(status) {
+			counts.Success += count
+			continue
+		}
+		counts.Failure += count
+	}
+
+	return counts
+}
+
+// ParseSuccessStatuses returns the status codes of successful relays, e.g. as read from a comma separated environment variable.
+//
+//	Empty entries are ignored, so no codes, i.e. the default classification, are returned for an empty variable.
+func ParseSuccessStatuses(codes map[string]bool) (map[int]bool, error) {
+	statuses := make(map[int]bool)
+	for code := range codes {
+		code = strings.TrimSpace(code)
+		if code == "" {
+			continue
+		}
+
+		status, err := strconv.Atoi(code)
+		if err != nil || status < 100 || status > 599 {
+			return nil, fmt.Errorf("invalid HTTP status code: %q", code)
+		}
+		statuses[status] = true
+	}
+
+	return statuses, nil
+}
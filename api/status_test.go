@@ -0,0 +1,83 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStatusClassification(t *testing.T) {
+	testCases := []struct {
+		name           string
+		classification StatusClassification
+		statusCounts   map[int]int64
+		expected       RelayCounts
+	}{
+		{
+			name:         "No per status counts",
+			statusCounts: nil,
+			expected:     RelayCounts{},
+		},
+		{
+			name:         "2xx status codes are successful by default",
+			statusCounts: map[int]int64{200: 5, 299: 1, 300: 2, 429: 3, 502: 4},
+			expected:     RelayCounts{Success: 6, Failure: 9},
+		},
+		{
+			name:           "Configured status codes replace the default ones",
+			classification: StatusClassification{SuccessStatuses: map[int]bool{200: true, 429: true}},
+			statusCounts:   map[int]int64{200: 5, 201: 1, 429: 3, 502: 4},
+			expected:       RelayCounts{Success: 8, Failure: 5},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, tc.classification.Classify(tc.statusCounts)); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseSuccessStatuses(t *testing.T) {
+	testCases := []struct {
+		name        string
+		codes       map[string]bool
+		expected    map[int]bool
+		expectedErr bool
+	}{
+		{
+			name:     "Empty variable returns no status codes",
+			codes:    map[string]bool{"": true},
+			expected: map[int]bool{},
+		},
+		{
+			name:     "Status codes are parsed",
+			codes:    map[string]bool{"200": true, " 404": true},
+			expected: map[int]bool{200: true, 404: true},
+		},
+		{
+			name:        "Non numeric status code is rejected",
+			codes:       map[string]bool{"ok": true},
+			expectedErr: true,
+		},
+		{
+			name:        "Out of range status code is rejected",
+			codes:       map[string]bool{"42": true},
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseSuccessStatuses(tc.codes)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("Expected error: %t, got: %v", tc.expectedErr, err)
+			}
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -3,8 +3,11 @@ package main
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	phdClient "github.com/pokt-foundation/portal-http-db/v2/client"
@@ -14,25 +17,43 @@ import (
 
 	// TODO: replace with pokt-foundation/relay-meter
 	_ "net/http/pprof"
+	// The tz parameter of the latency endpoints needs the time zone database, which the production image does not ship
+	_ "time/tzdata"
 
 	"github.com/pokt-foundation/relay-meter/api"
 	"github.com/pokt-foundation/relay-meter/cmd"
 	"github.com/pokt-foundation/relay-meter/db"
-	driver "github.com/pokt-foundation/relay-meter/driver-autogenerated"
 )
 
 const (
 	RELAY_METER_API_KEYS = "API_KEYS"
+	API_KEYS_FILE        = "API_KEYS_FILE"
+	MAX_API_KEYS         = "MAX_API_KEYS"
 	PHD_BASE_URL         = "BACKEND_API_URL"
 	PHD_API_KEY          = "BACKEND_API_TOKEN"
 
 	LOAD_INTERVAL_SECONDS      = "LOAD_INTERVAL_SECONDS"
 	DAILY_METRICS_TTL_SECONDS  = "DAILY_METRICS_TTL_SECONDS"
 	TODAYS_METRICS_TTL_SECONDS = "TODAYS_METRICS_TTL_SECONDS"
+	TODAYS_LATENCY_TTL_SECONDS = "TODAYS_LATENCY_TTL_SECONDS"
+	TODAYS_ORIGIN_TTL_SECONDS  = "TODAYS_ORIGIN_TTL_SECONDS"
 	MAX_ARCHIVE_AGE            = "MAX_ARCHIVE_AGE"
+	SERVED_PAST_DAYS           = "SERVED_PAST_DAYS"
 	API_SERVER_PORT            = "API_SERVER_PORT"
 	HTTP_TIMEOUT               = "HTTP_TIMEOUT"
 	HTTP_RETRIES               = "HTTP_RETRIES"
+	VALIDATE_APP_KEYS          = "VALIDATE_APP_KEYS"
+	APPS_EFFECTIVE_FROM        = "APPS_EFFECTIVE_FROM"
+	CACHE_PORTAL_APPS          = "CACHE_PORTAL_APPS"
+	OPTIONAL_ORIGIN_DATA       = "OPTIONAL_ORIGIN_DATA"
+	OPTIONAL_LATENCY_DATA      = "OPTIONAL_LATENCY_DATA"
+	DEFAULT_PAGE_LIMIT         = "DEFAULT_PAGE_LIMIT"
+	SLOW_QUERY_THRESHOLD_MS    = "SLOW_QUERY_THRESHOLD_MS"
+	READ_ONLY                  = "READ_ONLY"
+	SUCCESS_STATUS_CODES       = "SUCCESS_STATUS_CODES"
+	ANONYMIZED_API_KEYS        = "ANONYMIZED_API_KEYS"
+	ANONYMIZATION_SECRET       = "ANONYMIZATION_SECRET"
+	ANONYMIZED_KEY_LENGTH      = "ANONYMIZED_KEY_LENGTH"
 
 	defaultLoadIntervalSeconds      = 30
 	defaultDailyMetricsTTLSeconds   = 120
@@ -41,40 +62,121 @@ const (
 	defaultServerPort               = 9898
 	defaultHTTPTimeoutSeconds       = 5
 	defaultHTTPRetries              = 0
+	defaultValidateAppKeys          = false
+	defaultAppsEffectiveFrom        = false
+	defaultCachePortalApps          = false
+	defaultOptionalOriginData       = false
+	defaultOptionalLatencyData      = false
+	defaultReadOnly                 = false
 )
 
 type options struct {
 	relayMeterAPIKeys map[string]bool
+	apiKeysFile       string
+	maxAPIKeys        int
 	phdBaseURL        string
 	phdAPIKey         string
 
 	loadInterval            int
 	dailyMetricsTTLSeconds  int
 	todaysMetricsTTLSeconds int
+	todaysLatencyTTLSeconds int
+	todaysOriginTTLSeconds  int
 	maxPastDays             int
+	servedPastDays          int
 	timeout                 time.Duration
 	retries                 int
 	port                    int
+	validateAppKeys         bool
+	appsEffectiveFrom       bool
+	cachePortalApps         bool
+	optionalOriginData      bool
+	optionalLatencyData     bool
+	defaultPageLimit        int
+	slowQueryThreshold      time.Duration
+	readOnly                bool
+	successStatusCodes      map[string]bool
+	anonymizedAPIKeys       map[string]bool
+	anonymizationSecret     string
+	anonymizedKeyLength     int
 }
 
 func gatherOptions() options {
+	// The API keys file, if set, replaces API_KEYS: it is read again on SIGHUP, to rotate the keys without a restart
+	apiKeysFile := environment.GetString(API_KEYS_FILE, "")
+	var relayMeterAPIKeys map[string]bool
+	if apiKeysFile == "" {
+		relayMeterAPIKeys = environment.MustGetStringMap(RELAY_METER_API_KEYS, ";")
+	}
+
 	return options{
-		relayMeterAPIKeys: environment.MustGetStringMap(RELAY_METER_API_KEYS, ";"),
+		relayMeterAPIKeys: relayMeterAPIKeys,
+		apiKeysFile:       apiKeysFile,
+		maxAPIKeys:        int(environment.GetInt64(MAX_API_KEYS, 0)),
 		phdBaseURL:        environment.MustGetString(PHD_BASE_URL),
 		phdAPIKey:         environment.MustGetString(PHD_API_KEY),
 
 		loadInterval:            int(environment.GetInt64(LOAD_INTERVAL_SECONDS, defaultLoadIntervalSeconds)),
 		dailyMetricsTTLSeconds:  int(environment.GetInt64(DAILY_METRICS_TTL_SECONDS, defaultDailyMetricsTTLSeconds)),
 		todaysMetricsTTLSeconds: int(environment.GetInt64(TODAYS_METRICS_TTL_SECONDS, defaultsTodaysMetricsTTLSeconds)),
+		todaysLatencyTTLSeconds: int(environment.GetInt64(TODAYS_LATENCY_TTL_SECONDS, 0)),
+		todaysOriginTTLSeconds:  int(environment.GetInt64(TODAYS_ORIGIN_TTL_SECONDS, 0)),
 		maxPastDays:             int(environment.GetInt64(MAX_ARCHIVE_AGE, defaultMaxArchiveAgeDays)),
+		servedPastDays:          int(environment.GetInt64(SERVED_PAST_DAYS, 0)),
 		timeout:                 time.Duration(environment.GetInt64(HTTP_TIMEOUT, defaultHTTPTimeoutSeconds)) * time.Second,
 		retries:                 int(environment.GetInt64(HTTP_RETRIES, defaultHTTPRetries)),
 		port:                    int(environment.GetInt64(API_SERVER_PORT, defaultServerPort)),
+		validateAppKeys:         environment.GetBool(VALIDATE_APP_KEYS, defaultValidateAppKeys),
+		appsEffectiveFrom:       environment.GetBool(APPS_EFFECTIVE_FROM, defaultAppsEffectiveFrom),
+		cachePortalApps:         environment.GetBool(CACHE_PORTAL_APPS, defaultCachePortalApps),
+		optionalOriginData:      environment.GetBool(OPTIONAL_ORIGIN_DATA, defaultOptionalOriginData),
+		optionalLatencyData:     environment.GetBool(OPTIONAL_LATENCY_DATA, defaultOptionalLatencyData),
+		defaultPageLimit:        int(environment.GetInt64(DEFAULT_PAGE_LIMIT, 0)),
+		slowQueryThreshold:      time.Duration(environment.GetInt64(SLOW_QUERY_THRESHOLD_MS, 0)) * time.Millisecond,
+		readOnly:                environment.GetBool(READ_ONLY, defaultReadOnly),
+		successStatusCodes:      environment.GetStringMap(SUCCESS_STATUS_CODES, "", ","),
+		anonymizedAPIKeys:       environment.GetStringMap(ANONYMIZED_API_KEYS, "", ";"),
+		anonymizationSecret:     environment.GetString(ANONYMIZATION_SECRET, ""),
+		anonymizedKeyLength:     int(environment.GetInt64(ANONYMIZED_KEY_LENGTH, 0)),
+	}
+}
+
+// loadAPIKeys returns the API keys of the API_KEYS_FILE if set, or of the API_KEYS variable otherwise
+func loadAPIKeys(options options) (*api.APIKeys, error) {
+	keys := options.relayMeterAPIKeys
+	if options.apiKeysFile != "" {
+		var err error
+		if keys, err = api.ReadAPIKeysFile(options.apiKeysFile); err != nil {
+			return nil, err
+		}
+	}
+	return api.NewAPIKeys(keys, options.maxAPIKeys)
+}
+
+// reloadAPIKeysOnSignal replaces the API keys with those of the file on each SIGHUP: the current keys are kept if the file cannot be read
+func reloadAPIKeysOnSignal(apiKeys *api.APIKeys, path string, logger *logger.Logger) {
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+
+	for range reload {
+		keys, err := api.ReadAPIKeysFile(path)
+		if err == nil {
+			err = apiKeys.Set(keys)
+		}
+		if err != nil {
+			logger.Error("Error reloading the API keys: the current keys are kept",
+				slog.String("error", err.Error()),
+			)
+			continue
+		}
+		logger.Info("Reloaded the API keys",
+			slog.Int("api_keys_count", apiKeys.Len()),
+		)
 	}
 }
 
 type backendProvider struct {
-	db.PostgresClient
+	db.StorageClient
 	phd phdClient.IDBReader
 }
 
@@ -90,7 +192,7 @@ func (p *backendProvider) UserPortalAppPubKeys(ctx context.Context, userID types
 	for _, app := range userPortalApps {
 		for _, aat := range app.AATs {
 			if aat.PublicKey != "" {
-				appPubKeys = append(appPubKeys, aat.PublicKey)
+				appPubKeys = append(appPubKeys, api.NormalizePublicKey(aat.PublicKey))
 			}
 		}
 	}
@@ -111,11 +213,11 @@ func main() {
 	logger := logger.New()
 
 	go func() {
-		logger.Info("pprof:", http.ListenAndServe("localhost:6060", nil))
+		logger.Info("pprof:", slog.Any("error", http.ListenAndServe("localhost:6060", nil)))
 	}()
 
 	options := gatherOptions()
-	postgresOptions := cmd.GatherPostgresOptions()
+	storageOptions := cmd.GatherStorageOptions()
 
 	ctx := context.Background()
 
@@ -124,26 +226,35 @@ func main() {
 		LoadInterval:     time.Duration(options.loadInterval) * time.Second,
 		DailyMetricsTTL:  time.Duration(options.dailyMetricsTTLSeconds) * time.Second,
 		TodaysMetricsTTL: time.Duration(options.todaysMetricsTTLSeconds) * time.Second,
+		TodaysLatencyTTL: time.Duration(options.todaysLatencyTTLSeconds) * time.Second,
+		TodaysOriginTTL:  time.Duration(options.todaysOriginTTLSeconds) * time.Second,
 		MaxPastDays:      time.Duration(options.maxPastDays) * 24 * time.Hour,
+		ServedPastDays:   time.Duration(options.servedPastDays) * 24 * time.Hour,
+
+		AppsEffectiveFrom: options.appsEffectiveFrom,
+		CachePortalApps:   options.cachePortalApps,
+
+		OptionalOriginData:  options.optionalOriginData,
+		OptionalLatencyData: options.optionalLatencyData,
+
+		DefaultPageLimit:   options.defaultPageLimit,
+		SlowQueryThreshold: options.slowQueryThreshold,
 	}
 	logger.Info("gathered options")
 
-	/* Init Postgres Client */
-	dbInst, cleanup, err := db.NewDBConnection(postgresOptions)
+	/* Init Storage Clients */
+	storage, err := cmd.NewStorage(storageOptions, logger)
 	if err != nil {
-		fmt.Printf("Error setting up Postgres connection: %v\n", err)
+		fmt.Printf("Error setting up storage: %v\n", err)
 		os.Exit(1)
 	}
 	defer func() {
-		err := cleanup()
+		err := storage.Close()
 		if err != nil {
 			fmt.Printf("Error during cleanup: %v\n", err)
 		}
 	}()
 
-	pgClient := db.NewPostgresClientFromDBInstance(dbInst)
-	driver := driver.NewPostgresDriverFromDBInstance(dbInst)
-
 	/* Init PHD Client */
 	phdClient, err := phdClient.NewReadOnlyDBClient(phdClient.Config{
 		BaseURL: options.phdBaseURL,
@@ -156,10 +267,40 @@ func main() {
 		panic(err)
 	}
 
-	backend := &backendProvider{PostgresClient: pgClient, phd: phdClient}
+	backend := &backendProvider{StorageClient: storage.Client, phd: phdClient}
+
+	meter := api.NewRelayMeter(ctx, backend, storage.Driver, logger, meterOptions)
+	successStatuses, err := api.ParseSuccessStatuses(options.successStatusCodes)
+	if err != nil {
+		logger.Error(fmt.Sprintf("parse success status codes failed with error: %s", err.Error()))
+		panic(err)
+	}
+	// The anonymized keys of an unkeyed hash could be reversed, so the anonymized API keys require a secret
+	anonymizer := api.Anonymizer{Secret: options.anonymizationSecret, Length: options.anonymizedKeyLength}
+	if len(options.anonymizedAPIKeys) > 0 {
+		if err := anonymizer.Validate(); err != nil {
+			logger.Error(fmt.Sprintf("%s requires %s: %s", ANONYMIZED_API_KEYS, ANONYMIZATION_SECRET, err.Error()))
+			panic(err)
+		}
+	}
+	apiKeys, err := loadAPIKeys(options)
+	if err != nil {
+		logger.Error(fmt.Sprintf("load API keys failed with error: %s", err.Error()))
+		panic(err)
+	}
+	if options.apiKeysFile != "" {
+		go reloadAPIKeysOnSignal(apiKeys, options.apiKeysFile, logger)
+	}
 
-	meter := api.NewRelayMeter(ctx, backend, driver, logger, meterOptions)
-	http.HandleFunc("/", api.GetHttpServer(ctx, meter, logger, options.relayMeterAPIKeys))
+	serverOptions := api.ServerOptions{
+		ValidateAppKeys:      options.validateAppKeys,
+		ReadOnly:             options.readOnly,
+		StatusClassification: api.StatusClassification{SuccessStatuses: successStatuses},
+		Anonymizer:           anonymizer,
+		AnonymizedAPIKeys:    options.anonymizedAPIKeys,
+		APIKeys:              apiKeys,
+	}
+	http.HandleFunc("/", api.GetHttpServer(ctx, meter, logger, nil, serverOptions))
 
 	logger.Info("Starting the apiserver...")
 	err = http.ListenAndServe(fmt.Sprintf(":%d", options.port), nil)
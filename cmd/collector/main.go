@@ -2,33 +2,57 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
-	driver "github.com/pokt-foundation/relay-meter/driver-autogenerated"
 	"github.com/pokt-foundation/utils-go/environment"
 	"github.com/pokt-foundation/utils-go/logger"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"github.com/pokt-foundation/relay-meter/cmd"
 	"github.com/pokt-foundation/relay-meter/collector"
-	"github.com/pokt-foundation/relay-meter/db"
 )
 
 const (
 	collectingIntervalSeconds = "COLLECTION_INTERVAL_SECONDS"
 	reportIntervalSeconds     = "REPORT_INTERVAL_SECONDS"
 	maxArchiveAgeDays         = "MAX_ARCHIVE_AGE"
+	sourcesMergePolicy        = "SOURCES_MERGE_POLICY"
+	sourcesTolerancePercent   = "SOURCES_TOLERANCE_PERCENT"
+	sourcesPriority           = "SOURCES_PRIORITY"
+	sourcesOverlapping        = "SOURCES_OVERLAPPING"
+	webhookURL                = "WEBHOOK_URL"
+	webhookTimeoutSeconds     = "WEBHOOK_TIMEOUT_SECONDS"
+	todayOnlyMode             = "TODAY_ONLY"
+	statusPort                = "STATUS_PORT"
 
 	defaultCollectIntervalSeconds = 300
 	defaultReportIntervalSeconds  = 30
 	defaultMaxArchiveAgeDays      = 30
+	defaultSourcesMergePolicy     = string(collector.MergePolicySum)
+	defaultSourcesTolerance       = 10
+	defaultWebhookTimeoutSeconds  = 5
 )
 
 type options struct {
 	collectionInterval int
 	reportingInterval  int
 	maxArchiveAge      time.Duration
+	mergePolicy        string
+	tolerancePercent   float64
+	sourcesPriority    []string
+	sourcesOverlapping bool
+	webhookURL         string
+	webhookTimeout     time.Duration
+	todayOnly          bool
+	statusPort         int
 }
 
 func gatherOptions() options {
@@ -36,33 +60,115 @@ func gatherOptions() options {
 		collectionInterval: int(environment.GetInt64(collectingIntervalSeconds, defaultCollectIntervalSeconds)),
 		reportingInterval:  int(environment.GetInt64(reportIntervalSeconds, defaultReportIntervalSeconds)),
 		maxArchiveAge:      time.Duration(environment.GetInt64(maxArchiveAgeDays, defaultMaxArchiveAgeDays)) * 24 * time.Hour,
+		mergePolicy:        environment.GetString(sourcesMergePolicy, defaultSourcesMergePolicy),
+		tolerancePercent:   environment.GetFloat64(sourcesTolerancePercent, defaultSourcesTolerance),
+		sourcesPriority:    splitNames(environment.GetString(sourcesPriority, "")),
+		sourcesOverlapping: environment.GetBool(sourcesOverlapping, false),
+		webhookURL:         environment.GetString(webhookURL, ""),
+		webhookTimeout:     time.Duration(environment.GetInt64(webhookTimeoutSeconds, defaultWebhookTimeoutSeconds)) * time.Second,
+		todayOnly:          environment.GetBool(todayOnlyMode, false),
+		statusPort:         int(environment.GetInt64(statusPort, 0)),
 	}
 }
 
+// splitNames returns the comma-separated names, skipping the empty ones
+func splitNames(names string) []string {
+	var split []string
+	for _, name := range strings.Split(names, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			split = append(split, name)
+		}
+	}
+	return split
+}
+
 // TODO: add a /health endpoint
 func main() {
-	postgresOptions := cmd.GatherPostgresOptions()
+	selfTest := flag.Bool("selftest", false, "verify that the relay counts of a known fixture are collected into the test database set by "+cmd.SELFTEST_POSTGRES_DB+", then exit")
+	flag.Parse()
 
-	dbInst, cleanup, err := db.NewDBConnection(postgresOptions)
+	storageOptions := cmd.GatherStorageOptions()
+	// The collector writes, and verifies the saved metrics, on the primary: a read replica would only hold an idle connection
+	storageOptions.PostgresReplica = nil
+
+	// The self-test fixture is never written to the configured storage, where it would be rolled into the metrics
+	if *selfTest {
+		var err error
+		storageOptions, err = cmd.SelfTestStorageOptions(storageOptions, environment.GetString(cmd.SELFTEST_POSTGRES_DB, ""))
+		if err != nil {
+			fmt.Printf("Error setting up the self-test storage: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	logger := logger.New()
+
+	storage, err := cmd.NewStorage(storageOptions, logger)
 	if err != nil {
-		fmt.Printf("Error setting up Postgres connection: %v\n", err)
+		fmt.Printf("Error setting up storage: %v\n", err)
 		os.Exit(1)
 	}
 	defer func() {
-		err := cleanup()
+		err := storage.Close()
 		if err != nil {
 			fmt.Printf("Error during cleanup: %v\n", err)
 		}
 	}()
 
-	pgClient := db.NewPostgresClientFromDBInstance(dbInst)
-	driver := driver.NewPostgresDriverFromDBInstance(dbInst)
-
 	options := gatherOptions()
+	if err := collector.ValidateIntervals(options.collectionInterval, options.reportingInterval); err != nil {
+		fmt.Printf("Error setting up the collection intervals: %v\n", err)
+		os.Exit(1)
+	}
+
+	mergePolicy, err := collector.ParseMergePolicy(options.mergePolicy)
+	if err != nil {
+		fmt.Printf("Error setting up sources reconciliation: %v\n", err)
+		os.Exit(1)
+	}
+	reconcile := collector.ReconcileOptions{
+		Tolerance:   options.tolerancePercent / 100,
+		Policy:      mergePolicy,
+		Priority:    options.sourcesPriority,
+		Overlapping: options.sourcesOverlapping,
+	}
+	if err := reconcile.Validate(); err != nil {
+		fmt.Printf("Error setting up sources reconciliation: %v\n", err)
+		os.Exit(1)
+	}
+
+	// The webhook is optional: no summary is notified if its URL is not set
+	var notifier collector.Notifier
+	if options.webhookURL != "" {
+		notifier = collector.NewWebhookNotifier(options.webhookURL, options.webhookTimeout)
+	}
+
+	// The self-test exits with a non-zero status on failure, so it can gate a deployment
+	if *selfTest {
+		if err := collector.SelfTest(context.Background(), []collector.Source{storage.Driver}, storage.Driver, storage.Client, reconcile, logger); err != nil {
+			fmt.Printf("Self-test failed: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("Self-test passed")
+		return
+	}
 
 	fmt.Printf("Starting the collector...")
-	logger := logger.New()
 
-	collector := collector.NewCollector([]collector.Source{driver}, pgClient, options.maxArchiveAge, logger)
-	collector.Start(context.Background(), options.collectionInterval, options.reportingInterval)
+	metricsCollector := collector.NewCollector([]collector.Source{storage.Driver}, storage.Client, options.maxArchiveAge, reconcile, notifier, options.todayOnly, logger)
+	// Stop at the next transaction boundary on shutdown, rolling back any in-progress write
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	// The status endpoint, reporting the rows of the last writes, and the metrics are only served if the status port is set
+	if options.statusPort != 0 {
+		mux := http.NewServeMux()
+		mux.Handle("/status", collector.StatusHandler(metricsCollector))
+		mux.Handle("/metrics", promhttp.Handler())
+		go func() {
+			logger.Warn("Status endpoint stopped", slog.Any("error", http.ListenAndServe(fmt.Sprintf(":%d", options.statusPort), mux)))
+		}()
+	}
+
+	metricsCollector.Start(ctx, options.collectionInterval, options.reportingInterval)
 }
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"time"
+
 	"github.com/pokt-foundation/relay-meter/db"
 	"github.com/pokt-foundation/utils-go/environment"
 )
@@ -12,6 +14,12 @@ const (
 	POSTGRES_DB          = "POSTGRES_DB"
 	POSTGRES_USE_PRIVATE = "POSTGRES_USE_PRIVATE"
 
+	POSTGRES_REPLICA_HOST = "POSTGRES_REPLICA_HOST"
+
+	POSTGRES_SSL_MODE                  = "POSTGRES_SSL_MODE"
+	POSTGRES_SEARCH_PATH               = "POSTGRES_SEARCH_PATH"
+	POSTGRES_STATEMENT_TIMEOUT_SECONDS = "POSTGRES_STATEMENT_TIMEOUT_SECONDS"
+
 	TrueStringChar  = "y"
 	FalseStringChar = "n"
 )
@@ -25,5 +33,23 @@ func GatherPostgresOptions() db.PostgresOptions {
 		Host:       environment.MustGetString(POSTGRES_HOST),
 		DB:         environment.MustGetString(POSTGRES_DB),
 		UsePrivate: usePrivate == TrueStringChar,
+
+		SSLMode:          environment.GetString(POSTGRES_SSL_MODE, ""),
+		SearchPath:       environment.GetString(POSTGRES_SEARCH_PATH, ""),
+		StatementTimeout: time.Duration(environment.GetInt64(POSTGRES_STATEMENT_TIMEOUT_SECONDS, 0)) * time.Second,
 	}
 }
+
+// GatherPostgresReplicaOptions returns the options of the read replica, or nil if no replica is configured.
+//
+//	The replica shares all the options of the primary except for its host.
+func GatherPostgresReplicaOptions(primary db.PostgresOptions) *db.PostgresOptions {
+	host := environment.GetString(POSTGRES_REPLICA_HOST, "")
+	if host == "" {
+		return nil
+	}
+
+	replica := primary
+	replica.Host = host
+	return &replica
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/pokt-foundation/relay-meter/api"
+	"github.com/pokt-foundation/relay-meter/collector"
+	"github.com/pokt-foundation/relay-meter/db"
+	driver "github.com/pokt-foundation/relay-meter/driver-autogenerated"
+	"github.com/pokt-foundation/utils-go/environment"
+	"github.com/pokt-foundation/utils-go/logger"
+)
+
+const (
+	STORAGE_BACKEND       = "STORAGE_BACKEND"
+	DUPLICATE_TODAYS_ROWS = "DUPLICATE_TODAYS_ROWS"
+	SELFTEST_POSTGRES_DB  = "SELFTEST_POSTGRES_DB"
+
+	StorageBackendPostgres = "postgres"
+
+	defaultStorageBackend = StorageBackendPostgres
+)
+
+// StorageDriver stores the relay counts uploaded to the apiserver, and serves them as a source to the collector
+type StorageDriver interface {
+	api.Driver
+	collector.Source
+}
+
+// Storage holds the clients of the configured storage backend
+type Storage struct {
+	Client db.StorageClient
+	Driver StorageDriver
+	// Close releases the resources held by the storage backend
+	Close func() error
+}
+
+type StorageOptions struct {
+	Backend  string
+	Postgres db.PostgresOptions
+	// PostgresReplica, if set, is the read replica serving the Reporter reads: writes always go to the primary
+	PostgresReplica *db.PostgresOptions
+	// DuplicateTodaysRows is the handling of several rows of the same application in today's metrics, i.e. warn or sum
+	DuplicateTodaysRows string
+}
+
+func GatherStorageOptions() StorageOptions {
+	options := StorageOptions{
+		Backend:             environment.GetString(STORAGE_BACKEND, defaultStorageBackend),
+		DuplicateTodaysRows: environment.GetString(DUPLICATE_TODAYS_ROWS, ""),
+	}
+
+	if options.Backend == StorageBackendPostgres {
+		options.Postgres = GatherPostgresOptions()
+		options.PostgresReplica = GatherPostgresReplicaOptions(options.Postgres)
+	}
+
+	return options
+}
+
+// SelfTestStorageOptions returns the options of the storage the collector's self-test writes its fixture to, i.e. the testDB
+// database on the configured Postgres server. The fixture's counts would be rolled into the metrics of the configured storage,
+// so the test database must be set, and differ from the configured one.
+func SelfTestStorageOptions(options StorageOptions, testDB string) (StorageOptions, error) {
+	if options.Backend != StorageBackendPostgres {
+		return options, fmt.Errorf("no self-test storage for backend: %q", options.Backend)
+	}
+	if testDB == "" {
+		return options, fmt.Errorf("the self-test needs a separate test database: %s is not set", SELFTEST_POSTGRES_DB)
+	}
+	if testDB == options.Postgres.DB {
+		return options, fmt.Errorf("the self-test database %q must differ from the configured database", testDB)
+	}
+
+	options.Postgres.DB = testDB
+	options.PostgresReplica = nil
+	return options, nil
+}
+
+// NewStorage returns the clients of the storage backend selected by the options, which log their warnings to l
+func NewStorage(options StorageOptions, l *logger.Logger) (*Storage, error) {
+	switch options.Backend {
+	case StorageBackendPostgres:
+		duplicateRows, err := db.ParseDuplicateRows(options.DuplicateTodaysRows)
+		if err != nil {
+			return nil, err
+		}
+		return newPostgresStorage(options.Postgres, options.PostgresReplica, duplicateRows, l)
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %q", options.Backend)
+	}
+}
+
+func newPostgresStorage(options db.PostgresOptions, replicaOptions *db.PostgresOptions, duplicateRows db.DuplicateRows, l *logger.Logger) (*Storage, error) {
+	dbInst, cleanup, err := db.NewDBConnection(options)
+	if err != nil {
+		return nil, fmt.Errorf("error setting up Postgres connection: %w", err)
+	}
+	closers := []func() error{dbInst.Close, cleanup}
+
+	var replica *sql.DB
+	if replicaOptions != nil {
+		var replicaCleanup func() error
+		replica, replicaCleanup, err = db.NewDBConnection(*replicaOptions)
+		if err != nil {
+			dbInst.Close()
+			return nil, fmt.Errorf("error setting up Postgres replica connection: %w", err)
+		}
+		closers = append(closers, replica.Close, replicaCleanup)
+	}
+
+	return &Storage{
+		Client: db.NewPostgresClientWithReplica(dbInst, replica, duplicateRows, l),
+		Driver: driver.NewPostgresDriverFromDBInstance(dbInst),
+		Close: func() error {
+			for _, closer := range closers {
+				if closer == nil {
+					continue
+				}
+				if err := closer(); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}, nil
+}
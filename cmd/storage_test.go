@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pokt-foundation/relay-meter/db"
+	driver "github.com/pokt-foundation/relay-meter/driver-autogenerated"
+	"github.com/pokt-foundation/utils-go/logger"
+)
+
+func TestNewStorage(t *testing.T) {
+	testCases := []struct {
+		name        string
+		options     StorageOptions
+		expectedErr bool
+	}{
+		{
+			name: "Postgres backend is returned",
+			options: StorageOptions{
+				Backend: StorageBackendPostgres,
+				Postgres: db.PostgresOptions{
+					Host: "localhost:5432",
+					User: "postgres",
+					DB:   "postgres",
+				},
+			},
+		},
+		{
+			name: "Postgres backend with a read replica is returned",
+			options: StorageOptions{
+				Backend: StorageBackendPostgres,
+				Postgres: db.PostgresOptions{
+					Host: "localhost:5432",
+					User: "postgres",
+					DB:   "postgres",
+				},
+				PostgresReplica: &db.PostgresOptions{
+					Host: "localhost:5433",
+					User: "postgres",
+					DB:   "postgres",
+				},
+			},
+		},
+		{
+			name: "Invalid handling of duplicate rows returns an error",
+			options: StorageOptions{
+				Backend: StorageBackendPostgres,
+				Postgres: db.PostgresOptions{
+					Host: "localhost:5432",
+					User: "postgres",
+					DB:   "postgres",
+				},
+				DuplicateTodaysRows: "drop",
+			},
+			expectedErr: true,
+		},
+		{
+			name:        "Unknown backend returns an error",
+			options:     StorageOptions{Backend: "clickhouse"},
+			expectedErr: true,
+		},
+		{
+			name:        "Empty backend returns an error",
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			storage, err := NewStorage(tc.options, logger.New())
+			if tc.expectedErr {
+				if err == nil {
+					t.Fatalf("Expected an error for backend %q", tc.options.Backend)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			defer storage.Close()
+
+			if _, ok := storage.Client.(db.PostgresClient); !ok {
+				t.Errorf("Expected a Postgres client, got: %T", storage.Client)
+			}
+			if _, ok := storage.Driver.(*driver.PostgresDriver); !ok {
+				t.Errorf("Expected a Postgres driver, got: %T", storage.Driver)
+			}
+		})
+	}
+}
+
+func TestSelfTestStorageOptions(t *testing.T) {
+	options := StorageOptions{
+		Backend:         StorageBackendPostgres,
+		Postgres:        db.PostgresOptions{Host: "localhost:5432", User: "postgres", DB: "relay_meter"},
+		PostgresReplica: &db.PostgresOptions{Host: "localhost:5433", User: "postgres", DB: "relay_meter"},
+	}
+
+	testCases := []struct {
+		name        string
+		options     StorageOptions
+		testDB      string
+		expected    StorageOptions
+		expectedErr bool
+	}{
+		{
+			name:    "Test database replaces the configured one",
+			options: options,
+			testDB:  "relay_meter_selftest",
+			expected: StorageOptions{
+				Backend:  StorageBackendPostgres,
+				Postgres: db.PostgresOptions{Host: "localhost:5432", User: "postgres", DB: "relay_meter_selftest"},
+			},
+		},
+		{
+			name:        "Missing test database returns an error",
+			options:     options,
+			expectedErr: true,
+		},
+		{
+			name:        "Test database equal to the configured one returns an error",
+			options:     options,
+			testDB:      "relay_meter",
+			expectedErr: true,
+		},
+		{
+			name:        "Unknown backend returns an error",
+			options:     StorageOptions{Backend: "clickhouse"},
+			testDB:      "relay_meter_selftest",
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := SelfTestStorageOptions(tc.options, tc.testDB)
+			if tc.expectedErr {
+				if err == nil {
+					t.Fatalf("Expected an error for test database %q", tc.testDB)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -3,8 +3,10 @@ package collector
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
+	"sort"
 	"time"
 
 	"github.com/pokt-foundation/portal-http-db/v2/types"
@@ -22,39 +24,54 @@ type Source interface {
 	TodaysCounts() (map[types.PortalAppPublicKey]api.RelayCounts, error)
 	TodaysCountsPerOrigin() (map[types.PortalAppOrigin]api.RelayCounts, error)
 	TodaysLatency() (map[types.PortalAppPublicKey][]api.Latency, error)
+	// LatestDataTime returns the timestamp of the newest data available in the source, or the zero time if it has no data.
+	LatestDataTime() (time.Time, error)
 	Name() string
 }
 
 type Writer interface {
 	// Returns the 2 timestamps which mark the first and last day for
-	//	which the metrics are saved.
+	//	which the metrics are saved, and whether any metrics are saved at all.
 	//	It is assumed that there are no gaps in the returned time period.
-	ExistingMetricsTimespan() (time.Time, time.Time, error)
+	ExistingMetricsTimespan() (first time.Time, last time.Time, hasData bool, err error)
+	// Writes today's metrics in a single transaction, which is rolled back if the context is cancelled before it is committed.
 	// TODO: allow overwriting today's metrics
-	WriteTodaysMetrics(counts map[types.PortalAppPublicKey]api.RelayCounts, countsOrigin map[types.PortalAppOrigin]api.RelayCounts, latencies map[types.PortalAppPublicKey][]api.Latency) error
-	WriteDailyUsage(counts map[time.Time]map[types.PortalAppPublicKey]api.RelayCounts, countsOrigin map[types.PortalAppOrigin]api.RelayCounts) error
+	WriteTodaysMetrics(ctx context.Context, counts map[types.PortalAppPublicKey]api.RelayCounts, countsOrigin map[types.PortalAppOrigin]api.RelayCounts, latencies map[types.PortalAppPublicKey][]api.Latency) error
+	WriteDailyUsage(ctx context.Context, counts map[time.Time]map[types.PortalAppPublicKey]api.RelayCounts, countsOrigin map[types.PortalAppOrigin]api.RelayCounts) error
 	WriteTodaysUsage(ctx context.Context, tx *sql.Tx, counts map[types.PortalAppPublicKey]api.RelayCounts, countsOrigin map[types.PortalAppOrigin]api.RelayCounts) error
 }
 
+var ErrInvalidIntervals = errors.New("invalid collector intervals")
+
 type Collector interface {
 	// Start a goroutine, which collects data at set intervals
 	//	The routine respects existing metrics, i.e. will not collect/overwrite existing metrics
 	//	expect for today's metrics
+	//	Nothing is collected if the intervals are invalid, see ValidateIntervals
 	Start(ctx context.Context, collectIntervalSeconds, reportIntervalSeconds int)
 	// Collect and write metrics data: this will overwrite any existing metrics
 	//	This function exists to allow manually overriding the collector's behavior.
-	CollectDailyUsage(from, to time.Time) error
+	CollectDailyUsage(ctx context.Context, from, to time.Time) error
+	// WriteStatus returns the numbers of rows written by the last successful writes
+	WriteStatus() WriteStatus
 }
 
 // NewCollector returns a collector which will periodically (or on Collect being called)
 //
 //	gathers metrics from the source and writes to the writer.
 //	maxArchiveAge is the oldest time for which metrics are saved
-func NewCollector(sources []Source, writer Writer, maxArchiveAge time.Duration, log *logger.Logger) Collector {
+//	reconcile sets how the relay counts of multiple sources for the same application are reconciled
+//	notifier, if not nil, is notified of the summary of each successful collection
+//	todayOnly skips the collection of daily metrics, e.g. for a secondary collector only keeping today's metrics fresh
+func NewCollector(sources []Source, writer Writer, maxArchiveAge time.Duration, reconcile ReconcileOptions, notifier Notifier, todayOnly bool, log *logger.Logger) Collector {
 	return &collector{
 		Sources:       sources,
 		Writer:        writer,
 		MaxArchiveAge: maxArchiveAge,
+		Reconcile:     reconcile,
+		Notifier:      notifier,
+		TodayOnly:     todayOnly,
+		Now:           time.Now,
 		Logger:        log,
 	}
 }
@@ -63,20 +80,45 @@ type collector struct {
 	Sources []Source
 	Writer
 	MaxArchiveAge time.Duration
+	Reconcile     ReconcileOptions
+	Notifier      Notifier
+	TodayOnly     bool
+	// Now returns the current time: time.Now if not set
+	Now func() time.Time
 	*logger.Logger
+
+	writes writeCounters
+}
+
+// now returns the current time of the collector's clock
+func (c *collector) now() time.Time {
+	if c.Now == nil {
+		return time.Now()
+	}
+	return c.Now()
+}
+
+func (c *collector) WriteStatus() WriteStatus {
+	return c.writes.get()
 }
 
 // Collects relay usage data from the source and uses the writer to store.
 //
 //	-
-func (c *collector) CollectDailyUsage(from, to time.Time) error {
+func (c *collector) CollectDailyUsage(ctx context.Context, from, to time.Time) error {
+	_, err := c.collectDailyUsage(ctx, from, to)
+	return err
+}
+
+// collectDailyUsage collects and writes the daily metrics, and returns the written counts
+func (c *collector) collectDailyUsage(ctx context.Context, from, to time.Time) (map[time.Time]map[types.PortalAppPublicKey]api.RelayCounts, error) {
 	c.Logger.Info("Starting daily metrics collection...",
 		slog.Time("from", from),
 		slog.Time("to", to),
 	)
 	from, to, err := api.AdjustTimePeriod(from, to)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	c.Logger.Info("Daily metrics collection period adjusted.",
 		slog.Time("from", from),
@@ -84,11 +126,12 @@ func (c *collector) CollectDailyUsage(from, to time.Time) error {
 	)
 
 	var sourcesCounts []map[time.Time]map[types.PortalAppPublicKey]api.RelayCounts
+	var sourceNames []string
 
 	for _, source := range c.Sources {
 		sourceCounts, err := source.DailyCounts(from, to)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		c.Logger.Info("Collected daily metrics",
 			slog.Int("daily_metrics_count", len(sourceCounts)),
@@ -96,20 +139,47 @@ func (c *collector) CollectDailyUsage(from, to time.Time) error {
 			slog.Time("from", from),
 			slog.Time("to", to),
 		)
-		sourcesCounts = append(sourcesCounts, sourceCounts)
+		sourcesCounts = append(sourcesCounts, api.NormalizeDailyRelayCountsKeys(sourceCounts))
+		sourceNames = append(sourceNames, source.Name())
+	}
+
+	counts, err := c.reconcileTimeRelayCountsMaps(sourcesCounts, sourceNames)
+	if err != nil {
+		return nil, err
 	}
 
-	counts := mergeTimeRelayCountsMaps(sourcesCounts)
+	// Do not start a new transaction if the collector is shutting down
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	// TODO: Add counts per origins
-	return c.Writer.WriteDailyUsage(counts, nil)
+	if err := c.Writer.WriteDailyUsage(ctx, counts, nil); err != nil {
+		return nil, err
+	}
+	c.writes.recordDaily(c.now(), counts, nil)
 
+	return counts, nil
+}
+
+// collectTodaysUsage collects and writes today's metrics, and returns the written counts.
+//
+//	The runs and failures of the collection are counted in the collector's metrics.
+func (c *collector) collectTodaysUsage(ctx context.Context) (map[types.PortalAppPublicKey]api.RelayCounts, error) {
+	collectionRuns.WithLabelValues(collectionTodays).Inc()
+	counts, err := c.collectAndWriteTodaysUsage(ctx)
+	if err != nil {
+		collectionFailures.WithLabelValues(collectionTodays).Inc()
+	}
+	return counts, err
 }
 
-func (c *collector) collectTodaysUsage() error {
+// collectAndWriteTodaysUsage collects today's metrics from all the sources and writes them in a single transaction
+func (c *collector) collectAndWriteTodaysUsage(ctx context.Context) (map[types.PortalAppPublicKey]api.RelayCounts, error) {
 	var sourcesTodaysCounts []map[types.PortalAppPublicKey]api.RelayCounts
 	var sourcesTodaysRelaysInOrigin []map[types.PortalAppOrigin]api.RelayCounts
 	var sourcesTodaysLatency []map[types.PortalAppPublicKey][]api.Latency
+	var sourceNames []string
 
 	for _, source := range c.Sources {
 		sourceTodaysCounts, err := source.TodaysCounts()
@@ -122,11 +192,11 @@ func (c *collector) collectTodaysUsage() error {
 			slog.Int("todays_usage_count", len(sourceTodaysCounts)),
 			slog.String("source", source.Name()),
 		)
-		sourcesTodaysCounts = append(sourcesTodaysCounts, sourceTodaysCounts)
+		sourcesTodaysCounts = append(sourcesTodaysCounts, api.NormalizeRelayCountsKeys(sourceTodaysCounts))
 
 		sourceTodaysRelaysInOrigin, err := source.TodaysCountsPerOrigin()
 		if err != nil {
-			return err
+			return nil, err
 		}
 		c.Logger.Info("Collected todays metrics",
 			slog.Int("todays_metrics_count_per_origin", len(sourceTodaysRelaysInOrigin)),
@@ -144,29 +214,90 @@ func (c *collector) collectTodaysUsage() error {
 			slog.Int("todays_latencies_count", len(sourceTodaysLatency)),
 			slog.String("source", source.Name()),
 		)
-		sourcesTodaysLatency = append(sourcesTodaysLatency, sourceTodaysLatency)
+		sourcesTodaysLatency = append(sourcesTodaysLatency, api.NormalizeLatencyKeys(sourceTodaysLatency))
+		sourceNames = append(sourceNames, source.Name())
 	}
 
-	todaysCounts := mergeRelayCountsMaps(sourcesTodaysCounts)
+	todaysCounts, err := c.reconcileRelayCountsMaps(sourcesTodaysCounts, sourceNames)
+	if err != nil {
+		return nil, err
+	}
 	todaysRelaysInOrigin := mergeRelayCountsMapsByOrigin(sourcesTodaysRelaysInOrigin)
 	todaysLatency := mergeLatencyMaps(sourcesTodaysLatency)
 
-	return c.Writer.WriteTodaysMetrics(todaysCounts, todaysRelaysInOrigin, todaysLatency)
+	// Do not start a new transaction if the collector is shutting down
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := c.Writer.WriteTodaysMetrics(ctx, todaysCounts, todaysRelaysInOrigin, todaysLatency); err != nil {
+		return nil, err
+	}
+	c.writes.recordTodays(c.now(), todaysCounts, todaysRelaysInOrigin, todaysLatency)
+
+	return todaysCounts, nil
+}
+
+// collect writes today's metrics and any missing daily metrics, and notifies the summary of a successful collection.
+//
+//	Cancelling the context stops the collection at the next transaction boundary: a transaction
+//	in progress is rolled back, so today's tables are never left partially rebuilt.
+//	The current time is captured once, so a collection running past midnight does not write the day it started on
+//	both as today's and as daily metrics.
+func (c *collector) collect(ctx context.Context) error {
+	start := time.Now()
+	collectionRuns.WithLabelValues(collectionRun).Inc()
+
+	var summary CollectionSummary
+	if err := c.collectMetrics(ctx, c.now(), &summary); err != nil {
+		collectionFailures.WithLabelValues(collectionRun).Inc()
+		return err
+	}
+	summary.DurationMillis = time.Since(start).Milliseconds()
+
+	c.notify(ctx, summary)
+	return nil
 }
 
-func (c *collector) collect() error {
-	if err := c.collectTodaysUsage(); err != nil {
+// notify sends the collection summary to the notifier, if any: a failed notification does not fail the collection.
+func (c *collector) notify(ctx context.Context, summary CollectionSummary) {
+	if c.Notifier == nil {
+		return
+	}
+
+	if err := c.Notifier.Notify(ctx, summary); err != nil {
+		c.Logger.Warn("Failed to notify collection summary",
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+// collectMetrics writes today's metrics and any missing daily metrics, adding them to the summary.
+//
+//	The daily metrics are collected up to the day before the day of now, which is the day of today's metrics.
+func (c *collector) collectMetrics(ctx context.Context, now time.Time, summary *CollectionSummary) error {
+	todaysCounts, err := c.collectTodaysUsage(ctx)
+	if err != nil {
 		c.Logger.Warn("Failed to write todays metrics",
 			slog.String("error", err.Error()),
 		)
 		return err
 	}
+	for _, count := range todaysCounts {
+		summary.TotalRelays = summary.TotalRelays.Add(count)
+	}
 
-	first, last, err := c.Writer.ExistingMetricsTimespan()
+	if c.TodayOnly {
+		c.Logger.Info("Today only mode, skipping daily metrics collection...")
+		return nil
+	}
+
+	first, last, hasData, err := c.Writer.ExistingMetricsTimespan()
 	if err != nil {
 		return err
 	}
 	c.Logger.Info("Verified existing daily metrics",
+		slog.Bool("has_data", hasData),
 		slog.Time("first", first),
 		slog.Time("last", last),
 	)
@@ -174,11 +305,11 @@ func (c *collector) collect() error {
 	// We assume there are no gaps between stored metrics from start to end, so
 	// 	start collecting metrics after the last saved date
 	dayLayout := "2006-01-02"
-	today, err := time.Parse(dayLayout, time.Now().Format(dayLayout))
+	today, err := time.Parse(dayLayout, now.Format(dayLayout))
 	if err != nil {
 		return err
 	}
-	if last.Equal(today.AddDate(0, 0, -1)) || last.After(today.AddDate(0, 0, -1)) {
+	if hasData && !last.Before(today.AddDate(0, 0, -1)) {
 		c.Logger.Info("Last collected daily metric was yesterday, skipping daily metrics collection...",
 			slog.Time("today", today),
 			slog.Time("last_daily_collected", last),
@@ -186,8 +317,8 @@ func (c *collector) collect() error {
 		return nil
 	}
 	var from time.Time
-	if first.Equal(time.Time{}) {
-		from = time.Now().Add(-1 * c.MaxArchiveAge)
+	if !hasData {
+		from = now.Add(-1 * c.MaxArchiveAge)
 	} else {
 		from = last.AddDate(0, 0, 1)
 		if from.After(today) {
@@ -195,14 +326,111 @@ func (c *collector) collect() error {
 		}
 	}
 
-	// TODO: cover with unit tests
-	return c.CollectDailyUsage(from, time.Now().AddDate(0, 0, -1))
+	yesterday := now.AddDate(0, 0, -1)
+	to := c.lastCompleteDay(yesterday)
+	if skippedFrom := to.AddDate(0, 0, 1); skippedFrom.After(from) {
+		summary.SkippedDays = days(skippedFrom, yesterday)
+	} else {
+		summary.SkippedDays = days(from, yesterday)
+	}
+	if to.Before(from) {
+		c.Logger.Info("Sources have not completed ingesting the days with missing metrics, skipping daily metrics collection...",
+			slog.Time("from", from),
+			slog.Time("last_complete_day", to),
+		)
+		return nil
+	}
+
+	counts, err := c.collectDailyUsage(ctx, from, to)
+	if err != nil {
+		return err
+	}
+
+	for day, dayCounts := range counts {
+		summary.DaysCollected = append(summary.DaysCollected, day)
+		for _, count := range dayCounts {
+			summary.TotalRelays = summary.TotalRelays.Add(count)
+		}
+	}
+	sort.Slice(summary.DaysCollected, func(i, j int) bool {
+		return summary.DaysCollected[i].Before(summary.DaysCollected[j])
+	})
+
+	return nil
+}
+
+// days returns the start of each day from the day of from to the day of to, both included
+func days(from, to time.Time) []time.Time {
+	from, to, err := api.AdjustTimePeriod(from, to)
+	if err != nil {
+		return nil
+	}
+
+	var days []time.Time
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		days = append(days, day)
+	}
+
+	return days
+}
+
+// lastCompleteDay returns the last day, no later than the supplied one, for which all the sources have completed ingesting data.
+//
+//	The day of a source's newest data may still be receiving data, so only the days before it are complete.
+//	Sources with no data, or which fail to report their newest data, do not limit the collection.
+func (c *collector) lastCompleteDay(day time.Time) time.Time {
+	for _, source := range c.Sources {
+		latest, err := source.LatestDataTime()
+		if err != nil {
+			c.Logger.Warn("Failed to get the newest data time of source",
+				slog.String("error", err.Error()),
+				slog.String("source", source.Name()),
+			)
+			continue
+		}
+		if latest.IsZero() {
+			continue
+		}
+
+		c.Logger.Info("Verified the newest data time of source",
+			slog.Time("latest", latest),
+			slog.Duration("lag", time.Since(latest)),
+			slog.String("source", source.Name()),
+		)
+
+		if complete := latest.AddDate(0, 0, -1); complete.Before(day) {
+			day = complete
+		}
+	}
+
+	return day
+}
+
+// ValidateIntervals returns an error unless the collect interval is positive, and the report interval, i.e. the interval
+// of the countdown to the next collection, is positive and not longer than the collect interval.
+func ValidateIntervals(collectIntervalSeconds, reportIntervalSeconds int) error {
+	if collectIntervalSeconds <= 0 {
+		return fmt.Errorf("%w: the collect interval must be positive, got: %d seconds", ErrInvalidIntervals, collectIntervalSeconds)
+	}
+	if reportIntervalSeconds <= 0 || reportIntervalSeconds > collectIntervalSeconds {
+		return fmt.Errorf("%w: the report interval must be positive and at most the collect interval of %d seconds, got: %d seconds",
+			ErrInvalidIntervals, collectIntervalSeconds, reportIntervalSeconds)
+	}
+	return nil
 }
 
 func (c *collector) Start(ctx context.Context, collectIntervalSeconds, reportIntervalSeconds int) {
+	// The tickers would panic on a non-positive interval
+	if err := ValidateIntervals(collectIntervalSeconds, reportIntervalSeconds); err != nil {
+		c.Logger.Error("Collector not started",
+			slog.String("error", err.Error()),
+		)
+		return
+	}
+
 	// Do an initial data collection, and then repeat on set intervals
 	c.Logger.Info("Starting initial data collection...")
-	if err := c.collect(); err != nil {
+	if err := c.collect(ctx); err != nil {
 		c.Logger.Warn("Failed to collect data",
 			slog.String("error", err.Error()),
 		)
@@ -223,7 +451,7 @@ func (c *collector) Start(ctx context.Context, collectIntervalSeconds, reportInt
 			c.Logger.Info(fmt.Sprintf("Will collect data in %d seconds...", remaining))
 		case <-collectTicker.C:
 			c.Logger.Info("Starting data collection...")
-			if err := c.collect(); err != nil {
+			if err := c.collect(ctx); err != nil {
 				c.Logger.Warn("Failed to collect data",
 					slog.String("error", err.Error()),
 				)
@@ -3,12 +3,15 @@ package collector
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"testing"
 	"time"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/pokt-foundation/portal-http-db/v2/types"
 	"github.com/pokt-foundation/relay-meter/api"
 	"github.com/pokt-foundation/utils-go/logger"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 func TestCollect(t *testing.T) {
@@ -21,8 +24,10 @@ func TestCollect(t *testing.T) {
 	testCases := []struct {
 		name               string
 		maxArchiveAge      time.Duration
+		hasSaved           bool
 		firstSaved         time.Time
 		lastSaved          time.Time
+		latestDataTime     time.Time
 		expectedFrom       time.Time
 		expectedTo         time.Time
 		shouldCollectDaily bool
@@ -37,6 +42,7 @@ func TestCollect(t *testing.T) {
 		{
 			name:               "Previous days with existing metrics are skipped",
 			maxArchiveAge:      30 * 24 * time.Hour,
+			hasSaved:           true,
 			firstSaved:         today.AddDate(0, 0, -40),
 			lastSaved:          today.AddDate(0, 0, -10),
 			shouldCollectDaily: true,
@@ -46,12 +52,33 @@ func TestCollect(t *testing.T) {
 		{
 			name:          "Daily metrics are skipped altogether if yesterday's data already collected",
 			maxArchiveAge: 30 * 24 * time.Hour,
+			hasSaved:      true,
 			firstSaved:    today.AddDate(0, 0, -40),
 			lastSaved:     today.AddDate(0, 0, -1),
 		},
+		{
+			name:               "Days not completely ingested by the sources are skipped",
+			maxArchiveAge:      30 * 24 * time.Hour,
+			hasSaved:           true,
+			firstSaved:         today.AddDate(0, 0, -40),
+			lastSaved:          today.AddDate(0, 0, -10),
+			latestDataTime:     today.AddDate(0, 0, -5),
+			shouldCollectDaily: true,
+			expectedFrom:       today.AddDate(0, 0, -9),
+			expectedTo:         today.AddDate(0, 0, -5),
+		},
+		{
+			name:           "Daily metrics are skipped altogether if the sources have not completed any missing day",
+			maxArchiveAge:  30 * 24 * time.Hour,
+			hasSaved:       true,
+			firstSaved:     today.AddDate(0, 0, -40),
+			lastSaved:      today.AddDate(0, 0, -10),
+			latestDataTime: today.AddDate(0, 0, -9),
+		},
 		{
 			name:          "Today is not skipped even if metrics are saved for it",
 			maxArchiveAge: 30 * 24 * time.Hour,
+			hasSaved:      true,
 			firstSaved:    today.AddDate(0, 0, -40),
 			lastSaved:     today,
 		},
@@ -59,12 +86,13 @@ func TestCollect(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			source1 := &fakeSource{}
-			source2 := &fakeSource{}
+			source1 := &fakeSource{latestDataTime: tc.latestDataTime}
+			source2 := &fakeSource{latestDataTime: tc.latestDataTime}
 			sources := []*fakeSource{source1, source2}
 			writer := &fakeWriter{
-				first: tc.firstSaved,
-				last:  tc.lastSaved,
+				hasData: tc.hasSaved,
+				first:   tc.firstSaved,
+				last:    tc.lastSaved,
 			}
 			c := &collector{
 				Sources:       []Source{sources[0], sources[1]},
@@ -72,7 +100,7 @@ func TestCollect(t *testing.T) {
 				MaxArchiveAge: tc.maxArchiveAge,
 				Logger:        logger.New(),
 			}
-			if err := c.collect(); err != nil {
+			if err := c.collect(context.Background()); err != nil {
 				t.Fatalf("Unexpected error: %v", err)
 			}
 
@@ -109,6 +137,203 @@ func TestCollect(t *testing.T) {
 	}
 }
 
+func TestCollectTodayOnly(t *testing.T) {
+	source := &fakeSource{
+		todaysCounts: map[types.PortalAppPublicKey]api.RelayCounts{
+			"app1": {Success: 2, Failure: 1},
+		},
+	}
+	writer := &fakeWriter{}
+	c := &collector{
+		Sources:       []Source{source},
+		Writer:        writer,
+		MaxArchiveAge: 30 * 24 * time.Hour,
+		TodayOnly:     true,
+		Logger:        logger.New(),
+	}
+
+	if err := c.collect(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if writer.todaysWrites != 1 {
+		t.Fatalf("Expected 1 write of todays metrics, got: %d", writer.todaysWrites)
+	}
+	if writer.callsCount != 0 {
+		t.Errorf("Expected existing metrics not to be queried in today only mode, got: %d calls", writer.callsCount)
+	}
+	if source.dailyMetricsCollected {
+		t.Errorf("Expected daily metrics not to be collected in today only mode")
+	}
+	if writer.dailyWrites != 0 {
+		t.Errorf("Expected no writes of daily metrics in today only mode, got: %d", writer.dailyWrites)
+	}
+}
+
+func TestCollectCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	source := &fakeSource{
+		todaysCounts: map[types.PortalAppPublicKey]api.RelayCounts{
+			"app1": {Success: 2, Failure: 1},
+		},
+		cancel: cancel,
+	}
+	writer := &fakeWriter{}
+	c := &collector{
+		Sources:       []Source{source},
+		Writer:        writer,
+		MaxArchiveAge: 30 * 24 * time.Hour,
+		Logger:        logger.New(),
+	}
+
+	err := c.collect(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected error: %v, got: %v", context.Canceled, err)
+	}
+	if writer.todaysWrites != 0 {
+		t.Fatalf("Expected no writes of todays metrics after cancellation, got: %d", writer.todaysWrites)
+	}
+	if writer.callsCount != 0 {
+		t.Fatalf("Expected daily metrics collection to be skipped after cancellation, got: %d calls", writer.callsCount)
+	}
+}
+
+func TestCollectMetrics(t *testing.T) {
+	counts := func() (runs, failures, todaysRuns, todaysFailures float64) {
+		return testutil.ToFloat64(collectionRuns.WithLabelValues(collectionRun)),
+			testutil.ToFloat64(collectionFailures.WithLabelValues(collectionRun)),
+			testutil.ToFloat64(collectionRuns.WithLabelValues(collectionTodays)),
+			testutil.ToFloat64(collectionFailures.WithLabelValues(collectionTodays))
+	}
+
+	c := &collector{
+		Sources:   []Source{&fakeSource{todaysCounts: map[types.PortalAppPublicKey]api.RelayCounts{"app1": {Success: 2}}}},
+		Writer:    &fakeWriter{},
+		TodayOnly: true,
+		Logger:    logger.New(),
+	}
+
+	runs, failures, todaysRuns, todaysFailures := counts()
+	if err := c.collect(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := c.collect(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected error: %v, got: %v", context.Canceled, err)
+	}
+
+	gotRuns, gotFailures, gotTodaysRuns, gotTodaysFailures := counts()
+	if gotRuns-runs != 2 || gotTodaysRuns-todaysRuns != 2 {
+		t.Errorf("Expected 2 runs of each collection, got: %v runs and %v of today's metrics", gotRuns-runs, gotTodaysRuns-todaysRuns)
+	}
+	if gotFailures-failures != 1 || gotTodaysFailures-todaysFailures != 1 {
+		t.Errorf("Expected 1 failure of each collection, got: %v failures and %v of today's metrics", gotFailures-failures, gotTodaysFailures-todaysFailures)
+	}
+}
+
+func TestCollectAtMidnight(t *testing.T) {
+	today := time.Date(2022, time.July, 20, 0, 0, 0, 0, time.UTC)
+	now := today.Add(24*time.Hour - time.Second)
+
+	// Midnight passes once today's metrics have been collected
+	source := &fakeSource{
+		afterTodaysLatency: func() { now = today.AddDate(0, 0, 1).Add(time.Second) },
+	}
+	writer := &fakeWriter{
+		hasData: true,
+		first:   today.AddDate(0, 0, -40),
+		last:    today.AddDate(0, 0, -3),
+	}
+	c := &collector{
+		Sources:       []Source{source},
+		Writer:        writer,
+		MaxArchiveAge: 30 * 24 * time.Hour,
+		Now:           func() time.Time { return now },
+		Logger:        logger.New(),
+	}
+
+	if err := c.collect(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if writer.todaysWrites != 1 {
+		t.Fatalf("Expected 1 write of todays metrics, got: %d", writer.todaysWrites)
+	}
+	if !source.dailyMetricsCollected {
+		t.Fatalf("Expected daily metrics to be collected")
+	}
+
+	// The day written as today's metrics must not also be written as daily metrics
+	if expectedFrom := today.AddDate(0, 0, -2); !source.requestedFrom.Equal(expectedFrom) {
+		t.Errorf("Expected 'from': %v, got: %v", expectedFrom, source.requestedFrom)
+	}
+	if !source.requestedTo.Equal(today) {
+		t.Errorf("Expected 'to': %v, got: %v", today, source.requestedTo)
+	}
+}
+
+func TestCollectDailyUsage(t *testing.T) {
+	today := time.Date(2022, time.July, 20, 0, 0, 0, 0, time.UTC)
+	now := today.Add(12 * time.Hour)
+	day1, day2 := today.AddDate(0, 0, -2), today.AddDate(0, 0, -1)
+
+	source1 := &fakeSource{
+		response: map[time.Time]map[types.PortalAppPublicKey]api.RelayCounts{
+			day1: {"app1": {Success: 5, Failure: 1}},
+			day2: {"app1": {Success: 4}},
+		},
+	}
+	source2 := &fakeSource{
+		response: map[time.Time]map[types.PortalAppPublicKey]api.RelayCounts{
+			day1: {"app1": {Success: 3}, "app2": {Success: 2, Failure: 2}},
+		},
+	}
+	writer := &fakeWriter{
+		hasData: true,
+		first:   today.AddDate(0, 0, -40),
+		last:    today.AddDate(0, 0, -3),
+	}
+	c := &collector{
+		Sources:       []Source{source1, source2},
+		Writer:        writer,
+		MaxArchiveAge: 30 * 24 * time.Hour,
+		Reconcile:     ReconcileOptions{Tolerance: 1, Policy: MergePolicySum},
+		Now:           func() time.Time { return now },
+		Logger:        logger.New(),
+	}
+
+	var summary CollectionSummary
+	if err := c.collectMetrics(context.Background(), now, &summary); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The days missing from the storage are requested from all the sources, and their counts merged
+	for _, source := range []*fakeSource{source1, source2} {
+		if !source.requestedFrom.Equal(day1) || !source.requestedTo.Equal(today) {
+			t.Errorf("Expected daily metrics to be requested from %v to %v, got: %v to %v", day1, today, source.requestedFrom, source.requestedTo)
+		}
+	}
+	if writer.dailyWrites != 1 {
+		t.Fatalf("Expected 1 write of daily metrics, got: %d", writer.dailyWrites)
+	}
+	expectedCounts := map[time.Time]map[types.PortalAppPublicKey]api.RelayCounts{
+		day1: {"app1": {Success: 8, Failure: 1}, "app2": {Success: 2, Failure: 2}},
+		day2: {"app1": {Success: 4}},
+	}
+	if diff := cmp.Diff(expectedCounts, writer.dailyCounts); diff != "" {
+		t.Errorf("unexpected value (-want +got):\n%s", diff)
+	}
+
+	expectedSummary := CollectionSummary{
+		DaysCollected: []time.Time{day1, day2},
+		TotalRelays:   api.RelayCounts{Success: 14, Failure: 3},
+	}
+	if diff := cmp.Diff(expectedSummary, summary); diff != "" {
+		t.Errorf("unexpected value (-want +got):\n%s", diff)
+	}
+}
+
 func TestStart(t *testing.T) {
 	testCases := []struct {
 		name             string
@@ -149,6 +374,83 @@ func TestStart(t *testing.T) {
 	}
 }
 
+func TestValidateIntervals(t *testing.T) {
+	testCases := []struct {
+		name            string
+		collectInterval int
+		reportInterval  int
+		expectedErr     bool
+	}{
+		{
+			name:            "Report interval shorter than the collect interval is valid",
+			collectInterval: 300,
+			reportInterval:  30,
+		},
+		{
+			name:            "Report interval equal to the collect interval is valid",
+			collectInterval: 300,
+			reportInterval:  300,
+		},
+		{
+			name:            "Zero report interval is rejected",
+			collectInterval: 300,
+			expectedErr:     true,
+		},
+		{
+			name:            "Negative report interval is rejected",
+			collectInterval: 300,
+			reportInterval:  -30,
+			expectedErr:     true,
+		},
+		{
+			name:            "Report interval longer than the collect interval is rejected",
+			collectInterval: 30,
+			reportInterval:  300,
+			expectedErr:     true,
+		},
+		{
+			name:           "Zero collect interval is rejected",
+			reportInterval: 30,
+			expectedErr:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateIntervals(tc.collectInterval, tc.reportInterval)
+			if tc.expectedErr != errors.Is(err, ErrInvalidIntervals) {
+				t.Fatalf("Expected error: %t, got: %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestStartInvalidIntervals(t *testing.T) {
+	writer := &fakeWriter{}
+	c := &collector{
+		Sources:       []Source{&fakeSource{}},
+		Writer:        writer,
+		MaxArchiveAge: 30 * 24 * time.Hour,
+		Logger:        logger.New(),
+	}
+
+	// A zero report interval would otherwise make the ticker panic
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.Start(context.Background(), 4, 0)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the collector not to start with invalid intervals")
+	}
+	if writer.callsCount != 0 {
+		t.Fatalf("Expected no data collection calls, got: %d", writer.callsCount)
+	}
+}
+
 type fakeSource struct {
 	requestedFrom time.Time
 	requestedTo   time.Time
@@ -162,6 +464,12 @@ type fakeSource struct {
 	todaysMetricsCollected bool
 	dailyMetricsCollected  bool
 	todaysLatencyCollected bool
+	latestDataTime         time.Time
+
+	// cancel, if set, is called once todays latency has been collected
+	cancel context.CancelFunc
+	// afterTodaysLatency, if set, is called once todays latency has been collected
+	afterTodaysLatency func()
 }
 
 func (f *fakeSource) DailyCounts(from, to time.Time) (map[time.Time]map[types.PortalAppPublicKey]api.RelayCounts, error) {
@@ -183,31 +491,46 @@ func (f *fakeSource) TodaysCountsPerOrigin() (map[types.PortalAppOrigin]api.Rela
 
 func (f *fakeSource) TodaysLatency() (map[types.PortalAppPublicKey][]api.Latency, error) {
 	f.todaysLatencyCollected = true
+	if f.cancel != nil {
+		f.cancel()
+	}
+	if f.afterTodaysLatency != nil {
+		f.afterTodaysLatency()
+	}
 	return f.todaysLatency, nil
 }
 
+func (f *fakeSource) LatestDataTime() (time.Time, error) {
+	return f.latestDataTime, nil
+}
+
 func (f *fakeSource) Name() string {
 	return "fake"
 }
 
 type fakeWriter struct {
+	hasData             bool
 	first               time.Time
 	last                time.Time
 	callsCount          int
 	todaysWrites        int
 	todaysLatencyWrites int
+	dailyWrites         int
+	dailyCounts         map[time.Time]map[types.PortalAppPublicKey]api.RelayCounts
 }
 
-func (f *fakeWriter) ExistingMetricsTimespan() (time.Time, time.Time, error) {
+func (f *fakeWriter) ExistingMetricsTimespan() (time.Time, time.Time, bool, error) {
 	f.callsCount++
-	return f.first, f.last, nil
+	return f.first, f.last, f.hasData, nil
 }
 
-func (f *fakeWriter) WriteDailyUsage(counts map[time.Time]map[types.PortalAppPublicKey]api.RelayCounts, countsOrigin map[types.PortalAppOrigin]api.RelayCounts) error {
+func (f *fakeWriter) WriteDailyUsage(ctx context.Context, counts map[time.Time]map[types.PortalAppPublicKey]api.RelayCounts, countsOrigin map[types.PortalAppOrigin]api.RelayCounts) error {
+	f.dailyWrites++
+	f.dailyCounts = counts
 	return nil
 }
 
-func (f *fakeWriter) WriteTodaysMetrics(counts map[types.PortalAppPublicKey]api.RelayCounts, countsOrigin map[types.PortalAppOrigin]api.RelayCounts, latencies map[types.PortalAppPublicKey][]api.Latency) error {
+func (f *fakeWriter) WriteTodaysMetrics(ctx context.Context, counts map[types.PortalAppPublicKey]api.RelayCounts, countsOrigin map[types.PortalAppOrigin]api.RelayCounts, latencies map[types.PortalAppPublicKey][]api.Latency) error {
 	f.todaysWrites++
 	f.todaysLatencyWrites++
 	return nil
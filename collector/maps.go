@@ -32,14 +32,7 @@ func mergeRelayCountsMaps(appMaps []map[types.PortalAppPublicKey]api.RelayCounts
 
 	for _, appMap := range appMaps {
 		for app, count := range appMap {
-			if _, ok := mergedMap[app]; ok {
-				mergedMap[app] = api.RelayCounts{
-					Success: mergedMap[app].Success + count.Success,
-					Failure: mergedMap[app].Failure + count.Failure,
-				}
-			} else {
-				mergedMap[app] = count
-			}
+			mergedMap[app] = mergedMap[app].Add(count)
 		}
 	}
 
@@ -51,14 +44,7 @@ func mergeRelayCountsMapsByOrigin(appMaps []map[types.PortalAppOrigin]api.RelayC
 
 	for _, appMap := range appMaps {
 		for app, count := range appMap {
-			if _, ok := mergedMap[app]; ok {
-				mergedMap[app] = api.RelayCounts{
-					Success: mergedMap[app].Success + count.Success,
-					Failure: mergedMap[app].Failure + count.Failure,
-				}
-			} else {
-				mergedMap[app] = count
-			}
+			mergedMap[app] = mergedMap[app].Add(count)
 		}
 	}
 
@@ -0,0 +1,31 @@
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	collectionRun    = "run"
+	collectionTodays = "today"
+)
+
+var (
+	collectionRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "relay_meter",
+		Name:      "collector_runs_total",
+		Help:      "Number of collections started, by collection: run, for the periodic collections, or today, for the collections of today's metrics",
+	}, []string{"collection"})
+
+	collectionFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "relay_meter",
+		Name:      "collector_failures_total",
+		Help:      "Number of collections which failed, by collection: run or today",
+	}, []string{"collection"})
+
+	sourceDisagreements = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "relay_meter",
+		Name:      "collector_source_disagreements_total",
+		Help:      "Number of applications whose relay counts differ between the sources beyond the tolerance, by the merge policy applied",
+	}, []string{"policy"})
+)
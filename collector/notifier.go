@@ -0,0 +1,68 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pokt-foundation/relay-meter/api"
+)
+
+// CollectionSummary describes the metrics written by a successful collection
+type CollectionSummary struct {
+	// DaysCollected are the past days whose daily metrics were written: today's metrics are written on every collection
+	DaysCollected []time.Time `json:"DaysCollected"`
+	// SkippedDays are the past days missing daily metrics which were not collected, as the sources have not completed ingesting them
+	SkippedDays []time.Time `json:"SkippedDays"`
+	// TotalRelays is the sum of the relay counts written for today and for the collected days
+	TotalRelays    api.RelayCounts `json:"TotalRelays"`
+	DurationMillis int64           `json:"DurationMillis"`
+}
+
+// Notifier is notified of the summary of each successful collection
+type Notifier interface {
+	Notify(ctx context.Context, summary CollectionSummary) error
+}
+
+// NewWebhookNotifier returns a notifier which POSTs the collection summary, as JSON, to the supplied URL.
+//
+//	A notification taking longer than the timeout is abandoned.
+func NewWebhookNotifier(url string, timeout time.Duration) Notifier {
+	return &webhookNotifier{
+		URL:    url,
+		Client: &http.Client{Timeout: timeout},
+	}
+}
+
+type webhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+func (n *webhookNotifier) Notify(ctx context.Context, summary CollectionSummary) error {
+	body, err := json.Marshal(summary)
+	if err != nil {
+		return fmt.Errorf("error marshalling collection summary: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error posting collection summary: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status code: %d", resp.StatusCode)
+	}
+
+	return nil
+}
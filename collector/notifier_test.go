@@ -0,0 +1,145 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pokt-foundation/portal-http-db/v2/types"
+	"github.com/pokt-foundation/relay-meter/api"
+	"github.com/pokt-foundation/utils-go/logger"
+)
+
+func TestCollectNotifiesWebhook(t *testing.T) {
+	dayLayout := "2006-01-02"
+	today, err := time.Parse(dayLayout, time.Now().Format(dayLayout))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	received := make(chan map[string]json.RawMessage, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("Expected method: %s, got: %s", http.MethodPost, r.Method)
+		}
+		if contentType := r.Header.Get("Content-Type"); contentType != "application/json" {
+			t.Errorf("Expected content type: application/json, got: %s", contentType)
+		}
+
+		var payload map[string]json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("Unexpected error decoding payload: %v", err)
+		}
+		received <- payload
+	}))
+	defer server.Close()
+
+	source := &fakeSource{
+		response: map[time.Time]map[types.PortalAppPublicKey]api.RelayCounts{
+			today.AddDate(0, 0, -8): {"app1": {Success: 20}},
+			today.AddDate(0, 0, -9): {"app1": {Success: 10, Failure: 1}},
+		},
+		todaysCounts: map[types.PortalAppPublicKey]api.RelayCounts{
+			"app1": {Success: 5},
+		},
+		latestDataTime: today.AddDate(0, 0, -7),
+	}
+	c := &collector{
+		Sources: []Source{source},
+		Writer: &fakeWriter{
+			first: today.AddDate(0, 0, -40),
+			last:  today.AddDate(0, 0, -10),
+		},
+		MaxArchiveAge: 30 * 24 * time.Hour,
+		Notifier:      NewWebhookNotifier(server.URL, time.Second),
+		Logger:        logger.New(),
+	}
+
+	if err := c.collect(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var payload map[string]json.RawMessage
+	select {
+	case payload = <-received:
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the collection summary to be posted")
+	}
+
+	for _, field := range []string{"DaysCollected", "SkippedDays", "TotalRelays", "DurationMillis"} {
+		if _, ok := payload[field]; !ok {
+			t.Errorf("Expected field %q in payload", field)
+		}
+	}
+
+	var daysCollected, skippedDays []time.Time
+	var totalRelays api.RelayCounts
+	for field, value := range map[string]any{"DaysCollected": &daysCollected, "SkippedDays": &skippedDays, "TotalRelays": &totalRelays} {
+		if err := json.Unmarshal(payload[field], value); err != nil {
+			t.Fatalf("Unexpected error decoding %s: %v", field, err)
+		}
+	}
+
+	if diff := cmp.Diff([]time.Time{today.AddDate(0, 0, -9), today.AddDate(0, 0, -8)}, daysCollected); diff != "" {
+		t.Errorf("unexpected days collected (-want +got):\n%s", diff)
+	}
+
+	var expectedSkipped []time.Time
+	for day := today.AddDate(0, 0, -7); day.Before(today); day = day.AddDate(0, 0, 1) {
+		expectedSkipped = append(expectedSkipped, day)
+	}
+	if diff := cmp.Diff(expectedSkipped, skippedDays); diff != "" {
+		t.Errorf("unexpected skipped days (-want +got):\n%s", diff)
+	}
+
+	if diff := cmp.Diff(api.RelayCounts{Success: 35, Failure: 1}, totalRelays); diff != "" {
+		t.Errorf("unexpected total relays (-want +got):\n%s", diff)
+	}
+}
+
+func TestCollectWebhookFailure(t *testing.T) {
+	testCases := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{
+			name: "Webhook error response",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+		},
+		{
+			name: "Webhook timeout",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(200 * time.Millisecond)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(tc.handler)
+			defer server.Close()
+
+			writer := &fakeWriter{}
+			c := &collector{
+				Sources:       []Source{&fakeSource{}},
+				Writer:        writer,
+				MaxArchiveAge: 30 * 24 * time.Hour,
+				Notifier:      NewWebhookNotifier(server.URL, 50*time.Millisecond),
+				Logger:        logger.New(),
+			}
+
+			if err := c.collect(context.Background()); err != nil {
+				t.Fatalf("Expected a webhook failure not to fail the collection, got: %v", err)
+			}
+			if writer.todaysWrites != 1 {
+				t.Fatalf("Expected 1 write of todays metrics, got: %d", writer.todaysWrites)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/pokt-foundation/portal-http-db/v2/types"
+	"github.com/pokt-foundation/relay-meter/api"
+)
+
+// MergePolicy is the policy applied to an application's relay counts when the sources disagree beyond the tolerance
+type MergePolicy string
+
+const (
+	// MergePolicySum adds up the counts of all sources, i.e. the same as for counts within the tolerance
+	MergePolicySum MergePolicy = "sum"
+	// MergePolicyMax keeps the counts of the source reporting the most relays
+	MergePolicyMax MergePolicy = "max"
+	// MergePolicyAbort fails the collection, so no counts are written
+	MergePolicyAbort MergePolicy = "abort"
+	// MergePolicyPriority keeps the counts of the source listed first in the reconcile options' priority
+	MergePolicyPriority MergePolicy = "priority"
+)
+
+var (
+	ErrSourcesDisagree        = errors.New("sources disagree on relay counts beyond the tolerance")
+	ErrInvalidReconcileOption = errors.New("invalid sources reconciliation options")
+)
+
+// ReconcileOptions configures how the relay counts reported by multiple sources for the same application are reconciled
+type ReconcileOptions struct {
+	// Tolerance is the maximum relative difference, e.g. 0.1 for 10%, between the relay counts reported by the sources for an application
+	Tolerance float64
+	Policy    MergePolicy
+	// Priority lists the source names, highest priority first, for the priority policy.
+	//	Sources not listed rank after the listed ones, in the order of the collector's sources.
+	Priority []string
+	// Overlapping is set if the sources may report the same relays, e.g. relays both collected and uploaded over HTTP:
+	//	the counts of an application reported by multiple sources are then never summed. Within the tolerance the highest counts,
+	//	or those of the highest priority source with the priority policy, are kept, and the policy is applied beyond it.
+	Overlapping bool
+}
+
+// Validate returns an error if the options cannot be applied, e.g. summing the counts of overlapping sources
+func (o ReconcileOptions) Validate() error {
+	if o.Policy == MergePolicyPriority && len(o.Priority) == 0 {
+		return fmt.Errorf("%w: the priority policy needs the sources priority", ErrInvalidReconcileOption)
+	}
+	if o.Overlapping && o.Policy == MergePolicySum {
+		return fmt.Errorf("%w: the counts of overlapping sources cannot be summed", ErrInvalidReconcileOption)
+	}
+	return nil
+}
+
+// priorityIndex returns the index of the source with the highest priority, out of the supplied source names
+func (o ReconcileOptions) priorityIndex(sourceNames []string) int {
+	for _, name := range o.Priority {
+		for i, sourceName := range sourceNames {
+			if sourceName == name {
+				return i
+			}
+		}
+	}
+	return 0
+}
+
+// ParseMergePolicy returns the merge policy with the supplied name
+func ParseMergePolicy(name string) (MergePolicy, error) {
+	switch policy := MergePolicy(name); policy {
+	case MergePolicySum, MergePolicyMax, MergePolicyAbort, MergePolicyPriority:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown merge policy: %q", name)
+	}
+}
+
+// reconcileTimeRelayCountsMaps merges the daily counts of the sources, reconciling each day separately.
+func (c *collector) reconcileTimeRelayCountsMaps(dayMaps []map[time.Time]map[types.PortalAppPublicKey]api.RelayCounts, sourceNames []string) (map[time.Time]map[types.PortalAppPublicKey]api.RelayCounts, error) {
+	days := make(map[time.Time]bool)
+	for _, dayMap := range dayMaps {
+		for day := range dayMap {
+			days[day] = true
+		}
+	}
+
+	merged := make(map[time.Time]map[types.PortalAppPublicKey]api.RelayCounts)
+	for day := range days {
+		appMaps := make([]map[types.PortalAppPublicKey]api.RelayCounts, len(dayMaps))
+		for i, dayMap := range dayMaps {
+			appMaps[i] = dayMap[day]
+		}
+
+		counts, err := c.reconcileRelayCountsMaps(appMaps, sourceNames, slog.Time("day", day))
+		if err != nil {
+			return nil, err
+		}
+		merged[day] = counts
+	}
+
+	return merged, nil
+}
+
+// reconcileRelayCountsMaps merges the counts of the sources, i.e. one map per source in the order of the source names.
+//
+//	Applications reported by more than one source, with counts differing beyond the tolerance, are logged, counted in the metrics,
+//	and have the configured merge policy applied. The counts of overlapping sources are not summed within the tolerance either.
+func (c *collector) reconcileRelayCountsMaps(appMaps []map[types.PortalAppPublicKey]api.RelayCounts, sourceNames []string, attrs ...any) (map[types.PortalAppPublicKey]api.RelayCounts, error) {
+	merged := mergeRelayCountsMaps(appMaps)
+	if len(appMaps) < 2 {
+		return merged, nil
+	}
+
+	for app := range merged {
+		var reported []api.RelayCounts
+		var reportedBy []string
+		for i, appMap := range appMaps {
+			if count, ok := appMap[app]; ok {
+				reported = append(reported, count)
+				reportedBy = append(reportedBy, sourceNames[i])
+			}
+		}
+		if len(reported) < 2 {
+			continue
+		}
+
+		minIndex, maxIndex := 0, 0
+		for i, count := range reported {
+			if total(count) < total(reported[minIndex]) {
+				minIndex = i
+			}
+			if total(count) > total(reported[maxIndex]) {
+				maxIndex = i
+			}
+		}
+
+		keep := maxIndex
+		if c.Reconcile.Policy == MergePolicyPriority {
+			keep = c.Reconcile.priorityIndex(reportedBy)
+		}
+
+		maxTotal := total(reported[maxIndex])
+		var difference float64
+		if maxTotal > 0 {
+			difference = float64(maxTotal-total(reported[minIndex])) / float64(maxTotal)
+		}
+		if difference <= c.Reconcile.Tolerance {
+			// The sources agree, but their counts are the same relays: counting them once avoids doubling the app's relays
+			if c.Reconcile.Overlapping {
+				merged[app] = reported[keep]
+			}
+			continue
+		}
+
+		c.Logger.Warn("Sources disagree on relay counts",
+			append(attrs,
+				slog.String("application", string(app)),
+				slog.Float64("difference", difference),
+				slog.Float64("tolerance", c.Reconcile.Tolerance),
+				slog.String("policy", string(c.Reconcile.Policy)),
+				slog.Any("sources", reportedBy),
+				slog.Any("counts", reported),
+			)...,
+		)
+		sourceDisagreements.WithLabelValues(string(c.Reconcile.Policy)).Inc()
+
+		switch c.Reconcile.Policy {
+		case MergePolicyAbort:
+			return nil, fmt.Errorf("%w: application %s, difference %.2f", ErrSourcesDisagree, app, difference)
+		case MergePolicyMax:
+			merged[app] = reported[maxIndex]
+		case MergePolicyPriority:
+			merged[app] = reported[keep]
+		}
+	}
+
+	return merged, nil
+}
+
+func total(count api.RelayCounts) int64 {
+	return count.Success + count.Failure
+}
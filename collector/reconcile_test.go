@@ -0,0 +1,266 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pokt-foundation/portal-http-db/v2/types"
+	"github.com/pokt-foundation/relay-meter/api"
+	"github.com/pokt-foundation/utils-go/logger"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestReconcileRelayCountsMaps(t *testing.T) {
+	source1 := map[types.PortalAppPublicKey]api.RelayCounts{
+		"app1": {Success: 100, Failure: 0},
+		"app2": {Success: 100, Failure: 0},
+		"app3": {Success: 10, Failure: 0},
+	}
+	source2 := map[types.PortalAppPublicKey]api.RelayCounts{
+		"app1": {Success: 95, Failure: 0},
+		"app2": {Success: 40, Failure: 10},
+	}
+
+	testCases := []struct {
+		name        string
+		options     ReconcileOptions
+		expected    map[types.PortalAppPublicKey]api.RelayCounts
+		expectedErr error
+		// expectedDisagreements is the number of applications counted as disagreeing
+		expectedDisagreements float64
+	}{
+		{
+			name:    "Counts are summed with the sum policy",
+			options: ReconcileOptions{Tolerance: 0.1, Policy: MergePolicySum},
+			expected: map[types.PortalAppPublicKey]api.RelayCounts{
+				"app1": {Success: 195, Failure: 0},
+				"app2": {Success: 140, Failure: 10},
+				"app3": {Success: 10, Failure: 0},
+			},
+			expectedDisagreements: 1,
+		},
+		{
+			name:    "Counts beyond the tolerance are replaced by the highest counts with the max policy",
+			options: ReconcileOptions{Tolerance: 0.1, Policy: MergePolicyMax},
+			expected: map[types.PortalAppPublicKey]api.RelayCounts{
+				"app1": {Success: 195, Failure: 0},
+				"app2": {Success: 100, Failure: 0},
+				"app3": {Success: 10, Failure: 0},
+			},
+			expectedDisagreements: 1,
+		},
+		{
+			name:                  "Counts beyond the tolerance fail the merge with the abort policy",
+			options:               ReconcileOptions{Tolerance: 0.1, Policy: MergePolicyAbort},
+			expectedErr:           ErrSourcesDisagree,
+			expectedDisagreements: 1,
+		},
+		{
+			name:    "Counts within the tolerance are summed with the abort policy",
+			options: ReconcileOptions{Tolerance: 0.5, Policy: MergePolicyAbort},
+			expected: map[types.PortalAppPublicKey]api.RelayCounts{
+				"app1": {Success: 195, Failure: 0},
+				"app2": {Success: 140, Failure: 10},
+				"app3": {Success: 10, Failure: 0},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &collector{
+				Reconcile: tc.options,
+				Logger:    logger.New(),
+			}
+
+			disagreements := testutil.ToFloat64(sourceDisagreements.WithLabelValues(string(tc.options.Policy)))
+			got, err := c.reconcileRelayCountsMaps([]map[types.PortalAppPublicKey]api.RelayCounts{source1, source2}, []string{"source1", "source2"})
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("Expected error: %v, got: %v", tc.expectedErr, err)
+			}
+
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+			if got := testutil.ToFloat64(sourceDisagreements.WithLabelValues(string(tc.options.Policy))) - disagreements; got != tc.expectedDisagreements {
+				t.Errorf("Expected %v disagreements to be counted, got: %v", tc.expectedDisagreements, got)
+			}
+		})
+	}
+}
+
+func TestReconcileTimeRelayCountsMaps(t *testing.T) {
+	day1 := time.Date(2022, time.July, 20, 0, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+
+	source1 := map[time.Time]map[types.PortalAppPublicKey]api.RelayCounts{
+		day1: {"app1": {Success: 100}},
+		day2: {"app1": {Success: 100}},
+	}
+	source2 := map[time.Time]map[types.PortalAppPublicKey]api.RelayCounts{
+		day1: {"app1": {Success: 98}},
+		day2: {"app1": {Success: 10}},
+	}
+
+	c := &collector{
+		Reconcile: ReconcileOptions{Tolerance: 0.05, Policy: MergePolicyMax},
+		Logger:    logger.New(),
+	}
+
+	got, err := c.reconcileTimeRelayCountsMaps([]map[time.Time]map[types.PortalAppPublicKey]api.RelayCounts{source1, source2}, []string{"source1", "source2"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := map[time.Time]map[types.PortalAppPublicKey]api.RelayCounts{
+		day1: {"app1": {Success: 198}},
+		day2: {"app1": {Success: 100}},
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("unexpected value (-want +got):\n%s", diff)
+	}
+}
+
+func TestReconcileOverlappingSources(t *testing.T) {
+	day := time.Date(2022, time.July, 20, 0, 0, 0, 0, time.UTC)
+
+	// The same relays of app1 are both collected and uploaded, while app2 is only uploaded
+	collected := map[time.Time]map[types.PortalAppPublicKey]api.RelayCounts{
+		day: {"app1": {Success: 100, Failure: 4}},
+	}
+	uploaded := map[time.Time]map[types.PortalAppPublicKey]api.RelayCounts{
+		day: {"app1": {Success: 98, Failure: 5}, "app2": {Success: 7}},
+	}
+
+	testCases := []struct {
+		name        string
+		options     ReconcileOptions
+		expected    map[time.Time]map[types.PortalAppPublicKey]api.RelayCounts
+		expectedErr error
+	}{
+		{
+			name:    "Overlapping counts are summed without the overlapping option",
+			options: ReconcileOptions{Tolerance: 0.1, Policy: MergePolicyMax},
+			expected: map[time.Time]map[types.PortalAppPublicKey]api.RelayCounts{
+				day: {"app1": {Success: 198, Failure: 9}, "app2": {Success: 7}},
+			},
+		},
+		{
+			name:    "Highest overlapping counts are kept with the max policy",
+			options: ReconcileOptions{Tolerance: 0.1, Policy: MergePolicyMax, Overlapping: true},
+			expected: map[time.Time]map[types.PortalAppPublicKey]api.RelayCounts{
+				day: {"app1": {Success: 100, Failure: 4}, "app2": {Success: 7}},
+			},
+		},
+		{
+			name:    "Overlapping counts of the highest priority source are kept with the priority policy",
+			options: ReconcileOptions{Tolerance: 0.1, Policy: MergePolicyPriority, Priority: []string{"upload", "collected"}, Overlapping: true},
+			expected: map[time.Time]map[types.PortalAppPublicKey]api.RelayCounts{
+				day: {"app1": {Success: 98, Failure: 5}, "app2": {Success: 7}},
+			},
+		},
+		{
+			name:    "Counts beyond the tolerance of the highest priority source are kept with the priority policy",
+			options: ReconcileOptions{Tolerance: 0.001, Policy: MergePolicyPriority, Priority: []string{"upload"}},
+			expected: map[time.Time]map[types.PortalAppPublicKey]api.RelayCounts{
+				day: {"app1": {Success: 98, Failure: 5}, "app2": {Success: 7}},
+			},
+		},
+		{
+			name:        "Overlapping counts beyond the tolerance fail the merge with the abort policy",
+			options:     ReconcileOptions{Tolerance: 0.001, Policy: MergePolicyAbort, Overlapping: true},
+			expectedErr: ErrSourcesDisagree,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.options.Validate(); err != nil {
+				t.Fatalf("Unexpected error validating the options: %v", err)
+			}
+			c := &collector{
+				Reconcile: tc.options,
+				Logger:    logger.New(),
+			}
+
+			got, err := c.reconcileTimeRelayCountsMaps([]map[time.Time]map[types.PortalAppPublicKey]api.RelayCounts{collected, uploaded}, []string{"collected", "upload"})
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("Expected error: %v, got: %v", tc.expectedErr, err)
+			}
+
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("unexpected value (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateReconcileOptions(t *testing.T) {
+	testCases := []struct {
+		name        string
+		options     ReconcileOptions
+		expectedErr error
+	}{
+		{
+			name:    "Summing the counts of independent sources is valid",
+			options: ReconcileOptions{Policy: MergePolicySum},
+		},
+		{
+			name:        "Summing the counts of overlapping sources is rejected",
+			options:     ReconcileOptions{Policy: MergePolicySum, Overlapping: true},
+			expectedErr: ErrInvalidReconcileOption,
+		},
+		{
+			name:        "Priority policy without the sources priority is rejected",
+			options:     ReconcileOptions{Policy: MergePolicyPriority},
+			expectedErr: ErrInvalidReconcileOption,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.options.Validate(); !errors.Is(err, tc.expectedErr) {
+				t.Errorf("Expected error: %v, got: %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestCollectSourcesDisagree(t *testing.T) {
+	source1 := &fakeSource{todaysCounts: map[types.PortalAppPublicKey]api.RelayCounts{"app1": {Success: 100}}}
+	source2 := &fakeSource{todaysCounts: map[types.PortalAppPublicKey]api.RelayCounts{"app1": {Success: 10}}}
+	writer := &fakeWriter{}
+	c := &collector{
+		Sources:       []Source{source1, source2},
+		Writer:        writer,
+		MaxArchiveAge: 30 * 24 * time.Hour,
+		Reconcile:     ReconcileOptions{Tolerance: 0.1, Policy: MergePolicyAbort},
+		Logger:        logger.New(),
+	}
+
+	if err := c.collect(context.Background()); !errors.Is(err, ErrSourcesDisagree) {
+		t.Fatalf("Expected error: %v, got: %v", ErrSourcesDisagree, err)
+	}
+	if writer.todaysWrites != 0 {
+		t.Fatalf("Expected no writes of todays metrics, got: %d", writer.todaysWrites)
+	}
+}
+
+func TestParseMergePolicy(t *testing.T) {
+	for _, name := range []string{"sum", "max", "abort", "priority"} {
+		policy, err := ParseMergePolicy(name)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if string(policy) != name {
+			t.Errorf("Expected policy: %s, got: %s", name, policy)
+		}
+	}
+
+	if _, err := ParseMergePolicy("min"); err == nil {
+		t.Errorf("Expected an error for an unknown policy")
+	}
+}
@@ -0,0 +1,83 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/pokt-foundation/portal-http-db/v2/types"
+	"github.com/pokt-foundation/relay-meter/api"
+	"github.com/pokt-foundation/utils-go/logger"
+)
+
+// SelfTestAppPublicKey is the application public key of the self-test fixture: no application has an all-zero key,
+// so the fixture is never mistaken for the relays of an application.
+const SelfTestAppPublicKey types.PortalAppPublicKey = "0000000000000000000000000000000000000000000000000000000000000000"
+
+// selfTestCounts are the relay counts of the self-test fixture
+var selfTestCounts = api.RelayCounts{Success: 7, Failure: 3}
+
+var ErrSelfTestFailed = errors.New("self-test failed")
+
+// FixtureWriter writes relay counts to a source of the collector, e.g. the relay counts uploaded through the HTTP source
+type FixtureWriter interface {
+	WriteHTTPSourceRelayCounts(ctx context.Context, counts []api.HTTPSourceRelayCount) error
+}
+
+// SelfTestStorage is the storage the collector writes to, read back to verify the written metrics
+type SelfTestStorage interface {
+	Writer
+	TodaysUsage() (map[types.PortalAppPublicKey]api.RelayCounts, error)
+}
+
+// SelfTest verifies end-to-end that relay counts written to the sources are collected into the storage, e.g. to catch
+// a misconfiguration before a deployment: it writes a known fixture using the fixture writer, runs a collection of today's
+// metrics, and returns ErrSelfTestFailed unless the fixture's counts are read back from the storage.
+//
+//	The fixture adds its counts to today's metrics of SelfTestAppPublicKey, which later collections roll into the daily metrics
+//	and the network totals: the storage must be a separate test storage, e.g. as returned by cmd.SelfTestStorageOptions.
+//	The counts saved before the fixture is written are subtracted, so the self-test can be run repeatedly.
+func SelfTest(ctx context.Context, sources []Source, fixture FixtureWriter, storage SelfTestStorage, reconcile ReconcileOptions, log *logger.Logger) error {
+	before, err := storage.TodaysUsage()
+	if err != nil {
+		return fmt.Errorf("error reading todays metrics before writing the fixture: %w", err)
+	}
+
+	if err := fixture.WriteHTTPSourceRelayCounts(ctx, []api.HTTPSourceRelayCount{{
+		AppPublicKey: SelfTestAppPublicKey,
+		Day:          time.Now(),
+		Success:      selfTestCounts.Success,
+		Error:        selfTestCounts.Failure,
+	}}); err != nil {
+		return fmt.Errorf("error writing the fixture: %w", err)
+	}
+
+	c := &collector{
+		Sources:   sources,
+		Writer:    storage,
+		Reconcile: reconcile,
+		TodayOnly: true,
+		Logger:    log,
+	}
+	if err := c.collect(ctx); err != nil {
+		return fmt.Errorf("error collecting the fixture: %w", err)
+	}
+
+	after, err := storage.TodaysUsage()
+	if err != nil {
+		return fmt.Errorf("error reading todays metrics after collecting the fixture: %w", err)
+	}
+
+	got := after[SelfTestAppPublicKey].Sub(before[SelfTestAppPublicKey])
+	if got != selfTestCounts {
+		return fmt.Errorf("%w: expected the fixture's relay counts %+v to be collected, got: %+v", ErrSelfTestFailed, selfTestCounts, got)
+	}
+
+	log.Info("Self-test passed",
+		slog.Int64("success", got.Success),
+		slog.Int64("failure", got.Failure),
+	)
+	return nil
+}
@@ -0,0 +1,122 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pokt-foundation/portal-http-db/v2/types"
+	"github.com/pokt-foundation/relay-meter/api"
+	"github.com/pokt-foundation/utils-go/logger"
+)
+
+func TestSelfTest(t *testing.T) {
+	writeErr := errors.New("fixture write failed")
+
+	testCases := []struct {
+		name        string
+		source      *fakeFixtureSource
+		storage     *fakeSelfTestStorage
+		expectedErr error
+		anyErr      bool
+	}{
+		{
+			name:    "Fixture round-trips into the storage",
+			source:  &fakeFixtureSource{},
+			storage: &fakeSelfTestStorage{},
+		},
+		{
+			name: "Counts saved by a previous self-test are subtracted",
+			source: &fakeFixtureSource{
+				written: map[types.PortalAppPublicKey]api.RelayCounts{SelfTestAppPublicKey: selfTestCounts},
+			},
+			storage: &fakeSelfTestStorage{
+				todays: map[types.PortalAppPublicKey]api.RelayCounts{SelfTestAppPublicKey: selfTestCounts},
+			},
+		},
+		{
+			name:        "Fixture not collected fails the self-test",
+			source:      &fakeFixtureSource{dropWrites: true},
+			storage:     &fakeSelfTestStorage{},
+			expectedErr: ErrSelfTestFailed,
+		},
+		{
+			name:    "Failure to write the fixture fails the self-test",
+			source:  &fakeFixtureSource{writeErr: writeErr},
+			storage: &fakeSelfTestStorage{},
+			anyErr:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := SelfTest(context.Background(), []Source{tc.source}, tc.source, tc.storage, ReconcileOptions{}, logger.New())
+			switch {
+			case tc.expectedErr != nil:
+				if !errors.Is(err, tc.expectedErr) {
+					t.Fatalf("Expected error: %v, got: %v", tc.expectedErr, err)
+				}
+			case tc.anyErr:
+				if err == nil {
+					t.Fatalf("Expected an error")
+				}
+			case err != nil:
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if tc.source.writeErr == nil && tc.storage.todaysWrites != 1 {
+				t.Errorf("Expected 1 write of todays metrics, got: %d", tc.storage.todaysWrites)
+			}
+			if tc.storage.dailyWrites != 0 {
+				t.Errorf("Expected no writes of daily metrics, got: %d", tc.storage.dailyWrites)
+			}
+		})
+	}
+}
+
+// fakeFixtureSource is a source serving as today's counts the relay counts written to it, as the HTTP source does
+type fakeFixtureSource struct {
+	fakeSource
+
+	written    map[types.PortalAppPublicKey]api.RelayCounts
+	writeErr   error
+	dropWrites bool
+}
+
+func (f *fakeFixtureSource) WriteHTTPSourceRelayCounts(ctx context.Context, counts []api.HTTPSourceRelayCount) error {
+	if f.writeErr != nil {
+		return f.writeErr
+	}
+	if f.dropWrites {
+		return nil
+	}
+
+	if f.written == nil {
+		f.written = make(map[types.PortalAppPublicKey]api.RelayCounts)
+	}
+	for _, count := range counts {
+		f.written[count.AppPublicKey] = f.written[count.AppPublicKey].Add(api.RelayCounts{Success: count.Success, Failure: count.Error})
+	}
+	return nil
+}
+
+func (f *fakeFixtureSource) TodaysCounts() (map[types.PortalAppPublicKey]api.RelayCounts, error) {
+	return f.written, nil
+}
+
+// fakeSelfTestStorage keeps today's written relay counts, so they can be read back
+type fakeSelfTestStorage struct {
+	fakeWriter
+
+	todays map[types.PortalAppPublicKey]api.RelayCounts
+}
+
+func (f *fakeSelfTestStorage) WriteTodaysMetrics(ctx context.Context, counts map[types.PortalAppPublicKey]api.RelayCounts, countsOrigin map[types.PortalAppOrigin]api.RelayCounts, latencies map[types.PortalAppPublicKey][]api.Latency) error {
+	f.todaysWrites++
+	f.todays = counts
+	return nil
+}
+
+func (f *fakeSelfTestStorage) TodaysUsage() (map[types.PortalAppPublicKey]api.RelayCounts, error) {
+	return f.todays, nil
+}
@@ -0,0 +1,80 @@
+package collector
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pokt-foundation/portal-http-db/v2/types"
+	"github.com/pokt-foundation/relay-meter/api"
+)
+
+// WriteCounts are the numbers of rows written by a successful write of the collector
+type WriteCounts struct {
+	Time        time.Time `json:"Time"`
+	AppRows     int       `json:"AppRows"`
+	OriginRows  int       `json:"OriginRows"`
+	LatencyRows int       `json:"LatencyRows"`
+}
+
+// WriteStatus reports the rows written by the last writes of the collector, e.g. for operators to confirm that
+// the writes are happening and sized as expected. A write which has not happened yet since the collector started is nil.
+type WriteStatus struct {
+	LastTodaysWrite *WriteCounts `json:"LastTodaysWrite"`
+	LastDailyWrite  *WriteCounts `json:"LastDailyWrite"`
+}
+
+// writeCounters records the rows of the collector's last writes, which are read concurrently by the status endpoint
+type writeCounters struct {
+	mu     sync.Mutex
+	status WriteStatus
+}
+
+// recordTodays records a successful write of today's metrics
+func (w *writeCounters) recordTodays(now time.Time, counts map[types.PortalAppPublicKey]api.RelayCounts, countsOrigin map[types.PortalAppOrigin]api.RelayCounts, latencies map[types.PortalAppPublicKey][]api.Latency) {
+	written := WriteCounts{Time: now, AppRows: len(counts), OriginRows: len(countsOrigin)}
+	for _, appLatencies := range latencies {
+		written.LatencyRows += len(appLatencies)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.status.LastTodaysWrite = &written
+}
+
+// recordDaily records a successful write of daily metrics
+func (w *writeCounters) recordDaily(now time.Time, counts map[time.Time]map[types.PortalAppPublicKey]api.RelayCounts, countsOrigin map[types.PortalAppOrigin]api.RelayCounts) {
+	written := WriteCounts{Time: now, OriginRows: len(countsOrigin)}
+	for _, dayCounts := range counts {
+		written.AppRows += len(dayCounts)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.status.LastDailyWrite = &written
+}
+
+func (w *writeCounters) get() WriteStatus {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.status
+}
+
+// StatusHandler returns a handler serving the write status of the collector as JSON
+func StatusHandler(c Collector) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(c.WriteStatus()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
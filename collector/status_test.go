@@ -0,0 +1,73 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pokt-foundation/portal-http-db/v2/types"
+	"github.com/pokt-foundation/relay-meter/api"
+	"github.com/pokt-foundation/utils-go/logger"
+)
+
+func TestWriteStatus(t *testing.T) {
+	today := time.Date(2022, time.July, 20, 0, 0, 0, 0, time.UTC)
+	now := today.Add(10 * time.Hour)
+
+	source := &fakeSource{
+		response: map[time.Time]map[types.PortalAppPublicKey]api.RelayCounts{
+			today.AddDate(0, 0, -2): {"app1": {Success: 10}, "app2": {Success: 5}},
+			today.AddDate(0, 0, -1): {"app1": {Success: 8}},
+		},
+		todaysCounts: map[types.PortalAppPublicKey]api.RelayCounts{"app1": {Success: 3}, "app2": {Failure: 1}},
+		todaysCountsPerOrigin: map[types.PortalAppOrigin]api.RelayCounts{
+			"https://portal.pokt.network": {Success: 2},
+			"https://pokt.network":        {Success: 1},
+			"https://app.test1.io":        {Failure: 1},
+		},
+		todaysLatency: map[types.PortalAppPublicKey][]api.Latency{
+			"app1": {{Time: today, Latency: 0.1}, {Time: today.Add(time.Hour), Latency: 0.2}},
+			"app2": {{Time: today, Latency: 0.3}},
+		},
+	}
+	c := &collector{
+		Sources:       []Source{source},
+		Writer:        &fakeWriter{hasData: true, first: today.AddDate(0, 0, -10), last: today.AddDate(0, 0, -3)},
+		MaxArchiveAge: 30 * 24 * time.Hour,
+		Now:           func() time.Time { return now },
+		Logger:        logger.New(),
+	}
+
+	if diff := cmp.Diff(WriteStatus{}, c.WriteStatus()); diff != "" {
+		t.Errorf("Expected no writes before the first collection (-want +got):\n%s", diff)
+	}
+
+	if err := c.collect(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := WriteStatus{
+		LastTodaysWrite: &WriteCounts{Time: now, AppRows: 2, OriginRows: 3, LatencyRows: 3},
+		LastDailyWrite:  &WriteCounts{Time: now, AppRows: 3},
+	}
+	if diff := cmp.Diff(expected, c.WriteStatus()); diff != "" {
+		t.Errorf("unexpected value (-want +got):\n%s", diff)
+	}
+
+	w := httptest.NewRecorder()
+	StatusHandler(c).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/status", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code: %d, got: %d", http.StatusOK, w.Code)
+	}
+	var got WriteStatus
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("unexpected value (-want +got):\n%s", diff)
+	}
+}
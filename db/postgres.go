@@ -4,11 +4,15 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"database/sql"
@@ -19,6 +23,7 @@ import (
 	"github.com/jackc/pgx/v4/stdlib"
 	"github.com/pokt-foundation/portal-http-db/v2/types"
 	"github.com/pokt-foundation/relay-meter/api"
+	"github.com/pokt-foundation/utils-go/logger"
 	"github.com/pokt-foundation/utils-go/numbers"
 
 	// PQ import is required
@@ -27,12 +32,19 @@ import (
 
 // TODO: db package needs some form of unit testing
 const (
-	pgTimeFormat   = "2006-01-02 15:04:00+00"
 	dayLayout      = "2006-01-02"
 	tableDailySums = "daily_app_sums"
+	defaultSSLMode = "disable"
+	// insertBatchRows is the number of rows inserted by each statement of a bulk insert: Postgres accepts at most
+	//	65535 parameters per statement, i.e. 10922 rows of the widest table.
+	insertBatchRows = 1000
 )
 
-var ()
+var (
+	// registerCloudSQLDriver guards the registration of the Cloud SQL driver: sql.Register panics if a driver name is
+	//	registered twice, e.g. when connecting to both the primary and a read replica.
+	registerCloudSQLDriver sync.Once
+)
 
 // Will be implemented by Postgres DB interface
 type Reporter interface {
@@ -42,17 +54,21 @@ type Reporter interface {
 	TodaysUsage() (map[types.PortalAppPublicKey]api.RelayCounts, error)
 	TodaysOriginUsage() (map[types.PortalAppOrigin]api.RelayCounts, error)
 	TodaysLatency() (map[types.PortalAppPublicKey][]api.Latency, error)
+	// CoveredDays returns, in ascending order, the days of the specified time period with saved daily metrics
+	CoveredDays(from time.Time, to time.Time) ([]time.Time, error)
+	// RawDailyRows returns, in ascending order of day, the rows of the app's daily metrics stored for the specified time period
+	RawDailyRows(appPubKey types.PortalAppPublicKey, from time.Time, to time.Time) ([]api.DailyRow, error)
 }
 
 // Will be implemented by Postgres DB interface
 type Writer interface {
 	// TODO: rollover of entries
-	WriteDailyUsage(counts map[time.Time]map[types.PortalAppPublicKey]api.RelayCounts, countsOrigin map[types.PortalAppOrigin]api.RelayCounts) error
+	WriteDailyUsage(ctx context.Context, counts map[time.Time]map[types.PortalAppPublicKey]api.RelayCounts, countsOrigin map[types.PortalAppOrigin]api.RelayCounts) error
 	// WriteTodaysUsage writes todays relay counts to the underlying storage.
 	WriteTodaysUsage(ctx context.Context, tx *sql.Tx, counts map[types.PortalAppPublicKey]api.RelayCounts, countsOrigin map[types.PortalAppOrigin]api.RelayCounts) error
-	WriteTodaysMetrics(counts map[types.PortalAppPublicKey]api.RelayCounts, countsOrigin map[types.PortalAppOrigin]api.RelayCounts, latencies map[types.PortalAppPublicKey][]api.Latency) error
-	// Returns oldest and most recent timestamps for stored metrics
-	ExistingMetricsTimespan() (time.Time, time.Time, error)
+	WriteTodaysMetrics(ctx context.Context, counts map[types.PortalAppPublicKey]api.RelayCounts, countsOrigin map[types.PortalAppOrigin]api.RelayCounts, latencies map[types.PortalAppPublicKey][]api.Latency) error
+	// ExistingMetricsTimespan returns the oldest and most recent days with stored daily metrics, and whether there are any
+	ExistingMetricsTimespan() (first time.Time, last time.Time, hasData bool, err error)
 }
 
 type PostgresOptions struct {
@@ -61,13 +77,83 @@ type PostgresOptions struct {
 	Password   string
 	DB         string
 	UsePrivate bool
+	// SSLMode is the sslmode of the connection when not using the private IP, e.g. require: it defaults to disable for local testing.
+	//	Connections using the private IP are secured by the Cloud SQL connector.
+	SSLMode string
+	// SearchPath, if set, is the schema search path of the connection
+	SearchPath string
+	// StatementTimeout, if set, aborts on the server any statement taking longer
+	StatementTimeout time.Duration
 }
 
-type PostgresClient interface {
+// connectionURL returns the URL used to connect to the DB when not using the private IP
+func connectionURL(options PostgresOptions) string {
+	params := url.Values{}
+	params.Set("sslmode", options.SSLMode)
+	if options.SSLMode == "" {
+		params.Set("sslmode", defaultSSLMode)
+	}
+	for key, value := range runtimeParams(options) {
+		params.Set(key, value)
+	}
+
+	// The user info is escaped as such: a query escaped space, i.e. '+', would be read as a literal '+'
+	connURL := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(options.User, options.Password),
+		Host:     options.Host,
+		Path:     "/" + options.DB,
+		RawQuery: params.Encode(),
+	}
+
+	return connURL.String()
+}
+
+// privateConnectionDetails returns the connection string used to connect to the DB through the Cloud SQL connector
+func privateConnectionDetails(options PostgresOptions) string {
+	connectionDetails := fmt.Sprintf("user=%s password=%s database=%s", quoteConnectionValue(options.User), quoteConnectionValue(options.Password), quoteConnectionValue(options.DB))
+
+	params := runtimeParams(options)
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		connectionDetails += fmt.Sprintf(" %s=%s", key, quoteConnectionValue(params[key]))
+	}
+
+	return connectionDetails
+}
+
+// quoteConnectionValue returns the value single-quoted for a key=value connection string, so it may hold spaces or quotes
+func quoteConnectionValue(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+// runtimeParams returns the configured server run-time parameters of the connection
+func runtimeParams(options PostgresOptions) map[string]string {
+	params := make(map[string]string)
+	if options.SearchPath != "" {
+		params["search_path"] = options.SearchPath
+	}
+	if options.StatementTimeout > 0 {
+		params["statement_timeout"] = strconv.FormatInt(options.StatementTimeout.Milliseconds(), 10)
+	}
+
+	return params
+}
+
+// StorageClient is implemented by every storage backend
+type StorageClient interface {
 	Reporter
 	Writer
 }
 
+type PostgresClient interface {
+	StorageClient
+}
+
 // DO NOT use as a direct path to the database
 //
 // use NewPostgresClientFromDBInstance right after
@@ -77,7 +163,7 @@ func NewDBConnection(options PostgresOptions) (*sql.DB, func() error, error) {
 
 	// Used for local testing
 	if !options.UsePrivate {
-		connectionDetails = fmt.Sprintf("postgres://%s:%s@%s/%s?sslmode=disable", url.QueryEscape(options.User), url.QueryEscape(options.Password), options.Host, options.DB)
+		connectionDetails = connectionURL(options)
 		db, err := sql.Open("postgres", connectionDetails)
 		if err != nil {
 			return nil, nil, err
@@ -94,7 +180,7 @@ func NewDBConnection(options PostgresOptions) (*sql.DB, func() error, error) {
 	var dialOpts []cloudsqlconn.DialOption
 	dialOpts = append(dialOpts, cloudsqlconn.WithPrivateIP())
 
-	connectionDetails = fmt.Sprintf("user=%s password=%s database=%s", options.User, options.Password, options.DB)
+	connectionDetails = privateConnectionDetails(options)
 	config, err := pgx.ParseConfig(connectionDetails)
 	if err != nil {
 		return nil, nil, err
@@ -109,7 +195,11 @@ func NewDBConnection(options PostgresOptions) (*sql.DB, func() error, error) {
 		opts = append(opts, cloudsqlconn.WithDefaultDialOptions(opt))
 	}
 
-	cleanup, err := pgxv4.RegisterDriver("cloudsql-postgres", opts...)
+	// Only the first connection owns, and cleans up, the registered driver
+	var cleanup func() error
+	registerCloudSQLDriver.Do(func() {
+		cleanup, err = pgxv4.RegisterDriver("cloudsql-postgres", opts...)
+	})
 	if err != nil {
 		return nil, nil, err
 	}
@@ -124,22 +214,67 @@ func NewDBConnection(options PostgresOptions) (*sql.DB, func() error, error) {
 }
 
 func NewPostgresClientFromDBInstance(db *sql.DB) PostgresClient {
-	return &pgClient{DB: db}
+	return &pgClient{DB: db, duplicateRows: DuplicateRowsWarn, logger: logger.New()}
+}
+
+// NewPostgresClientWithReplica returns a client which writes to the primary, and serves the Reporter reads from the replica.
+//
+//	A nil replica falls back to the primary for reads. The client's warnings, e.g. of duplicate rows, are logged to l.
+func NewPostgresClientWithReplica(primary *sql.DB, replica *sql.DB, duplicateRows DuplicateRows, l *logger.Logger) PostgresClient {
+	return &pgClient{DB: primary, replica: replica, duplicateRows: duplicateRows, logger: l}
+}
+
+// DuplicateRows selects how the reads of today's metrics handle several rows of the same application,
+// e.g. left by a failed delete while today's tables were rebuilt. A warning is logged for each duplicate row in any case.
+type DuplicateRows string
+
+const (
+	// DuplicateRowsWarn keeps the last row read of the application: it is the default
+	DuplicateRowsWarn DuplicateRows = "warn"
+	// DuplicateRowsSum adds up the relay counts of all the rows of the application
+	DuplicateRowsSum DuplicateRows = "sum"
+)
+
+var ErrInvalidDuplicateRows = errors.New("invalid duplicate rows handling")
+
+// ParseDuplicateRows returns the handling of duplicate rows named by value, which defaults to DuplicateRowsWarn if empty
+func ParseDuplicateRows(value string) (DuplicateRows, error) {
+	switch handling := DuplicateRows(value); handling {
+	case "":
+		return DuplicateRowsWarn, nil
+	case DuplicateRowsWarn, DuplicateRowsSum:
+		return handling, nil
+	default:
+		return "", fmt.Errorf("%w: %q, expected %q or %q", ErrInvalidDuplicateRows, value, DuplicateRowsWarn, DuplicateRowsSum)
+	}
 }
 
 // type pgReporter
 type pgClient struct {
 	*sql.DB
+	// replica, if set, serves the Reporter reads to offload them from the primary
+	replica *sql.DB
+	// duplicateRows is the handling of several rows of the same application in today's metrics
+	duplicateRows DuplicateRows
+	logger        *logger.Logger
+}
+
+// reader returns the connection used for the Reporter reads
+func (p *pgClient) reader() *sql.DB {
+	if p.replica != nil {
+		return p.replica
+	}
+	return p.DB
 }
 
 func (p *pgClient) DailyUsage(from time.Time, to time.Time) (map[time.Time]map[types.PortalAppPublicKey]api.RelayCounts, error) {
 	ctx := context.Background()
-	// TODO: delegate dealing with the timestamps to the sql query: looks like there is a bug in QueryContext in dealing with parameters
-	q := fmt.Sprintf("SELECT (time, application, count_success, count_failure) FROM daily_app_sums as d WHERE d.time >= '%s' and d.time <= '%s'",
+	// The days are passed as dates, so the rows of the whole 'from' and 'to' days are read regardless of the time of day
+	rows, err := p.reader().QueryContext(ctx,
+		"SELECT time, application, count_success, count_failure FROM daily_app_sums WHERE time >= $1 AND time <= $2",
 		from.Format(dayLayout),
 		to.Format(dayLayout),
 	)
-	rows, err := p.DB.QueryContext(ctx, q)
 	if err != nil {
 		return nil, err
 	}
@@ -147,56 +282,24 @@ func (p *pgClient) DailyUsage(from time.Time, to time.Time) (map[time.Time]map[t
 
 	dailyUsage := make(map[time.Time]map[types.PortalAppPublicKey]api.RelayCounts)
 	for rows.Next() {
-		var r string
-		if err := rows.Scan(&r); err != nil {
+		var (
+			ts     time.Time
+			app    string
+			counts api.RelayCounts
+		)
+		if err := rows.Scan(&ts, &app, &counts.Success, &counts.Failure); err != nil {
 			return nil, err
 		}
-
-		// Example of query output (app public key has been modified)
-		// ("2022-06-25 00:00:00+00",33d4474f0a60b362103b1867c7edac323e39f416e7458f436623b9d96eb31k19,18931)
-		r = strings.ReplaceAll(r, "\"", "")
-		r = strings.TrimPrefix(r, "(")
-		r = strings.TrimSuffix(r, ")")
-		items := strings.Split(r, ",")
-		if len(items) != 4 {
-			return nil, fmt.Errorf("Invalid format in query output: %s", r)
-		}
-
-		// TODO: remove this after we get a better way to ensure that the layout will match in different timezones
-		if strings.HasSuffix(items[0], "Z") {
-			items[0] = strings.Replace(items[0], "Z", "+00", 1)
-		}
-		if !strings.HasSuffix(items[0], "+00") {
-			items[0] = items[0][:len(items[0])-3] + "+00"
-		}
-
-		ts, err := time.Parse(pgTimeFormat, items[0])
-		if err != nil {
-			return nil, fmt.Errorf("Invalid time format: %s in query result line: %s, error: %v", items[0], r, err)
-		}
-		countSuccess, err := strconv.ParseInt(items[2], 10, 64) // bitsize 64 for int64 return
-		if err != nil {
-			return nil, fmt.Errorf("Invalid total relays format: %s in query result line: %s, error: %v", items[2], r, err)
-		}
-		countFailure, err := strconv.ParseInt(items[3], 10, 64) // bitsize 64 for int64 return
-		if err != nil {
-			return nil, fmt.Errorf("Invalid total relays format: %s in query result line: %s, error: %v", items[3], r, err)
-		}
-
-		app := items[1]
 		if app == "" {
-			return nil, fmt.Errorf("Empty application public key, in query result line: %s", r)
+			return nil, fmt.Errorf("Empty application public key, in daily metrics of %s", ts)
 		}
-		appPubKey := types.PortalAppPublicKey(app)
 
+		// The days are keyed in UTC, whatever the time zone of the DB session
+		ts = ts.UTC()
 		if dailyUsage[ts] == nil {
 			dailyUsage[ts] = make(map[types.PortalAppPublicKey]api.RelayCounts)
 		}
-		dailyUsage[ts][appPubKey] = api.RelayCounts{Success: countSuccess, Failure: countFailure}
-	}
-	// TODO: verify this is needed
-	if rerr := rows.Close(); rerr != nil {
-		return nil, rerr
+		dailyUsage[ts][types.PortalAppPublicKey(app)] = counts
 	}
 	// Rows.Err will report the last error encountered by Rows.Scan.
 	if err := rows.Err(); err != nil {
@@ -206,29 +309,75 @@ func (p *pgClient) DailyUsage(from time.Time, to time.Time) (map[time.Time]map[t
 	return dailyUsage, nil
 }
 
-func (p *pgClient) WriteDailyUsage(counts map[time.Time]map[types.PortalAppPublicKey]api.RelayCounts, countsOrigin map[types.PortalAppOrigin]api.RelayCounts) error {
+func (p *pgClient) CoveredDays(from time.Time, to time.Time) ([]time.Time, error) {
+	ctx := context.Background()
+	q := fmt.Sprintf("SELECT DISTINCT time FROM %s WHERE time >= $1 AND time <= $2 ORDER BY time", tableDailySums)
+	rows, err := p.reader().QueryContext(ctx, q, from.Format(dayLayout), to.Format(dayLayout))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var days []time.Time
+	for rows.Next() {
+		var day time.Time
+		if err := rows.Scan(&day); err != nil {
+			return nil, err
+		}
+		days = append(days, day.UTC())
+	}
+	// Rows.Err will report the last error encountered by Rows.Scan.
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return days, nil
+}
+
+func (p *pgClient) RawDailyRows(appPubKey types.PortalAppPublicKey, from time.Time, to time.Time) ([]api.DailyRow, error) {
 	ctx := context.Background()
+	// The app public key is supplied by the client, so it is passed as a parameter instead of being formatted into the query
+	q := fmt.Sprintf("SELECT time, count_success, count_failure FROM %s WHERE application = $1 AND time >= $2 AND time <= $3 ORDER BY time", tableDailySums)
+	rows, err := p.reader().QueryContext(ctx, q, string(appPubKey), from.Format(dayLayout), to.Format(dayLayout))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	dailyRows := []api.DailyRow{}
+	for rows.Next() {
+		var row api.DailyRow
+		if err := rows.Scan(&row.Day, &row.Success, &row.Failure); err != nil {
+			return nil, err
+		}
+		row.Day = row.Day.UTC()
+		dailyRows = append(dailyRows, row)
+	}
+	// Rows.Err will report the last error encountered by Rows.Scan.
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return dailyRows, nil
+}
+
+func (p *pgClient) WriteDailyUsage(ctx context.Context, counts map[time.Time]map[types.PortalAppPublicKey]api.RelayCounts, countsOrigin map[types.PortalAppOrigin]api.RelayCounts) error {
 	// TODO: determine required isolation level
 	tx, err := p.DB.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
 	if err != nil {
 		return err
 	}
 
-	// TODO: bulk insert
+	var rows [][]any
 	for day, appCounts := range counts {
 		for app, counts := range appCounts {
-			_, execErr := tx.ExecContext(ctx,
-				"INSERT INTO daily_app_sums(application, count_success, count_failure, time) VALUES($1, $2, $3, $4);",
-				app, counts.Success, counts.Failure, day)
-			if execErr != nil {
-				if rollbackErr := tx.Rollback(); rollbackErr != nil {
-					fmt.Printf("update failed err write dailyUsage: %v, unable to rollback: %v\n", execErr, rollbackErr.Error())
-					return execErr
-				}
-				fmt.Printf("update failed err write dailyUsage: %v", execErr.Error())
-			}
+			rows = append(rows, []any{app, counts.Success, counts.Failure, day})
 		}
 	}
+	if execErr := bulkInsert(ctx, tx, tableDailySums, []string{"application", "count_success", "count_failure", "time"}, rows); execErr != nil {
+		rollback(tx, fmt.Errorf("write dailyUsage: %w", execErr))
+		return execErr
+	}
 
 	if err := tx.Commit(); err != nil {
 		return err
@@ -236,30 +385,30 @@ func (p *pgClient) WriteDailyUsage(counts map[time.Time]map[types.PortalAppPubli
 	return nil
 }
 
-func (p *pgClient) ExistingMetricsTimespan() (time.Time, time.Time, error) {
+// ExistingMetricsTimespan returns the oldest and most recent days with saved daily metrics.
+//
+//	If there are no saved daily metrics, hasData is false and both days are the zero time.
+func (p *pgClient) ExistingMetricsTimespan() (time.Time, time.Time, bool, error) {
 	ctx := context.Background()
-	row := p.DB.QueryRowContext(ctx, fmt.Sprintf("SELECT count(*), COALESCE(min(time), '2003-01-02 03:04' ), COALESCE(max(time), '2003-01-02 03:04') FROM %s", tableDailySums))
-	var countStr, firstStr, lastStr string
-	var first, last time.Time
-	if err := row.Scan(&countStr, &firstStr, &lastStr); err != nil {
-		return first, last, err
+	row := p.DB.QueryRowContext(ctx, fmt.Sprintf("SELECT min(time), max(time) FROM %s", tableDailySums))
+	// Both aggregates are NULL on an empty table
+	var first, last sql.NullTime
+	if err := row.Scan(&first, &last); err != nil {
+		return time.Time{}, time.Time{}, false, err
 	}
 
-	if countStr == "0" {
-		return time.Time{}, time.Time{}, nil
+	if !first.Valid || !last.Valid {
+		return time.Time{}, time.Time{}, false, nil
 	}
 
-	first, err := parseDate(firstStr)
-	if err != nil {
-		return first, last, err
-	}
-
-	last, err = parseDate(lastStr)
-	return first, last, err
+	return first.Time.UTC(), last.Time.UTC(), true, nil
 }
 
-func (p *pgClient) WriteTodaysMetrics(counts map[types.PortalAppPublicKey]api.RelayCounts, countsOrigin map[types.PortalAppOrigin]api.RelayCounts, latencies map[types.PortalAppPublicKey][]api.Latency) error {
-	ctx := context.Background()
+// WriteTodaysMetrics rebuilds all of today's tables in a single transaction.
+//
+//	The transaction is rolled back on any error, including the context being cancelled, so the tables are never left partially rebuilt.
+//	Concurrent readers keep seeing the previous metrics until the transaction commits, i.e. never the emptied tables of an in-progress rebuild.
+func (p *pgClient) WriteTodaysMetrics(ctx context.Context, counts map[types.PortalAppPublicKey]api.RelayCounts, countsOrigin map[types.PortalAppOrigin]api.RelayCounts, latencies map[types.PortalAppPublicKey][]api.Latency) error {
 	// TODO: determine required isolation level
 	tx, err := p.DB.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
 	if err != nil {
@@ -268,11 +417,13 @@ func (p *pgClient) WriteTodaysMetrics(counts map[types.PortalAppPublicKey]api.Re
 
 	err = p.writeTodaysLatency(ctx, tx, latencies)
 	if err != nil {
+		rollback(tx, err)
 		return fmt.Errorf("error writing latency: %s", err.Error())
 	}
 
 	err = p.WriteTodaysUsage(ctx, tx, counts, countsOrigin)
 	if err != nil {
+		rollback(tx, err)
 		return fmt.Errorf("error writing usage: %s", err.Error())
 	}
 
@@ -286,6 +437,7 @@ func (p *pgClient) WriteTodaysMetrics(counts map[types.PortalAppPublicKey]api.Re
 // WriteTodaysUsage writes the app metrics for today so far to the underlying PG table.
 //
 //	All the entries in the table holding todays metrics are deleted first.
+//	The transaction is owned by the caller, which is expected to roll it back if an error is returned.
 func (p *pgClient) WriteTodaysUsage(ctx context.Context, tx *sql.Tx, counts map[types.PortalAppPublicKey]api.RelayCounts, countsOrigin map[types.PortalAppOrigin]api.RelayCounts) error {
 	if err := WriteAppUsage(ctx, tx, counts); err != nil {
 		return err
@@ -298,98 +450,123 @@ func (p *pgClient) WriteTodaysUsage(ctx context.Context, tx *sql.Tx, counts map[
 	return nil
 }
 
+// WriteAppUsage rebuilds the table of todays app metrics within the transaction: the deleted entries are not visible to other readers until it commits.
 func WriteAppUsage(ctx context.Context, tx *sql.Tx, counts map[types.PortalAppPublicKey]api.RelayCounts) error {
 	// todays_sums table gets rebuilt every time
-	_, deleteErr := tx.ExecContext(ctx, "DELETE FROM todays_app_sums")
-	if deleteErr != nil {
-		if rollbackErr := tx.Rollback(); rollbackErr != nil {
-			fmt.Printf("delete failed: %v, unable to rollback: %v\n", deleteErr, rollbackErr.Error())
-			return deleteErr
-		}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM todays_app_sums"); err != nil {
+		return fmt.Errorf("delete failed: %w", err)
 	}
 
-	// TODO: bulk insert
+	rows := make([][]any, 0, len(counts))
 	for app, count := range counts {
-		_, execErr := tx.ExecContext(ctx,
-			"INSERT INTO todays_app_sums(application, count_success, count_failure) VALUES($1, $2, $3);",
-			app, count.Success, count.Failure)
-		if execErr != nil {
-			if rollbackErr := tx.Rollback(); rollbackErr != nil {
-				fmt.Printf("update failed err writeAppUsage: %v, unable to rollback: %v\n", execErr, rollbackErr.Error())
-				return execErr
-			}
-			fmt.Printf("update failed err writeAppUsage: %v", execErr.Error())
-		}
+		rows = append(rows, []any{app, count.Success, count.Failure})
+	}
+	if execErr := bulkInsert(ctx, tx, "todays_app_sums", []string{"application", "count_success", "count_failure"}, rows); execErr != nil {
+		return fmt.Errorf("update failed err writeAppUsage: %w", execErr)
 	}
 
 	return nil
 }
 
+// WriteOriginUsage rebuilds the table of todays origin metrics within the transaction: the deleted entries are not visible to other readers until it commits.
 func WriteOriginUsage(ctx context.Context, tx *sql.Tx, counts map[types.PortalAppOrigin]api.RelayCounts) error {
 	// todays_sums table gets rebuilt every time
-	_, deleteErr := tx.ExecContext(ctx, "DELETE FROM todays_relay_counts")
-	if deleteErr != nil {
-		if rollbackErr := tx.Rollback(); rollbackErr != nil {
-			fmt.Printf("delete failed: %v, unable to rollback: %v\n", deleteErr, rollbackErr)
-			return deleteErr
-		}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM todays_relay_counts"); err != nil {
+		return fmt.Errorf("delete failed: %w", err)
 	}
 
-	// TODO: bulk insert
+	rows := make([][]any, 0, len(counts))
 	for origin, count := range counts {
-		_, execErr := tx.ExecContext(ctx,
-			"INSERT INTO todays_relay_counts(origin, count_success, count_failure) VALUES($1, $2, $3);",
-			origin, count.Success, count.Failure)
-		if execErr != nil {
-			if rollbackErr := tx.Rollback(); rollbackErr != nil {
-				fmt.Printf("update failed err write origin usage: %v, unable to rollback: %v\n", execErr, rollbackErr.Error())
-				return execErr
-			}
-			fmt.Printf("update failed err write origin usage: %v", execErr.Error())
-		}
+		rows = append(rows, []any{origin, count.Success, count.Failure})
+	}
+	if execErr := bulkInsert(ctx, tx, "todays_relay_counts", []string{"origin", "count_success", "count_failure"}, rows); execErr != nil {
+		return fmt.Errorf("update failed err write origin usage: %w", execErr)
 	}
 
 	return nil
 }
 
-// WriteTodaysUsage writes the app metrics for today so far to the underlying PG table.
+// writeTodaysLatency writes the app latencies for today so far to the underlying PG table.
 //
-//	All the entries in the table holding todays metrics are deleted first.
+//	All the entries in the table holding todays latencies are deleted first.
 func (p *pgClient) writeTodaysLatency(ctx context.Context, tx *sql.Tx, latencies map[types.PortalAppPublicKey][]api.Latency) error {
 	// todays_app_latencies table gets rebuilt every time
-	_, deleteErr := tx.ExecContext(ctx, "DELETE FROM todays_app_latencies")
-	if deleteErr != nil {
-		if rollbackErr := tx.Rollback(); rollbackErr != nil {
-			fmt.Printf("delete failed: %v, unable to rollback: %v\n", deleteErr, rollbackErr)
-			return deleteErr
-		}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM todays_app_latencies"); err != nil {
+		return fmt.Errorf("delete failed: %w", err)
 	}
 
-	// TODO: bulk insert
+	var rows [][]any
 	for app, appLatency := range latencies {
 		for _, appLatency := range appLatency {
-			_, execErr := tx.ExecContext(ctx,
-				"INSERT INTO todays_app_latencies(application, time, latency) VALUES($1, $2, $3);",
-				app, appLatency.Time, appLatency.Latency)
-
-			if execErr != nil {
-				if rollbackErr := tx.Rollback(); rollbackErr != nil {
-					fmt.Printf("update failed err write today latency: %v, unable to rollback: %v\n", execErr, rollbackErr.Error())
-					return execErr
+			// The percentiles are NULL if the source does not provide them
+			var p50, p95, p99 sql.NullFloat64
+			if percentiles := appLatency.Percentiles; percentiles != nil {
+				p50 = sql.NullFloat64{Float64: percentiles.P50, Valid: true}
+				p95 = sql.NullFloat64{Float64: percentiles.P95, Valid: true}
+				p99 = sql.NullFloat64{Float64: percentiles.P99, Valid: true}
+			}
+			rows = append(rows, []any{app, appLatency.Time, appLatency.Latency, p50, p95, p99})
+		}
+	}
+	if execErr := bulkInsert(ctx, tx, "todays_app_latencies", []string{"application", "time", "latency", "p50", "p95", "p99"}, rows); execErr != nil {
+		return fmt.Errorf("update failed err write today latency: %w", execErr)
+	}
+
+	return nil
+}
+
+// bulkInsert inserts the rows, each holding a value per column, using multi-row INSERT statements of at most
+// insertBatchRows rows each, instead of a round trip per row.
+//
+//	An error is returned as soon as a statement fails: the caller is expected to roll the transaction back.
+func bulkInsert(ctx context.Context, tx *sql.Tx, table string, columns []string, rows [][]any) error {
+	for start := 0; start < len(rows); start += insertBatchRows {
+		batch := rows[start:min(start+insertBatchRows, len(rows))]
+
+		var q strings.Builder
+		fmt.Fprintf(&q, "INSERT INTO %s(%s) VALUES", table, strings.Join(columns, ", "))
+		args := make([]any, 0, len(batch)*len(columns))
+		for i, row := range batch {
+			if len(row) != len(columns) {
+				return fmt.Errorf("expected %d values in row %d of %s, got: %d", len(columns), start+i, table, len(row))
+			}
+			if i > 0 {
+				q.WriteString(",")
+			}
+			q.WriteString(" (")
+			for j, value := range row {
+				if j > 0 {
+					q.WriteString(", ")
 				}
-				fmt.Printf("update failed err write today latency: %v", execErr.Error())
+				args = append(args, value)
+				fmt.Fprintf(&q, "$%d", len(args))
 			}
+			q.WriteString(")")
+		}
+
+		if _, err := tx.ExecContext(ctx, q.String(), args...); err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
+// rollback rolls back the transaction after a failed write.
+//
+//	A transaction whose context has been cancelled is rolled back by database/sql itself, so sql.ErrTxDone is not reported.
+func rollback(tx *sql.Tx, cause error) {
+	if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+		fmt.Printf("update failed: %v, unable to rollback: %v\n", cause, err)
+	}
+}
+
 // TodaysUsage returns the current day's metrics so far.
+//
+//	Several rows of the same application are handled as set by the client's DuplicateRows.
 func (p *pgClient) TodaysUsage() (map[types.PortalAppPublicKey]api.RelayCounts, error) {
-	// TODO: factor-out the SQL statements
 	ctx := context.Background()
-	rows, err := p.DB.QueryContext(ctx, "SELECT (application, count_success, count_failure) FROM todays_app_sums")
+	rows, err := p.reader().QueryContext(ctx, "SELECT application, count_success, count_failure FROM todays_app_sums")
 	if err != nil {
 		return nil, err
 	}
@@ -397,40 +574,30 @@ func (p *pgClient) TodaysUsage() (map[types.PortalAppPublicKey]api.RelayCounts,
 
 	todaysUsage := make(map[types.PortalAppPublicKey]api.RelayCounts)
 	for rows.Next() {
-		var r string
-		if err := rows.Scan(&r); err != nil {
+		var (
+			app    string
+			counts api.RelayCounts
+		)
+		if err := rows.Scan(&app, &counts.Success, &counts.Failure); err != nil {
 			return nil, err
 		}
-
-		// Example of query output:
-		// (46d4474f0a60f062103b1867c7edac323e58f416e7458f436623b9d96eb44b37,18931)
-		r = strings.ReplaceAll(r, "\"", "")
-		r = strings.TrimPrefix(r, "(")
-		r = strings.TrimSuffix(r, ")")
-		items := strings.Split(r, ",")
-		if len(items) != 3 {
-			return nil, fmt.Errorf("Invalid format in query output: %s", r)
-		}
-
-		countSuccess, err := strconv.ParseInt(items[1], 10, 64) // bitsize 64 for int64 return
-		if err != nil {
-			return nil, fmt.Errorf("Invalid total relays format: %s in query result line: %s, error: %v", items[1], r, err)
-		}
-		countFailure, err := strconv.ParseInt(items[2], 10, 64) // bitsize 64 for int64 return
-		if err != nil {
-			return nil, fmt.Errorf("Invalid total relays format: %s in query result line: %s, error: %v", items[2], r, err)
-		}
-		app := items[0]
 		if app == "" {
-			return nil, fmt.Errorf("Empty application public key, in query result line: %s", r)
+			return nil, errors.New("Empty application public key, in todays metrics")
 		}
-		appPubKey := types.PortalAppPublicKey(app)
 
-		todaysUsage[appPubKey] = api.RelayCounts{Success: countSuccess, Failure: countFailure}
-	}
-	// TODO: verify this is needed
-	if rerr := rows.Close(); rerr != nil {
-		return nil, rerr
+		appPubKey := types.PortalAppPublicKey(app)
+		if previous, ok := todaysUsage[appPubKey]; ok {
+			if p.duplicateRows == DuplicateRowsSum {
+				counts = previous.Add(counts)
+			}
+			p.logger.Warn("Duplicate rows of an application in todays metrics",
+				slog.String("application", app),
+				slog.String("handling", string(p.duplicateRows)),
+				slog.Int64("previous_success", previous.Success),
+				slog.Int64("previous_failure", previous.Failure),
+			)
+		}
+		todaysUsage[appPubKey] = counts
 	}
 	// Rows.Err will report the last error encountered by Rows.Scan.
 	if err := rows.Err(); err != nil {
@@ -442,9 +609,9 @@ func (p *pgClient) TodaysUsage() (map[types.PortalAppPublicKey]api.RelayCounts,
 
 // TodaysLatency returns the past 24 hours' latency per app.
 func (p *pgClient) TodaysLatency() (map[types.PortalAppPublicKey][]api.Latency, error) {
-	// TODO: factor-out the SQL statements
 	ctx := context.Background()
-	rows, err := p.DB.QueryContext(ctx, "SELECT (application, time, latency) FROM todays_app_latencies")
+	// The time column is a VARCHAR, written in different layouts depending on the driver: Postgres parses all of them
+	rows, err := p.reader().QueryContext(ctx, "SELECT application, time::timestamptz, latency, p50, p95, p99 FROM todays_app_latencies")
 	if err != nil {
 		return nil, err
 	}
@@ -453,51 +620,28 @@ func (p *pgClient) TodaysLatency() (map[types.PortalAppPublicKey][]api.Latency,
 	todaysLatency := make(map[types.PortalAppPublicKey][]api.Latency)
 
 	for rows.Next() {
-		var r string
-		if err := rows.Scan(&r); err != nil {
+		var (
+			app           string
+			hourlyTime    time.Time
+			latency       float64
+			p50, p95, p99 sql.NullFloat64
+		)
+		if err := rows.Scan(&app, &hourlyTime, &latency, &p50, &p95, &p99); err != nil {
 			return nil, err
 		}
-
-		// Example of query output:
-		// (46d4474f0a60f062103b1867c7edac323e58f416e7458f436623b9d96eb44b37,18931)
-		r = strings.ReplaceAll(r, "\"", "")
-		r = strings.TrimPrefix(r, "(")
-		r = strings.TrimSuffix(r, ")")
-		items := strings.Split(r, ",")
-		if len(items) != 3 {
-			return nil, fmt.Errorf("Invalid format in query output: %s", r)
-		}
-
-		// TODO: remove this after we get a better way to ensure that the layout will match in different timezones
-		if strings.HasSuffix(items[1], "Z") {
-			items[1] = strings.Replace(items[1], "Z", "+00", 1)
-		}
-		if !strings.HasSuffix(items[1], "+00") {
-			items[1] = items[1][:len(items[1])-3] + "+00"
+		if app == "" {
+			return nil, fmt.Errorf("Empty application public key, in todays latency of %s", hourlyTime)
 		}
 
-		hourlyTime, err := time.Parse(pgTimeFormat, items[1])
+		percentiles, err := latencyPercentiles(p50, p95, p99)
 		if err != nil {
-			return nil, fmt.Errorf("Invalid latency time format: %s in query result line: %s, error: %v", items[1], r, err)
+			return nil, fmt.Errorf("Invalid latency percentiles of application %s at %s: %v", app, hourlyTime, err)
 		}
-		hourlyAverageLatency, err := strconv.ParseFloat(items[2], 32)
-		if err != nil {
-			return nil, fmt.Errorf("Invalid latency format: %s in query result line: %s, error: %v", items[2], r, err)
-		}
-		app := items[0]
-		if app == "" {
-			return nil, fmt.Errorf("Empty application public key, in query result line: %s", r)
-		}
-		appPubKey := types.PortalAppPublicKey(app)
 
-		latencyByHour := api.Latency{Time: hourlyTime, Latency: numbers.RoundFloat(hourlyAverageLatency, 5)}
+		latencyByHour := api.Latency{Time: hourlyTime.UTC(), Latency: numbers.RoundFloat(latency, 5), Percentiles: percentiles}
 
+		appPubKey := types.PortalAppPublicKey(app)
 		todaysLatency[appPubKey] = append(todaysLatency[appPubKey], latencyByHour)
-
-	}
-	// TODO: verify this is needed
-	if rerr := rows.Close(); rerr != nil {
-		return nil, rerr
 	}
 	// Rows.Err will report the last error encountered by Rows.Scan.
 	if err := rows.Err(); err != nil {
@@ -507,11 +651,26 @@ func (p *pgClient) TodaysLatency() (map[types.PortalAppPublicKey][]api.Latency,
 	return todaysLatency, nil
 }
 
-// TodaysUsage returns the current day's metrics so far.
+// latencyPercentiles returns the p50, p95 and p99 latencies of a row, or nil if they are NULL
+func latencyPercentiles(p50, p95, p99 sql.NullFloat64) (*api.LatencyPercentiles, error) {
+	if !p50.Valid && !p95.Valid && !p99.Valid {
+		return nil, nil
+	}
+	if !p50.Valid || !p95.Valid || !p99.Valid {
+		return nil, errors.New("expected either all or none of the percentiles to be NULL")
+	}
+
+	return &api.LatencyPercentiles{
+		P50: numbers.RoundFloat(p50.Float64, 5),
+		P95: numbers.RoundFloat(p95.Float64, 5),
+		P99: numbers.RoundFloat(p99.Float64, 5),
+	}, nil
+}
+
+// TodaysOriginUsage returns the current day's metrics of each origin so far.
 func (p *pgClient) TodaysOriginUsage() (map[types.PortalAppOrigin]api.RelayCounts, error) {
-	// TODO: factor-out the SQL statements
 	ctx := context.Background()
-	rows, err := p.DB.QueryContext(ctx, "SELECT (origin, count_success, count_failure) FROM todays_relay_counts")
+	rows, err := p.reader().QueryContext(ctx, "SELECT origin, count_success, count_failure FROM todays_relay_counts")
 	if err != nil {
 		return nil, err
 	}
@@ -520,43 +679,18 @@ func (p *pgClient) TodaysOriginUsage() (map[types.PortalAppOrigin]api.RelayCount
 	todaysUsage := make(map[types.PortalAppOrigin]api.RelayCounts)
 
 	for rows.Next() {
-		var r string
-		if err := rows.Scan(&r); err != nil {
+		var (
+			origin string
+			counts api.RelayCounts
+		)
+		if err := rows.Scan(&origin, &counts.Success, &counts.Failure); err != nil {
 			return nil, err
 		}
-
-		// Example of query output:
-		// (46d4474f0a60f062103b1867c7edac323e58f416e7458f436623b9d96eb44b37,18931)
-		r = strings.ReplaceAll(r, "\"", "")
-		r = strings.TrimPrefix(r, "(")
-		r = strings.TrimSuffix(r, ")")
-		items := strings.Split(r, ",")
-		if len(items) != 3 {
-			return nil, fmt.Errorf("Invalid format in query output: %s", r)
-		}
-
-		countSuccess, err := strconv.ParseInt(items[1], 10, 64) // bitsize 64 for int64 return
-		if err != nil {
-			return nil, fmt.Errorf("Invalid total relays format: %s in query result line: %s, error: %v", items[1], r, err)
-		}
-		countFailure, err := strconv.ParseInt(items[2], 10, 64) // bitsize 64 for int64 return
-		if err != nil {
-			return nil, fmt.Errorf("Invalid total relays format: %s in query result line: %s, error: %v", items[2], r, err)
-		}
-		origin := items[0]
 		if origin == "" {
-			return nil, fmt.Errorf("Empty origin, in query result line: %s", r)
+			return nil, errors.New("Empty origin, in todays origin metrics")
 		}
-		appOrigin := types.PortalAppOrigin(origin)
 
-		todaysUsage[appOrigin] = api.RelayCounts{
-			Success: countSuccess,
-			Failure: countFailure,
-		}
-	}
-	// TODO: verify this is needed
-	if rerr := rows.Close(); rerr != nil {
-		return nil, rerr
+		todaysUsage[types.PortalAppOrigin(origin)] = counts
 	}
 	// Rows.Err will report the last error encountered by Rows.Scan.
 	if err := rows.Err(); err != nil {
@@ -565,9 +699,3 @@ func (p *pgClient) TodaysOriginUsage() (map[types.PortalAppOrigin]api.RelayCount
 
 	return todaysUsage, nil
 }
-
-func parseDate(source string) (time.Time, error) {
-	// Postgres queries date output format: 2022-05-31T00:00:00Z
-	const layout = "2006-01-02T15:04:00Z"
-	return time.Parse(layout, source)
-}
@@ -0,0 +1,207 @@
+//go:build integration
+
+package db_test
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pokt-foundation/portal-http-db/v2/types"
+	"github.com/pokt-foundation/relay-meter/api"
+	"github.com/pokt-foundation/relay-meter/db"
+	"github.com/pokt-foundation/relay-meter/seed"
+	"github.com/pokt-foundation/utils-go/numbers"
+	timeUtils "github.com/pokt-foundation/utils-go/time"
+)
+
+// Test_Reads_Integration verifies that the metrics read using typed columns are the same as those read by the previous
+// queries, which selected each row as a single composite string to be split.
+func Test_Reads_Integration(t *testing.T) {
+	dbInst, _, err := db.NewDBConnection(seed.PostgresOptions)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer dbInst.Close()
+
+	ctx := context.Background()
+	dataset := seed.Dataset{
+		Apps:         []types.PortalAppPublicKey{"test_reads_app_1", "test_reads_app_2"},
+		Days:         3,
+		LatencyHours: 2,
+	}
+	if _, err := seed.Seed(ctx, dbInst, dataset); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The collector writes the latency times as timestamps, in a different layout than the seeder
+	today := timeUtils.StartOfDay(time.Now().UTC())
+	if _, err := dbInst.ExecContext(ctx,
+		"INSERT INTO todays_app_latencies(application, time, latency, p50, p95, p99) VALUES($1, $2, $3, $4, $5, $6)",
+		"test_reads_app_3", today.Add(time.Hour), 0.25, 0.2, 0.4, 0.8,
+	); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, err := dbInst.ExecContext(ctx, "DELETE FROM todays_relay_counts"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for origin, count := range map[string]api.RelayCounts{
+		"https://portal.pokt.network": {Success: 10, Failure: 2},
+		"https://pokt.network":        {Success: 5},
+	} {
+		if _, err := dbInst.ExecContext(ctx,
+			"INSERT INTO todays_relay_counts(origin, count_success, count_failure) VALUES($1, $2, $3)",
+			origin, count.Success, count.Failure,
+		); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	client := db.NewPostgresClientFromDBInstance(dbInst)
+	from, to := today.AddDate(0, 0, -dataset.Days), today.AddDate(0, 0, -1)
+
+	dailyUsage, err := client.DailyUsage(from, to)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(legacyDailyUsage(t, dbInst, from, to), dailyUsage); diff != "" {
+		t.Errorf("unexpected value (-want +got):\n%s", diff)
+	}
+
+	todaysUsage, err := client.TodaysUsage()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(legacyTodaysUsage(t, dbInst), todaysUsage); diff != "" {
+		t.Errorf("unexpected value (-want +got):\n%s", diff)
+	}
+
+	todaysOriginUsage, err := client.TodaysOriginUsage()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(legacyTodaysOriginUsage(t, dbInst), todaysOriginUsage); diff != "" {
+		t.Errorf("unexpected value (-want +got):\n%s", diff)
+	}
+
+	todaysLatency, err := client.TodaysLatency()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(legacyTodaysLatency(t, dbInst), todaysLatency); diff != "" {
+		t.Errorf("unexpected value (-want +got):\n%s", diff)
+	}
+}
+
+// legacyRows returns the items of the rows selected as a single composite string, e.g. ("2022-06-25 00:00:00+00",app,18931,3)
+func legacyRows(t *testing.T, dbInst *sql.DB, query string) [][]string {
+	t.Helper()
+
+	rows, err := dbInst.Query(query)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer rows.Close()
+
+	var items [][]string
+	for rows.Next() {
+		var r string
+		if err := rows.Scan(&r); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		r = strings.ReplaceAll(r, "\"", "")
+		r = strings.TrimPrefix(r, "(")
+		r = strings.TrimSuffix(r, ")")
+		items = append(items, strings.Split(r, ","))
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return items
+}
+
+// legacyTime parses a time of a composite row, normalizing the time zone suffix
+func legacyTime(t *testing.T, item string) time.Time {
+	t.Helper()
+
+	if strings.HasSuffix(item, "Z") {
+		item = strings.Replace(item, "Z", "+00", 1)
+	}
+	if !strings.HasSuffix(item, "+00") {
+		item = item[:len(item)-3] + "+00"
+	}
+	ts, err := time.Parse("2006-01-02 15:04:00+00", item)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// The parsed time may be in the local time zone, which is equal but not identical as a map key
+	return ts.UTC()
+}
+
+func legacyCounts(t *testing.T, success, failure string) api.RelayCounts {
+	t.Helper()
+
+	s, err := strconv.ParseInt(success, 10, 64)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	f, err := strconv.ParseInt(failure, 10, 64)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return api.RelayCounts{Success: s, Failure: f}
+}
+
+func legacyDailyUsage(t *testing.T, dbInst *sql.DB, from, to time.Time) map[time.Time]map[types.PortalAppPublicKey]api.RelayCounts {
+	dailyUsage := make(map[time.Time]map[types.PortalAppPublicKey]api.RelayCounts)
+	for _, items := range legacyRows(t, dbInst, "SELECT (time, application, count_success, count_failure) FROM daily_app_sums as d WHERE d.time >= '"+
+		from.Format("2006-01-02")+"' and d.time <= '"+to.Format("2006-01-02")+"'") {
+		ts := legacyTime(t, items[0])
+		if dailyUsage[ts] == nil {
+			dailyUsage[ts] = make(map[types.PortalAppPublicKey]api.RelayCounts)
+		}
+		dailyUsage[ts][types.PortalAppPublicKey(items[1])] = legacyCounts(t, items[2], items[3])
+	}
+	return dailyUsage
+}
+
+func legacyTodaysUsage(t *testing.T, dbInst *sql.DB) map[types.PortalAppPublicKey]api.RelayCounts {
+	todaysUsage := make(map[types.PortalAppPublicKey]api.RelayCounts)
+	for _, items := range legacyRows(t, dbInst, "SELECT (application, count_success, count_failure) FROM todays_app_sums") {
+		todaysUsage[types.PortalAppPublicKey(items[0])] = legacyCounts(t, items[1], items[2])
+	}
+	return todaysUsage
+}
+
+func legacyTodaysOriginUsage(t *testing.T, dbInst *sql.DB) map[types.PortalAppOrigin]api.RelayCounts {
+	todaysUsage := make(map[types.PortalAppOrigin]api.RelayCounts)
+	for _, items := range legacyRows(t, dbInst, "SELECT (origin, count_success, count_failure) FROM todays_relay_counts") {
+		todaysUsage[types.PortalAppOrigin(items[0])] = legacyCounts(t, items[1], items[2])
+	}
+	return todaysUsage
+}
+
+func legacyTodaysLatency(t *testing.T, dbInst *sql.DB) map[types.PortalAppPublicKey][]api.Latency {
+	parse := func(item string) float64 {
+		value, err := strconv.ParseFloat(item, 64)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return numbers.RoundFloat(value, 5)
+	}
+
+	todaysLatency := make(map[types.PortalAppPublicKey][]api.Latency)
+	for _, items := range legacyRows(t, dbInst, "SELECT (application, time, latency, p50, p95, p99) FROM todays_app_latencies") {
+		latency := api.Latency{Time: legacyTime(t, items[1]), Latency: parse(items[2])}
+		if items[3] != "" {
+			latency.Percentiles = &api.LatencyPercentiles{P50: parse(items[3]), P95: parse(items[4]), P99: parse(items[5])}
+		}
+		todaysLatency[types.PortalAppPublicKey(items[0])] = append(todaysLatency[types.PortalAppPublicKey(items[0])], latency)
+	}
+	return todaysLatency
+}
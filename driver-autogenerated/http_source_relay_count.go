@@ -65,3 +65,16 @@ func (d *PostgresDriver) ReadHTTPSourceRelayCounts(ctx context.Context, from, to
 
 	return counts, nil
 }
+
+// LatestHTTPSourceRelayCountDay returns the most recent day with uploaded relay counts, or the zero time if there are none
+func (d *PostgresDriver) LatestHTTPSourceRelayCountDay(ctx context.Context) (time.Time, error) {
+	day, err := d.SelectLatestHTTPSourceRelayCountDay(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if day.Year() <= 1 {
+		return time.Time{}, nil
+	}
+
+	return truncateToDay(day), nil
+}
@@ -41,6 +41,9 @@ type TodaysAppLatency struct {
 	Application types.PortalAppPublicKey `json:"application"`
 	Time        string                   `json:"time"`
 	Latency     string                   `json:"latency"`
+	P50         sql.NullString           `json:"p50"`
+	P95         sql.NullString           `json:"p95"`
+	P99         sql.NullString           `json:"p99"`
 }
 
 type TodaysAppSum struct {
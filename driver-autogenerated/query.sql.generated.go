@@ -105,3 +105,15 @@ func (q *Queries) SelectHTTPSourceRelayCounts(ctx context.Context, arg SelectHTT
 	}
 	return items, nil
 }
+
+const selectLatestHTTPSourceRelayCountDay = `-- name: SelectLatestHTTPSourceRelayCountDay :one
+SELECT COALESCE(MAX(day), '0001-01-01')::date AS latest_day
+FROM http_source_relay_count
+`
+
+func (q *Queries) SelectLatestHTTPSourceRelayCountDay(ctx context.Context) (time.Time, error) {
+	row := q.db.QueryRowContext(ctx, selectLatestHTTPSourceRelayCountDay)
+	var latest_day time.Time
+	err := row.Scan(&latest_day)
+	return latest_day, err
+}
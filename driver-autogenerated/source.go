@@ -77,6 +77,12 @@ func (d *PostgresDriver) TodaysLatency() (map[types.PortalAppPublicKey][]api.Lat
 	return map[types.PortalAppPublicKey][]api.Latency{}, nil
 }
 
+// LatestDataTime returns the most recent day with uploaded relay counts: the counts are uploaded per day,
+// so the day of the latest upload is the finest timestamp available.
+func (d *PostgresDriver) LatestDataTime() (time.Time, error) {
+	return d.LatestHTTPSourceRelayCountDay(context.Background())
+}
+
 func (d *PostgresDriver) Name() string {
 	return "http"
 }
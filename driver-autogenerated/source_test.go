@@ -1,6 +1,7 @@
 package postgresdriver
 
 import (
+	"context"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
@@ -129,6 +130,21 @@ func (ts *PGDriverTestSuite) TestPostgresDriver_TodaysLatency() {
 	}
 }
 
+func (ts *PGDriverTestSuite) TestPostgresDriver_LatestDataTime() {
+	latest := ts.today.AddDate(0, 0, 1)
+
+	ts.NoError(ts.driver.WriteHTTPSourceRelayCount(context.Background(), api.HTTPSourceRelayCount{
+		AppPublicKey: "2585504a028b138b4b535d2351bc45260a3de9cd66305a854049d1a5143392a8", // pragma: allowlist secret
+		Day:          latest,
+		Success:      1,
+		Error:        1,
+	}))
+
+	got, err := ts.driver.LatestDataTime()
+	ts.NoError(err)
+	ts.True(got.Equal(truncateToDay(latest)), "expected latest data time: %v, got: %v", truncateToDay(latest), got)
+}
+
 func (ts *PGDriverTestSuite) TestPostgresDriver_Name() {
 	tests := []struct {
 		name     string
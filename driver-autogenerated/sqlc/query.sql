@@ -18,3 +18,6 @@ ON CONFLICT (app_public_key, day) DO UPDATE
 SELECT app_public_key, day, success, error
 FROM http_source_relay_count
 WHERE day BETWEEN $1 AND $2;
+-- name: SelectLatestHTTPSourceRelayCountDay :one
+SELECT COALESCE(MAX(day), '0001-01-01')::date AS latest_day
+FROM http_source_relay_count;
@@ -37,7 +37,10 @@ CREATE TABLE todays_app_latencies (
   id INT GENERATED ALWAYS AS IDENTITY,
   application VARCHAR NOT NULL,
   time VARCHAR NOT NULL,
-  latency DECIMAL NOT NULL
+  latency DECIMAL NOT NULL,
+  p50 DECIMAL,
+  p95 DECIMAL,
+  p99 DECIMAL
 );
 
 CREATE SEQUENCE success_seq START 1;
@@ -0,0 +1,280 @@
+//go:build integration
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gojektech/heimdall/httpclient"
+	"github.com/pokt-foundation/portal-http-db/v2/types"
+	"github.com/pokt-foundation/relay-meter/api"
+	"github.com/pokt-foundation/relay-meter/db"
+	driver "github.com/pokt-foundation/relay-meter/driver-autogenerated"
+	"github.com/pokt-foundation/relay-meter/seed"
+	"github.com/pokt-foundation/utils-go/logger"
+	timeUtils "github.com/pokt-foundation/utils-go/time"
+	"github.com/stretchr/testify/suite"
+)
+
+/* To run the Integration suite use the command `make test_integration` from the repository root.
+
+The Integration test suite only requires the Relay Meter Postgres DB: it seeds the daily and today's
+sums tables directly using the seed package, bypassing the collector and its sources, and runs the API server in-process
+against the seeded DB. It is gated by the `integration` build tag and completes in a few seconds. */
+
+const (
+	integrationAPIKey = "test_integration_api_key"
+
+	integrationDailyApp = types.PortalAppPublicKey("test_34715cae753e67c75fbb340442e7de8e")
+	integrationTodayApp = types.PortalAppPublicKey("test_8237c72345f12d1b1a8b64a1a7f66fa4")
+)
+
+func Test_RunSuite_Integration(t *testing.T) {
+	suite.Run(t, new(RelayMeterIntegrationTestSuite))
+}
+
+type (
+	RelayMeterIntegrationTestSuite struct {
+		suite.Suite
+		dbInst      *sql.DB
+		server      *httptest.Server
+		cancel      context.CancelFunc
+		httpClient  *httpclient.Client
+		yesterday   time.Time
+		dateParams  string
+		todayParams string
+	}
+
+	// integrationBackend serves the relay metrics from the seeded DB, and no portal apps
+	integrationBackend struct {
+		db.StorageClient
+	}
+)
+
+func (b *integrationBackend) UserPortalAppPubKeys(ctx context.Context, userID types.UserID) ([]types.PortalAppPublicKey, error) {
+	return nil, nil
+}
+
+func (b *integrationBackend) PortalApp(ctx context.Context, portalAppID types.PortalAppID) (*types.PortalApp, error) {
+	return nil, api.ErrPortalAppNotFound
+}
+
+func (b *integrationBackend) PortalApps(ctx context.Context) ([]*types.PortalApp, error) {
+	return nil, nil
+}
+
+// SetupSuite seeds the DB and starts an in-process API server using it
+func (ts *RelayMeterIntegrationTestSuite) SetupSuite() {
+	dbInst, _, err := db.NewDBConnection(seed.PostgresOptions)
+	ts.Require().NoError(err)
+	ts.Require().NoError(dbInst.Ping())
+	ts.dbInst = dbInst
+
+	ts.yesterday = timeUtils.StartOfDay(time.Now().AddDate(0, 0, -1).UTC())
+	ts.dateParams = fmt.Sprintf("?from=%s&to=%s", ts.yesterday.Format(time.RFC3339), ts.yesterday.Format(time.RFC3339))
+	today := ts.yesterday.AddDate(0, 0, 1)
+	ts.todayParams = fmt.Sprintf("?from=%s&to=%s", today.Format(time.RFC3339), today.Format(time.RFC3339))
+
+	ts.Require().NoError(seed.DailyAppSums(context.Background(), dbInst, ts.yesterday, map[types.PortalAppPublicKey]api.RelayCounts{
+		integrationDailyApp: {Success: 1750, Failure: 20},
+	}))
+	ts.Require().NoError(seed.TodaysAppSums(context.Background(), dbInst, map[types.PortalAppPublicKey]api.RelayCounts{
+		integrationTodayApp: {Success: 500, Failure: 5},
+	}))
+
+	var ctx context.Context
+	ctx, ts.cancel = context.WithCancel(context.Background())
+
+	log := logger.New()
+	backend := &integrationBackend{StorageClient: db.NewPostgresClientFromDBInstance(dbInst)}
+	meter := api.NewRelayMeter(ctx, backend, driver.NewPostgresDriverFromDBInstance(dbInst), log, api.RelayMeterOptions{
+		LoadInterval:     time.Minute,
+		DailyMetricsTTL:  time.Minute,
+		TodaysMetricsTTL: time.Minute,
+		MaxPastDays:      7 * 24 * time.Hour,
+	})
+	ts.Require().Eventually(func() bool { return meter.DataGeneration() > 0 }, 5*time.Second, 50*time.Millisecond)
+
+	ts.server = httptest.NewServer(http.HandlerFunc(api.GetHttpServer(ctx, meter, log, map[string]bool{integrationAPIKey: true}, api.ServerOptions{})))
+
+	ts.httpClient = httpclient.NewClient(
+		httpclient.WithHTTPTimeout(5*time.Second), httpclient.WithRetryCount(0),
+	)
+}
+
+func (ts *RelayMeterIntegrationTestSuite) TearDownSuite() {
+	ts.server.Close()
+	ts.cancel()
+	ts.dbInst.Close()
+}
+
+func (ts *RelayMeterIntegrationTestSuite) Test_AppRelaysEndpoint() {
+	tests := []struct {
+		name      string
+		appPubKey types.PortalAppPublicKey
+		params    string
+		expected  api.RelayCounts
+	}{
+		{
+			name:      "Daily sums are returned for a past day",
+			appPubKey: integrationDailyApp,
+			params:    ts.dateParams,
+			expected:  api.RelayCounts{Success: 1750, Failure: 20},
+		},
+		{
+			name:      "Todays sums are returned for today",
+			appPubKey: integrationTodayApp,
+			params:    ts.todayParams,
+			expected:  api.RelayCounts{Success: 500, Failure: 5},
+		},
+	}
+
+	for _, test := range tests {
+		ts.Run(test.name, func() {
+			appRelays, err := get[api.AppRelaysResponse](getOptions{
+				baseURL:    ts.server.URL,
+				apiKey:     integrationAPIKey,
+				path:       "v1/relays/apps",
+				id:         string(test.appPubKey),
+				params:     test.params,
+				httpClient: ts.httpClient,
+			})
+			ts.NoError(err)
+			ts.Equal(test.appPubKey, appRelays.PublicKey)
+			ts.Equal(test.expected, appRelays.Count)
+		})
+	}
+}
+
+func (ts *RelayMeterIntegrationTestSuite) Test_TotalRelaysEndpoint() {
+	totalRelays, err := get[api.TotalRelaysResponse](getOptions{
+		baseURL:    ts.server.URL,
+		apiKey:     integrationAPIKey,
+		path:       "v1/relays",
+		params:     ts.dateParams,
+		httpClient: ts.httpClient,
+	})
+	ts.NoError(err)
+	ts.Equal(api.RelayCounts{Success: 1750, Failure: 20}, totalRelays.Count)
+	ts.Equal(ts.yesterday, totalRelays.From)
+	ts.Equal(ts.yesterday.AddDate(0, 0, 1), totalRelays.To)
+}
+
+func (ts *RelayMeterIntegrationTestSuite) Test_CoverageEndpoint() {
+	// Leave a gap between the seeded days: 4 and 3 days ago, and yesterday
+	for _, day := range []time.Time{ts.yesterday.AddDate(0, 0, -3), ts.yesterday.AddDate(0, 0, -2)} {
+		ts.Require().NoError(seed.DailyAppSums(context.Background(), ts.dbInst, day, map[types.PortalAppPublicKey]api.RelayCounts{
+			integrationDailyApp: {Success: 100},
+		}))
+	}
+	ts.Require().NoError(seed.DailyAppSums(context.Background(), ts.dbInst, ts.yesterday.AddDate(0, 0, -1), nil))
+	ts.Require().NoError(seed.DailyAppSums(context.Background(), ts.dbInst, ts.yesterday.AddDate(0, 0, -4), nil))
+
+	from := ts.yesterday.AddDate(0, 0, -4)
+	coverage, err := get[api.CoverageResponse](getOptions{
+		baseURL:    ts.server.URL,
+		apiKey:     integrationAPIKey,
+		path:       "v1/coverage",
+		params:     fmt.Sprintf("?from=%s&to=%s", from.Format(time.RFC3339), ts.yesterday.Format(time.RFC3339)),
+		httpClient: ts.httpClient,
+	})
+	ts.NoError(err)
+	ts.Equal([]api.DayRange{
+		{From: ts.yesterday.AddDate(0, 0, -3), To: ts.yesterday.AddDate(0, 0, -2)},
+		{From: ts.yesterday, To: ts.yesterday},
+	}, coverage.Days)
+}
+
+func (ts *RelayMeterIntegrationTestSuite) Test_RawDailyRowsEndpoint() {
+	// Seeded before the days of the other tests, with a day the app is absent from
+	first, second := ts.yesterday.AddDate(0, 0, -6), ts.yesterday.AddDate(0, 0, -5)
+	ts.Require().NoError(seed.DailyAppSums(context.Background(), ts.dbInst, first, map[types.PortalAppPublicKey]api.RelayCounts{
+		integrationDailyApp: {Success: 10, Failure: 1},
+	}))
+	ts.Require().NoError(seed.DailyAppSums(context.Background(), ts.dbInst, second, map[types.PortalAppPublicKey]api.RelayCounts{
+		integrationTodayApp: {Success: 20},
+	}))
+
+	rows, err := get[api.AppRawDailyRowsResponse](getOptions{
+		baseURL:    ts.server.URL,
+		apiKey:     integrationAPIKey,
+		path:       "v1/debug/apps",
+		id:         string(integrationDailyApp) + "/rows",
+		params:     fmt.Sprintf("?from=%s&to=%s", first.Format(time.RFC3339), second.Format(time.RFC3339)),
+		httpClient: ts.httpClient,
+	})
+	ts.NoError(err)
+	ts.Equal([]api.DailyRow{{Day: first, Success: 10, Failure: 1}}, rows.Rows)
+}
+
+func (ts *RelayMeterIntegrationTestSuite) Test_TodaysMetricsRebuildIsAtomic() {
+	client := db.NewPostgresClientFromDBInstance(ts.dbInst)
+	counts := map[types.PortalAppPublicKey]api.RelayCounts{
+		integrationTodayApp: {Success: 500, Failure: 5},
+	}
+
+	done := make(chan error)
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			if err := client.WriteTodaysMetrics(context.Background(), counts, nil, nil); err != nil {
+				done <- err
+				return
+			}
+		}
+	}()
+
+	// Readers must see either the previous or the rebuilt metrics, never the emptied table of an in-progress rebuild
+	for reading := true; reading; {
+		select {
+		case err := <-done:
+			ts.Require().NoError(err)
+			reading = false
+		default:
+		}
+
+		todaysUsage, err := client.TodaysUsage()
+		ts.Require().NoError(err)
+		ts.Require().Equal(counts, todaysUsage)
+	}
+}
+
+func (ts *RelayMeterIntegrationTestSuite) Test_ExistingMetricsTimespan() {
+	ts.Run("Populated table", func() {
+		first, last, hasData, err := db.NewPostgresClientFromDBInstance(ts.dbInst).ExistingMetricsTimespan()
+		ts.Require().NoError(err)
+		ts.True(hasData)
+		// Other tests seed older days, so only the bounds of the days seeded by the suite are checked
+		ts.False(first.After(ts.yesterday))
+		ts.Equal(ts.yesterday, last)
+	})
+
+	ts.Run("Empty table", func() {
+		// An empty daily sums table, in its own schema, is used to leave the suite's seeded metrics in place
+		const schema = "existing_metrics_timespan"
+		_, err := ts.dbInst.Exec(fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %[1]s; CREATE TABLE IF NOT EXISTS %[1]s.daily_app_sums (LIKE public.daily_app_sums)", schema))
+		ts.Require().NoError(err)
+		defer func() {
+			_, err := ts.dbInst.Exec(fmt.Sprintf("DROP SCHEMA %s CASCADE", schema))
+			ts.NoError(err)
+		}()
+
+		options := seed.PostgresOptions
+		options.SearchPath = schema
+		dbInst, _, err := db.NewDBConnection(options)
+		ts.Require().NoError(err)
+		defer dbInst.Close()
+
+		first, last, hasData, err := db.NewPostgresClientFromDBInstance(dbInst).ExistingMetricsTimespan()
+		ts.Require().NoError(err)
+		ts.False(hasData)
+		ts.True(first.IsZero())
+		ts.True(last.IsZero())
+	})
+}
@@ -0,0 +1,152 @@
+//go:build integration
+
+// Package seed populates a test Relay Meter Postgres DB with deterministic metrics, so the integration tests
+// can assert exact values without going through the collector and its sources.
+package seed
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/pokt-foundation/portal-http-db/v2/types"
+	"github.com/pokt-foundation/relay-meter/api"
+	"github.com/pokt-foundation/relay-meter/db"
+	timeUtils "github.com/pokt-foundation/utils-go/time"
+)
+
+// latencyTimeLayout is the layout of the todays_app_latencies time column, as parsed by the Postgres client
+const latencyTimeLayout = "2006-01-02 15:04:00+00"
+
+// PostgresOptions are the options of the test DB started by `make test_integration_env_up`
+var PostgresOptions = db.PostgresOptions{
+	Host:     "localhost:5434",
+	User:     "postgres",
+	Password: "pgpassword", // pragma: allowlist secret
+	DB:       "postgres",
+}
+
+// Dataset describes the metrics to seed: the same Dataset always produces the same metrics.
+type Dataset struct {
+	Apps []types.PortalAppPublicKey
+	// Days is the number of past days, ending yesterday, with daily metrics
+	Days int
+	// LatencyHours is the number of hours, starting at the beginning of today, with latencies
+	LatencyHours int
+}
+
+// Metrics are the metrics written by Seed, to compare with the values served by the tested code
+type Metrics struct {
+	DailyUsage    map[time.Time]map[types.PortalAppPublicKey]api.RelayCounts
+	TodaysUsage   map[types.PortalAppPublicKey]api.RelayCounts
+	TodaysLatency map[types.PortalAppPublicKey][]api.Latency
+}
+
+// Seed replaces the daily metrics of the dataset's days, and all of today's metrics, with the dataset's metrics.
+//
+//	The counts of the app at index i on the day d days before today are Success: 1000*(i+1) + d, Failure: 10*(i+1) + d,
+//	and today's counts are those of d = 0. The latency of the app at index i in hour h is (i+1)/4 + h/8 seconds.
+func Seed(ctx context.Context, dbInst *sql.DB, dataset Dataset) (Metrics, error) {
+	today := timeUtils.StartOfDay(time.Now().UTC())
+
+	metrics := Metrics{
+		DailyUsage:    make(map[time.Time]map[types.PortalAppPublicKey]api.RelayCounts),
+		TodaysUsage:   make(map[types.PortalAppPublicKey]api.RelayCounts),
+		TodaysLatency: make(map[types.PortalAppPublicKey][]api.Latency),
+	}
+
+	for d := 1; d <= dataset.Days; d++ {
+		day := today.AddDate(0, 0, -d)
+		counts := make(map[types.PortalAppPublicKey]api.RelayCounts)
+		for i, app := range dataset.Apps {
+			counts[app] = appCounts(i, d)
+		}
+		if err := DailyAppSums(ctx, dbInst, day, counts); err != nil {
+			return Metrics{}, err
+		}
+		metrics.DailyUsage[day] = counts
+	}
+
+	for i, app := range dataset.Apps {
+		metrics.TodaysUsage[app] = appCounts(i, 0)
+		for h := 0; h < dataset.LatencyHours; h++ {
+			metrics.TodaysLatency[app] = append(metrics.TodaysLatency[app], api.Latency{
+				Time:    today.Add(time.Duration(h) * time.Hour),
+				Latency: float64(i+1)/4 + float64(h)/8,
+			})
+		}
+	}
+
+	if err := TodaysAppSums(ctx, dbInst, metrics.TodaysUsage); err != nil {
+		return Metrics{}, err
+	}
+	if err := TodaysAppLatencies(ctx, dbInst, metrics.TodaysLatency); err != nil {
+		return Metrics{}, err
+	}
+
+	return metrics, nil
+}
+
+func appCounts(appIndex, daysAgo int) api.RelayCounts {
+	return api.RelayCounts{
+		Success: int64(1000*(appIndex+1) + daysAgo),
+		Failure: int64(10*(appIndex+1) + daysAgo),
+	}
+}
+
+// DailyAppSums replaces the daily sums of the given day with the supplied counts
+func DailyAppSums(ctx context.Context, dbInst *sql.DB, day time.Time, counts map[types.PortalAppPublicKey]api.RelayCounts) error {
+	if _, err := dbInst.ExecContext(ctx, "DELETE FROM daily_app_sums WHERE time = $1", day); err != nil {
+		return fmt.Errorf("error deleting daily sums: %w", err)
+	}
+
+	for app, count := range counts {
+		if _, err := dbInst.ExecContext(ctx,
+			"INSERT INTO daily_app_sums(application, count_success, count_failure, time) VALUES($1, $2, $3, $4)",
+			app, count.Success, count.Failure, day,
+		); err != nil {
+			return fmt.Errorf("error seeding daily sums: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// TodaysAppSums replaces today's sums with the supplied counts
+func TodaysAppSums(ctx context.Context, dbInst *sql.DB, counts map[types.PortalAppPublicKey]api.RelayCounts) error {
+	if _, err := dbInst.ExecContext(ctx, "DELETE FROM todays_app_sums"); err != nil {
+		return fmt.Errorf("error deleting todays sums: %w", err)
+	}
+
+	for app, count := range counts {
+		if _, err := dbInst.ExecContext(ctx,
+			"INSERT INTO todays_app_sums(application, count_success, count_failure) VALUES($1, $2, $3)",
+			app, count.Success, count.Failure,
+		); err != nil {
+			return fmt.Errorf("error seeding todays sums: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// TodaysAppLatencies replaces today's latencies with the supplied ones
+func TodaysAppLatencies(ctx context.Context, dbInst *sql.DB, latencies map[types.PortalAppPublicKey][]api.Latency) error {
+	if _, err := dbInst.ExecContext(ctx, "DELETE FROM todays_app_latencies"); err != nil {
+		return fmt.Errorf("error deleting todays latencies: %w", err)
+	}
+
+	for app, appLatencies := range latencies {
+		for _, latency := range appLatencies {
+			if _, err := dbInst.ExecContext(ctx,
+				"INSERT INTO todays_app_latencies(application, time, latency) VALUES($1, $2, $3)",
+				app, latency.Time.UTC().Format(latencyTimeLayout), latency.Latency,
+			); err != nil {
+				return fmt.Errorf("error seeding todays latencies: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
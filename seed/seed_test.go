@@ -0,0 +1,90 @@
+//go:build integration
+
+package seed
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pokt-foundation/portal-http-db/v2/types"
+	"github.com/pokt-foundation/relay-meter/db"
+	timeUtils "github.com/pokt-foundation/utils-go/time"
+)
+
+func Test_Seed_Integration(t *testing.T) {
+	dbInst, _, err := db.NewDBConnection(PostgresOptions)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer dbInst.Close()
+
+	dataset := Dataset{
+		Apps:         []types.PortalAppPublicKey{"test_seed_app_1", "test_seed_app_2"},
+		Days:         3,
+		LatencyHours: 2,
+	}
+	expected, err := Seed(context.Background(), dbInst, dataset)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The dataset is deterministic: the same metrics are seeded again
+	again, err := Seed(context.Background(), dbInst, dataset)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(expected, again); diff != "" {
+		t.Errorf("unexpected value (-want +got):\n%s", diff)
+	}
+
+	client := db.NewPostgresClientFromDBInstance(dbInst)
+	seeded := make(map[types.PortalAppPublicKey]bool)
+	for _, app := range dataset.Apps {
+		seeded[app] = true
+	}
+
+	// Other tests may have seeded other apps on the same days, so only the dataset's apps are compared
+	today := timeUtils.StartOfDay(time.Now().UTC())
+	dailyUsage, err := client.DailyUsage(today.AddDate(0, 0, -dataset.Days), today.AddDate(0, 0, -1))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for day, counts := range dailyUsage {
+		for app := range counts {
+			if !seeded[app] {
+				delete(counts, app)
+			}
+		}
+		if len(counts) == 0 {
+			delete(dailyUsage, day)
+		}
+	}
+	if diff := cmp.Diff(expected.DailyUsage, dailyUsage); diff != "" {
+		t.Errorf("unexpected value (-want +got):\n%s", diff)
+	}
+
+	// Today's metrics are replaced altogether by the seeder
+	todaysUsage, err := client.TodaysUsage()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(expected.TodaysUsage, todaysUsage); diff != "" {
+		t.Errorf("unexpected value (-want +got):\n%s", diff)
+	}
+
+	todaysLatency, err := client.TodaysLatency()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for app := range todaysLatency {
+		sort.Slice(todaysLatency[app], func(i, j int) bool {
+			return todaysLatency[app][i].Time.Before(todaysLatency[app][j].Time)
+		})
+	}
+	if diff := cmp.Diff(expected.TodaysLatency, todaysLatency); diff != "" {
+		t.Errorf("unexpected value (-want +got):\n%s", diff)
+	}
+}